@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// addressBar is the state of the ":" / ctrl+l prompt opening any OData URL,
+// or running a command such as ":set keymap=vim"
+type addressBar struct {
+	active bool
+	input  string
+}
+
+// openAddressBar asks for a URL to open
+func (m model) openAddressBar() model {
+	m.addressBar = addressBar{active: true}
+	return m
+}
+
+// updateAddressBar handles key presses while the address bar is open
+func (m model) updateAddressBar(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	ab := &m.addressBar
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		ab.active = false
+	case "enter":
+		ab.active = false
+		return m.openAddress(strings.TrimSpace(ab.input))
+	case "ctrl+u":
+		ab.input = ""
+	case "backspace":
+		if len(ab.input) > 0 {
+			runes := []rune(ab.input)
+			ab.input = string(runes[:len(runes)-1])
+		}
+	default:
+		ab.input += typedText(msg)
+	}
+	return m, nil
+}
+
+// openAddress opens an absolute OData URL, or a path relative to the
+// connected service, as a new column stack: service, entity set, entity
+// and navigation target
+func (m model) openAddress(input string) (tea.Model, tea.Cmd) {
+	if input == "" {
+		return m, nil
+	}
+	if next, cmd, ok := m.runCommand(input); ok {
+		return next, cmd
+	}
+	input, missing := expandVariables(input, m.variables)
+	if len(missing) > 0 {
+		m.logs = append(m.logs, fmt.Sprintf("Unknown variables: {{%s}}", strings.Join(missing, "}}, {{")))
+		return m, nil
+	}
+
+	if !strings.Contains(input, "://") {
+		if m.odata == nil || m.serviceIndex < 0 || m.serviceIndex >= len(m.services) {
+			m.logs = append(m.logs, "Connect to a service to open a relative path, or enter a full URL")
+			return m, nil
+		}
+		input = strings.TrimSuffix(m.services[m.serviceIndex].URL, "/") + "/" + strings.TrimPrefix(input, "/")
+	}
+	// Credentials of the connected service are not sent to a host it doesn't cover
+	link, services, err := parseLinkURL(input, m.services, ServiceConfig{})
+	if err != nil {
+		m.logs = append(m.logs, fmt.Sprintf("Cannot open %s: %v", redactURL(input), err))
+		return m, nil
+	}
+	if len(services) != len(m.services) {
+		// Each URL outside the configured services gets a service of its own
+		added := &services[len(services)-1]
+		for n := 2; hasService(m.services, added.Name); n++ {
+			added.Name = fmt.Sprintf("%s %d", linkServiceName, n)
+		}
+		link.Service = added.Name
+		m.services = services
+		m.columns[0].items = GetServiceNames(services)
+		m.logs = append(m.logs, fmt.Sprintf("Added %s for %s", link.Service, redactURL(services[len(services)-1].URL)))
+	}
+	m.logs = append(m.logs, "Opening "+redactURL(input))
+
+	// The entity sets of the connected service are already loaded
+	connected := m.odata != nil && m.serviceIndex >= 0 && m.services[m.serviceIndex].Name == link.Service &&
+		len(m.columns) > 1 && m.columns[1].title == "EntitySets" && m.columns[1].state == stateLoaded
+	if connected {
+		m.columns = m.columns[:2]
+		m.activeColumn = 1
+		m.columns[1].focused = true
+		m.updateColumnSizes()
+		if link.EntitySet == "" {
+			return m, m.updatePreview()
+		}
+		m.pendingLink = link
+		return m.followLink()
+	}
+	if link.EntitySet != "" {
+		m.pendingLink = link
+	}
+	return m.switchService(link.Service)
+}
+
+// hasService reports whether a service of the name is configured
+func hasService(services []ServiceConfig, name string) bool {
+	for _, svc := range services {
+		if svc.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// renderAddressBar renders the address bar box
+func (m model) renderAddressBar() string {
+	hint := lipgloss.NewStyle().Foreground(theme.Muted)
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Background(theme.Accent).
+		Foreground(theme.AccentText).
+		Padding(0, 1).
+		Render("Open URL")
+
+	base := "Enter a full URL"
+	if m.odata != nil && m.serviceIndex >= 0 && m.serviceIndex < len(m.services) {
+		base = "Relative to " + redactURL(m.services[m.serviceIndex].URL) + ", or a full URL"
+	}
+	content := title + "\n\n" +
+		hint.Render(base) + "\n" +
+		hint.Render("e.g. Products(1)/Category?$expand=Products or Orders?$filter=Freight gt 100") + "\n" +
+		hint.Render("Commands: set keymap=vim, set keymap=default, q (vim keymap)") + "\n\n" +
+		"> " + m.addressBar.input + "█\n\n" +
+		hint.Render("Enter: Open | Ctrl+U: Clear | ESC: Cancel")
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(theme.Accent).
+		Padding(0, 1).
+		Width(min(100, m.width-4)).
+		Render(content)
+}
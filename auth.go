@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuthConfig selects how requests to a service are authenticated when basic
+// auth with username and password isn't enough
+type AuthConfig struct {
+	Type string `json:"type"` // "oauth2" for the client credentials grant, "token" for static credentials
+
+	// OAuth2 client credentials
+	TokenURL     string   `json:"token_url,omitempty"`
+	ClientID     string   `json:"client_id,omitempty"`
+	ClientSecret string   `json:"client_secret,omitempty"`
+	Scopes       []string `json:"scopes,omitempty"`
+
+	// Static credentials of a "token" service
+	Token   string            `json:"token,omitempty"`   // Sent as Authorization: Bearer
+	Headers map[string]string `json:"headers,omitempty"` // API keys and other fixed headers
+}
+
+// tokenHeaders returns the headers carrying the static credentials of a
+// "token" service. Headers may replace the bearer Authorization header for
+// gateways that expect another scheme.
+func (a AuthConfig) tokenHeaders() http.Header {
+	headers := make(http.Header)
+	if a.Token != "" {
+		headers.Set("Authorization", "Bearer "+a.Token)
+	}
+	for name, value := range a.Headers {
+		headers.Set(name, value)
+	}
+	return headers
+}
+
+// tokenExpirySkew renews tokens this long before they expire so a request
+// never goes out with a token that lapses in flight
+const tokenExpirySkew = 30 * time.Second
+
+// tokenSource fetches and caches OAuth2 access tokens with the client
+// credentials grant
+type tokenSource struct {
+	config AuthConfig
+	client *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time // Zero when the server gave no expires_in
+}
+
+func newTokenSource(config AuthConfig, transport http.RoundTripper) *tokenSource {
+	return &tokenSource{config: config, client: &http.Client{Transport: newTracingTransport(transport)}}
+}
+
+// identity names the login the tokens are issued for, which stays the same
+// while the tokens change
+func (s *tokenSource) identity() string {
+	return "OAuth2 " + s.config.ClientID + " " + s.config.TokenURL
+}
+
+// Token returns a valid access token, fetching a new one when there is none
+// yet or the current one is about to expire
+func (s *tokenSource) Token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && (s.expires.IsZero() || time.Now().Before(s.expires.Add(-tokenExpirySkew))) {
+		return s.token, nil
+	}
+	return s.fetch()
+}
+
+// invalidate drops a token the service rejected so the next Token call fetches a new one
+func (s *tokenSource) invalidate(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == token {
+		s.token = ""
+	}
+}
+
+// fetch requests a new token from the token endpoint; s.mu must be held
+func (s *tokenSource) fetch() (string, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(s.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.config.Scopes, " "))
+	}
+	req, err := http.NewRequest("POST", s.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(s.config.ClientID), url.QueryEscape(s.config.ClientSecret))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("failed to parse token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("token response has no access_token")
+	}
+
+	s.token = token.AccessToken
+	s.expires = time.Time{}
+	if token.ExpiresIn > 0 {
+		s.expires = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return s.token, nil
+}
+
+// bearerTransport attaches an access token to every request and retries
+// once with a fresh token when the service answers 401
+type bearerTransport struct {
+	base   http.RoundTripper
+	source *tokenSource
+}
+
+func (t *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.source.Token()
+	if err != nil {
+		return nil, err
+	}
+	// Responses cached for this login stay valid when the token is refreshed
+	req = req.WithContext(context.WithValue(req.Context(), cacheIdentityKey{}, t.source.identity()))
+	resp, err := t.base.RoundTrip(withBearer(req, token))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	// The token may have been revoked early; only replayable requests can be retried
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+	t.source.invalidate(token)
+	token, err = t.source.Token()
+	if err != nil {
+		return resp, nil
+	}
+	retry := withBearer(req, token)
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+	resp.Body.Close()
+	return t.base.RoundTrip(retry)
+}
+
+// withBearer returns a copy of req carrying the access token
+func withBearer(req *http.Request, token string) *http.Request {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// BatchRequest is one operation sent inside a $batch request
+type BatchRequest struct {
+	Method    string // GET, POST, PUT, PATCH, MERGE or DELETE
+	EntitySet string
+	Key       string                 // Key predicate, see BuildURL
+	Query     QueryOptions           // Query options of a read
+	Body      map[string]interface{} // Payload of a create or update
+	IfMatch   string                 // ETag the entity must still have for an update or delete to apply
+}
+
+// BatchUnsupportedError is the answer of a service that rejects $batch
+// itself, as opposed to the requests in it
+type BatchUnsupportedError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *BatchUnsupportedError) Error() string {
+	return fmt.Sprintf("$batch not supported: HTTP %d: %s", e.StatusCode, e.Body)
+}
+
+// batchRejected reports whether the answer to the $batch POST means the
+// service has no $batch endpoint, rather than a failure of the batch: 405
+// or 501, or an error saying $batch isn't implemented. A 400 about the
+// content of the batch leaves batching on.
+func batchRejected(status int, body []byte) bool {
+	switch status {
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	if status < 400 {
+		return false
+	}
+	text := strings.ToLower(string(body))
+	if !strings.Contains(text, "$batch") {
+		return false
+	}
+	for _, phrase := range []string{"not implemented", "not supported", "unsupported"} {
+		if strings.Contains(text, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// operation names a batch request the way the single write methods do
+func (r BatchRequest) operation() string {
+	switch r.Method {
+	case "GET":
+		return "read"
+	case "POST":
+		return "create"
+	case "DELETE":
+		return "delete"
+	}
+	return "update"
+}
+
+// ExecuteBatch sends requests as one multipart/mixed $batch request and
+// returns a result per request, in order. Reads are sent on their own and
+// writes inside changesets: one per write, so each succeeds or fails by
+// itself, or a single changeset for all writes when atomic is set. A
+// changeset the server rejects as a whole reports its error on every write
+// in it. The returned error is set when the batch itself failed or when any
+// request in it did.
+func (o *ODataService) ExecuteBatch(requests []BatchRequest, atomic bool) ([]*OperationResult, error) {
+	if len(requests) == 0 {
+		return nil, nil
+	}
+
+	// Group the requests into the top-level parts of the batch; a write
+	// group becomes a changeset
+	type group struct {
+		changeset bool
+		indexes   []int
+	}
+	var groups []group
+	for i, r := range requests {
+		write := r.Method != "GET"
+		if write && atomic && len(groups) > 0 && groups[len(groups)-1].changeset {
+			groups[len(groups)-1].indexes = append(groups[len(groups)-1].indexes, i)
+			continue
+		}
+		groups = append(groups, group{changeset: write, indexes: []int{i}})
+	}
+
+	subRequests := make([]*http.Request, len(requests))
+	for i, r := range requests {
+		req, err := o.newBatchSubRequest(r)
+		if err != nil {
+			return nil, err
+		}
+		subRequests[i] = req
+	}
+
+	var payload bytes.Buffer
+	batch := multipart.NewWriter(&payload)
+	for _, g := range groups {
+		if !g.changeset {
+			if err := writeBatchOperation(batch, subRequests[g.indexes[0]], o.batchTarget(subRequests[g.indexes[0]]), 0); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		var changesetBody bytes.Buffer
+		changeset := multipart.NewWriter(&changesetBody)
+		for n, i := range g.indexes {
+			if err := writeBatchOperation(changeset, subRequests[i], o.batchTarget(subRequests[i]), n+1); err != nil {
+				return nil, err
+			}
+		}
+		if err := changeset.Close(); err != nil {
+			return nil, err
+		}
+		part, err := batch.CreatePart(textproto.MIMEHeader{
+			"Content-Type": {"multipart/mixed; boundary=" + changeset.Boundary()},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := part.Write(changesetBody.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+	if err := batch.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", o.BuildURL("$batch", "", QueryOptions{}), &payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "multipart/mixed; boundary="+batch.Boundary())
+	req.Header.Set("Accept", "multipart/mixed")
+
+	if o.username != "" && o.password != "" {
+		req.SetBasicAuth(o.username, o.password)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send batch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if batchRejected(resp.StatusCode, body) {
+		return nil, &BatchUnsupportedError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	responses, err := readBatchParts(resp.Header.Get("Content-Type"), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse batch response: %w", err)
+	}
+	if len(responses) != len(groups) {
+		return nil, fmt.Errorf("batch response has %d parts for %d requests", len(responses), len(groups))
+	}
+
+	results := make([]*OperationResult, len(requests))
+	failed := 0
+	for gi, g := range groups {
+		parts := responses[gi]
+		for n, i := range g.indexes {
+			// A failed changeset is answered with a single response
+			part := parts[0]
+			if len(parts) == len(g.indexes) {
+				part = parts[n]
+			}
+			results[i] = newOperationResult(requests[i].operation(), subRequests[i], part.resp, part.body)
+			if part.resp.StatusCode < 200 || part.resp.StatusCode >= 300 {
+				failed++
+			}
+		}
+	}
+	if failed > 0 {
+		return results, fmt.Errorf("%d of %d batch requests failed", failed, len(requests))
+	}
+	return results, nil
+}
+
+// newBatchSubRequest builds the HTTP request of one batch operation
+func (o *ODataService) newBatchSubRequest(r BatchRequest) (*http.Request, error) {
+	url := o.BuildURL(r.EntitySet, r.Key, r.Query)
+	if r.Method == "GET" {
+		url = o.jsonFormat(url)
+	}
+
+	var body io.Reader
+	if r.Body != nil {
+		jsonData, err := json.Marshal(stripMetadata(r.Body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal entity: %w", err)
+		}
+		body = bytes.NewReader(jsonData)
+	}
+
+	req, err := http.NewRequest(r.Method, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+		o.setPrefer(req)
+	}
+	if r.IfMatch != "" {
+		req.Header.Set("If-Match", r.IfMatch)
+	}
+	return req, nil
+}
+
+// batchTarget addresses a batch operation relative to the service root, as
+// servers resolve it against the $batch URL; related collections living
+// elsewhere keep their absolute URL
+func (o *ODataService) batchTarget(req *http.Request) string {
+	return strings.TrimPrefix(req.URL.String(), strings.TrimSuffix(o.baseURL, "/")+"/")
+}
+
+// writeBatchOperation writes a request for target as an application/http
+// part. Parts inside a changeset are numbered with a Content-ID, which V4
+// requires.
+func writeBatchOperation(w *multipart.Writer, req *http.Request, target string, contentID int) error {
+	header := textproto.MIMEHeader{
+		"Content-Type":              {"application/http"},
+		"Content-Transfer-Encoding": {"binary"},
+	}
+	if contentID > 0 {
+		header.Set("Content-ID", fmt.Sprint(contentID))
+	}
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(part, "%s %s HTTP/1.1\r\n", req.Method, target)
+	for name, values := range req.Header {
+		for _, value := range values {
+			fmt.Fprintf(part, "%s: %s\r\n", name, value)
+		}
+	}
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+		fmt.Fprintf(part, "Content-Length: %d\r\n", len(body))
+	}
+	fmt.Fprint(part, "\r\n")
+	_, err = part.Write(body)
+	return err
+}
+
+// batchPart is one response read from a $batch response
+type batchPart struct {
+	resp *http.Response
+	body []byte
+}
+
+// readBatchParts splits a multipart $batch response into its top-level
+// parts, each holding one response or the responses of a changeset
+func readBatchParts(contentType string, body []byte) ([][]batchPart, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		return nil, fmt.Errorf("unexpected content type %q", contentType)
+	}
+
+	var parts [][]batchPart
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return parts, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.HasPrefix(part.Header.Get("Content-Type"), "multipart/") {
+			changeset, err := readBatchParts(part.Header.Get("Content-Type"), content)
+			if err != nil {
+				return nil, err
+			}
+			var responses []batchPart
+			for _, p := range changeset {
+				responses = append(responses, p...)
+			}
+			parts = append(parts, responses)
+			continue
+		}
+
+		resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(content)), nil)
+		if err != nil {
+			return nil, err
+		}
+		respBody, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		parts = append(parts, []batchPart{{resp: resp, body: respBody}})
+	}
+}
+
+// ExecuteBulk sends write requests in a single $batch round trip, each in its
+// own changeset, and falls back to one request at a time when the service
+// rejects $batch. Any other failure of the batch is returned as it is: the
+// server may have applied the writes already, so resending them one by one
+// could repeat them. It reports whether $batch was used, and the requests
+// done so far to report when it isn't nil.
+func (o *ODataService) ExecuteBulk(requests []BatchRequest, report func(done int)) ([]*OperationResult, bool, error) {
+	if report == nil {
+		report = func(int) {}
+	}
+	if !o.batchUnsupported() {
+		results, err := o.ExecuteBatch(requests, false)
+		var unsupported *BatchUnsupportedError
+		if !errors.As(err, &unsupported) {
+			if results != nil {
+				report(len(requests))
+			}
+			return results, true, err
+		}
+		o.markBatchUnsupported()
+	}
+
+	results := make([]*OperationResult, len(requests))
+	failed := 0
+	for i, r := range requests {
+		url := o.BuildURL(r.EntitySet, r.Key, QueryOptions{})
+		result, err := o.writeEntity(r.operation(), r.Method, url, r.Body, r.IfMatch)
+		if err != nil {
+			failed++
+			// Keep a result for requests that never got an answer
+			if result == nil {
+				result = &OperationResult{Operation: r.operation(), Method: r.Method, URL: url, Status: err.Error()}
+			}
+		}
+		results[i] = result
+		report(i + 1)
+	}
+	if failed > 0 {
+		return results, false, fmt.Errorf("%d of %d requests failed", failed, len(requests))
+	}
+	return results, false, nil
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWriteBatchOperation(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		target    string
+		body      string
+		ifMatch   string
+		contentID int
+	}{
+		{"read", "GET", "Products(1)?$format=json", "", "", 0},
+		{"create in a changeset", "POST", "Products", `{"Name":"Chai"}`, "", 1},
+		{"versioned update", "MERGE", "Products(1)", `{"Price":"9.5"}`, `W/"42"`, 2},
+		{"delete", "DELETE", "Customers('A%2FB')", "", "*", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+			req, err := http.NewRequest(tt.method, "https://host/service.svc/"+tt.target, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			var buf bytes.Buffer
+			w := multipart.NewWriter(&buf)
+			if err := writeBatchOperation(w, req, tt.target, tt.contentID); err != nil {
+				t.Fatal(err)
+			}
+			w.Close()
+
+			part, err := multipart.NewReader(&buf, w.Boundary()).NextPart()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if part.Header.Get("Content-Type") != "application/http" || part.Header.Get("Content-Transfer-Encoding") != "binary" {
+				t.Errorf("part headers %v", part.Header)
+			}
+			if id := part.Header.Get("Content-ID"); (id != "") != (tt.contentID > 0) {
+				t.Errorf("Content-ID %q for content ID %d", id, tt.contentID)
+			}
+			r := textproto.NewReader(bufio.NewReader(part))
+			line, err := r.ReadLine()
+			if err != nil {
+				t.Fatal(err)
+			}
+			header, err := r.ReadMIMEHeader()
+			if err != nil {
+				t.Fatal(err)
+			}
+			sentBody, _ := io.ReadAll(r.R)
+			if want := tt.method + " " + tt.target + " HTTP/1.1"; line != want {
+				t.Errorf("request line %q, want %q", line, want)
+			}
+			if string(sentBody) != tt.body || header.Get("If-Match") != tt.ifMatch {
+				t.Errorf("sent If-Match %q and %q", header.Get("If-Match"), sentBody)
+			}
+			if tt.body != "" && header.Get("Content-Length") != strconv.Itoa(len(tt.body)) {
+				t.Errorf("Content-Length %q for %d bytes", header.Get("Content-Length"), len(tt.body))
+			}
+		})
+	}
+}
+
+func TestReadBatchParts(t *testing.T) {
+	crlf := func(s string) string { return strings.ReplaceAll(s, "\n", "\r\n") }
+	response := crlf(`--batch_1
+Content-Type: application/http
+Content-Transfer-Encoding: binary
+
+HTTP/1.1 200 OK
+Content-Type: application/json
+
+{"d":{"Name":"Chai"}}
+--batch_1
+Content-Type: multipart/mixed; boundary=changeset_1
+
+--changeset_1
+Content-Type: application/http
+Content-Transfer-Encoding: binary
+
+HTTP/1.1 201 Created
+Location: https://host/service.svc/Products(2)
+
+{"d":{"ID":2}}
+--changeset_1
+Content-Type: application/http
+Content-Transfer-Encoding: binary
+
+HTTP/1.1 204 No Content
+
+
+--changeset_1--
+--batch_1
+Content-Type: application/http
+Content-Transfer-Encoding: binary
+
+HTTP/1.1 412 Precondition Failed
+
+{"error":"changed"}
+--batch_1--
+`)
+	parts, err := readBatchParts("multipart/mixed; boundary=batch_1", []byte(response))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]struct {
+		status int
+		body   string
+	}{
+		{{200, `{"d":{"Name":"Chai"}}`}},
+		{{201, `{"d":{"ID":2}}`}, {204, ""}},
+		{{412, `{"error":"changed"}`}},
+	}
+	if len(parts) != len(want) {
+		t.Fatalf("%d parts, want %d", len(parts), len(want))
+	}
+	for i, part := range parts {
+		if len(part) != len(want[i]) {
+			t.Fatalf("part %d has %d responses, want %d", i, len(part), len(want[i]))
+		}
+		for j, resp := range part {
+			if resp.resp.StatusCode != want[i][j].status || strings.TrimSpace(string(resp.body)) != want[i][j].body {
+				t.Errorf("part %d response %d: %d %q, want %d %q", i, j, resp.resp.StatusCode, resp.body, want[i][j].status, want[i][j].body)
+			}
+		}
+	}
+	if location := parts[1][0].resp.Header.Get("Location"); location != "https://host/service.svc/Products(2)" {
+		t.Errorf("Location %q", location)
+	}
+}
+
+func TestReadBatchPartsErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{"not multipart", "application/json", `{"error":{}}`},
+		{"no content type", "", ""},
+		{"not an HTTP response", "multipart/mixed; boundary=b", "--b\r\nContent-Type: application/http\r\n\r\ngarbage\r\n--b--\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := readBatchParts(tt.contentType, []byte(tt.body)); err == nil {
+				t.Error("no error")
+			}
+		})
+	}
+}
+
+func TestBatchRejected(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   bool
+	}{
+		{"not implemented", http.StatusNotImplemented, "", true},
+		{"method not allowed", http.StatusMethodNotAllowed, "", true},
+		{"batch not supported", http.StatusBadRequest, `{"error":{"message":"$batch is not supported"}}`, true},
+		{"bad batch content", http.StatusBadRequest, `{"error":{"message":"Invalid changeset boundary"}}`, false},
+		{"unrelated unsupported", http.StatusBadRequest, `{"error":{"message":"Media type unsupported"}}`, false},
+		{"server error", http.StatusInternalServerError, "", false},
+		{"accepted", http.StatusAccepted, "$batch not implemented", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := batchRejected(tt.status, []byte(tt.body)); got != tt.want {
+				t.Errorf("batchRejected = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// batchReport is the outcome of a bulk write, kept with its Result column
+// so the operations that failed can be sent again
+type batchReport struct {
+	operation string // "delete" or "update"
+	entitySet string
+	requests  []BatchRequest
+	keys      []string
+	results   []*OperationResult
+}
+
+// failed returns the indexes of the operations that did not succeed
+func (r *batchReport) failed() []int {
+	var failed []int
+	for i, result := range r.results {
+		if result.StatusCode < 200 || result.StatusCode >= 300 {
+			failed = append(failed, i)
+		}
+	}
+	return failed
+}
+
+// lines renders the report as one row per operation with its status and
+// the OData error of a failure, followed by the actions on failures
+func (r *batchReport) lines(via string) []string {
+	failed := r.failed()
+	lines := []string{
+		fmt.Sprintf("Bulk %s via %s: %d of %d succeeded", r.operation, via, len(r.results)-len(failed), len(r.results)),
+		"",
+	}
+	for i, result := range r.results {
+		row := fmt.Sprintf("#%d %s %s(%s): %s", i+1, r.requests[i].Method, r.entitySet, r.keys[i], result.Status)
+		if result.StatusCode < 200 || result.StatusCode >= 300 {
+			row = "✗ " + row
+			if message := errorMessage(result.Body); result.Body != "" && message != "" {
+				row += " - " + strings.ReplaceAll(message, "\n", " ")
+			}
+		} else {
+			row = "✓ " + row
+		}
+		lines = append(lines, row)
+	}
+	if len(failed) > 0 {
+		lines = append(lines, "", "r: Retry failed | E: Edit and resubmit failed | Enter: Details")
+	}
+	return append(lines, "")
+}
+
+// openBatchReport shows the results of a bulk write in the Result column,
+// headed by the report
+func (m *model) openBatchReport(report *batchReport, via string) {
+	m.openResultColumn(report.entitySet, report.results)
+	col := &m.columns[m.activeColumn]
+	col.report = report
+
+	head := report.lines(via)
+	lineResults := make([]int, len(head))
+	for i := range head {
+		// Rows of the operations lead to their result
+		lineResults[i] = -1
+		if n := i - 2; n >= 0 && n < len(report.results) {
+			lineResults[i] = n
+		}
+	}
+	col.items = append(head, col.items...)
+	col.resultLines = append(lineResults, col.resultLines...)
+}
+
+// retryFailedOperations sends the failed operations of the report in the
+// active Result column again, as they were
+func (m model) retryFailedOperations() (tea.Model, tea.Cmd) {
+	report := m.columns[m.activeColumn].report
+	failed := report.failed()
+	if len(failed) == 0 {
+		m.logs = append(m.logs, "No failed operations to retry")
+		return m, nil
+	}
+	var requests []BatchRequest
+	var keys []string
+	for _, i := range failed {
+		requests = append(requests, report.requests[i])
+		keys = append(keys, report.keys[i])
+	}
+	m.logs = append(m.logs, fmt.Sprintf("Retrying %d failed %s operations on %s...", len(requests), report.operation, report.entitySet))
+	return m.sendBulkWrite(report.operation, report.entitySet, requests, keys)
+}
+
+// sendBulkWrite runs bulk write requests and reports them as a bulkWriteMsg
+func (m model) sendBulkWrite(operation, entitySet string, requests []BatchRequest, keys []string) (tea.Model, tea.Cmd) {
+	p := m.startProgress(fmt.Sprintf("Bulk %s in %s", operation, entitySet), "requests", len(requests))
+	if p == nil {
+		return m, nil
+	}
+	return m, p.run(func() tea.Msg {
+		results, batched, err := p.odata.ExecuteBulk(requests, func(done int) { p.report(done, len(requests)) })
+		return bulkWriteMsg{operation: operation, entitySet: entitySet, keys: keys, requests: requests, results: results, batched: batched, err: err}
+	})
+}
+
+// resubmitEntry is a failed operation as written to the editor for "E"
+type resubmitEntry struct {
+	Method string                 `json:"method"`
+	Key    string                 `json:"key"`
+	Body   map[string]interface{} `json:"body,omitempty"`
+}
+
+// editFailedOperations opens the failed operations of the report in the
+// active Result column in the modal editor, to be fixed and sent with F2
+func (m model) editFailedOperations() model {
+	report := m.columns[m.activeColumn].report
+	var entries []resubmitEntry
+	for _, i := range report.failed() {
+		r := report.requests[i]
+		entries = append(entries, resubmitEntry{Method: r.Method, Key: report.keys[i], Body: r.Body})
+	}
+	if len(entries) == 0 {
+		m.logs = append(m.logs, "No failed operations to edit")
+		return m
+	}
+
+	jsonData, _ := json.MarshalIndent(entries, "", "  ")
+	m.modalEditor = true
+	m.modalOperation = "resubmit"
+	m.editor = newTextEditor(strings.Split(string(jsonData), "\n"), 0, 0)
+	m.logs = append(m.logs, fmt.Sprintf("Resubmit mode - fix the %d failed operations, F2 to send them again, ESC to cancel", len(entries)))
+	return m
+}
+
+// saveModalResubmit sends the operations edited after "E" again
+func (m model) saveModalResubmit(jsonContent string) (tea.Model, tea.Cmd) {
+	var report *batchReport
+	if m.activeColumn < len(m.columns) {
+		report = m.columns[m.activeColumn].report
+	}
+	if report == nil {
+		m.logs = append(m.logs, "The batch report to resubmit is no longer open")
+		return m, nil
+	}
+
+	var entries []resubmitEntry
+	if err := json.Unmarshal([]byte(jsonContent), &entries); err != nil {
+		m.logs = append(m.logs, fmt.Sprintf("Invalid JSON array of operations: %v", err))
+		return m, nil
+	}
+	if len(entries) == 0 {
+		m.logs = append(m.logs, "No operations left to resubmit")
+		return m, nil
+	}
+	var requests []BatchRequest
+	var keys []string
+	for i, e := range entries {
+		if e.Method == "" || e.Key == "" {
+			m.logs = append(m.logs, fmt.Sprintf("Operation #%d needs a method and a key", i+1))
+			return m, nil
+		}
+		requests = append(requests, BatchRequest{Method: strings.ToUpper(e.Method), EntitySet: report.entitySet, Key: e.Key, Body: e.Body})
+		keys = append(keys, e.Key)
+	}
+	m.logs = append(m.logs, fmt.Sprintf("Resubmitting %d %s operations on %s...", len(requests), report.operation, report.entitySet))
+	return m.sendBulkWrite(report.operation, report.entitySet, requests, keys)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+)
+
+// breadcrumb returns the column stack up to the active column as OData
+// path segments, starting with the service, and the URL the active column
+// reads; nil when no service is connected
+func (m model) breadcrumb() ([]string, string) {
+	if m.odata == nil || m.serviceIndex < 0 || m.serviceIndex >= len(m.services) || len(m.columns) < 2 {
+		return nil, ""
+	}
+	segments := []string{m.services[m.serviceIndex].Name}
+	for i := 1; i <= m.activeColumn && i < len(m.columns); i++ {
+		col := m.columns[i]
+		switch {
+		case i == 1 && !col.isDetails:
+			// The entity sets are the service root
+		case col.isResult:
+			segments = append(segments, "Result")
+		case col.raw != nil:
+			segments = append(segments, "Raw Response")
+		case col.navURL != "":
+			segments = append(segments, col.title)
+		case col.isDetails:
+			if len(col.entities) == 0 {
+				continue
+			}
+			entitySet := m.detailsEntitySet(i)
+			key := extractEntityKey(m.metadata(), entitySet, col.entities[0])
+			if key == "" {
+				continue
+			}
+			// An entity of the list before it takes the place of the list
+			last := len(segments) - 1
+			if last > 0 && i > 1 && !m.columns[i-1].isDetails {
+				segments[last] += "(" + key + ")"
+			} else {
+				segments = append(segments, entitySet+"("+key+")")
+			}
+		case col.entitySet != "":
+			segments = append(segments, col.entitySet)
+		default:
+			segments = append(segments, col.title)
+		}
+	}
+	_, u, _, _ := m.requestURLs()
+	return segments, u
+}
+
+// renderBreadcrumb renders the breadcrumb line under the header, or ""
+// when no service is connected
+func (m model) renderBreadcrumb() string {
+	segments, u := m.breadcrumb()
+	if len(segments) == 0 {
+		return ""
+	}
+	hint := lipgloss.NewStyle().Foreground(theme.Muted)
+	crumb := lipgloss.NewStyle().Foreground(theme.Accent)
+	rendered := make([]string, len(segments))
+	for i, segment := range segments {
+		rendered[i] = crumb.Render(segment)
+	}
+	line := strings.Join(rendered, hint.Render(" › "))
+	if u != "" {
+		line += hint.Render("   " + u + "  (B: Copy)")
+	}
+	return truncate.StringWithTail(line, uint(max(m.width, 1)), "…")
+}
+
+// copyBreadcrumb puts the URL of the breadcrumb on the clipboard
+func (m model) copyBreadcrumb() model {
+	_, u := m.breadcrumb()
+	if u == "" {
+		m.logs = append(m.logs, "Nothing to copy here - open an entity set or entity")
+		return m
+	}
+	u = copyToClipboard(u)
+	m.logs = append(m.logs, "Copied the URL of the breadcrumb to the clipboard:", "  "+u)
+	return m
+}
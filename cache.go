@@ -0,0 +1,313 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultCacheTTL is how long cached responses are served without asking the server
+const DefaultCacheTTL = 24 * time.Hour
+
+// diskCacheMaxBytes caps the size of the cache dir; the entries stored
+// longest ago go first
+const diskCacheMaxBytes = 256 << 20
+
+// diskCachePruneEvery is the number of stores after which the cache dir is
+// checked against diskCacheMaxBytes again
+const diskCachePruneEvery = 100
+
+// cacheEntry is one GET response stored on disk
+type cacheEntry struct {
+	URL        string      `json:"url"`
+	ETag       string      `json:"etag,omitempty"`
+	Stored     time.Time   `json:"stored"`
+	StatusCode int         `json:"status"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+}
+
+// diskCache stores GET responses under the user cache dir, one file per
+// URL. Entries are served directly while fresh, revalidated with their ETag
+// once expired, and served stale when the server cannot be reached.
+type diskCache struct {
+	dir    string
+	ttl    time.Duration
+	stores atomic.Int32 // Stores since the last prune
+}
+
+// responseCache is the disk cache shared by all OData clients, nil when disabled
+var responseCache *diskCache
+
+// enableDiskCache turns on the response cache for clients created afterwards
+func enableDiskCache(ttl time.Duration) error {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(base, "odatanavigator", "responses")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	responseCache = &diskCache{dir: dir, ttl: ttl}
+	go responseCache.prune()
+	return nil
+}
+
+// path returns the entry file of a URL. The login of the request is part
+// of the key so different logins to the same service never share data.
+func (c *diskCache) path(req *http.Request) string {
+	sum := sha256.Sum256([]byte(requestIdentity(req) + " " + req.URL.String()))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// cacheIdentityKey is the request context key of the login an
+// Authorization header stands for, set where the header changes within
+// one login such as with refreshed OAuth2 tokens
+type cacheIdentityKey struct{}
+
+// requestIdentity returns the login a request is sent with: the user of
+// Basic auth, the OAuth2 client of a bearer token, and the API keys, static
+// tokens and other token headers. Passwords, refreshed tokens, session
+// cookies and CSRF tokens change within one login and are left out.
+func requestIdentity(req *http.Request) string {
+	var credentials []string
+	for name, values := range req.Header {
+		canonical := http.CanonicalHeaderKey(name)
+		if canonical == "Cookie" || canonical == "X-Csrf-Token" {
+			continue
+		}
+		if canonical == "Authorization" {
+			credentials = append(credentials, canonical+": "+authorizationIdentity(req))
+		} else if sensitiveHeader.MatchString(name+":") || sensitiveHeaderName.MatchString(name) {
+			credentials = append(credentials, canonical+": "+strings.Join(values, ", "))
+		}
+	}
+	sort.Strings(credentials)
+	return strings.Join(credentials, "\n")
+}
+
+// authorizationIdentity returns the login the Authorization header of a
+// request stands for
+func authorizationIdentity(req *http.Request) string {
+	if identity, ok := req.Context().Value(cacheIdentityKey{}).(string); ok {
+		return identity
+	}
+	if user, _, ok := req.BasicAuth(); ok {
+		return "Basic " + user
+	}
+	return req.Header.Get("Authorization")
+}
+
+func (c *diskCache) load(req *http.Request) *cacheEntry {
+	data, err := os.ReadFile(c.path(req))
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil
+	}
+	return &entry
+}
+
+func (c *diskCache) store(req *http.Request, entry *cacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	// Write to a temp file first so a crash, or another instance storing
+	// the same response, never leaves half an entry behind
+	writeFileAtomic(c.path(req), data, 0o600)
+	if c.stores.Add(1) >= diskCachePruneEvery {
+		c.stores.Store(0)
+		go c.prune()
+	}
+}
+
+// prune removes the entries written longest ago until the cache dir fits
+// in diskCacheMaxBytes
+func (c *diskCache) prune() {
+	files, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return
+	}
+	type entryFile struct {
+		path    string
+		size    int64
+		written time.Time
+	}
+	var entries []entryFile
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, entryFile{file, info.Size(), info.ModTime()})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].written.After(entries[j].written) })
+	var total int64
+	for _, entry := range entries {
+		total += entry.size
+		if total > diskCacheMaxBytes {
+			os.Remove(entry.path)
+		}
+	}
+}
+
+// writePrefix is the URL prefix of the cached responses a write request
+// makes stale, e.g. a POST to Products or a PUT to Products(1) affects every
+// Products URL. A $batch request may touch any entity set of its service.
+func writePrefix(req *http.Request) string {
+	prefix := req.URL.Scheme + "://" + req.URL.Host + req.URL.Path
+	if strings.HasSuffix(prefix, "/$batch") {
+		return strings.TrimSuffix(prefix, "$batch")
+	}
+	if paren := strings.Index(prefix, "("); paren != -1 && paren > strings.LastIndex(prefix, "/") {
+		prefix = prefix[:paren]
+	}
+	return prefix
+}
+
+// invalidate drops the entries of the entity set a write request targets
+func (c *diskCache) invalidate(req *http.Request) {
+	c.drop(writePrefix(req))
+}
+
+// drop removes the entries whose URL starts with prefix
+func (c *diskCache) drop(prefix string) {
+	files, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var entry struct {
+			URL string `json:"url"`
+		}
+		if json.Unmarshal(data, &entry) == nil && strings.HasPrefix(entry.URL, prefix) {
+			os.Remove(file)
+		}
+	}
+}
+
+// storedHeader returns the response headers kept with a cache entry:
+// session cookies are neither written to disk nor set again when the
+// entry is served
+func storedHeader(header http.Header) http.Header {
+	header = header.Clone()
+	header.Del("Set-Cookie")
+	return header
+}
+
+// response rebuilds an http.Response from a cache entry
+func (e *cacheEntry) response(req *http.Request, state string) *http.Response {
+	header := e.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	header.Set("X-Odatanavigator-Cache", state)
+	return &http.Response{
+		Status:        http.StatusText(e.StatusCode),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// cachingTransport answers GET requests from a diskCache
+type cachingTransport struct {
+	base  http.RoundTripper
+	cache *diskCache
+}
+
+func newCachingTransport(base http.RoundTripper, cache *diskCache) *cachingTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &cachingTransport{base: base, cache: cache}
+}
+
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Streams downloaded to disk must not be read into memory here
+	if req.Header.Get("Cache-Control") == "no-store" {
+		return t.base.RoundTrip(req)
+	}
+	if req.Method != http.MethodGet {
+		resp, err := t.base.RoundTrip(req)
+		if err == nil && resp.StatusCode < 300 {
+			t.cache.invalidate(req)
+		}
+		return resp, err
+	}
+
+	entry := t.cache.load(req)
+	if entry != nil && time.Since(entry.Stored) < t.cache.ttl {
+		requestTraces.addCached(req, entry, "cache hit")
+		return entry.response(req, "hit"), nil
+	}
+
+	if entry != nil && entry.ETag != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		// Keep browsing with what we have while the connection is down,
+		// but a cancelled request stays cancelled
+		if entry != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, errRequestCancelled) {
+			requestTraces.addCached(req, entry, "stale: "+err.Error())
+			return entry.response(req, "stale"), nil
+		}
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		resp.Body.Close()
+		entry.Stored = time.Now()
+		t.cache.store(req, entry)
+		return entry.response(req, "revalidated"), nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	t.cache.store(req, &cacheEntry{
+		URL:        req.URL.String(),
+		ETag:       resp.Header.Get("ETag"),
+		Stored:     time.Now(),
+		StatusCode: resp.StatusCode,
+		Header:     storedHeader(resp.Header),
+		Body:       body,
+	})
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// DefaultTimeout is how long a service may take to start answering a
+// request before it is given up on
+const DefaultTimeout = 60 * time.Second
+
+// errRequestCancelled is the error of requests aborted with Esc
+var errRequestCancelled = errors.New("request cancelled")
+
+type detachedKey struct{}
+
+// detached marks a request that ODataService.Cancel leaves running, such as
+// a download or upload that has its own progress display
+func detached(req *http.Request) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), detachedKey{}, true))
+}
+
+// canceller holds the context the requests of a service run under;
+// cancelling it aborts every request in flight. The loads of columns run
+// under a context of their own within it, so one can be cancelled alone.
+type canceller struct {
+	mu      sync.Mutex
+	ctx     context.Context
+	cancel  context.CancelFunc
+	columns map[int]columnLoad // Contexts of the column loads, by column ID
+}
+
+// columnLoad is the context a load of a column runs under
+type columnLoad struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newCanceller() *canceller {
+	c := &canceller{columns: make(map[int]columnLoad)}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+	return c
+}
+
+func (c *canceller) current() context.Context {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ctx
+}
+
+// column returns a fresh context for a load of the column with the ID; a
+// load of the column still running is superseded and cancelled
+func (c *canceller) column(id int) context.Context {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if load, ok := c.columns[id]; ok {
+		load.cancel()
+	}
+	ctx, cancel := context.WithCancel(c.ctx)
+	c.columns[id] = columnLoad{ctx: ctx, cancel: cancel}
+	return ctx
+}
+
+// release drops the context of a load of the column with the ID once it is
+// done, unless a newer load of the column superseded it
+func (c *canceller) release(id int, ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if load, ok := c.columns[id]; ok && load.ctx == ctx {
+		load.cancel()
+		delete(c.columns, id)
+	}
+}
+
+// cancelColumn aborts the load of the column with the ID only
+func (c *canceller) cancelColumn(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if load, ok := c.columns[id]; ok {
+		load.cancel()
+		delete(c.columns, id)
+	}
+}
+
+// cancelAll aborts the requests in flight and starts a fresh context for the next ones
+func (c *canceller) cancelAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cancel()
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+	c.columns = make(map[int]columnLoad)
+}
+
+// Cancel aborts all requests in flight except detached transfers
+func (o *ODataService) Cancel() {
+	if o.cancels != nil {
+		o.cancels.cancelAll()
+	}
+}
+
+// CancelColumn aborts the load of one column, leaving the other requests
+// in flight running
+func (o *ODataService) CancelColumn(id int) {
+	if o.cancels != nil {
+		o.cancels.cancelColumn(id)
+	}
+}
+
+// forColumn returns the service for a load of the column with the ID: its
+// requests run under the context of the column, so CancelColumn aborts
+// them alone. What the load learns about the service, such as its
+// metadata, is shared with the service it was made from. The load calls
+// loaded when its result message is ready, which drops the context.
+func (o *ODataService) forColumn(id int) *ODataService {
+	if o == nil || o.cancels == nil || id == 0 {
+		return o
+	}
+	ctx := o.cancels.column(id)
+	scoped := o.withContext(ctx)
+	scoped.release = func() { o.cancels.release(id, ctx) }
+	return scoped
+}
+
+// loaded ends the column load the service was made for by forColumn
+func (o *ODataService) loaded() {
+	if o != nil && o.release != nil {
+		o.release()
+	}
+}
+
+// withContext returns the service with its requests running under ctx,
+// for a column load or an operation that is cancelled on its own
+func (o *ODataService) withContext(ctx context.Context) *ODataService {
+	scoped := *o
+	client := *o.client
+	client.Transport = &cancelTransport{base: o.client.Transport, parent: func() context.Context { return ctx }}
+	scoped.client = &client
+	return &scoped
+}
+
+// cancelTransport ties every request to the context parent returns: that
+// of its service, or of the column it loads
+type cancelTransport struct {
+	base   http.RoundTripper
+	parent func() context.Context
+}
+
+func (t *cancelTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Context().Value(detachedKey{}) != nil {
+		return t.base.RoundTrip(req)
+	}
+	parent := t.parent()
+	if parent.Err() != nil {
+		return nil, errRequestCancelled
+	}
+	ctx, cancel := context.WithCancel(req.Context())
+	stop := context.AfterFunc(parent, cancel)
+	release := func() {
+		stop()
+		cancel()
+	}
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		release()
+		if parent.Err() != nil {
+			return nil, errRequestCancelled
+		}
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, parent: parent, release: release}
+	return resp, nil
+}
+
+// cancelBody releases the request context once the body is closed
+type cancelBody struct {
+	io.ReadCloser
+	parent  context.Context
+	release func()
+}
+
+func (b *cancelBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF && b.parent.Err() != nil {
+		err = errRequestCancelled
+	}
+	return n, err
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
+// isCancelled reports whether an error message comes from a request aborted with Esc
+func isCancelled(message string) bool {
+	return strings.Contains(message, errRequestCancelled.Error())
+}
+
+// Update lets x cancel the requests in flight, and Esc the load of the
+// active column, keeps the spinner and the elapsed time of loading columns
+// ticking, trims the log to its retention and records the navigation
+// history and visits
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if _, ok := msg.(spinnerTickMsg); ok {
+		return m.updateSpinner()
+	}
+	if key, ok := msg.(tea.KeyMsg); ok && m.activeOverlay() == nil {
+		switch {
+		case key.String() == "x" && m.busy():
+			return m.cancelLoad()
+		case key.String() == "esc" && m.activeColumn < len(m.columns) && m.columns[m.activeColumn].loading():
+			return m.cancelColumnLoad()
+		case key.String() == "esc" && m.escCancels():
+			return m.cancelLoad()
+		}
+	}
+
+	next, cmd := m.update(msg)
+	nm, ok := next.(model)
+	if !ok {
+		return next, cmd
+	}
+	nm.trimLogs()
+	nm.recordHistory()
+	nm.recordVisit()
+	if spin := nm.trackLoading(); spin != nil {
+		return nm, tea.Batch(cmd, spin)
+	}
+	return nm, cmd
+}
+
+// cancelLoad aborts the requests in flight. The columns waiting for them
+// stay where they are and offer to retry.
+func (m model) cancelLoad() (tea.Model, tea.Cmd) {
+	if m.odata != nil {
+		m.odata.Cancel()
+	}
+	if m.progress != nil {
+		m.progress.cancel()
+	}
+	m.loading = false
+	m.preview.loading = false
+	m.failColumns(errorMsg{err: errRequestCancelled.Error()})
+	m.logs = append(m.logs, "Loading cancelled")
+	return m, nil
+}
+
+// cancelColumnLoad aborts the load of the active column only; the other
+// columns keep loading and can be browsed meanwhile
+func (m model) cancelColumnLoad() (tea.Model, tea.Cmd) {
+	col := &m.columns[m.activeColumn]
+	if col.id == 0 {
+		return m.cancelLoad()
+	}
+	if m.odata != nil {
+		m.odata.CancelColumn(col.id)
+	}
+	m.failColumns(errorMsg{err: errRequestCancelled.Error(), column: col.id})
+	if m.loadingColumns() == 0 {
+		m.loading = false
+	}
+	m.logs = append(m.logs, fmt.Sprintf("Loading of %s cancelled", col.title))
+	return m, nil
+}
@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestCancellerRelease(t *testing.T) {
+	c := newCanceller()
+	first := c.column(1)
+	second := c.column(1)
+	if first.Err() == nil {
+		t.Error("a newer load did not cancel the one it superseded")
+	}
+
+	c.release(1, first)
+	if second.Err() != nil || len(c.columns) != 1 {
+		t.Fatal("the superseded load released the newer one")
+	}
+	c.release(1, second)
+	if second.Err() == nil || len(c.columns) != 0 {
+		t.Errorf("a finished load is still registered: %d loads", len(c.columns))
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// shiftMoves are the keys that extend the selection of the modal editor,
+// with the movement each makes
+var shiftMoves = map[string]string{
+	"shift+up":    "up",
+	"shift+down":  "down",
+	"shift+left":  "left",
+	"shift+right": "right",
+	"shift+home":  "home",
+	"shift+end":   "end",
+}
+
+// clipboardMsg carries the text read from the system clipboard for pasting
+type clipboardMsg struct {
+	text string
+	err  error
+}
+
+// readClipboard reads the system clipboard with the tool of the platform.
+// OSC 52 only writes to the clipboard of the terminal, so reading it needs
+// pbpaste, PowerShell, wl-paste, xclip or xsel.
+func readClipboard() tea.Cmd {
+	return func() tea.Msg {
+		var commands [][]string
+		switch runtime.GOOS {
+		case "darwin":
+			commands = [][]string{{"pbpaste"}}
+		case "windows":
+			commands = [][]string{{"powershell", "-NoProfile", "-Command", "Get-Clipboard -Raw"}}
+		default:
+			if os.Getenv("WAYLAND_DISPLAY") != "" {
+				commands = append(commands, []string{"wl-paste", "--no-newline"})
+			}
+			commands = append(commands,
+				[]string{"xclip", "-selection", "clipboard", "-out"},
+				[]string{"xsel", "--clipboard", "--output"})
+		}
+		for _, command := range commands {
+			if _, err := exec.LookPath(command[0]); err != nil {
+				continue
+			}
+			out, err := exec.Command(command[0], command[1:]...).Output()
+			if err != nil {
+				return clipboardMsg{err: fmt.Errorf("%s: %v", command[0], err)}
+			}
+			return clipboardMsg{text: string(out)}
+		}
+		return clipboardMsg{err: errors.New("no clipboard tool found (install xclip, xsel or wl-clipboard)")}
+	}
+}
+
+// selection returns the start and end of the selected text, in order, and
+// false when nothing is selected
+func (e textEditor) selection() (from, to editorMark, ok bool) {
+	if e.anchor == nil || e.anchor.line >= len(e.content) || e.cursor >= len(e.content) {
+		return from, to, false
+	}
+	from = *e.anchor
+	from.col = min(from.col, len(e.content[from.line]))
+	to = editorMark{line: e.cursor, col: e.col}
+	if to.line < from.line || (to.line == from.line && to.col < from.col) {
+		from, to = to, from
+	}
+	return from, to, from != to
+}
+
+// selectedCols returns the bytes of a line that are selected, from a to b;
+// a equals b on lines outside the selection
+func (e textEditor) selectedCols(line int) (a, b int) {
+	from, to, ok := e.selection()
+	if !ok || line < from.line || line > to.line {
+		return 0, 0
+	}
+	b = len(e.content[line])
+	if line == from.line {
+		a = from.col
+	}
+	if line == to.line {
+		b = to.col
+	}
+	return a, b
+}
+
+// selects reports whether part of a line is selected
+func (e textEditor) selects(line int) bool {
+	a, b := e.selectedCols(line)
+	return a < b
+}
+
+// paint returns the part of a line from start to end with its selected
+// bytes highlighted
+func (e textEditor) paint(line, start, end int) string {
+	text := e.content[line]
+	a, b := e.selectedCols(line)
+	a, b = max(a, start), min(b, end)
+	if a >= b {
+		return text[start:end]
+	}
+	selected := lipgloss.NewStyle().Background(theme.Selection).Foreground(theme.AccentText)
+	return text[start:a] + selected.Render(text[a:b]) + text[b:end]
+}
+
+// selectedText returns the selected text, or the line of the cursor with
+// its line feed when nothing is selected
+func (e textEditor) selectedText() string {
+	from, to, ok := e.selection()
+	if !ok {
+		if e.cursor >= len(e.content) {
+			return ""
+		}
+		return e.content[e.cursor] + "\n"
+	}
+	if from.line == to.line {
+		return e.content[from.line][from.col:to.col]
+	}
+	lines := []string{e.content[from.line][from.col:]}
+	lines = append(lines, e.content[from.line+1:to.line]...)
+	lines = append(lines, e.content[to.line][:to.col])
+	return strings.Join(lines, "\n")
+}
+
+// deleteSelection removes the selected text, leaving the cursor where it
+// started
+func (e *textEditor) deleteSelection() {
+	from, to, ok := e.selection()
+	e.anchor = nil
+	if !ok {
+		return
+	}
+	joined := e.content[from.line][:from.col] + e.content[to.line][to.col:]
+	content := append([]string(nil), e.content[:from.line]...)
+	content = append(content, joined)
+	e.content = append(content, e.content[to.line+1:]...)
+	e.cursor, e.col = from.line, from.col
+}
+
+// insertText inserts text at the cursor as it is, without auto-closing or
+// indenting, and leaves the cursor after it
+func (e *textEditor) insertText(text string) {
+	text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+	if e.cursor >= len(e.content) {
+		e.content = append(e.content, "")
+		e.cursor = len(e.content) - 1
+	}
+	line := e.content[e.cursor]
+	before, after := line[:e.col], line[e.col:]
+	lines := strings.Split(text, "\n")
+	last := len(lines) - 1
+	e.col = len(lines[last])
+	lines[0] = before + lines[0]
+	lines[last] += after
+
+	content := append([]string(nil), e.content[:e.cursor]...)
+	content = append(content, lines...)
+	e.content = append(content, e.content[e.cursor+1:]...)
+	e.cursor += last
+}
+
+// copyToClipboard puts text on the clipboard with its credentials redacted,
+// like the log pane and the trace viewer show it, and returns what was copied
+func copyToClipboard(text string) string {
+	text = redactText(text)
+	// OSC 52 puts the text on the clipboard of the terminal, even over SSH
+	termenv.Copy(text)
+	return text
+}
+
+// copyFromEditor puts the selection, or the line of the cursor, on the
+// clipboard, and cuts it from the text when cut is true
+func (m model) copyFromEditor(cut bool) (tea.Model, tea.Cmd) {
+	text := m.editor.selectedText()
+	copyToClipboard(text)
+	m.editorClipboard = text
+	what := "the selection"
+	if _, _, ok := m.editor.selection(); !ok {
+		what = "the line"
+	}
+	if !cut {
+		m.logs = append(m.logs, fmt.Sprintf("Copied %s to the clipboard (%d chars)", what, len([]rune(text))))
+		return m, nil
+	}
+	if _, _, ok := m.editor.selection(); ok {
+		m.editor.deleteSelection()
+	} else {
+		m.editor.deleteLine()
+	}
+	m.editor.follow(m.editor.rows(m.editorWidth()), m.editorHeight())
+	m.logs = append(m.logs, fmt.Sprintf("Cut %s to the clipboard (%d chars)", what, len([]rune(text))))
+	cmd := m.scheduleJSONCheck()
+	return m, cmd
+}
+
+// pasteIntoEditor inserts the text read from the clipboard at the cursor of
+// the modal editor, replacing the selection. Where the clipboard can't be
+// read, the text last copied in the editor is pasted instead.
+func (m model) pasteIntoEditor(msg clipboardMsg) (tea.Model, tea.Cmd) {
+	if !m.modalEditor || m.form.active {
+		return m, nil
+	}
+	text := msg.text
+	if msg.err != nil {
+		if m.editorClipboard == "" {
+			m.logs = append(m.logs, fmt.Sprintf("ERROR [paste]: reading the clipboard: %v", msg.err))
+			return m, nil
+		}
+		m.logs = append(m.logs, fmt.Sprintf("WARNING: cannot read the clipboard (%v) - pasted the text last copied in the editor", msg.err))
+		text = m.editorClipboard
+	}
+	// The clipboard holds the text last copied in the editor with its
+	// credentials redacted; the text as it was copied goes back in
+	if msg.err == nil && m.editorClipboard != "" && text == redactText(m.editorClipboard) {
+		text = m.editorClipboard
+	}
+	if text == "" {
+		m.logs = append(m.logs, "The clipboard is empty")
+		return m, nil
+	}
+	m.editor.deleteSelection()
+	if text == m.editorClipboard && strings.HasSuffix(text, "\n") {
+		// A whole line copied or cut goes in above the line of the cursor
+		m.editor.col = 0
+	}
+	m.editor.insertText(text)
+	m.editor.follow(m.editor.rows(m.editorWidth()), m.editorHeight())
+	m.debugf("Pasted %d bytes into the modal editor", len(text))
+	cmd := m.scheduleJSONCheck()
+	return m, cmd
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPasteIntoEditor(t *testing.T) {
+	copied := `"password": "s3cret",`
+	tests := []struct {
+		name      string
+		clipboard clipboardMsg
+		want      string
+	}{
+		{"own copy redacted", clipboardMsg{text: redactText(copied)}, copied},
+		{"other text", clipboardMsg{text: `"Name": "Chai",`}, `"Name": "Chai",`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := model{modalEditor: true, editorClipboard: copied, editor: newTextEditor([]string{""}, 0, 0), width: 120, height: 40}
+			next, _ := m.pasteIntoEditor(tt.clipboard)
+			if got := strings.Join(next.(model).editor.content, "\n"); got != tt.want {
+				t.Errorf("pasted %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
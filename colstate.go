@@ -0,0 +1,54 @@
+package main
+
+// columnState is how far a column got with loading what it shows
+type columnState int
+
+const (
+	stateIdle    columnState = iota // Shows nothing from the server, or is filled in place
+	stateLoading                    // A request for its content is in flight
+	stateLoaded                     // Shows the response of its last load
+	stateError                      // Its last load failed; failure says why
+)
+
+func (s columnState) String() string {
+	switch s {
+	case stateLoading:
+		return "loading"
+	case stateLoaded:
+		return "loaded"
+	case stateError:
+		return "error"
+	}
+	return "idle"
+}
+
+// newColumnID hands out the ID a column is known by in the messages of its
+// loads, so responses find their column even when titles repeat
+func (m *model) newColumnID() int {
+	m.lastColumnID++
+	return m.lastColumnID
+}
+
+// columnByID returns the open column with the ID, nil when it was closed
+// before its response arrived
+func (m *model) columnByID(id int) *column {
+	if id == 0 {
+		return nil
+	}
+	for i := range m.columns {
+		if m.columns[i].id == id {
+			return &m.columns[i]
+		}
+	}
+	return nil
+}
+
+// columnIndex returns the position of the column with the ID, -1 when it is closed
+func (m model) columnIndex(id int) int {
+	for i := range m.columns {
+		if id != 0 && m.columns[i].id == id {
+			return i
+		}
+	}
+	return -1
+}
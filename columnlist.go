@@ -0,0 +1,381 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// columnView is what drawing a column takes from the rest of the screen
+type columnView struct {
+	active   bool
+	wrapping bool          // Long items wrap instead of being cut
+	finding  bool          // A find query is being typed into the column
+	spinner  string        // Spinner frame shown in the title while the column loads
+	timeout  time.Duration // Request timeout of the service, 0 for none
+	edit     *inlineEditor // Edit mode of the column, nil unless it is edited
+}
+
+// Update handles what concerns the column alone: the navigation keys Up/Down
+// (k/j), PgUp/PgDown, Home and End move the cursor, scrolling the rows the
+// column shows to keep it in view, and a page of entities loaded for it
+// fills or extends the list. It returns false for other messages.
+func (c column) Update(msg tea.Msg) (column, bool) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		return c, c.moveCursor(msg.String())
+	case entitiesMsg:
+		c.showEntities(msg)
+		return c, true
+	}
+	return c, false
+}
+
+// moveCursor moves the cursor for a navigation key, returning false for
+// other keys
+func (c *column) moveCursor(key string) bool {
+	visibleHeight := c.height - 2 // Account for borders
+	switch key {
+	case "up", "k":
+		if c.cursor > 0 {
+			c.cursor--
+		}
+	case "down", "j":
+		if c.cursor < len(c.items)-1 {
+			c.cursor++
+		}
+	case "pgup":
+		c.cursor = max(c.cursor-visibleHeight, 0)
+		c.scrollOffset = c.cursor
+	case "pgdown":
+		c.cursor = max(min(c.cursor+visibleHeight, len(c.items)-1), 0)
+	case "home":
+		c.cursor = 0
+	case "end":
+		c.cursor = max(len(c.items)-1, 0)
+		c.scrollOffset = max(len(c.items)-visibleHeight, 0)
+	default:
+		return false
+	}
+	if c.cursor < c.scrollOffset {
+		c.scrollOffset = c.cursor
+	}
+	if c.cursor >= c.scrollOffset+visibleHeight {
+		c.scrollOffset = c.cursor - visibleHeight + 1
+	}
+	return true
+}
+
+// showEntities fills the column with a page of entities, or appends the
+// next page in place of the "more" marker keeping the cursor
+func (c *column) showEntities(msg entitiesMsg) {
+	c.state = stateLoaded
+	if msg.appendPage {
+		c.entities = append(c.entities, msg.entities...)
+		c.items = c.items[:0]
+		for _, entity := range c.entities {
+			c.items = append(c.items, formatEntityForDisplay(entity))
+		}
+		c.hasMore = msg.hasMore
+		c.nextLink = msg.nextLink
+		if msg.counted {
+			c.counted, c.total = true, msg.total
+		}
+		if msg.hasMore {
+			c.items = append(c.items, c.moreItem())
+		}
+		if c.cursor >= len(c.items) {
+			c.cursor = len(c.items) - 1
+		}
+		return
+	}
+	c.entities = msg.entities
+	c.hasMore = msg.hasMore
+	c.nextLink = msg.nextLink
+	c.selected = nil
+	c.counted = msg.counted
+	c.total = msg.total
+
+	// Handle metadata specially
+	if msg.entitySet == "Metadata" && len(msg.entities) > 0 {
+		if metadataStr, ok := msg.entities[0]["metadata"].(string); ok {
+			// Format metadata for better display with word wrapping
+			c.items = formatMetadataForDisplay(metadataStr, c.width-4) // Account for borders and padding
+		} else {
+			c.items = []string{"Error: Could not parse metadata"}
+		}
+		return
+	}
+	// Regular entity list
+	c.items = []string{}
+	for _, entity := range msg.entities {
+		c.items = append(c.items, formatEntityForDisplay(entity))
+	}
+	// Add "more" indicator if truncated
+	if msg.hasMore {
+		c.items = append(c.items, c.moreItem())
+	}
+	if len(c.items) == 0 {
+		c.items = []string{"(No items)"}
+	}
+}
+
+// showDetails shows an entity read in full as the lines of a Details column
+func (c *column) showDetails(entity map[string]interface{}, lines []string) {
+	c.entities = []map[string]interface{}{entity}
+	c.items = lines
+	c.openSections = nil
+	c.state = stateLoaded
+	c.cursor = 0
+	c.scrollOffset = 0
+}
+
+// loadingText is the placeholder of a loading column with the time the load
+// has been running and, when the service has a timeout, how long it has left
+func (c column) loadingText(placeholder string, v columnView) string {
+	if c.loadStarted.IsZero() {
+		return placeholder
+	}
+	elapsed := time.Since(c.loadStarted).Truncate(time.Second)
+	text := fmt.Sprintf("%s %s", placeholder, elapsed)
+	if v.timeout > 0 {
+		left := (v.timeout - elapsed).Truncate(time.Second)
+		if left < 0 {
+			left = 0
+		}
+		text += fmt.Sprintf(" (times out in %s)", left)
+	}
+	if v.active {
+		return text + " | ESC: Cancel | x: Cancel all"
+	}
+	return text + " | x: Cancel all"
+}
+
+// columnView returns how the column at index i is drawn
+func (m model) columnView(i int) columnView {
+	col := m.columns[i]
+	v := columnView{
+		active:   i == m.activeColumn,
+		wrapping: m.wraps(col),
+		spinner:  m.spinner(),
+	}
+	v.finding = m.finding && v.active
+	if m.serviceIndex >= 0 && m.serviceIndex < len(m.services) {
+		v.timeout = m.services[m.serviceIndex].RequestTimeout()
+	}
+	if m.inlineEdit.active && v.active && col.isDetails {
+		v.edit = &m.inlineEdit
+	}
+	return v
+}
+
+// View draws the column: its title, and the rows in view with the cursor,
+// marks and wrapping
+func (col column) View(v columnView) string {
+	var items []string
+	// Items are cut, or wrapped, to the width inside the padding
+	width := max(col.width-2, 1)
+	wrapping := v.wrapping
+	isActive := v.active
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Padding(0, 1)
+
+	if isActive {
+		titleStyle = titleStyle.Foreground(theme.Accent)
+	} else {
+		titleStyle = titleStyle.Foreground(theme.Border)
+	}
+
+	// If in edit mode and this is the active column with details
+	if v.edit != nil {
+		// Show editable content with EDIT indicator in title
+		titleStyle = titleStyle.Background(theme.Edit).Foreground(theme.AccentText)
+
+		items = v.edit.View()
+	} else {
+		// Normal display mode, showing the viewport of the column or its find matches
+		rows := col.visibleRows()
+		if wrapping {
+			rows = col.wrappedRows(width)
+		}
+		if col.find != "" && len(rows) == 0 {
+			items = append(items, lipgloss.NewStyle().Padding(0, 1).Foreground(theme.Muted).Render("(no matches)"))
+		}
+		for _, i := range rows {
+			item := col.items[i]
+			style := lipgloss.NewStyle().Padding(0, 1)
+			if col.isLoadingPlaceholder() {
+				item = col.loadingText(item, v)
+			}
+			if col.find != "" {
+				item = highlightMatch(item, col.find)
+			}
+
+			// Color function imports and more indicators differently
+			if strings.HasPrefix(col.items[i], "[FUNC]") {
+				if i == col.cursor && isActive {
+					style = style.Background(theme.Selection).Foreground(theme.AccentText)
+				} else if i == col.cursor {
+					style = style.Background(theme.Border).Foreground(theme.Text)
+				} else {
+					// Function imports in purple/magenta
+					style = style.Foreground(theme.Function)
+				}
+			} else if strings.HasPrefix(col.items[i], "[...more") {
+				// More indicator in gray/dimmed
+				if i == col.cursor && isActive {
+					style = style.Background(theme.Selection).Foreground(theme.AccentText)
+				} else if i == col.cursor {
+					style = style.Background(theme.Border).Foreground(theme.Text)
+				} else {
+					style = style.Foreground(theme.Dim) // Gray/dimmed
+				}
+			} else {
+				if i == col.cursor && isActive {
+					style = style.Background(theme.Selection).Foreground(theme.AccentText)
+				} else if i == col.cursor {
+					style = style.Background(theme.Border).Foreground(theme.Text)
+				}
+
+				// Mark selected entities, indenting the others to keep them aligned
+				if len(col.selected) > 0 && i < len(col.entities) {
+					if col.selected[i] {
+						item = "● " + item
+						if i != col.cursor {
+							style = style.Foreground(theme.Warning)
+						}
+					} else {
+						item = "  " + item
+					}
+				}
+
+				// Handle grayed out additional info
+				if strings.Contains(item, " | ") {
+					parts := strings.SplitN(item, " | ", 2)
+					if len(parts) == 2 {
+						// Style: key (normal) + " | " + description (grayed)
+						mainPart := parts[0]
+						grayPart := " | " + parts[1]
+
+						if i == col.cursor && isActive {
+							item = mainPart + lipgloss.NewStyle().Foreground(theme.Dim).Render(grayPart)
+						} else if i == col.cursor {
+							item = mainPart + lipgloss.NewStyle().Foreground(theme.Dim).Render(grayPart)
+						} else {
+							item = mainPart + lipgloss.NewStyle().Foreground(theme.Dim).Render(grayPart)
+						}
+					}
+				}
+			}
+
+			if wrapping {
+				item = strings.Join(wrapItem(item, width), "\n")
+			} else {
+				item = fitItem(item, width)
+			}
+			items = append(items, style.Render(item))
+		}
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Left, items...)
+	if lines := strings.Split(content, "\n"); wrapping && len(lines) > col.height-2 {
+		// The last row wrapped may not fit in full
+		content = strings.Join(lines[:max(col.height-2, 1)], "\n")
+	}
+
+	columnStyle := lipgloss.NewStyle().
+		Width(col.width).
+		Height(col.height).
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(theme.Border)
+
+	if isActive {
+		columnStyle = columnStyle.BorderForeground(theme.Accent)
+	}
+	if col.state == stateError {
+		columnStyle = columnStyle.BorderForeground(theme.Error)
+	}
+
+	// Modify title for edit mode and add scroll indicator
+	baseTitle := col.countedTitle()
+	if col.query.Search != "" {
+		baseTitle += " [search: " + col.query.Search + "]"
+	}
+	if col.query.Filter != "" {
+		baseTitle += " [$filter=" + col.query.Filter + "]"
+	}
+	if col.query.OrderBy != "" {
+		baseTitle += " [$orderby=" + col.query.OrderBy + "]"
+	}
+	if col.query.Expand != "" {
+		baseTitle += " [$expand=" + col.query.Expand + "]"
+	}
+	if len(col.selected) > 0 {
+		baseTitle += fmt.Sprintf(" [%d selected]", len(col.selected))
+	}
+	if col.find != "" || v.finding {
+		baseTitle += fmt.Sprintf(" [/%s: %d of %d]", col.find, len(col.findMatches()), len(col.items))
+	}
+	if col.loading() && !col.isPreview {
+		baseTitle = v.spinner + " " + baseTitle
+	}
+	title := baseTitle
+	if v.edit != nil {
+		title = "[EDIT] " + col.title
+	}
+	// Add scroll indicator for any column with large content
+	if col.find == "" && len(col.items) > col.height-2 && col.height > 2 {
+		totalLines := len(col.items)
+		visibleHeight := col.height - 2
+		currentPos := col.scrollOffset + 1
+		endPos := currentPos + visibleHeight - 1
+		if endPos > totalLines {
+			endPos = totalLines
+		}
+		title = fmt.Sprintf("%s (%d-%d/%d)", baseTitle, currentPos, endPos, totalLines)
+	}
+
+	return columnStyle.Render(
+		lipgloss.JoinVertical(lipgloss.Left,
+			titleStyle.Render(fitItem(title, width)),
+			"",
+			content,
+		),
+	)
+}
+
+// applyEntitySets fills the EntitySets column with the entity sets of the
+// service after its $metadata entry, then goes on to a start location
+func (m model) applyEntitySets(msg entitySetsMsg) (tea.Model, tea.Cmd) {
+	m.loading = false
+	m.logs = append(m.logs, fmt.Sprintf("Loaded %d entity sets", len(msg.names)))
+	m.logs = append(m.logs, strings.Join(m.odata.ServiceSummary(), " | "))
+
+	if col := m.columnByID(msg.column); col != nil {
+		col.items = []string{}
+
+		// Add $metadata as first entry
+		if problem := m.odata.MetadataProblem(); problem != "" {
+			col.items = append(col.items, "$metadata [UNAVAILABLE: "+problem+"]")
+			m.logs = append(m.logs, fmt.Sprintf("$metadata unavailable (%s): entity sets from the service document, keys and types inferred from data", problem))
+		} else {
+			col.items = append(col.items, "$metadata [META]")
+		}
+
+		col.items = append(col.items, m.odata.EntitySetDisplayItems(msg.names)...)
+		col.items = m.withSetCounts(m.pinFavorites(col.items))
+		if len(col.items) == 1 { // Only $metadata
+			col.items = append(col.items, "(No entity sets)")
+		}
+		col.state = stateLoaded
+	}
+	if m.pendingLink != nil {
+		return m.followLink()
+	}
+	return m, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// listColumn is an entity list column of n items, 7 rows high
+func listColumn(n, cursor, scroll int) column {
+	col := column{title: "Products", width: 40, height: 7, cursor: cursor, scrollOffset: scroll}
+	for i := 0; i < n; i++ {
+		col.items = append(col.items, fmt.Sprintf("Product %d", i))
+	}
+	return col
+}
+
+func TestColumnUpdateKeys(t *testing.T) {
+	tests := []struct {
+		name       string
+		col        column
+		key        tea.KeyMsg
+		wantCursor int
+		wantScroll int
+		handled    bool
+	}{
+		{"down", listColumn(20, 0, 0), tea.KeyMsg{Type: tea.KeyDown}, 1, 0, true},
+		{"down scrolls", listColumn(20, 4, 0), keyRunes("j"), 5, 1, true},
+		{"down at the end", listColumn(3, 2, 0), tea.KeyMsg{Type: tea.KeyDown}, 2, 0, true},
+		{"up scrolls", listColumn(20, 5, 5), keyRunes("k"), 4, 4, true},
+		{"pgdown", listColumn(20, 0, 0), tea.KeyMsg{Type: tea.KeyPgDown}, 5, 1, true},
+		{"pgup", listColumn(20, 12, 8), tea.KeyMsg{Type: tea.KeyPgUp}, 7, 7, true},
+		{"home", listColumn(20, 12, 8), tea.KeyMsg{Type: tea.KeyHome}, 0, 0, true},
+		{"end", listColumn(20, 0, 0), tea.KeyMsg{Type: tea.KeyEnd}, 19, 15, true},
+		{"end of an empty column", listColumn(0, 0, 0), tea.KeyMsg{Type: tea.KeyEnd}, 0, 0, true},
+		{"other keys", listColumn(20, 3, 0), keyRunes("x"), 3, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, handled := tt.col.Update(tt.key)
+			if got.cursor != tt.wantCursor || got.scrollOffset != tt.wantScroll || handled != tt.handled {
+				t.Errorf("Update = cursor %d, scroll %d, handled %v; want %d, %d, %v", got.cursor, got.scrollOffset, handled, tt.wantCursor, tt.wantScroll, tt.handled)
+			}
+		})
+	}
+}
+
+func TestColumnUpdateEntities(t *testing.T) {
+	page := func(names ...string) []map[string]interface{} {
+		var entities []map[string]interface{}
+		for _, name := range names {
+			entities = append(entities, map[string]interface{}{"Name": name})
+		}
+		return entities
+	}
+	tests := []struct {
+		name      string
+		col       column
+		msg       entitiesMsg
+		wantItems int
+		wantMore  bool
+		cursor    int
+	}{
+		{"first page", column{state: stateLoading, selected: map[int]bool{0: true}}, entitiesMsg{entities: page("a", "b"), hasMore: true}, 3, true, 0},
+		{"empty", column{state: stateLoading}, entitiesMsg{}, 1, false, 0},
+		{"next page", column{entities: page("a", "b"), items: []string{"a", "b", "[...more]"}, hasMore: true, cursor: 2}, entitiesMsg{entities: page("c"), appendPage: true}, 3, false, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, handled := tt.col.Update(tt.msg)
+			if !handled {
+				t.Fatal("entitiesMsg not handled")
+			}
+			if len(got.items) != tt.wantItems || got.hasMore != tt.wantMore || got.cursor != tt.cursor {
+				t.Errorf("items %q, more %v, cursor %d; want %d items, more %v, cursor %d", got.items, got.hasMore, got.cursor, tt.wantItems, tt.wantMore, tt.cursor)
+			}
+			if got.state != stateLoaded || got.selected != nil {
+				t.Errorf("state %v, selected %v after a load", got.state, got.selected)
+			}
+		})
+	}
+}
+
+func TestColumnView(t *testing.T) {
+	col := listColumn(20, 2, 0)
+	col.selected = map[int]bool{2: true}
+	col.entities = make([]map[string]interface{}, 20)
+	tests := []struct {
+		name string
+		col  column
+		v    columnView
+		want []string
+	}{
+		{"rows in view", col, columnView{active: true}, []string{"Products", "Product 0", "● Product 2", "(1-5/20)", "[1 selected]"}},
+		{"edit mode", listColumn(3, 0, 0), columnView{active: true, edit: &inlineEditor{active: true, lines: []string{"{", "}"}}}, []string{"[EDIT] Products", "► {"}},
+		{"loading", column{title: "Details", width: 40, height: 7, items: []string{"Loading..."}, state: stateLoading}, columnView{spinner: "*"}, []string{"* Details", "Loading..."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			view := tt.col.View(tt.v)
+			for _, want := range tt.want {
+				if !strings.Contains(view, want) {
+					t.Errorf("view misses %q:\n%s", want, view)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Where Tab completes in the modal editor: a property name after its
+// opening quote, and a value after the colon of a boolean or enumeration
+// property. Elsewhere Tab stays a tab, as in pasted spreadsheet rows.
+var (
+	nameCompletion  = regexp.MustCompile(`^\s*"([A-Za-z0-9_]*)$`)
+	valueCompletion = regexp.MustCompile(`^\s*"([A-Za-z0-9_]+)"\s*:\s*("?)([A-Za-z0-9_]*)$`)
+)
+
+// completeAtCursor completes the property name or value at the cursor of
+// the modal editor from the metadata of the entity type. Several candidates
+// are completed as far as they agree and listed in the status line. It
+// returns false where there is nothing to complete.
+func (m model) completeAtCursor() (model, bool) {
+	et := m.metadata().EntityTypeOf(m.editorEntitySet())
+	e := &m.editor
+	if et == nil || m.modalOperation == "resubmit" || e.cursor >= len(e.content) {
+		return m, false
+	}
+	line := e.content[e.cursor]
+	before, after := line[:e.col], line[e.col:]
+
+	if match := nameCompletion.FindStringSubmatch(before); match != nil {
+		partial := match[1]
+		present := m.editorMembers()
+		var candidates []string
+		for _, p := range et.Properties {
+			if !present[p.Name] && hasFoldPrefix(p.Name, partial) {
+				candidates = append(candidates, p.Name)
+			}
+		}
+		if len(candidates) == 0 {
+			m.completionHint = fmt.Sprintf("No other property of %s starts with %q", et.Name, partial)
+			return m, true
+		}
+		start := len(before) - len(partial)
+		if len(candidates) == 1 {
+			// The closing quote typed, or added by auto-close, is replaced
+			completed := candidates[0] + `": `
+			e.content[e.cursor] = before[:start] + completed + strings.TrimPrefix(after, `"`)
+			e.col = start + len(completed)
+			return m, true
+		}
+		m.completeCommonPrefix(start, partial, candidates)
+		return m, true
+	}
+
+	match := valueCompletion.FindStringSubmatch(before)
+	if match == nil {
+		return m, false
+	}
+	p := et.Property(match[1])
+	if p == nil {
+		return m, false
+	}
+	quoted, partial := match[2] == `"`, match[3]
+	var values []string
+	switch members, enum := m.metadata().EnumTypes[p.Type]; {
+	case p.Type == "Edm.Boolean":
+		values = []string{"true", "false"}
+	case enum:
+		for _, member := range members {
+			values = append(values, `"`+member+`"`)
+		}
+	default:
+		return m, false
+	}
+	if p.Nullable {
+		values = append(values, "null")
+	}
+
+	var candidates []string
+	for _, v := range values {
+		if hasFoldPrefix(strings.Trim(v, `"`), partial) {
+			candidates = append(candidates, v)
+		}
+	}
+	start := len(before) - len(partial)
+	if quoted {
+		start--
+	}
+	switch len(candidates) {
+	case 0:
+		m.completionHint = fmt.Sprintf("%s is one of %s", p.Name, strings.Join(values, ", "))
+	case 1:
+		if quoted {
+			after = strings.TrimPrefix(after, `"`)
+		}
+		e.content[e.cursor] = before[:start] + candidates[0] + after
+		e.col = start + len(candidates[0])
+	default:
+		m.completionHint = p.Name + ": " + strings.Join(candidates, ", ")
+	}
+	return m, true
+}
+
+// completeCommonPrefix extends the partial name at start to what all the
+// candidates begin with, and lists them in the status line
+func (m *model) completeCommonPrefix(start int, partial string, candidates []string) {
+	common := candidates[0]
+	for _, c := range candidates[1:] {
+		for !strings.HasPrefix(c, common) {
+			common = common[:len(common)-1]
+		}
+	}
+	e := &m.editor
+	if len(common) > len(partial) {
+		line := e.content[e.cursor]
+		e.content[e.cursor] = line[:start] + common + line[e.col:]
+		e.col = start + len(common)
+	}
+	m.completionHint = "Tab: " + strings.Join(candidates, ", ")
+}
+
+// hasFoldPrefix reports whether s starts with prefix, ignoring case
+func hasFoldPrefix(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// editorMembers returns the names of the properties the entity in the
+// modal editor already has, so completion offers the others. An array of
+// entities offers them all.
+func (m model) editorMembers() map[string]bool {
+	present := make(map[string]bool)
+	if strings.HasPrefix(strings.TrimSpace(m.editor.Text()), "[") {
+		return present
+	}
+	for _, line := range m.editor.content {
+		name, _, found := strings.Cut(strings.TrimSpace(line), `":`)
+		if found && strings.HasPrefix(name, `"`) {
+			present[name[1:]] = true
+		}
+	}
+	return present
+}
+
+// unknownProperties returns the members of the entity, or entities, in the
+// modal editor that the entity type declares neither as a property nor as
+// a navigation property. Annotations such as @odata.bind and __metadata
+// are the service's and pass.
+func (m model) unknownProperties() []string {
+	et := m.metadata().EntityTypeOf(m.editorEntitySet())
+	if et == nil || m.modalOperation == "resubmit" {
+		return nil
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(checkedText(m.editor.content)), &value); err != nil {
+		return nil
+	}
+	entities, ok := value.([]interface{})
+	if !ok {
+		entities = []interface{}{value}
+	}
+
+	navigation := make(map[string]bool)
+	for _, name := range et.Navigation {
+		navigation[name] = true
+	}
+	seen := make(map[string]bool)
+	var unknown []string
+	for _, entity := range entities {
+		object, ok := entity.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for name := range object {
+			if seen[name] || et.Property(name) != nil || navigation[name] ||
+				strings.HasPrefix(name, "__") || strings.Contains(name, "@") || strings.HasPrefix(name, "odata.") {
+				continue
+			}
+			seen[name] = true
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
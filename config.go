@@ -5,7 +5,10 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
+	"path/filepath"
+	"time"
 )
 
 type ServiceConfig struct {
@@ -13,10 +16,50 @@ type ServiceConfig struct {
 	URL      string `json:"url"`
 	Username string `json:"username,omitempty"`
 	Password string `json:"password,omitempty"`
+
+	// Client identification, for gateways that route or log by client
+	UserAgent   string `json:"user_agent,omitempty"`
+	SAPClientID string `json:"sap_client_id,omitempty"` // Sent as sap-client-id
+	AppName     string `json:"app_name,omitempty"`      // Sent as x-app-name
+
+	// Extra headers sent with every request, e.g. sap-client or Accept-Language
+	Headers map[string]string `json:"headers,omitempty"`
+
+	Auth *AuthConfig `json:"auth,omitempty"` // Authentication other than basic auth
+
+	Proxy *ProxyConfig `json:"proxy,omitempty"` // Overrides HTTP(S)_PROXY and NO_PROXY
+
+	Prefer *PreferConfig `json:"prefer,omitempty"` // Prefer header: return preference and page size
+
+	// How long the service may take to start answering, e.g. "90s";
+	// defaults to the -timeout flag
+	Timeout string `json:"timeout,omitempty"`
+
+	// How often the service is pinged while connected so its session and
+	// tokens don't expire, e.g. "5m"; defaults to the -keep-alive flag
+	KeepAlive string `json:"keep_alive,omitempty"`
+
+	// Acknowledges that the service is reached over plain HTTP, so its
+	// credentials are sent without asking first
+	AcceptInsecure bool `json:"accept_insecure,omitempty"`
+
+	// Updates send only the properties that changed, with MERGE (V2/V3) or
+	// PATCH (V4), instead of replacing the whole entity with PUT, so fields
+	// the server calculates are left alone
+	PartialUpdates bool `json:"partial_updates,omitempty"`
 }
 
+// DefaultUserAgent identifies the navigator when a service sets no user_agent
+const DefaultUserAgent = "odatanavigator"
+
+// requestTimeout applies to services without a timeout of their own
+var requestTimeout = DefaultTimeout
+
 type Config struct {
 	Services []ServiceConfig `json:"services"`
+	Keymap   string          `json:"keymap,omitempty"` // Key profile: "default" or "vim"
+	Theme    *Theme          `json:"theme,omitempty"`  // Colors: a preset and the colors changed from it
+	Layout   *Layout         `json:"layout,omitempty"` // Shares of the preview and active column
 }
 
 var DefaultServices = []ServiceConfig{
@@ -34,26 +77,142 @@ var DefaultServices = []ServiceConfig{
 	},
 }
 
+// DefaultConfigFile is the config file read from the current directory
+const DefaultConfigFile = "odatanavigator.json"
+
+// firstRun is set when no config file was found and no service was given
+// on the command line or in the environment, so the setup wizard runs
+var firstRun bool
+
+// userConfigPath is the config file in the user's config directory, read
+// when the current directory has none and written by the setup wizard
+func userConfigPath() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "odatanavigator", "config.json"), nil
+}
+
+// findConfigFile returns the config file to read: the one in the current
+// directory, else the one in the user's config directory, "" when neither exists
+func findConfigFile() string {
+	if _, err := os.Stat(DefaultConfigFile); err == nil {
+		return DefaultConfigFile
+	}
+	if path, err := userConfigPath(); err == nil {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// withDefaultServices puts the demo services the config doesn't list
+// already in front of the configured ones
+func withDefaultServices(configured []ServiceConfig) []ServiceConfig {
+	listed := make(map[string]bool)
+	for _, svc := range configured {
+		listed[svc.URL] = true
+	}
+	var services []ServiceConfig
+	for _, svc := range DefaultServices {
+		if !listed[svc.URL] {
+			services = append(services, svc)
+		}
+	}
+	return append(services, configured...)
+}
+
+// saveConfig writes a config file, readable by the user only as it may
+// hold passwords
+func saveConfig(path string, config Config) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	unlock, err := lockState(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return writeFileAtomic(path, append(data, '\n'), 0o600)
+}
+
 func LoadConfig() []ServiceConfig {
 	// Parse command line flags
 	var url = flag.String("url", "", "OData service URL")
 	var user = flag.String("user", "", "Username for authentication")
 	var pass = flag.String("pass", "", "Password for authentication")
+	var token = flag.String("token", "", "Bearer token for authentication")
+	var cache = flag.Bool("cache", false, "Cache responses on disk for offline browsing")
+	var cacheTTL = flag.Duration("cache-ttl", DefaultCacheTTL, "How long cached responses are used without revalidation")
+	var memoryTTL = flag.Duration("memory-cache-ttl", DefaultMemoryCacheTTL, "How long responses are reused in memory; ctrl+r refreshes a column (0 disables)")
+	var noRedact = flag.Bool("no-redact", false, "Show credentials in logs and traces (local debugging only)")
+	var trace = flag.String("trace", "", "Append every HTTP request and response to this file, with credentials redacted")
+	var configFile = flag.String("config", "", "Config file with the services to offer (default: "+DefaultConfigFile+", else config.json in the user config directory)")
+	var service = flag.String("service", "", "Service to connect to at startup")
+	var entitySet = flag.String("entityset", "", "Entity set to open at startup (with -service)")
+	var key = flag.String("key", "", "Key of the entity to show at startup (with -entityset), e.g. 1 or 'ALFKI'")
+	flag.DurationVar(&requestTimeout, "timeout", DefaultTimeout, "How long a service may take to start answering a request (0 waits forever)")
+	flag.DurationVar(&keepAliveInterval, "keep-alive", 0, "How often the connected service is pinged to keep its session alive (0 never)")
+	flag.IntVar(&logRetention, "log-lines", DefaultLogRetention, "How many log lines to keep (0 keeps all)")
+	flag.IntVar(&maxValueLength, "max-value", DefaultMaxValueLength, "How many characters of a string value Details shows before cutting it short (0 shows all)")
+	var logLevelName = flag.String("log-level", startLogLevel.String(), "Lowest level the log shows: debug, info, warn or error (L cycles it)")
+	flag.StringVar(&exportPath, "o", "", "File export-metadata writes the JSON model to (default: standard output)")
+	var keymap = flag.String("keymap", "", "Key profile: default or vim (default: keymap of the config file)")
 	flag.Parse()
 
+	if level, ok := parseLogLevel(*logLevelName); ok {
+		startLogLevel = level
+	} else {
+		fmt.Printf("Warning: Unknown log level %q, using %s\n", *logLevelName, startLogLevel)
+	}
+
+	redactSecrets = !*noRedact
+
+	if *trace != "" {
+		if err := enableTraceLog(*trace); err != nil {
+			fmt.Printf("Warning: Could not open trace file: %v\n", err)
+		}
+	}
+
+	if *cache || os.Getenv("ODATA_CACHE") != "" {
+		if err := enableDiskCache(*cacheTTL); err != nil {
+			fmt.Printf("Warning: Could not enable disk cache: %v\n", err)
+		}
+	}
+
+	if *memoryTTL > 0 {
+		enableMemoryCache(*memoryTTL)
+	}
+
 	// Check environment variables
 	envURL := os.Getenv("ODATA_URL")
 	envUser := os.Getenv("ODATA_USER")
 	envPass := os.Getenv("ODATA_PASS")
+	envToken := os.Getenv("ODATA_TOKEN")
 
-	// Start with default services
-	var services []ServiceConfig
-	services = append(services, DefaultServices...)
-
-	// Add services from config file
-	if configServices := loadFromConfigFile(); configServices != nil {
-		services = append(services, configServices...)
+	// Start with default services, then those of the config file
+	path := *configFile
+	if path == "" {
+		path = findConfigFile()
 	}
+	services := withDefaultServices(loadFromConfigFile(path))
+	configPath = path
+	if *keymap != "" {
+		if validKeymap(*keymap) {
+			startKeymap = *keymap
+		} else {
+			fmt.Printf("Warning: Unknown keymap %q, using %s\n", *keymap, startKeymap)
+		}
+	}
+
+	// Without any config or service to connect to, the setup wizard asks for one
+	firstRun = path == "" && envURL == "" && *url == "" && *service == "" && flag.NArg() == 0
 
 	// Add environment service if provided
 	if envURL != "" {
@@ -62,6 +221,7 @@ func LoadConfig() []ServiceConfig {
 			URL:      envURL,
 			Username: envUser,
 			Password: envPass,
+			Auth:     tokenAuth(envToken),
 		})
 	}
 
@@ -72,14 +232,17 @@ func LoadConfig() []ServiceConfig {
 			URL:      *url,
 			Username: *user,
 			Password: *pass,
+			Auth:     tokenAuth(*token),
 		})
 	}
 
-	return services
+	// An OData URL argument or -service opens the navigator at that location
+	adHoc := ServiceConfig{Username: *user, Password: *pass, Auth: tokenAuth(*token)}
+	return resolveDeepLink(services, *service, *entitySet, *key, flag.Args(), adHoc)
 }
 
-func loadFromConfigFile() []ServiceConfig {
-	file, err := os.Open("odatanavigator.json")
+func loadFromConfigFile(path string) []ServiceConfig {
+	file, err := os.Open(path)
 	if err != nil {
 		return nil // File doesn't exist or can't be opened
 	}
@@ -97,13 +260,79 @@ func loadFromConfigFile() []ServiceConfig {
 		return nil
 	}
 
+	if config.Layout != nil {
+		startLayout = config.Layout.withDefaults()
+	}
+	if config.Theme != nil {
+		if resolved, err := config.Theme.resolve(); err != nil {
+			fmt.Printf("Warning: %v, using the %s theme\n", err, theme.Preset)
+		} else {
+			theme = resolved
+		}
+	}
+	if config.Keymap != "" {
+		if validKeymap(config.Keymap) {
+			startKeymap = config.Keymap
+		} else {
+			fmt.Printf("Warning: Unknown keymap %q in the config file, using %s\n", config.Keymap, startKeymap)
+		}
+	}
+	for _, svc := range config.Services {
+		if svc.Auth != nil && svc.Auth.Type != "oauth2" && svc.Auth.Type != "token" {
+			fmt.Printf("Warning: Service %s has unknown auth type %q, using basic auth\n", svc.Name, svc.Auth.Type)
+		}
+		if _, err := time.ParseDuration(svc.Timeout); svc.Timeout != "" && err != nil {
+			fmt.Printf("Warning: Service %s has invalid timeout %q, using %s\n", svc.Name, svc.Timeout, requestTimeout)
+		}
+		if _, err := svc.Proxy.proxyFunc(); err != nil {
+			fmt.Printf("Warning: Service %s: %v, using the proxy settings of the environment\n", svc.Name, err)
+		}
+	}
 	return config.Services
 }
 
+// tokenAuth returns the auth block for a bearer token given on the command
+// line or in the environment, or nil when there is none
+func tokenAuth(token string) *AuthConfig {
+	if token == "" {
+		return nil
+	}
+	return &AuthConfig{Type: "token", Token: token}
+}
+
 func GetServiceNames(services []ServiceConfig) []string {
 	names := make([]string, len(services))
 	for i, svc := range services {
 		names[i] = svc.Name
 	}
 	return names
+}
+
+// RequestTimeout returns how long the service may take to start answering
+func (svc ServiceConfig) RequestTimeout() time.Duration {
+	if timeout, err := time.ParseDuration(svc.Timeout); svc.Timeout != "" && err == nil {
+		return timeout
+	}
+	return requestTimeout
+}
+
+// ClientHeaders returns the identification and custom headers sent with
+// every request to the service. Custom headers win over the identification
+// headers of the same name.
+func (svc ServiceConfig) ClientHeaders() http.Header {
+	headers := make(http.Header)
+	headers.Set("User-Agent", DefaultUserAgent)
+	if svc.UserAgent != "" {
+		headers.Set("User-Agent", svc.UserAgent)
+	}
+	if svc.SAPClientID != "" {
+		headers.Set("sap-client-id", svc.SAPClientID)
+	}
+	if svc.AppName != "" {
+		headers.Set("x-app-name", svc.AppName)
+	}
+	for name, value := range svc.Headers {
+		headers.Set(name, value)
+	}
+	return headers
 }
\ No newline at end of file
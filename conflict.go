@@ -0,0 +1,276 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// entityETag returns the ETag an entity was read with: __metadata.etag on
+// V2/V3 services, @odata.etag on V4; "" when the service sends none
+func entityETag(entity map[string]interface{}) string {
+	if metadata, ok := entity["__metadata"].(map[string]interface{}); ok {
+		if etag, ok := metadata["etag"].(string); ok {
+			return etag
+		}
+	}
+	etag, _ := entity["@odata.etag"].(string)
+	return etag
+}
+
+// conflictMsg carries an update the service refused because the entity
+// changed since it was loaded, with the entity as the server has it now
+type conflictMsg struct {
+	entitySet string
+	key       string
+	status    string
+	base      map[string]interface{} // Entity as loaded, before the edits
+	mine      map[string]interface{} // Entity as edited
+	server    map[string]interface{} // Entity as read again after the refusal
+}
+
+// fetchConflict reads the entity an update was refused for (412 Precondition
+// Failed or 409 Conflict) again, so the edits can be applied on top of it;
+// the refusal is reported as an error when the entity can't be read
+func fetchConflict(odata *ODataService, r updateReview, result *OperationResult, err error) tea.Msg {
+	odata.Refresh(odata.BuildURL(r.entitySet, r.key, QueryOptions{}))
+	server, readErr := odata.GetEntity(r.entitySet, r.key, QueryOptions{})
+	if readErr != nil {
+		return newErrorMsg(err, "update operation")
+	}
+	return conflictMsg{
+		entitySet: r.entitySet,
+		key:       r.key,
+		status:    result.Status,
+		base:      r.before,
+		mine:      r.after,
+		server:    server,
+	}
+}
+
+// conflictView shows the entity on the server, as edited and merged side by
+// side after an update was refused, to send the merged entity again
+type conflictView struct {
+	active     bool
+	entitySet  string
+	key        string
+	status     string
+	base       map[string]interface{}
+	mine       map[string]interface{}
+	server     map[string]interface{}
+	names      []string        // Properties shown, in order
+	keepServer map[string]bool // Edited properties that keep the value of the server instead
+	cursor     int
+}
+
+// serverChanged reports whether a property changed on the server since the
+// entity was loaded
+func (c conflictView) serverChanged(name string) bool {
+	return diffValue(c.base[name]) != diffValue(c.server[name])
+}
+
+// mineChanged reports whether a property was edited
+func (c conflictView) mineChanged(name string) bool {
+	_, changed := changedProperties(c.base, c.mine)[name]
+	return changed
+}
+
+// conflicts reports whether a property was changed both on the server and
+// in the edits, to different values
+func (c conflictView) conflicts(name string) bool {
+	return c.serverChanged(name) && c.mineChanged(name) && diffValue(c.server[name]) != diffValue(c.mine[name])
+}
+
+// merged returns the entity of the server with the edits applied on top,
+// except those set to keep the value of the server
+func (c conflictView) merged() map[string]interface{} {
+	merged := make(map[string]interface{}, len(c.server))
+	for name, value := range c.server {
+		merged[name] = value
+	}
+	for name, value := range changedProperties(c.base, c.mine) {
+		if !c.keepServer[name] {
+			merged[name] = value
+		}
+	}
+	return merged
+}
+
+// openConflict shows a refused update next to the entity the server has
+// now. The Details showing the entity take the version of the server, so
+// the next update compares against it and sends its ETag.
+func (m model) openConflict(msg conflictMsg) model {
+	m.loading = false
+	m.logs = append(m.logs, fmt.Sprintf("ERROR [update %s(%s)]: %s - the entity changed on the server since it was loaded; compare and retry", msg.entitySet, msg.key, msg.status))
+
+	seen := make(map[string]bool)
+	var names []string
+	for _, entity := range []map[string]interface{}{msg.server, msg.mine, msg.base} {
+		for name, value := range entity {
+			if !seen[name] && sentProperty(name, value) {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	m.conflict = conflictView{
+		active:     true,
+		entitySet:  msg.entitySet,
+		key:        msg.key,
+		status:     msg.status,
+		base:       msg.base,
+		mine:       msg.mine,
+		server:     msg.server,
+		names:      names,
+		keepServer: make(map[string]bool),
+	}
+	conflicting := 0
+	for i, name := range names {
+		if m.conflict.conflicts(name) {
+			if conflicting == 0 {
+				m.conflict.cursor = i
+			}
+			conflicting++
+		}
+	}
+	m.logs = append(m.logs, fmt.Sprintf("%d properties changed on both sides - Space picks the value to keep, Enter sends the merged entity", conflicting))
+
+	for i := range m.columns {
+		col := &m.columns[i]
+		if !col.isDetails || col.isResult || col.raw != nil || len(col.entities) == 0 || i == 0 {
+			continue
+		}
+		if m.detailsEntitySet(i) == msg.entitySet && extractEntityKey(m.metadata(), msg.entitySet, col.entities[0]) == msg.key {
+			col.entities = []map[string]interface{}{msg.server}
+			col.items = m.detailsLines(msg.entitySet, msg.server, nil)
+			col.cursor = min(col.cursor, max(len(col.items)-1, 0))
+		}
+	}
+	return m
+}
+
+// updateConflict handles key presses while a refused update is compared:
+// Space switches an edited property between the edit and the value of the
+// server, Enter reviews the merged entity to send it again and e takes it
+// back into the editor
+func (m model) updateConflict(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	c := &m.conflict
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		c.active = false
+		m.logs = append(m.logs, "Update not sent - back to the editor; F2 compares the edits with the entity of the server")
+	case "up", "k":
+		c.cursor = max(c.cursor-1, 0)
+	case "down", "j":
+		c.cursor = min(c.cursor+1, len(c.names)-1)
+	case " ":
+		if c.cursor < len(c.names) && c.mineChanged(c.names[c.cursor]) {
+			name := c.names[c.cursor]
+			c.keepServer[name] = !c.keepServer[name]
+		}
+	case "enter":
+		conflict := m.conflict
+		m.conflict = conflictView{}
+		return m.openUpdateReview(conflict.entitySet, conflict.key, conflict.server, conflict.merged()), nil
+	case "e":
+		edited := make(map[string]interface{})
+		for name, value := range c.merged() {
+			if sentProperty(name, value) {
+				edited[name] = value
+			}
+		}
+		data, _ := json.MarshalIndent(edited, "", "  ")
+		m.editor = newTextEditor(strings.Split(string(data), "\n"), 0, 0)
+		m.conflict = conflictView{}
+		m.logs = append(m.logs, "Merged entity in the editor - F2 to review and send it")
+		cmd := m.scheduleJSONCheck()
+		return m, cmd
+	}
+	return m, nil
+}
+
+// conflictHeight is the number of properties the conflict view shows at once
+func (m model) conflictHeight() int {
+	return max(m.height-16, 3)
+}
+
+// renderConflict draws the entity of the server, the edited and the merged
+// entity in three panes, a property per row
+func (m model) renderConflict() string {
+	c := m.conflict
+	hint := lipgloss.NewStyle().Foreground(theme.Muted)
+	width := min(150, m.width-4)
+	pane := max((width-10)/3, 8)
+	serverStyle := lipgloss.NewStyle().Foreground(theme.Warning)
+	mineStyle := lipgloss.NewStyle().Foreground(theme.Success)
+	conflictStyle := lipgloss.NewStyle().Foreground(theme.Error)
+
+	cell := func(text string, style lipgloss.Style) string {
+		text = fitItem(text, pane)
+		return style.Render(text) + strings.Repeat(" ", pane-lipgloss.Width(text))
+	}
+	separator := hint.Render(" │ ")
+	header := lipgloss.NewStyle().Bold(true)
+
+	lines := []string{
+		hint.Render(fmt.Sprintf("%s(%s) - %s: the entity changed on the server since it was loaded", c.entitySet, c.key, c.status)),
+		"",
+		"  " + cell("Server (now)", header) + separator + cell("Mine", header) + separator + cell("Merged (sent)", header),
+	}
+	height := m.conflictHeight()
+	start := max(0, min(c.cursor-height/2, len(c.names)-height))
+	end := min(start+height, len(c.names))
+	merged := c.merged()
+	for i := start; i < end; i++ {
+		name := c.names[i]
+		server, mine := lipgloss.NewStyle(), lipgloss.NewStyle()
+		if c.serverChanged(name) {
+			server = serverStyle
+		}
+		result := server
+		if c.mineChanged(name) {
+			mine = mineStyle
+			if !c.keepServer[name] {
+				result = mineStyle
+			}
+		}
+		pointer, marker := " ", " "
+		if i == c.cursor {
+			pointer = "►"
+		}
+		if c.conflicts(name) {
+			marker = conflictStyle.Render("!")
+			server, mine = conflictStyle, conflictStyle
+		}
+		lines = append(lines, pointer+marker+
+			cell(name+": "+diffValue(c.server[name]), server)+separator+
+			cell(name+": "+diffValue(c.mine[name]), mine)+separator+
+			cell(name+": "+diffValue(merged[name]), result))
+	}
+	if end < len(c.names) {
+		lines = append(lines, hint.Render(fmt.Sprintf("  ... %d more (Down scrolls)", len(c.names)-end)))
+	}
+	lines = append(lines, "",
+		hint.Render("! changed on both sides | Space: Keep mine/the server's | Enter: Review and send | e: Edit merged | ESC: Back"))
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Background(theme.Accent).
+		Foreground(theme.AccentText).
+		Padding(0, 1).
+		Render("Update Conflict")
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(theme.Accent).
+		Padding(0, 1).
+		Width(width).
+		Render(title + "\n\n" + strings.Join(lines, "\n"))
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// v2TimePattern matches the Edm.Time durations of V2, as PT12H30M15S
+	v2TimePattern = regexp.MustCompile(`^PT(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?$`)
+	// v4TimePattern matches the Edm.TimeOfDay values of V4, as 12:30:15
+	v4TimePattern = regexp.MustCompile(`^(\d{2}):(\d{2})(?::(\d{2}(?:\.\d+)?))?$`)
+)
+
+// payloadConverter rewrites an entity payload written for one OData
+// version into the value formats of another, so an entity read from a V2
+// service can be posted to a V4 one and back. Formats the target version
+// shares with the source are left alone.
+type payloadConverter struct {
+	md      *Metadata
+	v4      bool            // Convert to V4, else to V2
+	changes map[string]bool // What was converted, for the log
+}
+
+// convertPayload converts an entity of the entity set to the formats of
+// the version, returning it with a description of each kind of change made
+func convertPayload(entity map[string]interface{}, md *Metadata, entitySet, version string) (map[string]interface{}, []string) {
+	c := &payloadConverter{md: md, v4: strings.HasPrefix(version, "4"), changes: make(map[string]bool)}
+	out := c.entity(entity, md.EntityTypeOf(entitySet))
+	changes := make([]string, 0, len(c.changes))
+	for change := range c.changes {
+		changes = append(changes, change)
+	}
+	sort.Strings(changes)
+	return out, changes
+}
+
+// convertPayloads converts every entity of a list, as convertPayload
+func convertPayloads(entities []map[string]interface{}, md *Metadata, entitySet, version string) ([]map[string]interface{}, []string) {
+	seen := make(map[string]bool)
+	var changes []string
+	out := make([]map[string]interface{}, len(entities))
+	for i, entity := range entities {
+		var entityChanges []string
+		out[i], entityChanges = convertPayload(entity, md, entitySet, version)
+		for _, change := range entityChanges {
+			if !seen[change] {
+				seen[change] = true
+				changes = append(changes, change)
+			}
+		}
+	}
+	sort.Strings(changes)
+	return out, changes
+}
+
+// entity converts the properties of an entity of type et, nil when unknown
+func (c *payloadConverter) entity(entity map[string]interface{}, et *EntityType) map[string]interface{} {
+	out := make(map[string]interface{}, len(entity))
+	for k, v := range entity {
+		switch {
+		case c.v4 && k == "__metadata":
+			c.changes["__metadata removed"] = true
+			continue
+		case c.v4 && isNavigationValue(v):
+			c.changes["deferred links removed"] = true
+			continue
+		case !c.v4 && strings.Contains(k, "@odata."):
+			c.changes["@odata annotations removed"] = true
+			continue
+		}
+
+		if nav := c.navigation(et, k); nav != nil || isNestedEntities(v) {
+			out[k] = c.nested(v, nav)
+			continue
+		}
+		var edmType string
+		if p := et.Property(k); p != nil {
+			edmType = p.Type
+		}
+		out[k] = c.value(v, edmType)
+	}
+	return out
+}
+
+// navigation returns the navigation property of the entity type, or nil
+func (c *payloadConverter) navigation(et *EntityType, name string) *NavigationProperty {
+	if et == nil {
+		return nil
+	}
+	for i := range et.NavigationProperties {
+		if et.NavigationProperties[i].Name == name {
+			return &et.NavigationProperties[i]
+		}
+	}
+	return nil
+}
+
+// isNestedEntities reports whether a value looks like an expanded
+// collection: a V2 {"results": [...]} wrapper or an array of objects
+func isNestedEntities(v interface{}) bool {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		_, ok := value["results"].([]interface{})
+		return ok && len(value) == 1
+	case []interface{}:
+		if len(value) == 0 {
+			return false
+		}
+		_, ok := value[0].(map[string]interface{})
+		return ok
+	}
+	return false
+}
+
+// nested converts an expanded navigation property or complex value. V2
+// wraps collections in {"results": [...]}, V4 sends plain arrays.
+func (c *payloadConverter) nested(v interface{}, nav *NavigationProperty) interface{} {
+	var target *EntityType
+	if nav != nil && c.md != nil {
+		target = c.md.EntityTypes[nav.Target]
+	}
+	convertItems := func(items []interface{}) []interface{} {
+		out := make([]interface{}, len(items))
+		for i, item := range items {
+			if entity, ok := item.(map[string]interface{}); ok {
+				out[i] = c.entity(entity, target)
+			} else {
+				out[i] = item
+			}
+		}
+		return out
+	}
+
+	switch value := v.(type) {
+	case map[string]interface{}:
+		if results, ok := value["results"].([]interface{}); ok && len(value) == 1 {
+			if c.v4 {
+				c.changes["collections unwrapped from results"] = true
+				return convertItems(results)
+			}
+			return map[string]interface{}{"results": convertItems(results)}
+		}
+		if isNavigationValue(value) {
+			return value
+		}
+		return c.entity(value, target)
+	case []interface{}:
+		if !c.v4 && nav != nil && nav.Many {
+			c.changes["collections wrapped in results"] = true
+			return map[string]interface{}{"results": convertItems(value)}
+		}
+		return convertItems(value)
+	}
+	return v
+}
+
+// value converts a primitive value of the Edm type, "" when unknown
+func (c *payloadConverter) value(v interface{}, edmType string) interface{} {
+	if c.v4 {
+		return c.toV4(v, edmType)
+	}
+	return c.toV2(v, edmType)
+}
+
+// toV4 converts V2 dates to ISO 8601, Edm.Time durations to times of day
+// and the numbers V2 sends as strings to numbers. A /Date(…)/ string is
+// only taken for a date where the metadata says it is one, or where there
+// is no type to go by, so strings that merely contain it stay as they are.
+func (c *payloadConverter) toV4(v interface{}, edmType string) interface{} {
+	s, ok := v.(string)
+	if !ok {
+		return v
+	}
+	dateType := edmType == "" || edmType == "Edm.DateTime" || edmType == "Edm.DateTimeOffset" || edmType == "Edm.Date"
+	if t, hasOffset, ok := parseV2Date(s); ok && dateType {
+		c.changes["dates"] = true
+		switch {
+		case edmType == "Edm.Date":
+			return t.Format("2006-01-02")
+		case hasOffset:
+			return t.Format(time.RFC3339Nano)
+		}
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+	switch edmType {
+	case "Edm.TimeOfDay":
+		if parts := v2TimePattern.FindStringSubmatch(s); parts != nil && s != "PT" {
+			c.changes["times"] = true
+			return twoDigits(parts[1]) + ":" + twoDigits(parts[2]) + ":" + twoDigits(parts[3])
+		}
+	case "Edm.Int64", "Edm.Decimal", "Edm.Double", "Edm.Single", "Edm.Int32", "Edm.Int16", "Edm.Byte", "Edm.SByte":
+		if _, err := strconv.ParseFloat(s, 64); err == nil && strings.Trim(s, "+-0123456789.eE") == "" {
+			c.changes["numbers"] = true
+			return json.Number(s)
+		}
+	}
+	return v
+}
+
+// toV2 converts ISO 8601 dates to /Date(…)/, times of day to Edm.Time
+// durations and 64-bit integers and decimals to the strings V2 expects.
+// Strings are only taken for dates where the metadata says they are.
+func (c *payloadConverter) toV2(v interface{}, edmType string) interface{} {
+	switch value := v.(type) {
+	case float64:
+		if edmType == "Edm.Int64" || edmType == "Edm.Decimal" {
+			c.changes["numbers"] = true
+			return strconv.FormatFloat(value, 'f', -1, 64)
+		}
+	case json.Number:
+		if edmType == "Edm.Int64" || edmType == "Edm.Decimal" {
+			c.changes["numbers"] = true
+			return value.String()
+		}
+	case string:
+		switch edmType {
+		case "Edm.DateTime", "Edm.DateTimeOffset", "Edm.Date":
+			if _, _, ok := parseV2Date(value); ok {
+				return v
+			}
+			for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02"} {
+				t, err := time.Parse(layout, value)
+				if err != nil {
+					continue
+				}
+				c.changes["dates"] = true
+				if edmType == "Edm.DateTimeOffset" {
+					return fmt.Sprintf("/Date(%d+0000)/", t.UnixMilli())
+				}
+				// Edm.DateTime has no time zone; keep the wall clock time
+				wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+				return fmt.Sprintf("/Date(%d)/", wall.UnixMilli())
+			}
+		case "Edm.Time":
+			if parts := v4TimePattern.FindStringSubmatch(value); parts != nil {
+				c.changes["times"] = true
+				return "PT" + parts[1] + "H" + parts[2] + "M" + twoDigits(parts[3]) + "S"
+			}
+		}
+	}
+	return v
+}
+
+// twoDigits writes a part of a time with at least two integer digits,
+// keeping any fraction
+func twoDigits(s string) string {
+	whole, _, _ := strings.Cut(s, ".")
+	return strings.Repeat("0", max(2-len(whole), 0)) + s
+}
+
+// convertForService converts an entity about to be sent to the entity set
+// to the formats of the connected service, logging what was converted
+func (m *model) convertForService(entitySet string, entity map[string]interface{}) map[string]interface{} {
+	converted, changes := convertPayload(entity, m.metadata(), entitySet, m.odata.Version())
+	if len(changes) > 0 {
+		m.logs = append(m.logs, fmt.Sprintf("Converted for OData %s: %s", m.odata.Version(), strings.Join(changes, ", ")))
+	}
+	return converted
+}
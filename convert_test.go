@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestToV4(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		edmType string
+		want    interface{}
+		change  string
+	}{
+		{"datetime", "/Date(1706704200000)/", "Edm.DateTime", "2024-01-31T12:30:00Z", "dates"},
+		{"datetime with millis", "/Date(1706704200123)/", "Edm.DateTime", "2024-01-31T12:30:00.123Z", "dates"},
+		{"before 1970", "/Date(-86400000)/", "Edm.DateTime", "1969-12-31T00:00:00Z", "dates"},
+		{"offset kept", "/Date(1706704200000+0060)/", "Edm.DateTimeOffset", "2024-01-31T13:30:00+01:00", "dates"},
+		{"negative offset", "/Date(1706704200000-0120)/", "Edm.DateTimeOffset", "2024-01-31T10:30:00-02:00", "dates"},
+		{"date only", "/Date(1706659200000)/", "Edm.Date", "2024-01-31", "dates"},
+		{"untyped date", "/Date(1706704200000)/", "", "2024-01-31T12:30:00Z", "dates"},
+		{"date text in a string property", "/Date(1706704200000)/", "Edm.String", "/Date(1706704200000)/", ""},
+		{"not a date", "/Date(soon)/", "Edm.DateTime", "/Date(soon)/", ""},
+		{"time of day", "PT9H5M3S", "Edm.TimeOfDay", "09:05:03", "times"},
+		{"int64 string", "42", "Edm.Int64", json.Number("42"), "numbers"},
+		{"decimal string", "-1.50", "Edm.Decimal", json.Number("-1.50"), "numbers"},
+		{"not a number", "0x10", "Edm.Int64", "0x10", ""},
+		{"number already", 42.0, "Edm.Int64", 42.0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &payloadConverter{v4: true, changes: make(map[string]bool)}
+			if got := c.toV4(tt.value, tt.edmType); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("toV4(%v, %s) = %#v, want %#v", tt.value, tt.edmType, got, tt.want)
+			}
+			if tt.change != "" && !c.changes[tt.change] || tt.change == "" && len(c.changes) > 0 {
+				t.Errorf("changes %v, want %q", c.changes, tt.change)
+			}
+		})
+	}
+}
+
+func TestToV2(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		edmType string
+		want    interface{}
+	}{
+		{"datetime keeps the wall clock", "2024-01-31T12:30:00+01:00", "Edm.DateTime", "/Date(1706704200000)/"},
+		{"datetimeoffset in UTC", "2024-01-31T12:30:00+01:00", "Edm.DateTimeOffset", "/Date(1706700600000+0000)/"},
+		{"date only", "2024-01-31", "Edm.DateTime", "/Date(1706659200000)/"},
+		{"v2 date kept", "/Date(1706704200000)/", "Edm.DateTime", "/Date(1706704200000)/"},
+		{"time", "09:05:03", "Edm.Time", "PT09H05M03S"},
+		{"int64 as string", 42.0, "Edm.Int64", "42"},
+		{"decimal number as string", json.Number("1.50"), "Edm.Decimal", "1.50"},
+		{"int32 stays a number", 42.0, "Edm.Int32", 42.0},
+		{"string date without type", "2024-01-31", "Edm.String", "2024-01-31"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &payloadConverter{changes: make(map[string]bool)}
+			if got := c.toV2(tt.value, tt.edmType); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("toV2(%v, %s) = %#v, want %#v", tt.value, tt.edmType, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// copyMenu is the state of the "y" popup choosing a request to copy
+type copyMenu struct {
+	active  bool
+	cursor  int
+	choices []copyChoice
+}
+
+// copyChoice is a URL or curl command the copy popup offers
+type copyChoice struct {
+	label string
+	text  string
+}
+
+// nonEnvChars are replaced to turn a header name into an environment variable
+var nonEnvChars = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// requestURLs returns the URL the active column reads and the URL of the
+// entity, entity set or service under its cursor, each with a label and
+// "" when there is none. Credentials in the URLs are redacted.
+func (m model) requestURLs() (columnLabel, columnURL, itemLabel, itemURL string) {
+	if m.activeColumn >= len(m.columns) {
+		return
+	}
+	col := m.columns[m.activeColumn]
+
+	switch {
+	case m.activeColumn == 0:
+		if col.cursor < len(col.items) {
+			if name, ok := findService(m.services, col.items[col.cursor]); ok {
+				for _, svc := range m.services {
+					if svc.Name == name {
+						itemLabel, itemURL = "service "+name, svc.URL
+					}
+				}
+			}
+		}
+	case m.odata == nil || col.raw != nil || col.isResult:
+	case m.activeColumn == 1 && !col.isDetails:
+		columnLabel, columnURL = "service root", m.odata.BuildURL("", "", QueryOptions{})
+		if col.cursor < len(col.items) {
+			if name := itemEntitySet(col.items[col.cursor]); name != "" && !strings.HasPrefix(name, "$") {
+				itemLabel, itemURL = name, m.odata.jsonFormat(m.odata.BuildURL(name, "", QueryOptions{}))
+			}
+		}
+	case col.isDetails && len(col.entities) > 0:
+		var opts QueryOptions
+		if m.activeColumn > 0 {
+			list := m.columns[m.activeColumn-1].query
+			opts = QueryOptions{Select: list.Select, Expand: list.Expand}
+		}
+		columnURL = m.entityURL(m.detailsEntitySet(m.activeColumn), col.entities[0], opts)
+		columnLabel = "entity"
+	case col.entitySet != "" || col.navURL != "":
+		columnLabel, columnURL = col.title, m.odata.jsonFormat(m.odata.BuildURL(col.resource(), "", col.query))
+		if col.cursor < len(col.entities) {
+			entity := col.entities[col.cursor]
+			opts := QueryOptions{Select: col.query.Select, Expand: col.query.Expand}
+			itemLabel, itemURL = "entity "+formatEntityForDisplay(entity), m.entityURL(col.entitySet, entity, opts)
+		}
+	}
+	return columnLabel, redactURL(columnURL), itemLabel, redactURL(itemURL)
+}
+
+// entityURL returns the URL reading a single entity: its V2 __metadata uri,
+// else its key in the entity set; "" when neither is known
+func (m model) entityURL(entitySet string, entity map[string]interface{}, opts QueryOptions) string {
+	resource := ""
+	if meta, ok := entity["__metadata"].(map[string]interface{}); ok {
+		resource, _ = meta["uri"].(string)
+	}
+	if resource == "" {
+		if entitySet == "" {
+			return ""
+		}
+		key := extractEntityKey(m.metadata(), entitySet, entity)
+		if key == "" {
+			return ""
+		}
+		return m.odata.jsonFormat(m.odata.BuildURL(entitySet, key, opts))
+	}
+	return m.odata.jsonFormat(m.odata.BuildURL(resource, "", opts))
+}
+
+// curlCommand writes a GET of the URL as a curl command line. Credentials
+// are left to environment variables, so the command can be shared.
+func (m model) curlCommand(u string) string {
+	var args []string
+	args = append(args, "curl -sS", "-H 'Accept: application/json'")
+	switch {
+	case m.odata.tokens != nil:
+		args = append(args, `-H "Authorization: Bearer $ODATA_TOKEN"`)
+	case m.odata.username != "":
+		args = append(args, `-u "$ODATA_USER:$ODATA_PASS"`)
+	}
+
+	args = append(args, m.headerLines()...)
+	args = append(args, shellQuote(u))
+	return strings.Join(args, " ")
+}
+
+// headerLines returns the -H options of the fixed headers of the service,
+// with credentials replaced by environment variables named after them
+func (m model) headerLines() []string {
+	names := make([]string, 0, len(m.odata.headers))
+	for name := range m.odata.headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var lines []string
+	for _, name := range names {
+		for _, value := range m.odata.headers[name] {
+			switch {
+			case name == "User-Agent" && value == DefaultUserAgent:
+			case name == "Authorization" && strings.HasPrefix(value, "Bearer "):
+				lines = append(lines, `-H "Authorization: Bearer $ODATA_TOKEN"`)
+			case sensitiveHeader.MatchString(name+":") || sensitiveHeaderName.MatchString(name):
+				env := strings.Trim(nonEnvChars.ReplaceAllString(strings.ToUpper(name), "_"), "_")
+				lines = append(lines, fmt.Sprintf(`-H "%s: $%s"`, name, env))
+			default:
+				lines = append(lines, "-H "+shellQuote(name+": "+value))
+			}
+		}
+	}
+	return lines
+}
+
+// shellQuote quotes a word for POSIX shells
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// openCopyMenu offers the URLs of the active column and of the item under
+// its cursor, plainly and as curl commands
+func (m model) openCopyMenu() model {
+	columnLabel, columnURL, itemLabel, itemURL := m.requestURLs()
+	var choices []copyChoice
+	if columnURL != "" {
+		choices = append(choices, copyChoice{"URL of " + columnLabel, columnURL})
+	}
+	if itemURL != "" {
+		choices = append(choices, copyChoice{"URL of " + itemLabel, itemURL})
+	}
+	if m.odata != nil && m.activeColumn > 0 {
+		if columnURL != "" {
+			choices = append(choices, copyChoice{"curl for " + columnLabel, m.curlCommand(columnURL)})
+		}
+		if itemURL != "" {
+			choices = append(choices, copyChoice{"curl for " + itemLabel, m.curlCommand(itemURL)})
+		}
+	}
+	if len(choices) == 0 {
+		m.logs = append(m.logs, "Nothing to copy here - open an entity list or entity")
+		return m
+	}
+	m.copyMenu = copyMenu{active: true, choices: choices}
+	return m
+}
+
+// updateCopyMenu handles key presses while the copy popup is open
+func (m model) updateCopyMenu(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	menu := &m.copyMenu
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		menu.active = false
+	case "up", "k":
+		if menu.cursor > 0 {
+			menu.cursor--
+		}
+	case "down", "j":
+		if menu.cursor < len(menu.choices)-1 {
+			menu.cursor++
+		}
+	case "enter", "y":
+		menu.active = false
+		choice := menu.choices[menu.cursor]
+		copyToClipboard(choice.text)
+		m.logs = append(m.logs, "Copied "+choice.label+" to the clipboard:", "  "+choice.text)
+	}
+	return m, nil
+}
+
+// renderCopyMenu renders the choice of what to copy
+func (m model) renderCopyMenu() string {
+	menu := m.copyMenu
+	labels := make([]string, len(menu.choices))
+	for i, choice := range menu.choices {
+		labels[i] = choice.label
+	}
+	var lines []string
+	lines = append(lines, renderChoiceList(labels, menu.cursor, len(labels))...)
+	preview := menu.choices[menu.cursor].text
+	lines = append(lines, "", lipgloss.NewStyle().Foreground(theme.Muted).Render(preview))
+	lines = append(lines, "", lipgloss.NewStyle().Foreground(theme.Muted).Render("Enter: Copy to clipboard | ESC: Cancel"))
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Background(theme.Accent).
+		Foreground(theme.AccentText).
+		Padding(0, 1).
+		Render("Copy Request")
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(theme.Accent).
+		Padding(0, 1).
+		Width(min(90, m.width-4)).
+		Render(title + "\n\n" + strings.Join(lines, "\n"))
+}
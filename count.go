@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// entitySetCountMsg carries the size of an entity set counted for the EntitySets column
+type entitySetCountMsg struct {
+	key       string // countKey of the entity set
+	entitySet string
+	count     int // -1 when the service could not count it
+}
+
+// GetCountedPage is GetEntitiesPage asking the server for the total number
+// of matching entities as well. A request rejected for the count is sent
+// again without it. Services whose error names $inlinecount or $count, or
+// that reject the count of a plain list, are asked without it from then on;
+// a list with a filter or search may have been rejected for those instead.
+func (o *ODataService) GetCountedPage(entitySet string, opts QueryOptions) (*EntityPage, error) {
+	if !o.countUnsupported() {
+		counted := opts
+		counted.Count = true
+		page, err := o.GetEntitiesPage(entitySet, counted)
+		if err == nil || !countRejected(err) {
+			return page, err
+		}
+		if countNamed(err) || (opts.Filter == "" && opts.Search == "" && len(opts.Custom) == 0) {
+			o.markCountUnsupported()
+		}
+	}
+	return o.GetEntitiesPage(entitySet, opts)
+}
+
+// countRejected reports whether a request failed in a way a service that
+// does not support counting answers
+func countRejected(err error) bool {
+	message := err.Error()
+	return strings.HasPrefix(message, "HTTP 400") || strings.HasPrefix(message, "HTTP 501") || countNamed(err)
+}
+
+// countNamed reports whether the error the server answered a request with
+// names the count options
+func countNamed(err error) bool {
+	message := strings.ToLower(err.Error())
+	if !strings.HasPrefix(message, "http ") {
+		return false
+	}
+	return strings.Contains(message, "$inlinecount") || strings.Contains(message, "$count")
+}
+
+// GetCount returns the number of entities in an entity set via its /$count
+// resource, which both V2 and V4 answer in plain text
+func (o *ODataService) GetCount(entitySet string) (int, error) {
+	req, err := http.NewRequest("GET", o.BuildURL(entitySet+"/$count", "", QueryOptions{}), nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/plain")
+
+	if o.username != "" && o.password != "" {
+		req.SetBasicAuth(o.username, o.password)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64))
+	if err != nil {
+		return 0, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(string(body)))
+	if err != nil {
+		return 0, fmt.Errorf("unexpected $count response %q", body)
+	}
+	return count, nil
+}
+
+// countKey identifies the count of an entity set of the connected service
+func (m model) countKey(entitySet string) string {
+	return m.odata.BuildURL(entitySet+"/$count", "", QueryOptions{})
+}
+
+// loadEntitySetCount counts the entity set under the cursor of the
+// EntitySets column unless it was counted before
+func (m model) loadEntitySetCount(entitySet string) tea.Cmd {
+	key := m.countKey(entitySet)
+	if _, known := m.setCounts[key]; known {
+		return nil
+	}
+	odata := m.odata
+	return func() tea.Msg {
+		count, err := odata.GetCount(entitySet)
+		if err != nil {
+			count = -1
+		}
+		return entitySetCountMsg{key: key, entitySet: entitySet, count: count}
+	}
+}
+
+// applyEntitySetCount remembers a count and shows it next to its entity set
+func (m *model) applyEntitySetCount(msg entitySetCountMsg) {
+	if m.setCounts == nil {
+		m.setCounts = make(map[string]int)
+	}
+	m.setCounts[msg.key] = msg.count
+	if msg.count < 0 || m.odata == nil || m.countKey(msg.entitySet) != msg.key {
+		return
+	}
+	for i := range m.columns {
+		if m.columns[i].title != "EntitySets" {
+			continue
+		}
+		for j, item := range m.columns[i].items {
+			if itemEntitySet(item) == msg.entitySet {
+				m.columns[i].items[j] = withCount(item, msg.count)
+			}
+		}
+	}
+}
+
+// withSetCounts shows the sizes counted before on fresh EntitySets column items
+func (m model) withSetCounts(items []string) []string {
+	for i, item := range items {
+		name := itemEntitySet(item)
+		if _, _, entity := favoriteEntity(name); entity || name == "$metadata" || strings.HasPrefix(name, "[FUNC] ") || strings.HasPrefix(name, "(") {
+			continue
+		}
+		if count, known := m.setCounts[m.countKey(name)]; known && count >= 0 {
+			items[i] = withCount(item, count)
+		}
+	}
+	return items
+}
+
+// withCount adds the size of an entity set to its EntitySets column item,
+// between the name and the capability badges: "Products (77) [CRUD]"
+func withCount(item string, count int) string {
+	name := itemEntitySet(item)
+	prefix := ""
+	if strings.HasPrefix(item, favoriteMarker) {
+		prefix = favoriteMarker
+	}
+	rest := strings.TrimPrefix(strings.TrimPrefix(item, prefix), name)
+	if strings.HasPrefix(rest, " (") {
+		rest = rest[strings.Index(rest, ")")+1:]
+	}
+	return fmt.Sprintf("%s%s (%d)%s", prefix, name, count, rest)
+}
+
+// countedTitle shows how many of the counted entities an entity list has loaded
+func (c column) countedTitle() string {
+	if !c.counted {
+		return c.title
+	}
+	return fmt.Sprintf("%s (%d of %d)", c.title, len(c.entities), c.total)
+}
+
+// moreItem is the last row of an entity list with more entities on the server
+func (c column) moreItem() string {
+	if c.counted && c.total > len(c.entities) {
+		return fmt.Sprintf("[...%d more items]", c.total-len(c.entities))
+	}
+	return "[...more items]"
+}
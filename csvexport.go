@@ -0,0 +1,568 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// csvChoice is one value of a CSV export option
+type csvChoice struct {
+	name  string
+	value string
+}
+
+var (
+	csvDelimiters = []csvChoice{{"Comma", ","}, {"Semicolon", ";"}, {"Tab", "\t"}}
+	csvDecimals   = []csvChoice{{"Point (1.5)", "."}, {"Comma (1,5)", ","}}
+	// Date layouts; a time of day other than midnight is appended after the separator
+	csvDateFormats = []csvChoice{{"ISO 8601 (2024-12-31)", "2006-01-02|T"}, {"DD.MM.YYYY", "02.01.2006| "}, {"DD/MM/YYYY", "02/01/2006| "}, {"MM/DD/YYYY", "01/02/2006| "}, {"As sent", ""}}
+	csvEncodings   = []csvChoice{{"UTF-8", "utf-8"}, {"UTF-8 with BOM", "utf-8-bom"}, {"UTF-16LE with BOM", "utf-16le"}}
+	csvQuotings    = []csvChoice{{"Only where needed", "minimal"}, {"All fields", "all"}}
+)
+
+// csvOptions are the choices of a CSV export, as indexes into the choice
+// lists above; they are kept for the session
+type csvOptions struct {
+	delimiter, decimal, dateFormat, encoding, quoting int
+}
+
+// csvPreset sets every option at once for a common target
+type csvPreset struct {
+	name    string
+	options csvOptions
+}
+
+var csvPresets = []csvPreset{
+	{"RFC 4180", csvOptions{}},
+	{"Excel (US/UK)", csvOptions{encoding: 1}},
+	{"Excel (Europe)", csvOptions{delimiter: 1, decimal: 1, dateFormat: 1, encoding: 1}},
+	{"Excel (Unicode)", csvOptions{delimiter: 2, encoding: 2}},
+}
+
+// Rows of the export dialog
+const (
+	csvRowFile = iota
+	csvRowFields
+	csvRowPreset
+	csvRowDelimiter
+	csvRowDecimal
+	csvRowDate
+	csvRowEncoding
+	csvRowQuoting
+	csvRowCount
+)
+
+// csvExportMsg carries the outcome of writing a CSV export
+type csvExportMsg struct {
+	path      string
+	entitySet string
+	count     int
+	options   csvOptions
+	partial   bool // Only the loaded pages of the list were exported
+	err       error
+}
+
+// csvExportDialog is the state of the "X" overlay exporting an entity list
+type csvExportDialog struct {
+	active  bool
+	column  int // Index of the exported entities column
+	row     int
+	path    string
+	preset  int // Index into csvPresets, -1 for custom options
+	options csvOptions
+
+	// Properties exported, in column order, and the picker choosing them
+	fields      []csvField
+	fieldsOf    string // Entity set and $select the fields were set up for
+	picking     bool
+	fieldCursor int
+}
+
+// csvField is a property offered for export
+type csvField struct {
+	name    string
+	include bool
+}
+
+// openCSVExport asks where and how to export the active entity list
+func (m model) openCSVExport() model {
+	if m.activeColumn >= len(m.columns) || !m.columns[m.activeColumn].isEntityList() {
+		m.logs = append(m.logs, "CSV export is only available on an entity list")
+		return m
+	}
+	col := m.columns[m.activeColumn]
+	name := strings.Trim(unsafeFileChars.ReplaceAllString(col.entitySet, "_"), "_")
+	if name == "" {
+		name = "export"
+	}
+	dialog := m.csvExport
+	dialog.active = true
+	dialog.column = m.activeColumn
+	dialog.row = csvRowFile
+	dialog.path = name + ".csv"
+	dialog.picking = false
+	// The choice of fields is kept while the entity set and $select stay
+	if fieldsOf := col.entitySet + "?" + col.query.Select; dialog.fieldsOf != fieldsOf {
+		dialog.fields = m.csvFields(col)
+		dialog.fieldsOf = fieldsOf
+		dialog.fieldCursor = 0
+	}
+	m.csvExport = dialog
+	return m
+}
+
+// csvFields offers the properties of an entity list for export: those of
+// its $select first and in that order, or all of them without one
+func (m model) csvFields(col column) []csvField {
+	names, _ := m.columnProperties(col)
+	var selected []string
+	for _, name := range strings.Split(col.query.Select, ",") {
+		if name = strings.TrimSpace(name); name != "" && name != "*" {
+			selected = append(selected, name)
+		}
+	}
+
+	var fields []csvField
+	chosen := make(map[string]bool)
+	for _, name := range selected {
+		fields = append(fields, csvField{name: name, include: true})
+		chosen[name] = true
+	}
+	for _, name := range names {
+		if !chosen[name] && !strings.Contains(name, "@") {
+			fields = append(fields, csvField{name: name, include: len(selected) == 0})
+		}
+	}
+	return fields
+}
+
+// updateCSVFieldPicker handles key presses while the fields to export are
+// chosen: space includes or leaves out a field, shift+up and shift+down
+// move it
+func (m model) updateCSVFieldPicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	d := &m.csvExport
+	if len(d.fields) == 0 {
+		d.picking = false
+		return m, nil
+	}
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "enter":
+		d.picking = false
+	case "up", "k":
+		if d.fieldCursor > 0 {
+			d.fieldCursor--
+		}
+	case "down", "j":
+		if d.fieldCursor < len(d.fields)-1 {
+			d.fieldCursor++
+		}
+	case " ":
+		d.fields[d.fieldCursor].include = !d.fields[d.fieldCursor].include
+	case "a":
+		// Include all, or leave all out when all are included
+		all := true
+		for _, f := range d.fields {
+			all = all && f.include
+		}
+		for i := range d.fields {
+			d.fields[i].include = !all
+		}
+	case "shift+up", "K":
+		if i := d.fieldCursor; i > 0 {
+			d.fields[i-1], d.fields[i] = d.fields[i], d.fields[i-1]
+			d.fieldCursor--
+		}
+	case "shift+down", "J":
+		if i := d.fieldCursor; i < len(d.fields)-1 {
+			d.fields[i+1], d.fields[i] = d.fields[i], d.fields[i+1]
+			d.fieldCursor++
+		}
+	}
+	return m, nil
+}
+
+// exportedFields returns the names of the included fields in column order
+func (d csvExportDialog) exportedFields() []string {
+	var names []string
+	for _, f := range d.fields {
+		if f.include {
+			names = append(names, f.name)
+		}
+	}
+	return names
+}
+
+// updateCSVExport handles key presses while the export dialog is open:
+// up and down pick an option, left and right change it, typing edits the
+// file name
+func (m model) updateCSVExport(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	d := &m.csvExport
+	if d.picking {
+		return m.updateCSVFieldPicker(msg)
+	}
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		d.active = false
+	case "enter":
+		if d.row == csvRowFields {
+			d.picking = true
+			return m, nil
+		}
+		if len(d.exportedFields()) == 0 {
+			m.logs = append(m.logs, "CSV export: choose at least one field")
+			return m, nil
+		}
+		d.active = false
+		return m.exportCSV()
+	case "up":
+		d.row = (d.row + csvRowCount - 1) % csvRowCount
+	case "down", "tab":
+		d.row = (d.row + 1) % csvRowCount
+	case "left", "right":
+		step := 1
+		if msg.String() == "left" {
+			step = -1
+		}
+		d.cycle(step)
+	case "backspace":
+		if d.row == csvRowFile && len(d.path) > 0 {
+			runes := []rune(d.path)
+			d.path = string(runes[:len(runes)-1])
+		}
+	default:
+		if d.row == csvRowFile {
+			d.path += typedText(msg)
+		}
+	}
+	return m, nil
+}
+
+// cycle changes the option of the current row; choosing a preset sets all
+// options, and changing one by hand leaves the preset
+func (d *csvExportDialog) cycle(step int) {
+	next := func(i, n int) int { return (i + step + n) % n }
+	o := &d.options
+	switch d.row {
+	case csvRowPreset:
+		d.preset = next(d.preset, len(csvPresets))
+		*o = csvPresets[d.preset].options
+		return
+	case csvRowDelimiter:
+		o.delimiter = next(o.delimiter, len(csvDelimiters))
+	case csvRowDecimal:
+		o.decimal = next(o.decimal, len(csvDecimals))
+	case csvRowDate:
+		o.dateFormat = next(o.dateFormat, len(csvDateFormats))
+	case csvRowEncoding:
+		o.encoding = next(o.encoding, len(csvEncodings))
+	case csvRowQuoting:
+		o.quoting = next(o.quoting, len(csvQuotings))
+	default:
+		return
+	}
+	d.preset = -1
+	for i, p := range csvPresets {
+		if p.options == *o {
+			d.preset = i
+		}
+	}
+}
+
+// exportCSV writes the loaded entities of the exported column, or only its
+// marked ones, to the file chosen in the dialog, in the background with
+// the rows written so far
+func (m model) exportCSV() (tea.Model, tea.Cmd) {
+	d := m.csvExport
+	if d.column >= len(m.columns) {
+		return m, nil
+	}
+	col := m.columns[d.column]
+	entities := col.entities
+	if len(col.selected) > 0 {
+		entities = nil
+		for _, i := range col.selectedIndexes() {
+			entities = append(entities, col.entities[i])
+		}
+	}
+
+	_, types := m.columnProperties(col)
+	fields := d.exportedFields()
+
+	path := exportFilePath(d.path, col.entitySet+".csv")
+	p := m.startProgress("Exporting "+col.entitySet, "rows", len(entities))
+	if p == nil {
+		return m, nil
+	}
+	return m, p.run(func() tea.Msg {
+		msg := csvExportMsg{path: path, entitySet: col.entitySet, count: len(entities), options: d.options, partial: col.hasMore}
+		text := writeCSV(fields, types, entities, d.options, func(rows int) bool {
+			p.report(rows, len(entities))
+			return !p.cancelled()
+		})
+		if p.cancelled() {
+			msg.err = errRequestCancelled
+			return msg
+		}
+		msg.err = writeExport(path, encodeCSV(text, d.options))
+		return msg
+	})
+}
+
+// applyCSVExport reports a written CSV export
+func (m *model) applyCSVExport(msg csvExportMsg) {
+	m.loading = false
+	if msg.err != nil {
+		m.logs = append(m.logs, fmt.Sprintf("ERROR [CSV export %s]: %v", msg.entitySet, msg.err))
+		return
+	}
+	m.logs = append(m.logs, fmt.Sprintf("Exported %d %s entities to %s (%s, %s)", msg.count, msg.entitySet, msg.path, csvDelimiters[msg.options.delimiter].name, csvEncodings[msg.options.encoding].name))
+	if msg.partial {
+		m.logs = append(m.logs, "Only the loaded entities were exported; load more pages to export them too")
+	}
+}
+
+// exportFilePath resolves the file an export is written to: ~ stands for
+// the home directory, and a directory gets a file with the default name.
+// An export never overwrites an existing file.
+func exportFilePath(input, defaultName string) string {
+	path := strings.TrimSpace(input)
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+	if path == "" {
+		path = defaultName
+	}
+	if info, err := os.Stat(path); err == nil {
+		if info.IsDir() {
+			path = uniqueFilePath(path, defaultName)
+		} else {
+			path = uniqueFilePath(filepath.Dir(path), filepath.Base(path))
+		}
+	}
+	return path
+}
+
+// writeCSV renders a header row and a row per entity with CRLF line ends,
+// as Excel expects. It reports the rows written to progress, and stops
+// early once progress returns false.
+func writeCSV(fields []string, types map[string]string, entities []map[string]interface{}, o csvOptions, progress func(rows int) bool) string {
+	var b strings.Builder
+	writeRow := func(values []string) {
+		for i, value := range values {
+			if i > 0 {
+				b.WriteString(csvDelimiters[o.delimiter].value)
+			}
+			b.WriteString(csvQuote(value, o))
+		}
+		b.WriteString("\r\n")
+	}
+
+	writeRow(fields)
+	for n, entity := range entities {
+		values := make([]string, len(fields))
+		for i, field := range fields {
+			values[i] = csvValue(entity[field], types[field], o)
+		}
+		writeRow(values)
+		if !progress(n + 1) {
+			break
+		}
+	}
+	return b.String()
+}
+
+// csvQuote quotes a field that holds the delimiter, a quote, a line break
+// or surrounding spaces, or every field when asked to
+func csvQuote(value string, o csvOptions) string {
+	needed := csvQuotings[o.quoting].value == "all" ||
+		strings.ContainsAny(value, "\"\r\n") ||
+		strings.Contains(value, csvDelimiters[o.delimiter].value) ||
+		strings.TrimSpace(value) != value
+	if !needed {
+		return value
+	}
+	return `"` + strings.ReplaceAll(value, `"`, `""`) + `"`
+}
+
+// csvValue formats a property value with the decimal separator and date
+// format of the options
+func csvValue(value interface{}, edmType string, o csvOptions) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return csvDecimal(strconv.FormatFloat(v, 'f', -1, 64), o)
+	case string:
+		switch edmType {
+		case "Edm.Decimal", "Edm.Double", "Edm.Single":
+			// V2 sends these as strings
+			return csvDecimal(v, o)
+		}
+		if t, ok := csvDate(v, edmType); ok {
+			return formatCSVDate(t, v, o)
+		}
+		return v
+	}
+	jsonData, _ := json.Marshal(value)
+	return string(jsonData)
+}
+
+// csvDecimal swaps the decimal point for the chosen separator
+func csvDecimal(number string, o csvOptions) string {
+	return strings.Replace(number, ".", csvDecimals[o.decimal].value, 1)
+}
+
+// csvDate reads V2 /Date(…)/ values and the ISO dates of V4 date properties
+func csvDate(value, edmType string) (time.Time, bool) {
+	if t, _, ok := parseV2Date(value); ok {
+		return t, true
+	}
+	switch edmType {
+	case "Edm.Date":
+		t, err := time.Parse("2006-01-02", value)
+		return t, err == nil
+	case "Edm.DateTimeOffset", "Edm.DateTime":
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			t, err = time.Parse("2006-01-02T15:04:05.999999999", value)
+		}
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
+
+// formatCSVDate renders a date with the chosen layout, adding the time of
+// day when there is one, or keeps it as sent
+func formatCSVDate(t time.Time, sent string, o csvOptions) string {
+	layout, sep, ok := strings.Cut(csvDateFormats[o.dateFormat].value, "|")
+	if !ok {
+		return sent
+	}
+	if t.Hour() != 0 || t.Minute() != 0 || t.Second() != 0 {
+		layout += sep + "15:04:05"
+	}
+	return t.Format(layout)
+}
+
+// encodeCSV converts the CSV text to the chosen encoding
+func encodeCSV(text string, o csvOptions) []byte {
+	switch csvEncodings[o.encoding].value {
+	case "utf-8-bom":
+		return append([]byte{0xEF, 0xBB, 0xBF}, text...)
+	case "utf-16le":
+		units := utf16.Encode([]rune(text))
+		data := make([]byte, 2+2*len(units))
+		data[0], data[1] = 0xFF, 0xFE
+		for i, u := range units {
+			binary.LittleEndian.PutUint16(data[2+2*i:], u)
+		}
+		return data
+	}
+	return []byte(text)
+}
+
+// renderCSVExport renders the export dialog box
+func (m model) renderCSVExport() string {
+	d := m.csvExport
+	col := m.columns[d.column]
+
+	preset := "Custom"
+	if d.preset >= 0 {
+		preset = csvPresets[d.preset].name
+	}
+	if d.picking {
+		return m.renderCSVFieldPicker()
+	}
+	rows := []struct{ label, value string }{
+		{"File", d.path + "█"},
+		{"Fields", fmt.Sprintf("%d of %d (Enter: choose and order)", len(d.exportedFields()), len(d.fields))},
+		{"Preset", preset},
+		{"Delimiter", csvDelimiters[d.options.delimiter].name},
+		{"Decimal", csvDecimals[d.options.decimal].name},
+		{"Dates", csvDateFormats[d.options.dateFormat].name},
+		{"Encoding", csvEncodings[d.options.encoding].name},
+		{"Quoting", csvQuotings[d.options.quoting].name},
+	}
+	count := len(col.entities)
+	if len(col.selected) > 0 {
+		count = len(col.selected)
+	}
+	lines := []string{fmt.Sprintf("%d loaded entities", count), ""}
+	for i, row := range rows {
+		value := row.value
+		if i != csvRowFile && i != csvRowFields {
+			value = "◂ " + value + " ▸"
+		}
+		line := fmt.Sprintf("%-10s %s", row.label+":", value)
+		if i == d.row {
+			line = lipgloss.NewStyle().Background(theme.Selection).Foreground(theme.AccentText).Render(line)
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "", lipgloss.NewStyle().Foreground(theme.Muted).Render("Up/Down: Option | Left/Right: Change | Enter: Export | ESC: Cancel"))
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Background(theme.Accent).
+		Foreground(theme.AccentText).
+		Padding(0, 1).
+		Render("Export " + col.entitySet + " as CSV")
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(theme.Accent).
+		Padding(0, 1).
+		Width(min(70, m.width-4)).
+		Render(title + "\n\n" + strings.Join(lines, "\n"))
+}
+
+// renderCSVFieldPicker renders the choice of the fields to export
+func (m model) renderCSVFieldPicker() string {
+	d := m.csvExport
+	col := m.columns[d.column]
+
+	var choices []string
+	for _, f := range d.fields {
+		mark := "[ ]"
+		if f.include {
+			mark = "[x]"
+		}
+		choices = append(choices, mark+" "+f.name)
+	}
+	var lines []string
+	lines = append(lines, "Fields in column order:")
+	lines = append(lines, renderChoiceList(choices, d.fieldCursor, m.height/2)...)
+	lines = append(lines, "", lipgloss.NewStyle().Foreground(theme.Muted).Render("Space: Toggle | a: All | Shift+Up/Down: Move | Enter: Done"))
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Background(theme.Accent).
+		Foreground(theme.AccentText).
+		Padding(0, 1).
+		Render("Export " + col.entitySet + " as CSV")
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(theme.Accent).
+		Padding(0, 1).
+		Width(min(70, m.width-4)).
+		Render(title + "\n\n" + strings.Join(lines, "\n"))
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rawDates shows V2 /Date(…)/ values as sent instead of as ISO 8601, toggled with "D"
+var rawDates bool
+
+// parseV2Date reads a V2 JSON date such as /Date(1234567890000)/ or, for
+// Edm.DateTimeOffset, /Date(1234567890000+0060)/ with the offset in minutes
+func parseV2Date(value string) (t time.Time, hasOffset bool, ok bool) {
+	if !v2DateLiteral.MatchString(value) {
+		return time.Time{}, false, false
+	}
+	digits := strings.TrimSuffix(strings.TrimPrefix(value, "/Date("), ")/")
+	offset := 0
+	if sign := strings.LastIndexAny(digits, "+-"); sign > 0 {
+		offset, _ = strconv.Atoi(digits[sign:])
+		digits = digits[:sign]
+		hasOffset = true
+	}
+	ms, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return time.Time{}, false, false
+	}
+	t = time.UnixMilli(ms).UTC()
+	if hasOffset {
+		t = t.In(time.FixedZone("", offset*60))
+	}
+	return t, hasOffset, true
+}
+
+// displayDate renders a V2 date as ISO 8601: Edm.DateTime carries no time
+// zone, so it is shown without one, while offsets are kept
+func displayDate(value string) string {
+	t, hasOffset, ok := parseV2Date(value)
+	if !ok || rawDates {
+		return value
+	}
+	if hasOffset {
+		return t.Format("2006-01-02T15:04:05.999Z07:00")
+	}
+	return t.Format("2006-01-02T15:04:05.999")
+}
+
+// displayDates returns a JSON value with the V2 dates in it, at any depth,
+// rendered by displayDate. The value itself is left untouched.
+func displayDates(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return displayDate(v)
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted[key] = displayDates(item)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, item := range v {
+			converted[i] = displayDates(item)
+		}
+		return converted
+	}
+	return value
+}
+
+// toggleRawDates switches between ISO and raw V2 dates and renders the
+// entity lists and Details columns again
+func (m model) toggleRawDates() model {
+	rawDates = !rawDates
+	if rawDates {
+		m.logs = append(m.logs, "Showing dates as sent by the service")
+	} else {
+		m.logs = append(m.logs, "Showing /Date(…)/ values as ISO 8601")
+	}
+
+	for i := range m.columns {
+		col := &m.columns[i]
+		if len(col.entities) == 0 || col.isResult || col.raw != nil || col.title == "Metadata" {
+			continue
+		}
+		if col.isDetails {
+			col.items = m.detailsLines(m.detailsEntitySet(i), col.entities[0], col.openSections)
+			continue
+		}
+		col.items = col.items[:0]
+		for _, entity := range col.entities {
+			col.items = append(col.items, formatEntityForDisplay(entity))
+		}
+		if col.hasMore {
+			col.items = append(col.items, col.moreItem())
+		}
+	}
+	return m
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// DeepLink is a location to open at startup, given with -service,
+// -entityset and -key or as an OData or odata:// URL argument, or typed
+// into the address bar
+type DeepLink struct {
+	Service    string       // Name of the service to connect to
+	EntitySet  string       // Entity set to open, if any
+	Key        string       // Key predicate of the entity to show, e.g. 1 or 'ALFKI'
+	Navigation string       // Navigation path followed from the entity, e.g. Orders or Category/Products
+	Query      QueryOptions // $filter, $orderby, ... of the entity list, or of the navigation target
+}
+
+// startLink is the location given on the command line, nil to start at the services column
+var startLink *DeepLink
+
+// deepLinkMsg starts following startLink once the program runs
+type deepLinkMsg struct{}
+
+// linkServiceName names the service added for an OData URL argument that no
+// configured service covers
+const linkServiceName = "Link Service"
+
+// resolveDeepLink builds the start location from the deep link flags and
+// argument, adding a service for a URL outside the configured ones.
+// Problems are printed as warnings and leave the navigator at its start.
+func resolveDeepLink(services []ServiceConfig, service, entitySet, key string, args []string, adHoc ServiceConfig) []ServiceConfig {
+	var link *DeepLink
+	switch {
+	case len(args) > 0:
+		var err error
+		link, services, err = parseLinkURL(args[0], services, adHoc)
+		if err != nil {
+			fmt.Printf("Warning: Cannot open %s: %v\n", args[0], err)
+			return services
+		}
+	case service != "":
+		link = &DeepLink{Service: service, EntitySet: entitySet, Key: key}
+	case entitySet != "" || key != "":
+		fmt.Println("Warning: -entityset and -key need -service")
+		return services
+	default:
+		return services
+	}
+
+	name, ok := findService(services, link.Service)
+	if !ok {
+		fmt.Printf("Warning: No configured service named %q\n", link.Service)
+		return services
+	}
+	link.Service = name
+	if link.Key != "" && link.EntitySet == "" {
+		fmt.Println("Warning: -key needs -entityset")
+		link.Key = ""
+	}
+	startLink = link
+	return services
+}
+
+// findService returns the name of a service by its name, ignoring case, or
+// by a part of its name that only one service has
+func findService(services []ServiceConfig, name string) (string, bool) {
+	var partial []string
+	for _, svc := range services {
+		if strings.EqualFold(svc.Name, name) {
+			return svc.Name, true
+		}
+		if strings.Contains(strings.ToLower(svc.Name), strings.ToLower(name)) {
+			partial = append(partial, svc.Name)
+		}
+	}
+	if len(partial) == 1 {
+		return partial[0], true
+	}
+	return "", false
+}
+
+// parseLinkURL reads a location from an OData URL such as
+// https://host/service.svc/Products(1)?$expand=Category. The service is the
+// configured one the URL starts with; otherwise adHoc is added with the
+// service root guessed from the URL.
+//
+// odata:// links match configured services over either http or https, and
+// may name the service instead of its host: odata://Northwind/Products(1).
+// Unknown hosts are reached over https.
+func parseLinkURL(raw string, services []ServiceConfig, adHoc ServiceConfig) (*DeepLink, []ServiceConfig, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != linkScheme) {
+		return nil, services, fmt.Errorf("not an http(s) or %s:// URL", linkScheme)
+	}
+	anyScheme := u.Scheme == linkScheme
+	// Keys may contain encoded slashes, so such a path is split before it is decoded
+	path, encoded := u.RawPath, true
+	if path == "" {
+		path, encoded = u.Path, false
+	}
+	location := strings.ToLower(u.Host) + strings.TrimSuffix(path, "/")
+
+	link := &DeepLink{}
+	rest := ""
+	for _, svc := range services {
+		root, err := url.Parse(strings.TrimSuffix(svc.URL, "/"))
+		if err != nil || (!anyScheme && !strings.EqualFold(root.Scheme, u.Scheme)) {
+			continue
+		}
+		prefix := strings.ToLower(root.Host) + root.Path
+		if (location == prefix || strings.HasPrefix(location, prefix+"/")) && len(prefix) > len(rest) {
+			link.Service = svc.Name
+			rest = prefix
+		}
+	}
+	if link.Service != "" {
+		rest = strings.TrimPrefix(location[len(rest):], "/")
+	} else if name, ok := findService(services, u.Host); anyScheme && ok {
+		link.Service = name
+		rest = strings.Trim(path, "/")
+	} else {
+		if anyScheme {
+			u.Scheme = "https"
+		}
+		root, entityPath := splitServiceRoot(path)
+		adHoc.Name = linkServiceName
+		adHoc.URL = u.Scheme + "://" + u.Host + root
+		services = append(services, adHoc)
+		link.Service = adHoc.Name
+		rest = entityPath
+	}
+
+	// The first segment is the entity set; what follows an entity is a
+	// navigation path read as a whole
+	segments := strings.SplitN(rest, "/", 2)
+	segment := segments[0]
+	if encoded {
+		if decoded, err := url.PathUnescape(segment); err == nil {
+			segment = decoded
+		}
+	}
+	if paren := strings.Index(segment, "("); paren != -1 && strings.HasSuffix(segment, ")") {
+		link.Key = segment[paren+1 : len(segment)-1]
+		segment = segment[:paren]
+	}
+	link.EntitySet = segment
+	if len(segments) == 2 && link.Key != "" {
+		link.Navigation = strings.Trim(segments[1], "/")
+	}
+
+	query := u.Query()
+	link.Query = QueryOptions{
+		Filter:  query.Get("$filter"),
+		Select:  query.Get("$select"),
+		Expand:  query.Get("$expand"),
+		OrderBy: query.Get("$orderby"),
+		Search:  query.Get("$search"),
+	}
+	link.Query.Top, _ = strconv.Atoi(query.Get("$top"))
+	link.Query.Skip, _ = strconv.Atoi(query.Get("$skip"))
+	return link, services, nil
+}
+
+// splitServiceRoot guesses where the service root of an OData URL path ends:
+// after a .svc segment or the service of an SAP Gateway path, otherwise
+// before a last segment that addresses an entity
+func splitServiceRoot(path string) (root, rest string) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	end := len(segments)
+	switch {
+	case indexOfSuffix(segments, ".svc") != -1:
+		end = indexOfSuffix(segments, ".svc") + 1
+	case len(segments) >= 5 && segments[0] == "sap" && segments[1] == "opu" && segments[2] == "odata":
+		end = 5 // /sap/opu/odata/<namespace>/<service>
+	case len(segments) > 1 && strings.Contains(segments[len(segments)-1], "("):
+		end = len(segments) - 1
+	}
+	return "/" + strings.Join(segments[:end], "/"), strings.Join(segments[end:], "/")
+}
+
+func indexOfSuffix(segments []string, suffix string) int {
+	for i, segment := range segments {
+		if strings.HasSuffix(strings.ToLower(segment), suffix) {
+			return i
+		}
+	}
+	return -1
+}
+
+// openLinkService connects to the service of the start location
+func (m model) openLinkService() (tea.Model, tea.Cmd) {
+	link := m.pendingLink
+	if link == nil {
+		return m, nil
+	}
+	if link.EntitySet == "" {
+		m.pendingLink = nil
+	}
+	for i, item := range m.columns[0].items {
+		if item == link.Service {
+			m.columns[0].cursor = i
+		}
+	}
+	m.logs = append(m.logs, "Opening "+link.Service)
+	return m.drillDown()
+}
+
+// followLink opens the entity set, the entity and the navigation target of
+// the start location once the entity sets of its service are loaded
+func (m model) followLink() (tea.Model, tea.Cmd) {
+	link := m.pendingLink
+	m.pendingLink = nil
+	if m.activeColumn != 1 || m.columns[1].title != "EntitySets" || m.services[m.serviceIndex].Name != link.Service {
+		return m, nil
+	}
+
+	found := -1
+	for i, item := range m.columns[1].items {
+		name := itemEntitySet(item)
+		if name == link.EntitySet || (found == -1 && strings.EqualFold(name, link.EntitySet)) {
+			found = i
+		}
+	}
+	if found == -1 {
+		m.logs = append(m.logs, fmt.Sprintf("Entity set %s not found in %s", link.EntitySet, link.Service))
+		return m, nil
+	}
+	entitySet := itemEntitySet(m.columns[1].items[found])
+	m.columns[1].cursor = found
+	m.columns[1].focused = false
+
+	// The query of a URL reading a navigation target belongs to the target
+	listQuery := link.Query
+	if link.Navigation != "" {
+		listQuery = QueryOptions{}
+	}
+	list := column{
+		title:     entitySet,
+		items:     []string{"Loading..."},
+		entitySet: entitySet,
+		query:     listQuery,
+		id:        m.newColumnID(),
+		state:     stateLoading,
+	}
+	m.columns = append(m.columns[:2], list)
+	m.activeColumn = 2
+	m.loading = true
+	cmds := []tea.Cmd{loadEntities(m.odata, list)}
+
+	if link.Key != "" {
+		details := column{
+			title:     "Details",
+			items:     []string{"Loading..."},
+			isDetails: true,
+			entitySet: entitySet,
+			id:        m.newColumnID(),
+			state:     stateLoading,
+		}
+		m.columns = append(m.columns, details)
+		m.activeColumn = 3
+		odata, key, expand, id := m.odata.forColumn(details.id), m.odata.KeyPredicate(entitySet, link.Key), listQuery.Expand, details.id
+		m.logs = append(m.logs, fmt.Sprintf("Reading %s(%s)", entitySet, key))
+		cmds = append(cmds, func() tea.Msg {
+			defer odata.loaded()
+			entity, err := odata.GetEntity(entitySet, key, QueryOptions{Expand: expand})
+			if err != nil {
+				return newErrorMsg(err, fmt.Sprintf("readEntity(%s, %s)", entitySet, key)).forColumn(id)
+			}
+			return entityDetailMsg{column: id, entitySet: entitySet, entityKey: key, entity: entity}
+		})
+
+		if link.Navigation != "" {
+			name := link.Navigation[strings.LastIndex(link.Navigation, "/")+1:]
+			if paren := strings.Index(name, "("); paren != -1 {
+				name = name[:paren]
+			}
+			opts := link.Query
+			opts.Top, opts.Skip = 0, 0
+			nav := column{
+				title:  name,
+				items:  []string{"Loading..."},
+				navURL: m.odata.BuildURL(m.odata.BuildURL(entitySet, key, QueryOptions{})+"/"+link.Navigation, "", opts),
+				id:     m.newColumnID(),
+				state:  stateLoading,
+			}
+			m.columns = append(m.columns, nav)
+			m.activeColumn = 4
+			cmds = append(cmds, loadNavigation(m.odata, nav))
+		}
+	}
+	m.columns[m.activeColumn].focused = true
+	m.updateColumnSizes()
+	return m, tea.Batch(cmds...)
+}
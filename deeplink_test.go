@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLinkURL(t *testing.T) {
+	services := []ServiceConfig{
+		{Name: "Northwind", URL: "https://services.odata.org/V2/Northwind/Northwind.svc/"},
+		{Name: "Gateway", URL: "http://sap.example.com/sap/opu/odata/sap/ZORDERS_SRV"},
+	}
+	tests := []struct {
+		name    string
+		raw     string
+		want    DeepLink
+		service string // URL of the service added for the link, if one is
+	}{
+		{"entity set", "https://services.odata.org/V2/Northwind/Northwind.svc/Products",
+			DeepLink{Service: "Northwind", EntitySet: "Products"}, ""},
+		{"entity with options", "https://services.odata.org/V2/Northwind/Northwind.svc/Products(1)?$expand=Category&$select=Name",
+			DeepLink{Service: "Northwind", EntitySet: "Products", Key: "1", Query: QueryOptions{Expand: "Category", Select: "Name"}}, ""},
+		{"list options", "https://services.odata.org/V2/Northwind/Northwind.svc/Products?$filter=Price%20gt%2010&$orderby=Name&$top=5&$skip=10",
+			DeepLink{Service: "Northwind", EntitySet: "Products", Query: QueryOptions{Filter: "Price gt 10", OrderBy: "Name", Top: 5, Skip: 10}}, ""},
+		{"navigation", "https://services.odata.org/V2/Northwind/Northwind.svc/Categories(1)/Products",
+			DeepLink{Service: "Northwind", EntitySet: "Categories", Key: "1", Navigation: "Products"}, ""},
+		{"encoded slash in a key", "http://sap.example.com/sap/opu/odata/sap/ZORDERS_SRV/Orders('A%2FB')/Items",
+			DeepLink{Service: "Gateway", EntitySet: "Orders", Key: "'A/B'", Navigation: "Items"}, ""},
+		{"host case ignored", "https://SERVICES.odata.org/V2/Northwind/Northwind.svc/Orders",
+			DeepLink{Service: "Northwind", EntitySet: "Orders"}, ""},
+		{"scheme must match", "http://services.odata.org/V2/Northwind/Northwind.svc/Orders",
+			DeepLink{Service: linkServiceName, EntitySet: "Orders"}, "http://services.odata.org/V2/Northwind/Northwind.svc"},
+		{"odata link by name", "odata://Northwind/Products(1)",
+			DeepLink{Service: "Northwind", EntitySet: "Products", Key: "1"}, ""},
+		{"odata link over http", "odata://sap.example.com/sap/opu/odata/sap/ZORDERS_SRV/Orders",
+			DeepLink{Service: "Gateway", EntitySet: "Orders"}, ""},
+		{"unknown service", "https://other.example.com/odata/v4/Catalog.svc/Books(3)",
+			DeepLink{Service: linkServiceName, EntitySet: "Books", Key: "3"}, "https://other.example.com/odata/v4/Catalog.svc"},
+		{"unknown gateway service", "https://gw.example.com/sap/opu/odata/sap/ZSALES_SRV/SalesOrders",
+			DeepLink{Service: linkServiceName, EntitySet: "SalesOrders"}, "https://gw.example.com/sap/opu/odata/sap/ZSALES_SRV"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link, got, err := parseLinkURL(tt.raw, services, ServiceConfig{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*link, tt.want) {
+				t.Errorf("link = %+v\nwant   %+v", *link, tt.want)
+			}
+			added := ""
+			if len(got) > len(services) {
+				added = got[len(got)-1].URL
+			}
+			if added != tt.service {
+				t.Errorf("added service %q, want %q", added, tt.service)
+			}
+		})
+	}
+}
+
+func TestParseLinkURLErrors(t *testing.T) {
+	for _, raw := range []string{"Products(1)", "ftp://host/svc/Products", "https:///Products", "::"} {
+		t.Run(raw, func(t *testing.T) {
+			if _, services, err := parseLinkURL(raw, nil, ServiceConfig{}); err == nil || len(services) != 0 {
+				t.Errorf("no error, %d services", len(services))
+			}
+		})
+	}
+}
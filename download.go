@@ -0,0 +1,300 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// DownloadValue streams a $value resource (an entity's media stream or the
+// raw value of a property) into the file target, calling progress as data
+// arrives, and returns the path of the file. A target that is a directory
+// gets a new file named after the server's file name or name. total is -1
+// when the server sends no Content-Length. Nothing is held in memory, so
+// multi-GB streams are fine.
+func (o *ODataService) DownloadValue(url, target, name string, progress func(written, total int64)) (string, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	// Keep streams out of the response cache, which buffers whole bodies
+	req.Header.Set("Cache-Control", "no-store")
+	// Esc cancels loads, not a download running in the status bar
+	req = detached(req)
+
+	if o.username != "" && o.password != "" {
+		req.SetBasicAuth(o.username, o.password)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	path := target
+	if info, err := os.Stat(target); err == nil && info.IsDir() {
+		path = uniqueFilePath(target, downloadFileName(name, resp.Header))
+	}
+	// Write to a .part file of this download so an interrupted download
+	// never looks complete and other instances never write into it
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.part")
+	if err != nil {
+		return "", err
+	}
+	part := file.Name()
+	counter := &progressWriter{total: resp.ContentLength, report: progress}
+	_, err = io.Copy(io.MultiWriter(file, counter), resp.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(part)
+		return "", fmt.Errorf("download of %s failed after %s: %w", name, formatBytes(counter.written), err)
+	}
+	if progress != nil {
+		progress(counter.written, resp.ContentLength)
+	}
+	os.Chmod(part, 0o644)
+	return path, os.Rename(part, path)
+}
+
+// unsafeFileChars matches characters that don't belong in a file name
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// downloadFileName prefers the server's Content-Disposition file name and
+// otherwise names the file after name with an extension for its content type.
+// The server's name loses any directories and characters that don't belong
+// in a file name; one left with nothing but dots, such as "..", isn't used.
+func downloadFileName(name string, header http.Header) string {
+	if _, params, err := mime.ParseMediaType(header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		server := filepath.Base(strings.ReplaceAll(params["filename"], "\\", "/"))
+		server = strings.Trim(unsafeFileChars.ReplaceAllString(server, "_"), "_")
+		if strings.Trim(server, ".") != "" {
+			return server
+		}
+	}
+
+	name = strings.Trim(unsafeFileChars.ReplaceAllString(name, "_"), "_")
+	if name == "" {
+		name = "download"
+	}
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	switch mediaType {
+	case "", "application/octet-stream":
+		return name + ".bin"
+	case "text/plain":
+		return name + ".txt"
+	case "image/jpeg":
+		return name + ".jpg" // Rather than the first known extension, .jfif
+	}
+	if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+		return name + exts[0]
+	}
+	return name + ".bin"
+}
+
+// uniqueFilePath returns dir/name, numbering the name if the file exists
+func uniqueFilePath(dir, name string) string {
+	path := filepath.Join(dir, name)
+	ext := filepath.Ext(name)
+	for i := 1; ; i++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return path
+		}
+		path = filepath.Join(dir, fmt.Sprintf("%s-%d%s", strings.TrimSuffix(name, ext), i, ext))
+	}
+}
+
+// targetEntity returns the entity a download or upload acts on: the one
+// shown in a Details column or the one under the cursor of an entity list
+func (m model) targetEntity() (string, map[string]interface{}, bool) {
+	if m.odata == nil || m.activeColumn >= len(m.columns) {
+		return "", nil, false
+	}
+	col := m.columns[m.activeColumn]
+	switch {
+	case col.isDetails && !col.isResult && len(col.entities) > 0:
+		return m.detailsEntitySet(m.activeColumn), col.entities[0], true
+	case col.isEntityList() && col.cursor < len(col.entities):
+		return col.entitySet, col.entities[col.cursor], true
+	}
+	return "", nil, false
+}
+
+// downloadTarget returns what "d" downloads: the property under the cursor
+// of a Details column, or else the media stream of the selected entity with
+// the content type the entity announces for it
+func (m model) downloadTarget() (url, name, contentType string, ok bool) {
+	entitySet, entity, ok := m.targetEntity()
+	if !ok {
+		return "", "", "", false
+	}
+	col := m.columns[m.activeColumn]
+
+	key := extractEntityKey(m.metadata(), entitySet, entity)
+	if key == "" {
+		return "", "", "", false
+	}
+	name = entitySet + "_" + key
+
+	// A scalar property under the cursor is read from its own $value
+	if col.isDetails {
+		if _, property, found := topLevelProperty(col.items, col.cursor); found && !strings.Contains(property, "@") {
+			switch entity[property].(type) {
+			case string, float64, bool:
+				return m.odata.BuildURL(entitySet, key, QueryOptions{}) + "/" + property + "/$value", name + "_" + property, "", true
+			}
+		}
+	}
+
+	// Media entities name their stream in __metadata.media_src (V2) or
+	// @odata.mediaReadLink (V4)
+	if metadata, ok := entity["__metadata"].(map[string]interface{}); ok {
+		if src, ok := metadata["media_src"].(string); ok && src != "" {
+			return m.odata.BuildURL(src, "", QueryOptions{}), name, mediaContentType(entity), true
+		}
+	}
+	if link, ok := entity["@odata.mediaReadLink"].(string); ok && link != "" {
+		return m.odata.BuildURL(link, "", QueryOptions{}), name, mediaContentType(entity), true
+	}
+	// Without a link only entity types the metadata flags HasStream have a $value
+	if et := m.metadata().EntityTypeOf(entitySet); et != nil && !et.HasStream {
+		return "", "", "", false
+	}
+	return m.odata.BuildURL(entitySet, key, QueryOptions{}) + "/$value", name, mediaContentType(entity), true
+}
+
+// mediaContentType returns the content type a media entity announces for
+// its stream, "" when it names none
+func mediaContentType(entity map[string]interface{}) string {
+	if metadata, ok := entity["__metadata"].(map[string]interface{}); ok {
+		if contentType, ok := metadata["content_type"].(string); ok {
+			return contentType
+		}
+	}
+	contentType, _ := entity["@odata.mediaContentType"].(string)
+	return contentType
+}
+
+// downloadPrompt asks where a download is saved
+type downloadPrompt struct {
+	active bool
+	name   string // Entity or property downloaded, for display and file naming
+	url    string // $value resource read
+	path   string // File to write, or a directory to name the file in
+}
+
+// openDownloadPrompt asks where to save the download target, suggesting a
+// file in the working directory named after it
+func (m model) openDownloadPrompt() model {
+	if m.transfer != nil {
+		m.logs = append(m.logs, fmt.Sprintf("%s %s still running", m.transfer.verb, m.transfer.name))
+		return m
+	}
+	url, name, contentType, ok := m.downloadTarget()
+	if !ok {
+		m.logs = append(m.logs, "Download: select a media entity or place the cursor on a property in the Details column")
+		return m
+	}
+	header := http.Header{"Content-Type": {contentType}}
+	m.downloadDialog = downloadPrompt{active: true, name: name, url: url, path: downloadFileName(name, header)}
+	return m
+}
+
+// updateDownloadPrompt handles key presses while the download prompt is open
+func (m model) updateDownloadPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	dp := &m.downloadDialog
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		dp.active = false
+	case "enter":
+		dp.active = false
+		return m.startDownload(strings.TrimSpace(dp.path))
+	case "backspace":
+		if len(dp.path) > 0 {
+			runes := []rune(dp.path)
+			dp.path = string(runes[:len(runes)-1])
+		}
+	default:
+		dp.path += typedText(msg)
+	}
+	return m, nil
+}
+
+// renderDownloadPrompt renders the download prompt box
+func (m model) renderDownloadPrompt() string {
+	dp := m.downloadDialog
+	lines := []string{
+		"Save to file (a directory keeps the server's file name):",
+		lipgloss.NewStyle().Background(theme.Panel).Render("> " + dp.path + "█"),
+		"",
+		lipgloss.NewStyle().Foreground(theme.Muted).Render(shortenURL(dp.url)),
+		"",
+		lipgloss.NewStyle().Foreground(theme.Muted).Render("Enter: Download | ESC: Cancel"),
+	}
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Background(theme.Accent).
+		Foreground(theme.AccentText).
+		Padding(0, 1).
+		Render("Download " + dp.name)
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(theme.Accent).
+		Padding(0, 1).
+		Width(min(70, m.width-4)).
+		Render(title + "\n\n" + strings.Join(lines, "\n"))
+}
+
+// startDownload streams the target chosen in the download prompt to path,
+// reporting progress to the status bar and the log pane
+func (m model) startDownload(path string) (tea.Model, tea.Cmd) {
+	if path == "" {
+		path = "."
+	}
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		// A chosen file name never overwrites an existing file
+		path = uniqueFilePath(filepath.Dir(path), filepath.Base(path))
+	}
+
+	name := m.downloadDialog.name
+	url := m.downloadDialog.url
+	transfer, progress := newTransfer("Downloading", name)
+	m.transfer = transfer
+	m.logs = append(m.logs, fmt.Sprintf("Downloading %s to %s...", shortenURL(url), path))
+
+	odata := m.odata
+	go func() {
+		var written int64
+		path, err := odata.DownloadValue(url, path, name, func(n, total int64) {
+			written = n
+			progress(n, total)
+		})
+		transfer.updates <- downloadDoneMsg{name: name, path: path, written: written, elapsed: time.Since(transfer.started), err: err}
+	}()
+	return m, waitForTransfer(transfer.updates)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestDownloadFileName(t *testing.T) {
+	tests := []struct {
+		name        string
+		disposition string
+		contentType string
+		want        string
+	}{
+		{"server name", `attachment; filename="report.pdf"`, "application/pdf", "report.pdf"},
+		{"directories dropped", `attachment; filename="../../etc/passwd"`, "", "passwd"},
+		{"windows directories dropped", `attachment; filename="C:\\temp\\photo.png"`, "", "photo.png"},
+		{"unsafe characters", `attachment; filename="my report (1).pdf"`, "", "my_report_1_.pdf"},
+		{"parent directory", `attachment; filename=".."`, "image/png", "Photo.png"},
+		{"current directory", `attachment; filename="."`, "", "Photo.bin"},
+		{"root", `attachment; filename="/"`, "text/plain", "Photo.txt"},
+		{"only dots", `attachment; filename="..."`, "", "Photo.bin"},
+		{"no server name", "", "image/jpeg", "Photo.jpg"},
+		{"unknown type", "", "application/x-odatanavigator", "Photo.bin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{"Content-Disposition": {tt.disposition}, "Content-Type": {tt.contentType}}
+			if got := downloadFileName("Photo", header); got != tt.want {
+				t.Errorf("downloadFileName = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,789 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+// textEditor is the multi-line text area of the modal editor. It only knows
+// about text and cursor movement; what the text is saved as is up to the
+// model that embeds it.
+type textEditor struct {
+	content []string    // Lines being edited
+	cursor  int         // Cursor line
+	col     int         // Cursor position within the line, in bytes; always at the start of a rune
+	scroll  int         // First row shown; long lines wrap onto several rows
+	assist  bool        // Close brackets and quotes as typed and indent new lines
+	anchor  *editorMark // Where the selection started, nil when nothing is selected
+}
+
+// closers are the characters that close each opening one when assist is on
+var closers = map[string]string{"{": "}", "[": "]", "\"": "\""}
+
+// editorRow is a row of the editor: the part of a line from start to end,
+// in bytes. Lines wider than the wrap width continue on the next row.
+type editorRow struct {
+	line       int
+	start, end int
+	last       bool // The row ends its line
+}
+
+// rows splits the lines into rows at most width cells wide, so wide runes
+// such as CJK take the two cells they are drawn in, and never cuts a rune.
+// A width of 0 or less doesn't wrap.
+func (e textEditor) rows(width int) []editorRow {
+	var rows []editorRow
+	for i, line := range e.content {
+		start := 0
+		for {
+			end, cells := start, 0
+			for end < len(line) {
+				r, size := utf8.DecodeRuneInString(line[end:])
+				w := max(runewidth.RuneWidth(r), 1)
+				if width > 0 && cells+w > width && end > start {
+					break
+				}
+				cells += w
+				end += size
+			}
+			rows = append(rows, editorRow{line: i, start: start, end: end, last: end == len(line)})
+			if end == len(line) {
+				break
+			}
+			start = end
+		}
+	}
+	return rows
+}
+
+// cursorRow returns the index of the row holding the cursor
+func (e textEditor) cursorRow(rows []editorRow) int {
+	for i, r := range rows {
+		if r.line == e.cursor && e.col >= r.start && (e.col < r.end || r.last) {
+			return i
+		}
+	}
+	return max(len(rows)-1, 0)
+}
+
+// moveToRow puts the cursor on a row, as many cells into it as it was into
+// its current row where the row is long enough
+func (e *textEditor) moveToRow(rows []editorRow, from, to int) {
+	if len(rows) == 0 {
+		return
+	}
+	to = max(0, min(to, len(rows)-1))
+	offset := runewidth.StringWidth(e.content[rows[from].line][rows[from].start:e.col])
+	r := rows[to]
+	line := e.content[r.line]
+	e.cursor = r.line
+	e.col = r.start
+	for cells := 0; e.col < r.end; {
+		char, size := utf8.DecodeRuneInString(line[e.col:])
+		w := runewidth.RuneWidth(char)
+		if cells+w > offset {
+			break
+		}
+		cells += w
+		e.col += size
+	}
+	// The end of a wrapped row is the start of the next one
+	if !r.last && e.col == r.end && r.end > r.start {
+		e.col -= e.runeBefore()
+	}
+}
+
+// runeBefore and runeAfter return the size in bytes of the rune before and
+// after the cursor, 0 at the start and end of the line. The cursor moves,
+// and deletes, by whole runes, so umlauts and CJK text stay intact.
+func (e textEditor) runeBefore() int {
+	_, size := utf8.DecodeLastRuneInString(e.content[e.cursor][:e.col])
+	return size
+}
+
+func (e textEditor) runeAfter() int {
+	_, size := utf8.DecodeRuneInString(e.content[e.cursor][e.col:])
+	return size
+}
+
+// clampCol keeps the cursor within its line and at the start of a rune
+func (e *textEditor) clampCol() {
+	line := e.content[e.cursor]
+	e.col = max(0, min(e.col, len(line)))
+	for e.col > 0 && e.col < len(line) && !utf8.RuneStart(line[e.col]) {
+		e.col--
+	}
+}
+
+// follow scrolls so the row of the cursor is among the height rows shown
+func (e *textEditor) follow(rows []editorRow, height int) {
+	row := e.cursorRow(rows)
+	if row < e.scroll {
+		e.scroll = row
+	}
+	if row >= e.scroll+height {
+		e.scroll = row - height + 1
+	}
+	e.scroll = max(0, min(e.scroll, len(rows)-1))
+}
+
+// newTextEditor returns an editor holding lines with the cursor at line, col
+func newTextEditor(lines []string, line, col int) textEditor {
+	return textEditor{content: lines, cursor: line, col: col}
+}
+
+// Text returns the edited text
+func (e textEditor) Text() string {
+	return strings.Join(e.content, "\n")
+}
+
+// Format re-indents the text as JSON with two spaces per level, keeping
+// the order of the properties. The cursor stays on its line number.
+func (e textEditor) Format() (textEditor, error) {
+	text := strings.TrimSpace(e.Text())
+	var out bytes.Buffer
+	if err := json.Indent(&out, []byte(text), "", "  "); err != nil {
+		var syntax *json.SyntaxError
+		if errors.As(err, &syntax) {
+			return e, fmt.Errorf("line %d: %v", strings.Count(text[:syntax.Offset], "\n")+1, err)
+		}
+		return e, err
+	}
+	e.content = strings.Split(out.String(), "\n")
+	e.anchor = nil
+	e.cursor = min(e.cursor, len(e.content)-1)
+	e.clampCol()
+	return e, nil
+}
+
+// Update applies an editing or cursor key to the text; height is the number
+// of rows shown, which paging and scrolling keep the cursor within, and
+// width the number of cells lines wrap at
+func (e textEditor) Update(msg tea.KeyMsg, height, width int) textEditor {
+	key := msg.String()
+	if move, ok := shiftMoves[key]; ok {
+		// Shift with a movement key selects from where the cursor was
+		if e.anchor == nil {
+			e.anchor = &editorMark{line: e.cursor, col: e.col}
+		}
+		key = move
+	} else if e.anchor != nil {
+		// Any other key ends the selection; typing replaces it
+		_, _, selected := e.selection()
+		switch {
+		case selected && (key == "backspace" || key == "delete"):
+			e.deleteSelection()
+			e.follow(e.rows(width), height)
+			return e
+		case selected && (msg.Type == tea.KeyRunes || key == "enter" || key == "ctrl+j" || key == "tab"):
+			e.deleteSelection()
+		}
+		e.anchor = nil
+	}
+	rows := e.rows(width)
+	row := e.cursorRow(rows)
+	switch key {
+	case "up", "k":
+		// Up and down move by rows, within wrapped lines too
+		if row > 0 {
+			e.moveToRow(rows, row, row-1)
+		}
+	case "down", "j":
+		if row < len(rows)-1 {
+			e.moveToRow(rows, row, row+1)
+		}
+	case "left":
+		if e.col > 0 {
+			e.col -= e.runeBefore()
+		} else if e.cursor > 0 {
+			// Move to end of previous line
+			e.cursor--
+			if e.cursor < len(e.content) {
+				e.col = len(e.content[e.cursor])
+			}
+		}
+	case "right":
+		if e.cursor < len(e.content) && e.col < len(e.content[e.cursor]) {
+			e.col += e.runeAfter()
+		} else if e.cursor < len(e.content)-1 {
+			// Move to beginning of next line
+			e.cursor++
+			e.col = 0
+		}
+	case "enter", "ctrl+j":
+		// Insert new line (pasted text arrives with ctrl+j line feeds and
+		// is never indented)
+		if e.assist && msg.String() == "enter" && e.cursor < len(e.content) {
+			e.indentedNewLine()
+		} else if e.cursor < len(e.content) {
+			currentLine := e.content[e.cursor]
+			beforeCursor := currentLine[:e.col]
+			afterCursor := currentLine[e.col:]
+
+			// Replace current line with part before cursor
+			e.content[e.cursor] = beforeCursor
+
+			// Insert new line with part after cursor
+			newContent := make([]string, len(e.content)+1)
+			copy(newContent[:e.cursor+1], e.content[:e.cursor+1])
+			newContent[e.cursor+1] = afterCursor
+			copy(newContent[e.cursor+2:], e.content[e.cursor+1:])
+			e.content = newContent
+
+			// Move to next line, beginning
+			e.cursor++
+			e.col = 0
+		}
+	case "backspace":
+		if e.assist && e.insidePair() {
+			// Delete an empty pair of brackets or quotes at once
+			line := e.content[e.cursor]
+			e.content[e.cursor] = line[:e.col-1] + line[e.col+1:]
+			e.col--
+		} else if e.col > 0 {
+			// Delete character before cursor
+			if e.cursor < len(e.content) {
+				line := e.content[e.cursor]
+				size := e.runeBefore()
+				e.content[e.cursor] = line[:e.col-size] + line[e.col:]
+				e.col -= size
+			}
+		} else if e.cursor > 0 {
+			// Join with previous line
+			if e.cursor < len(e.content) {
+				prevLine := e.content[e.cursor-1]
+				currentLine := e.content[e.cursor]
+				e.col = len(prevLine)
+				e.content[e.cursor-1] = prevLine + currentLine
+
+				// Remove current line
+				newContent := make([]string, len(e.content)-1)
+				copy(newContent[:e.cursor], e.content[:e.cursor])
+				copy(newContent[e.cursor:], e.content[e.cursor+1:])
+				e.content = newContent
+				e.cursor--
+			}
+		}
+	case "delete":
+		if e.cursor < len(e.content) {
+			line := e.content[e.cursor]
+			if e.col < len(line) {
+				// Delete character at cursor
+				e.content[e.cursor] = line[:e.col] + line[e.col+e.runeAfter():]
+			} else if e.cursor < len(e.content)-1 {
+				// Join with next line
+				nextLine := e.content[e.cursor+1]
+				e.content[e.cursor] = line + nextLine
+
+				// Remove next line
+				newContent := make([]string, len(e.content)-1)
+				copy(newContent[:e.cursor+1], e.content[:e.cursor+1])
+				copy(newContent[e.cursor+1:], e.content[e.cursor+2:])
+				e.content = newContent
+			}
+		}
+	case "pgup":
+		e.moveToRow(rows, row, row-height)
+		e.scroll = max(e.scroll-height, 0)
+	case "pgdown":
+		e.moveToRow(rows, row, row+height)
+		e.scroll += height
+	case "home":
+		e.col = 0
+	case "end":
+		if e.cursor < len(e.content) {
+			e.col = len(e.content[e.cursor])
+		}
+	case "ctrl+home":
+		e.cursor = 0
+		e.col = 0
+	case "ctrl+end":
+		if len(e.content) > 0 {
+			e.cursor = len(e.content) - 1
+			e.col = len(e.content[e.cursor])
+		}
+	case "ctrl+d":
+		e.duplicateLine()
+	case "ctrl+k":
+		e.deleteLine()
+	case "alt+up":
+		e.moveLine(-1)
+	case "alt+down":
+		e.moveLine(1)
+	case "tab":
+		// Keep tabs literally so pasted spreadsheet rows survive
+		if e.cursor >= len(e.content) {
+			e.content = append(e.content, "")
+		}
+		line := e.content[e.cursor]
+		e.content[e.cursor] = line[:e.col] + "\t" + line[e.col:]
+		e.col++
+	default:
+		// Handle regular character input (pasted text arrives as multiple runes)
+		if msg.Type == tea.KeyRunes || len(msg.String()) == 1 {
+			char := string(msg.Runes)
+			if msg.Type != tea.KeyRunes {
+				char = msg.String()
+			}
+			if e.cursor >= len(e.content) {
+				// Add new line if needed
+				e.content = append(e.content, "")
+			}
+
+			if e.assist && len(msg.Runes) == 1 {
+				e.typePaired(char)
+				break
+			}
+			line := e.content[e.cursor]
+			// Insert character at cursor position
+			e.content[e.cursor] = line[:e.col] + char + line[e.col:]
+			e.col += len(char)
+		}
+	}
+	e.follow(e.rows(width), height)
+	return e
+}
+
+// typePaired inserts a typed character, adding the closing bracket or quote
+// after an opening one and stepping over a closing one typed where it
+// already is
+func (e *textEditor) typePaired(char string) {
+	line := e.content[e.cursor]
+	next := ""
+	if e.col < len(line) {
+		next = line[e.col : e.col+1]
+	}
+	switch {
+	case next == char && (char == "}" || char == "]" || char == "\""):
+		e.col++
+		return
+	case closers[char] != "" && !(char == "\"" && e.col > 0 && line[e.col-1] == '\\'):
+		e.content[e.cursor] = line[:e.col] + char + closers[char] + line[e.col:]
+		e.col++
+		return
+	}
+	e.content[e.cursor] = line[:e.col] + char + line[e.col:]
+	e.col += len(char)
+}
+
+// insidePair reports whether the cursor is between an opening bracket or
+// quote and its closing one
+func (e textEditor) insidePair() bool {
+	if e.cursor >= len(e.content) || e.col == 0 || e.col >= len(e.content[e.cursor]) {
+		return false
+	}
+	line := e.content[e.cursor]
+	return closers[line[e.col-1:e.col]] == line[e.col:e.col+1]
+}
+
+// indentedNewLine breaks the line at the cursor, indenting the new line as
+// the current one and a level deeper after an opening bracket. Between a
+// pair of brackets the closing one moves to a line of its own.
+func (e *textEditor) indentedNewLine() {
+	line := e.content[e.cursor]
+	before, after := line[:e.col], strings.TrimLeft(line[e.col:], " ")
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	if len(indent) > len(before) {
+		indent = before
+	}
+
+	lines := []string{before}
+	inner := indent
+	trimmed := strings.TrimRight(before, " ")
+	if strings.HasSuffix(trimmed, "{") || strings.HasSuffix(trimmed, "[") {
+		inner += "  "
+		if after != "" && closers[trimmed[len(trimmed)-1:]] == after[:1] {
+			lines = append(lines, inner, indent+after)
+		}
+	}
+	if len(lines) == 1 {
+		lines = append(lines, inner+after)
+	}
+
+	content := append([]string(nil), e.content[:e.cursor]...)
+	content = append(content, lines...)
+	e.content = append(content, e.content[e.cursor+1:]...)
+	e.cursor++
+	e.col = len(inner)
+}
+
+// moveToValue puts the cursor at the value of a property, inside the quotes
+// of an empty string
+func (e *textEditor) moveToValue(name string) {
+	for i, line := range e.content {
+		if strings.HasPrefix(strings.TrimSpace(line), fmt.Sprintf("%q:", name)) {
+			e.cursor = i
+			e.col = strings.Index(line, ":") + 2
+			if strings.HasSuffix(strings.TrimSuffix(line, ","), `""`) {
+				e.col++
+			}
+			return
+		}
+	}
+}
+
+// duplicateLine copies the line of the cursor below it and moves onto the copy
+func (e *textEditor) duplicateLine() {
+	if e.cursor >= len(e.content) {
+		return
+	}
+	content := append([]string(nil), e.content[:e.cursor+1]...)
+	content = append(content, e.content[e.cursor])
+	e.content = append(content, e.content[e.cursor+1:]...)
+	e.cursor++
+}
+
+// moveLine swaps the line of the cursor with the one above or below it,
+// the cursor moving along
+func (e *textEditor) moveLine(delta int) {
+	to := e.cursor + delta
+	if e.cursor >= len(e.content) || to < 0 || to >= len(e.content) {
+		return
+	}
+	e.content[e.cursor], e.content[to] = e.content[to], e.content[e.cursor]
+	e.cursor = to
+}
+
+// deleteLine removes the line of the cursor, keeping one empty line at least
+func (e *textEditor) deleteLine() {
+	if e.cursor >= len(e.content) {
+		return
+	}
+	e.content = append(e.content[:e.cursor:e.cursor], e.content[e.cursor+1:]...)
+	if len(e.content) == 0 {
+		e.content = []string{""}
+	}
+	e.cursor = min(e.cursor, len(e.content)-1)
+	e.clampCol()
+}
+
+// editorMark is a position in the text that View underlines, such as where
+// it stops parsing; col is in bytes
+type editorMark struct {
+	line, col int
+}
+
+// View renders height rows of the text from the scroll position, numbered,
+// with the cursor shown and lines wrapped at width cells. A rule marks the
+// right margin, with ↩ on rows whose line continues on the next one. Lines
+// for which highlight is true stand out, and the mark, when not nil, is
+// underlined with its line number.
+func (e textEditor) View(height, width int, highlight func(line string) bool, mark *editorMark) []string {
+	rows := e.rows(width)
+	dim := lipgloss.NewStyle().Foreground(theme.Muted)
+	cursorStyle := lipgloss.NewStyle().Background(theme.Cursor).Foreground(theme.AccentText)
+	markStyle := lipgloss.NewStyle().Underline(true).Bold(true).Foreground(theme.Error)
+
+	var renderedLines []string
+	for i := e.scroll; i < len(rows) && i < e.scroll+height; i++ {
+		r := rows[i]
+		line := e.content[r.line]
+		segment := line[r.start:r.end]
+
+		prefix := fmt.Sprintf("%4d ", r.line+1)
+		if r.start > 0 {
+			prefix = "   ↪ "
+		}
+		margin := dim.Render("│")
+		if !r.last {
+			margin = dim.Render("↩")
+		}
+		padding := ""
+		if width > 0 {
+			padding = strings.Repeat(" ", max(width-lipgloss.Width(segment), 0))
+		}
+
+		switch {
+		case r.line == e.cursor && e.col >= r.start && (e.col < r.end || r.last):
+			// Show the cursor as background highlight on its character, or
+			// after the end of the line
+			displayLine := e.paint(r.line, r.start, r.end) + cursorStyle.Render(" ")
+			if e.col < r.end {
+				_, size := utf8.DecodeRuneInString(line[e.col:])
+				displayLine = e.paint(r.line, r.start, e.col) + cursorStyle.Render(line[e.col:e.col+size]) + e.paint(r.line, e.col+size, r.end)
+			} else if padding != "" {
+				padding = padding[1:]
+			}
+			segment = lipgloss.NewStyle().
+				Background(theme.Selection).
+				Foreground(theme.AccentText).
+				Render(prefix) + displayLine
+		case mark != nil && r.line == mark.line && mark.col >= r.start && (mark.col < r.end || r.last):
+			// Where the text went wrong, or after the end of the line
+			if mark.col < r.end {
+				_, size := utf8.DecodeRuneInString(line[mark.col:])
+				segment = line[r.start:mark.col] + markStyle.Render(line[mark.col:mark.col+size]) + line[mark.col+size:r.end]
+			} else {
+				segment += lipgloss.NewStyle().Background(theme.Error).Render(" ")
+				if padding != "" {
+					padding = padding[1:]
+				}
+			}
+			segment = markStyle.Render(prefix) + segment
+		case highlight(line) && !e.selects(r.line):
+			// Fields that must be filled in: the keys of a copy, the
+			// required properties of a create
+			segment = dim.Render(prefix) + lipgloss.NewStyle().Background(theme.Edit).Foreground(theme.AccentText).Render(segment)
+		default:
+			segment = dim.Render(prefix) + e.paint(r.line, r.start, r.end)
+		}
+		if width > 0 {
+			segment += padding + margin
+		}
+		renderedLines = append(renderedLines, segment)
+	}
+
+	// Fill remaining space with empty lines
+	for len(renderedLines) < height {
+		renderedLines = append(renderedLines, "")
+	}
+
+	return renderedLines
+}
+
+// editorWidth is the number of cells lines wrap at in the modal editor:
+// its width less the line numbers and the right margin
+func (m model) editorWidth() int {
+	return int(float64(m.width)*0.95) - 7
+}
+
+// editorHeight is the number of text lines the modal editor shows
+func (m model) editorHeight() int {
+	return int(float64(m.height)*0.95) - 5 // Borders, title and the line saying whether the JSON parses
+}
+
+// updateModalEditor handles key presses while the modal editor is open
+func (m model) updateModalEditor(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.completionHint = ""
+	switch msg.String() {
+	case "f10":
+		// q is typed and Ctrl+C copies here, so only F10 quits
+		return m, tea.Quit
+	case "ctrl+c", "ctrl+x":
+		return m.copyFromEditor(msg.String() == "ctrl+x")
+	case "ctrl+v":
+		return m, readClipboard()
+	case "esc":
+		// Cancel modal editor
+		m.closeModalEditor()
+		m.logs = append(m.logs, "Modal editor cancelled")
+		return m, nil
+	case "f2":
+		// Save changes and close modal, once the text parses
+		m.checkEditorJSON()
+		if m.jsonProblem != nil {
+			m.logs = append(m.logs, "Cannot save - fix the JSON first: "+m.jsonProblem.String(m.editor.content))
+			return m, nil
+		}
+		// Members the metadata doesn't know are most likely typos
+		if unknown := m.unknownProperties(); len(unknown) > 0 && m.unknownWarned != m.editor.Text() {
+			m.unknownWarned = m.editor.Text()
+			m.completionHint = fmt.Sprintf("%s has no property %s - F2 again sends it anyway", m.editorEntitySet(), strings.Join(unknown, ", "))
+			m.logs = append(m.logs, "WARNING: "+m.completionHint)
+			return m, nil
+		}
+		return m.saveModalChanges()
+	case "tab":
+		before := m.editor.Text()
+		if completed, ok := m.completeAtCursor(); ok {
+			m = completed
+			m.editor.follow(m.editor.rows(m.editorWidth()), m.editorHeight())
+			if m.editor.Text() != before {
+				cmd := m.scheduleJSONCheck()
+				return m, cmd
+			}
+			return m, nil
+		}
+	case "f1":
+		return m.openPropertyDoc(), nil
+	case "ctrl+e":
+		if m.modalOperation == "bulkupdate" || m.modalOperation == "resubmit" {
+			return m, nil
+		}
+		return m.openEntityForm(), nil
+	case "ctrl+f":
+		formatted, err := m.editor.Format()
+		if err != nil {
+			m.logs = append(m.logs, fmt.Sprintf("Cannot format - not valid JSON at %v", err))
+			return m, nil
+		}
+		m.editor = formatted
+		m.editor.follow(m.editor.rows(m.editorWidth()), m.editorHeight())
+		m.checkEditorJSON()
+		m.logs = append(m.logs, "Formatted the JSON")
+		return m, nil
+	case "ctrl+t":
+		m.editorPlain = !m.editorPlain
+		if m.editorPlain {
+			m.logs = append(m.logs, "Auto-closing and auto-indent off")
+		} else {
+			m.logs = append(m.logs, "Auto-closing and auto-indent on")
+		}
+		return m, nil
+	}
+	m.editor.assist = !m.editorPlain
+	before := m.editor.Text()
+	m.editor = m.editor.Update(msg, m.editorHeight(), m.editorWidth())
+	if m.editor.Text() != before {
+		cmd := m.scheduleJSONCheck()
+		return m, cmd
+	}
+	return m, nil
+}
+
+// editorBufferFile is where the text of the modal editor is kept while it
+// is open, in the temp directory of the instance, so edits survive a crash
+func editorBufferFile() (string, error) {
+	dir, err := instanceTempDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "editor.json"), nil
+}
+
+// saveEditorBuffer writes the text of the modal editor to its buffer file,
+// once typing pauses
+func (m *model) saveEditorBuffer() {
+	path, err := editorBufferFile()
+	if err == nil {
+		err = writeFileAtomic(path, []byte(m.editor.Text()), 0o600)
+	}
+	if err != nil {
+		m.debugf("Cannot keep the editor text in a temp file: %v", err)
+		return
+	}
+	if !m.editorBuffered {
+		m.editorBuffered = true
+		m.debugf("The editor text is kept in %s while the editor is open", path)
+	}
+}
+
+// dropEditorBuffer removes the buffer file once the editor closes
+func (m *model) dropEditorBuffer() {
+	if !m.editorBuffered {
+		return
+	}
+	m.editorBuffered = false
+	if path, err := editorBufferFile(); err == nil {
+		os.Remove(path)
+	}
+}
+
+// inlineEditor is the edit mode of a Details column: the lines of the
+// entity, edited in place, with the line under the cursor
+type inlineEditor struct {
+	active bool
+	lines  []string
+	cursor int
+}
+
+// Update handles the keys of the edit mode: Up and Down move in the text,
+// Esc (Left) leaves it and drilling down is off. It returns false for
+// other keys.
+func (e inlineEditor) Update(msg tea.KeyMsg) (inlineEditor, bool) {
+	switch msg.String() {
+	case "up", "k":
+		if e.cursor > 0 {
+			e.cursor--
+		}
+	case "down", "j":
+		if e.cursor < len(e.lines)-1 {
+			e.cursor++
+		}
+	case "left", "h", "esc":
+		e.active = false
+	case "right", "l", "enter":
+	default:
+		return e, false
+	}
+	return e, true
+}
+
+// View draws the lines being edited, the one under the cursor marked
+func (e inlineEditor) View() []string {
+	var rows []string
+	for i, line := range e.lines {
+		style := lipgloss.NewStyle().Padding(0, 1)
+		if i == e.cursor {
+			// Highlight current edit line with different color
+			style = style.Background(theme.Edit).Foreground(theme.AccentText)
+			line = "► " + line // Add edit cursor indicator
+		} else {
+			// Make non-cursor lines stand out as editable
+			style = style.Background(theme.Panel).Foreground(theme.Text)
+		}
+		rows = append(rows, style.Render(line))
+	}
+	return rows
+}
+
+// toggleEditMode enters or leaves the inline edit mode of a Details column
+func (m model) toggleEditMode() model {
+	// Only allow edit mode when viewing details of an entity
+	if m.activeColumn >= 0 && m.activeColumn < len(m.columns) {
+		currentCol := m.columns[m.activeColumn]
+		if currentCol.isDetails && len(currentCol.entities) > 0 {
+			m.inlineEdit.active = !m.inlineEdit.active
+			if m.inlineEdit.active {
+				// Copy current JSON content for editing
+				m.inlineEdit.lines = make([]string, len(currentCol.items))
+				copy(m.inlineEdit.lines, currentCol.items)
+				m.inlineEdit.cursor = currentCol.cursor
+				m.logs = append(m.logs, "Entered EDIT mode - F5 to save, ESC to cancel")
+			} else {
+				m.logs = append(m.logs, "Exited EDIT mode")
+			}
+		} else {
+			m.logs = append(m.logs, "Edit mode only available for entity details")
+		}
+	}
+	return m
+}
+
+// saveChanges keeps the text of the inline edit mode as the entity shown,
+// without sending it
+func (m model) saveChanges() model {
+	if !m.inlineEdit.active || m.activeColumn >= len(m.columns) {
+		return m
+	}
+
+	currentCol := &m.columns[m.activeColumn]
+	if !currentCol.isDetails || len(currentCol.entities) == 0 {
+		m.logs = append(m.logs, "No entity data to save")
+		return m
+	}
+
+	// Try to parse the edited JSON
+	jsonContent := strings.Join(m.inlineEdit.lines, "\n")
+	var updatedEntity map[string]interface{}
+	if err := json.Unmarshal([]byte(jsonContent), &updatedEntity); err != nil {
+		m.logs = append(m.logs, fmt.Sprintf("Invalid JSON: %v", err))
+		return m
+	}
+
+	// Update the stored entity
+	currentCol.entities[0] = updatedEntity
+
+	// Update the display
+	jsonData, err := json.MarshalIndent(updatedEntity, "", "  ")
+	if err != nil {
+		m.logs = append(m.logs, fmt.Sprintf("Error formatting JSON: %v", err))
+		return m
+	}
+
+	currentCol.items = strings.Split(string(jsonData), "\n")
+	m.inlineEdit.active = false
+	m.logs = append(m.logs, "Changes saved locally (not persisted to server)")
+
+	return m
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestInlineEditorUpdate(t *testing.T) {
+	lines := []string{"{", `  "Name": "Chai"`, "}"}
+	tests := []struct {
+		name       string
+		cursor     int
+		key        tea.KeyMsg
+		wantCursor int
+		wantActive bool
+		handled    bool
+	}{
+		{"down", 0, tea.KeyMsg{Type: tea.KeyDown}, 1, true, true},
+		{"j", 1, keyRunes("j"), 2, true, true},
+		{"down at the end", 2, tea.KeyMsg{Type: tea.KeyDown}, 2, true, true},
+		{"up", 2, tea.KeyMsg{Type: tea.KeyUp}, 1, true, true},
+		{"up at the start", 0, keyRunes("k"), 0, true, true},
+		{"esc leaves", 1, tea.KeyMsg{Type: tea.KeyEsc}, 1, false, true},
+		{"left leaves", 1, tea.KeyMsg{Type: tea.KeyLeft}, 1, false, true},
+		{"enter does not drill down", 1, tea.KeyMsg{Type: tea.KeyEnter}, 1, true, true},
+		{"other keys", 1, keyRunes("x"), 1, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := inlineEditor{active: true, lines: lines, cursor: tt.cursor}
+			got, handled := e.Update(tt.key)
+			if got.cursor != tt.wantCursor || got.active != tt.wantActive || handled != tt.handled {
+				t.Errorf("Update = cursor %d, active %v, handled %v; want %d, %v, %v", got.cursor, got.active, handled, tt.wantCursor, tt.wantActive, tt.handled)
+			}
+		})
+	}
+}
+
+func TestInlineEditorView(t *testing.T) {
+	e := inlineEditor{active: true, lines: []string{"{", `  "Name": "Chai"`, "}"}, cursor: 1}
+	rows := e.View()
+	if len(rows) != 3 {
+		t.Fatalf("View has %d rows, want 3", len(rows))
+	}
+	for i, row := range rows {
+		if marked := strings.Contains(row, "► "); marked != (i == e.cursor) {
+			t.Errorf("row %d marked = %v: %q", i, marked, row)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// errorBodyLimit is how much of a non-OData error body a failed column shows
+const errorBodyLimit = 300
+
+// isLoadingPlaceholder reports whether a column only shows that it is still loading
+func (c column) isLoadingPlaceholder() bool {
+	return len(c.entities) == 0 && len(c.items) == 1 && strings.HasPrefix(c.items[0], "Loading")
+}
+
+// errorMessage turns an error like `HTTP 404: {"error": ...}` into the code
+// and message of the OData error in its body, for V2 and V4 alike
+func errorMessage(text string) string {
+	status, body := "", text
+	if strings.HasPrefix(text, "HTTP ") {
+		if colon := strings.Index(text, ": "); colon != -1 {
+			status, body = text[:colon], text[colon+2:]
+		}
+	}
+
+	var odataErr struct {
+		Error struct {
+			Code    string          `json:"code"`
+			Message json.RawMessage `json:"message"`
+		} `json:"error"`
+	}
+	if start := strings.Index(body, "{"); start != -1 && json.Unmarshal([]byte(body[start:]), &odataErr) == nil && odataErr.Error.Message != nil {
+		// V2 nests the text as {"lang": "en", "value": "..."}, V4 has a plain string
+		var message string
+		if json.Unmarshal(odataErr.Error.Message, &message) != nil {
+			var v2 struct {
+				Value string `json:"value"`
+			}
+			json.Unmarshal(odataErr.Error.Message, &v2)
+			message = v2.Value
+		}
+		if odataErr.Error.Code != "" {
+			message = fmt.Sprintf("[%s] %s", odataErr.Error.Code, message)
+		}
+		body = message
+	} else if len(body) > errorBodyLimit {
+		body = body[:errorBodyLimit] + "..."
+	}
+
+	if status == "" {
+		return body
+	}
+	return status + ": " + body
+}
+
+// applyError logs a failed request and shows it in the column it was for,
+// or the raw response of the server when there is one
+func (m *model) applyError(msg errorMsg) {
+	m.loading = false
+	// Loads cancelled with Esc were already reported
+	if isCancelled(msg.err) {
+		return
+	}
+	m.logs = append(m.logs, fmt.Sprintf("ERROR [%s]: %s", msg.context, msg.err))
+	if msg.raw != nil {
+		m.openRawResponseColumn(msg.raw, msg.context)
+		// A column left waiting behind the raw response stops loading
+		if col := m.columnByID(msg.column); col != nil && col.loading() {
+			col.fail(errorMessage(msg.err))
+		}
+	} else {
+		m.failColumns(msg)
+	}
+}
+
+// failColumns shows the error, and how to retry it, in the column whose load
+// failed. Errors for no column in particular, such as a cancelled load, go
+// to every column still loading.
+func (m *model) failColumns(msg errorMsg) {
+	message := errorMessage(msg.err)
+	if msg.column != 0 {
+		if col := m.columnByID(msg.column); col != nil {
+			col.fail(message)
+		}
+		return
+	}
+	for i := range m.columns {
+		if m.columns[i].state == stateLoading || m.columns[i].isLoadingPlaceholder() {
+			m.columns[i].fail(message)
+		}
+	}
+}
+
+// fail puts a column whose load failed into the error state
+func (c *column) fail(message string) {
+	loadingMore := false
+	for j, item := range c.items {
+		// A next page that failed can be asked for again with Enter
+		if item == "[...loading more items]" {
+			c.items[j] = c.moreItem()
+			loadingMore = true
+		}
+	}
+	if loadingMore || !c.isLoadingPlaceholder() {
+		// What the column showed before stays
+		c.state = stateLoaded
+		return
+	}
+	c.state = stateError
+	c.failure = message
+	c.items = []string{"ERROR"}
+	c.items = append(c.items, wrapLine(redactText(message), max(c.width-4, 20))...)
+	c.items = append(c.items, "", "r: Retry | ESC: Back")
+	c.cursor = 0
+	c.scrollOffset = 0
+}
+
+// retryColumn loads a column whose load failed once more
+func (m model) retryColumn() (tea.Model, tea.Cmd) {
+	if m.activeColumn >= len(m.columns) || m.columns[m.activeColumn].state != stateError {
+		return m, nil
+	}
+	failed := m
+	col := &m.columns[m.activeColumn]
+	col.failure = ""
+	col.items = []string{"Loading..."}
+	col.state = stateLoading
+
+	// A navigation property may lead to a single entity as well as a collection
+	if col.navURL != "" && col.entitySet == "" {
+		m.columns = m.columns[:m.activeColumn+1]
+		m.loading = true
+		m.logs = append(m.logs, fmt.Sprintf("Retrying %s", col.title))
+		return m, loadNavigation(m.odata, *col)
+	}
+	next, cmd := m.refreshColumn()
+	if cmd == nil {
+		// Nothing the column was loaded from can be asked for again
+		failed.logs = append(failed.logs, fmt.Sprintf("r: %s cannot be retried here, go back and open it again", col.title))
+		return failed, nil
+	}
+	return next, cmd
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// expandPicker is the state of the $expand picker overlay
+type expandPicker struct {
+	active     bool
+	column     int // Index of the entities column being expanded
+	properties []string
+	selected   map[string]bool
+	cursor     int
+}
+
+// openExpandDialog opens the $expand picker for the active entities column
+func (m model) openExpandDialog() model {
+	if m.activeColumn >= len(m.columns) || m.columns[m.activeColumn].entitySet == "" || m.columns[m.activeColumn].isDetails {
+		m.logs = append(m.logs, "Expand is only available on an entity list")
+		return m
+	}
+	col := m.columns[m.activeColumn]
+
+	ep := expandPicker{
+		active:     true,
+		column:     m.activeColumn,
+		properties: m.navigationProperties(col),
+		selected:   make(map[string]bool),
+	}
+	if len(ep.properties) == 0 {
+		m.logs = append(m.logs, fmt.Sprintf("No navigation properties known for %s", col.entitySet))
+		return m
+	}
+	for _, name := range strings.Split(col.query.Expand, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			ep.selected[name] = true
+		}
+	}
+	m.expandDialog = ep
+	return m
+}
+
+// navigationProperties lists the navigation properties of an entity list
+// column, from the metadata or from the deferred links of the loaded entities
+func (m model) navigationProperties(col column) []string {
+	if et := m.odata.metadata().EntityTypeOf(col.entitySet); et != nil && len(et.Navigation) > 0 {
+		return et.Navigation
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+	for _, entity := range col.entities {
+		for k, v := range entity {
+			name := k
+			if strings.HasSuffix(k, "@odata.navigationLink") {
+				name = strings.TrimSuffix(k, "@odata.navigationLink")
+			} else if !isNavigationValue(v) {
+				continue
+			}
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// updateExpandDialog handles key presses while the expand picker is open
+func (m model) updateExpandDialog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	ep := &m.expandDialog
+
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "f1":
+		return m.openHelp(helpPageCheatSheet), nil
+	case "esc":
+		ep.active = false
+	case "up", "k":
+		if ep.cursor > 0 {
+			ep.cursor--
+		}
+	case "down", "j":
+		if ep.cursor < len(ep.properties)-1 {
+			ep.cursor++
+		}
+	case " ":
+		name := ep.properties[ep.cursor]
+		ep.selected[name] = !ep.selected[name]
+	case "enter":
+		var names []string
+		for _, name := range ep.properties {
+			if ep.selected[name] {
+				names = append(names, name)
+			}
+		}
+		return m.applyExpand(strings.Join(names, ","))
+	}
+	return m, nil
+}
+
+// applyExpand sets the $expand of the expanded column and reloads it
+func (m model) applyExpand(expand string) (tea.Model, tea.Cmd) {
+	ep := m.expandDialog
+	m.expandDialog.active = false
+	if ep.column >= len(m.columns) {
+		return m, nil
+	}
+
+	col := &m.columns[ep.column]
+	col.query.Expand = expand
+	col.items = []string{"Loading..."}
+	col.state = stateLoading
+	col.entities = nil
+	col.cursor = 0
+	col.scrollOffset = 0
+
+	// Details of the old entities are stale now
+	m.columns = m.columns[:ep.column+1]
+	m.activeColumn = ep.column
+
+	if expand == "" {
+		m.logs = append(m.logs, fmt.Sprintf("Cleared expand on %s", col.entitySet))
+	} else {
+		m.logs = append(m.logs, fmt.Sprintf("Expanding %s: $expand=%s", col.entitySet, expand))
+	}
+	m.updateColumnSizes()
+	m.loading = true
+	return m, loadEntities(m.odata, *col)
+}
+
+// renderExpandDialog renders the expand picker box
+func (m model) renderExpandDialog() string {
+	ep := m.expandDialog
+	col := m.columns[ep.column]
+
+	var choices []string
+	for _, name := range ep.properties {
+		mark := "[ ]"
+		if ep.selected[name] {
+			mark = "[x]"
+		}
+		choices = append(choices, mark+" "+name)
+	}
+
+	var lines []string
+	lines = append(lines, "Expand navigation properties:")
+	lines = append(lines, renderChoiceList(choices, ep.cursor, m.height/2)...)
+	lines = append(lines, "", lipgloss.NewStyle().Foreground(theme.Muted).Render("Space: Toggle | Enter: Apply | ESC: Cancel"))
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Background(theme.Accent).
+		Foreground(theme.AccentText).
+		Padding(0, 1).
+		Render("Expand " + col.entitySet)
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(theme.Accent).
+		Padding(0, 1).
+		Width(min(50, m.width-4)).
+		Render(title + "\n\n" + strings.Join(lines, "\n"))
+}
+
+// isNavigationValue reports whether a property value is a V2 deferred
+// navigation link
+func isNavigationValue(v interface{}) bool {
+	nested, ok := v.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, deferred := nested["__deferred"]
+	return deferred
+}
+
+// expandedSummary describes an inline expanded navigation property for its
+// collapsed section header. Deferred links and plain values are not sections.
+func expandedSummary(v interface{}) (string, bool) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		if _, deferred := value["__deferred"]; deferred {
+			return "", false
+		}
+		// V2 expanded collections are wrapped in {"results": [...]}
+		if results, ok := value["results"].([]interface{}); ok {
+			return fmt.Sprintf("[%d entities]", len(results)), true
+		}
+		if _, ok := value["__metadata"]; ok {
+			return fmt.Sprintf("{%s}", formatEntityForDisplay(value)), true
+		}
+	case []interface{}:
+		if len(value) == 0 {
+			return "", false
+		}
+		if _, ok := value[0].(map[string]interface{}); ok {
+			return fmt.Sprintf("[%d entities]", len(value)), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// favoriteMarker prefixes starred entity sets and entities in the EntitySets column
+const favoriteMarker = "★ "
+
+// favoritesPath is the file the starred entity sets and entities of all
+// services are kept in
+func favoritesPath() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "odatanavigator", "favorites.json"), nil
+}
+
+// loadFavorites reads the starred entity sets and entities, such as
+// "Products(1)", keyed by service URL. A missing or unreadable file just
+// means no favorites.
+func loadFavorites() map[string][]string {
+	favorites := make(map[string][]string)
+	path, err := favoritesPath()
+	if err != nil {
+		return favorites
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &favorites)
+	}
+	return favorites
+}
+
+// updateFavorites applies a change to the favorites file, returning the
+// favorites of every service as they are now. Other instances may have
+// starred entity sets since this one read the file, so the change is made
+// to what the file holds, under its lock; when the file can't be used, the
+// change is made to current only.
+func updateFavorites(current map[string][]string, change func(map[string][]string)) (map[string][]string, error) {
+	path, err := favoritesPath()
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0o700)
+	}
+	var unlock func()
+	if err == nil {
+		unlock, err = lockState(path)
+	}
+	if err != nil {
+		change(current)
+		return current, err
+	}
+	defer unlock()
+
+	favorites := current
+	if _, statErr := os.Stat(path); statErr == nil {
+		favorites = loadFavorites()
+	}
+	change(favorites)
+	data, err := json.MarshalIndent(favorites, "", "  ")
+	if err == nil {
+		err = writeFileAtomic(path, data, 0o600)
+	}
+	return favorites, err
+}
+
+// itemEntitySet extracts the entity set from an EntitySets column item such
+// as "★ Products (77) [CRUD]"
+func itemEntitySet(item string) string {
+	name := strings.Split(strings.TrimPrefix(item, favoriteMarker), " [")[0]
+	return strings.Split(name, " (")[0]
+}
+
+// serviceFavorites returns the starred entity sets and entities of the
+// connected service
+func (m model) serviceFavorites() []string {
+	if m.serviceIndex < 0 || m.serviceIndex >= len(m.services) {
+		return nil
+	}
+	return m.favorites[m.services[m.serviceIndex].URL]
+}
+
+// favoriteEntity splits a starred entity such as "Products(1)" into its
+// entity set and key; ok is false for a starred entity set
+func favoriteEntity(name string) (entitySet, key string, ok bool) {
+	paren := strings.Index(name, "(")
+	if paren <= 0 || !strings.HasSuffix(name, ")") {
+		return "", "", false
+	}
+	return name[:paren], name[paren+1 : len(name)-1], true
+}
+
+// pinFavorites moves the starred entity sets to the top of the entity set
+// items in the order they were starred, marking them with a star, and adds
+// the starred entities among them; the other items keep their order.
+// $metadata stays first.
+func (m model) pinFavorites(items []string) []string {
+	starred := make(map[string]int)
+	var head, pinned, rest []string
+	pinned = make([]string, len(m.serviceFavorites()))
+	for i, name := range m.serviceFavorites() {
+		starred[name] = i
+		if _, _, ok := favoriteEntity(name); ok {
+			pinned[i] = favoriteMarker + name
+		}
+	}
+
+	for _, item := range items {
+		item = strings.TrimPrefix(item, favoriteMarker)
+		name := itemEntitySet(item)
+		i, ok := starred[name]
+		_, _, entity := favoriteEntity(name)
+		switch {
+		case entity:
+			// Starred entities were added above, unstarred ones are dropped
+		case name == "$metadata":
+			head = append(head, item)
+		case ok && !strings.HasPrefix(item, "[FUNC] "):
+			pinned[i] = favoriteMarker + item
+		default:
+			rest = append(rest, item)
+		}
+	}
+	// Starred entity sets the service no longer has leave gaps
+	for _, item := range pinned {
+		if item != "" {
+			head = append(head, item)
+		}
+	}
+	return append(head, rest...)
+}
+
+// toggleFavorite stars or unstars the entity set or entity under the
+// cursor of the EntitySets column and keeps the cursor on it as it moves
+func (m model) toggleFavorite() model {
+	if m.activeColumn != 1 || m.activeColumn >= len(m.columns) || m.serviceIndex < 0 || m.serviceIndex >= len(m.services) {
+		return m
+	}
+	col := m.columns[m.activeColumn]
+	if col.cursor >= len(col.items) {
+		return m
+	}
+	name := itemEntitySet(col.items[col.cursor])
+	if name == "$metadata" || strings.HasPrefix(name, "[FUNC] ") || strings.HasPrefix(name, "(") {
+		return m
+	}
+	m.toggleFavoriteName(name)
+	return m
+}
+
+// toggleEntityFavorite stars or unstars the entity under the cursor of an
+// entity list, or the entity shown in Details, so it can be opened from
+// the top of the EntitySets column
+func (m model) toggleEntityFavorite() model {
+	if m.activeColumn == 1 {
+		return m.toggleFavorite()
+	}
+	if m.activeColumn >= len(m.columns) || m.serviceIndex < 0 || m.serviceIndex >= len(m.services) {
+		return m
+	}
+	col := m.columns[m.activeColumn]
+	var entity map[string]interface{}
+	entitySet := col.entitySet
+	switch {
+	case col.isResult || col.raw != nil || col.isPreview:
+	case col.isDetails && len(col.entities) > 0:
+		entity = col.entities[0]
+		entitySet = m.detailsEntitySet(m.activeColumn)
+	case col.isEntityList() && col.cursor < len(col.entities):
+		entity = col.entities[col.cursor]
+	}
+	if entity == nil {
+		m.logs = append(m.logs, "b: Select an entity in a list, or open one in Details, to star it")
+		return m
+	}
+	if entitySet == "" {
+		entitySet = entitySetFromEntity(entity)
+	}
+	key := extractEntityKey(m.metadata(), entitySet, entity)
+	if entitySet == "" || key == "" {
+		m.logs = append(m.logs, "b: The key of this entity is unknown, so it can't be starred")
+		return m
+	}
+	m.toggleFavoriteName(entitySet + "(" + key + ")")
+	return m
+}
+
+// toggleFavoriteName stars or unstars an entity set or entity of the
+// connected service and pins the favorites of the EntitySets column anew,
+// keeping its cursor on the item it was on
+func (m *model) toggleFavoriteName(name string) {
+	url := m.services[m.serviceIndex].URL
+	if m.favorites == nil {
+		m.favorites = make(map[string][]string)
+	}
+	starred := false
+	favorites, err := updateFavorites(m.favorites, func(all map[string][]string) {
+		var kept []string
+		starred = false
+		for _, favorite := range all[url] {
+			if favorite == name {
+				starred = true
+				continue
+			}
+			kept = append(kept, favorite)
+		}
+		if !starred {
+			kept = append(kept, name)
+		}
+		all[url] = kept
+	})
+	m.favorites = favorites
+
+	if len(m.columns) > 1 && m.columns[1].title == "EntitySets" && m.columns[1].state == stateLoaded {
+		col := &m.columns[1]
+		var current string
+		if col.cursor < len(col.items) {
+			current = itemEntitySet(col.items[col.cursor])
+		}
+		col.items = m.pinFavorites(col.items)
+		for i, item := range col.items {
+			if itemEntitySet(item) == current {
+				col.cursor = i
+			}
+		}
+		// An unstarred entity leaves the column
+		if col.cursor >= len(col.items) {
+			col.cursor = max(len(col.items)-1, 0)
+		}
+	}
+	if starred {
+		m.logs = append(m.logs, fmt.Sprintf("Unstarred %s", name))
+	} else {
+		m.logs = append(m.logs, fmt.Sprintf("Starred %s", name))
+	}
+	if err != nil {
+		m.logs = append(m.logs, fmt.Sprintf("ERROR [favorites]: %v", err))
+	}
+}
+
+// openFavoriteEntity reads a starred entity of the EntitySets column into
+// its entity set and a Details column
+func (m model) openFavoriteEntity(entitySet, key string) (tea.Model, tea.Cmd) {
+	cursor := m.columns[1].cursor
+	m.pendingLink = &DeepLink{Service: m.services[m.serviceIndex].Name, EntitySet: entitySet, Key: key}
+	next, cmd := m.followLink()
+	m = next.(model)
+	// The cursor stays on the favorite rather than on its entity set
+	m.columns[1].cursor = cursor
+	return m, cmd
+}
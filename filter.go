@@ -0,0 +1,268 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	filterClearEntry  = "(clear filter)"
+	filterCustomEntry = "(custom expression)"
+)
+
+// Filter operators offered by the builder, in display order
+var filterOperators = []string{"eq", "ne", "gt", "ge", "lt", "le", "contains", "startswith", "endswith"}
+
+// filterBuilder is the state of the F7 $filter builder overlay
+type filterBuilder struct {
+	active     bool
+	step       int               // 0: pick property, 1: pick operator, 2: enter value
+	column     int               // Index of the entities column being filtered
+	properties []string          // Property choices, including the special entries
+	types      map[string]string // Edm type per property, when known
+	cursor     int
+	property   string
+	operator   string
+	value      string
+}
+
+// openFilterDialog opens the $filter builder for the active entities column
+func (m model) openFilterDialog() model {
+	if m.activeColumn >= len(m.columns) || m.columns[m.activeColumn].entitySet == "" || m.columns[m.activeColumn].isDetails {
+		m.logs = append(m.logs, "F7: Filter is only available on an entity list")
+		return m
+	}
+	col := m.columns[m.activeColumn]
+
+	fb := filterBuilder{
+		active: true,
+		column: m.activeColumn,
+	}
+	fb.properties, fb.types = m.columnProperties(col)
+
+	specials := []string{filterCustomEntry}
+	if col.query.Filter != "" {
+		specials = append([]string{filterClearEntry}, specials...)
+	}
+	fb.properties = append(specials, fb.properties...)
+
+	m.filterDialog = fb
+	return m
+}
+
+// columnProperties lists the properties of the entities in an entity list
+// column with their Edm types. They come from the metadata, or from the
+// loaded entities when the service has none.
+func (m model) columnProperties(col column) ([]string, map[string]string) {
+	var names []string
+	types := make(map[string]string)
+
+	if et := m.odata.metadata().EntityTypeOf(col.entitySet); et != nil {
+		for _, p := range et.Properties {
+			names = append(names, p.Name)
+			types[p.Name] = p.Type
+		}
+		return names, types
+	}
+
+	seen := make(map[string]bool)
+	for _, entity := range col.entities {
+		for k, v := range entity {
+			if strings.HasPrefix(k, "__") || seen[k] {
+				continue
+			}
+			if _, nested := v.(map[string]interface{}); nested {
+				continue
+			}
+			seen[k] = true
+			names = append(names, k)
+			if edmType := inferEdmType(v); edmType != "" {
+				types[k] = edmType
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, types
+}
+
+// updateFilterDialog handles key presses while the filter builder is open
+func (m model) updateFilterDialog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	fb := &m.filterDialog
+
+	choices := fb.properties
+	if fb.step == 1 {
+		choices = filterOperators
+	}
+
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "f1":
+		return m.openHelp(helpPageCheatSheet), nil
+	case "esc":
+		if fb.step == 0 {
+			fb.active = false
+		} else if fb.step == 2 && fb.property == filterCustomEntry {
+			fb.step = 0
+		} else {
+			fb.step--
+		}
+		fb.cursor = 0
+	case "up":
+		if fb.step < 2 && fb.cursor > 0 {
+			fb.cursor--
+		}
+	case "down":
+		if fb.step < 2 && fb.cursor < len(choices)-1 {
+			fb.cursor++
+		}
+	case "enter":
+		switch fb.step {
+		case 0:
+			if len(choices) == 0 {
+				return m, nil
+			}
+			fb.property = choices[fb.cursor]
+			fb.cursor = 0
+			switch fb.property {
+			case filterClearEntry:
+				return m.applyFilter("")
+			case filterCustomEntry:
+				fb.value = m.columns[fb.column].query.Filter
+				fb.step = 2
+			default:
+				fb.step = 1
+			}
+		case 1:
+			fb.operator = choices[fb.cursor]
+			fb.value = ""
+			fb.step = 2
+		case 2:
+			if fb.property == filterCustomEntry {
+				return m.applyFilter(strings.TrimSpace(fb.value))
+			}
+			// Expand variables before quoting so captured values are escaped too
+			value, _ := expandVariables(fb.value, m.variables)
+			return m.applyFilter(buildFilterExpression(fb.property, fb.operator, fb.types[fb.property], value, m.odataVersion()))
+		}
+	case "backspace":
+		if fb.step == 2 && len(fb.value) > 0 {
+			runes := []rune(fb.value)
+			fb.value = string(runes[:len(runes)-1])
+		}
+	default:
+		if fb.step == 2 {
+			fb.value += typedText(msg)
+		}
+	}
+	return m, nil
+}
+
+// applyFilter sets the $filter of the filtered column and reloads it
+func (m model) applyFilter(filter string) (tea.Model, tea.Cmd) {
+	fb := m.filterDialog
+	m.filterDialog.active = false
+	if fb.column >= len(m.columns) {
+		return m, nil
+	}
+
+	filter, missing := expandVariables(filter, m.variables)
+	if len(missing) > 0 {
+		m.logs = append(m.logs, fmt.Sprintf("Unknown variables: {{%s}}", strings.Join(missing, "}}, {{")))
+		return m, nil
+	}
+
+	col := &m.columns[fb.column]
+	col.query.Filter = filter
+	col.items = []string{"Loading..."}
+	col.state = stateLoading
+	col.entities = nil
+	col.cursor = 0
+	col.scrollOffset = 0
+
+	if filter == "" {
+		m.logs = append(m.logs, fmt.Sprintf("Cleared filter on %s", col.entitySet))
+	} else {
+		m.logs = append(m.logs, fmt.Sprintf("Filtering %s: $filter=%s", col.entitySet, filter))
+	}
+	m.loading = true
+	return m, loadEntities(m.odata, *col)
+}
+
+// odataVersion returns the protocol version of the connected service
+func (m model) odataVersion() string {
+	if m.odata != nil {
+		return m.odata.Version()
+	}
+	return "2.0"
+}
+
+// buildFilterExpression composes a single $filter condition, using the
+// V2 substringof/startswith-eq-true forms or the V4 functions by version
+func buildFilterExpression(property, operator, edmType, value, version string) string {
+	v4 := strings.HasPrefix(version, "4")
+
+	switch operator {
+	case "contains", "startswith", "endswith":
+		// String functions always take a string literal
+		literal := formatODataLiteral("Edm.String", value, version)
+		if operator == "contains" {
+			if v4 {
+				return fmt.Sprintf("contains(%s,%s)", property, literal)
+			}
+			return fmt.Sprintf("substringof(%s,%s)", literal, property)
+		}
+		if v4 {
+			return fmt.Sprintf("%s(%s,%s)", operator, property, literal)
+		}
+		return fmt.Sprintf("%s(%s,%s) eq true", operator, property, literal)
+	}
+
+	return fmt.Sprintf("%s %s %s", property, operator, formatODataLiteral(edmType, value, version))
+}
+
+// renderFilterDialog renders the filter builder box
+func (m model) renderFilterDialog() string {
+	fb := m.filterDialog
+	col := m.columns[fb.column]
+
+	var lines []string
+	switch fb.step {
+	case 0:
+		lines = append(lines, "Select property:")
+		lines = append(lines, renderChoiceList(fb.properties, fb.cursor, m.height/2)...)
+	case 1:
+		lines = append(lines, fmt.Sprintf("Property: %s %s", fb.property, fb.types[fb.property]), "Select operator:")
+		lines = append(lines, renderChoiceList(filterOperators, fb.cursor, len(filterOperators))...)
+	case 2:
+		if fb.property == filterCustomEntry {
+			lines = append(lines, "$filter expression:")
+		} else {
+			lines = append(lines, fmt.Sprintf("%s %s", fb.property, fb.operator), "Value:")
+		}
+		lines = append(lines, lipgloss.NewStyle().Background(theme.Panel).Render("> "+fb.value+"█"))
+		if fb.property != filterCustomEntry && fb.value != "" {
+			preview := buildFilterExpression(fb.property, fb.operator, fb.types[fb.property], fb.value, m.odataVersion())
+			lines = append(lines, "", lipgloss.NewStyle().Foreground(theme.Muted).Render("$filter="+preview))
+		}
+	}
+	lines = append(lines, "", lipgloss.NewStyle().Foreground(theme.Muted).Render("Enter: Select | ESC: Back"))
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Background(theme.Accent).
+		Foreground(theme.AccentText).
+		Padding(0, 1).
+		Render("Filter " + col.entitySet)
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(theme.Accent).
+		Padding(0, 1).
+		Width(min(60, m.width-4)).
+		Render(title + "\n\n" + strings.Join(lines, "\n"))
+}
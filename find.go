@@ -0,0 +1,198 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// fuzzyMatch reports whether the runes of query appear in item in order,
+// ignoring case, and returns the positions of the runes of item matched
+func fuzzyMatch(item, query string) ([]int, bool) {
+	q := []rune(strings.ToLower(query))
+	if len(q) == 0 {
+		return nil, true
+	}
+	var positions []int
+	for i, r := range []rune(item) {
+		if unicode.ToLower(r) == q[len(positions)] {
+			positions = append(positions, i)
+			if len(positions) == len(q) {
+				return positions, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// findMatches returns the indexes of the items matching the find query of
+// the column, nil when it has none
+func (c column) findMatches() []int {
+	if c.find == "" {
+		return nil
+	}
+	matches := []int{}
+	for i, item := range c.items {
+		if _, ok := fuzzyMatch(item, c.find); ok {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
+// visibleRows returns the indexes of the items a column shows: the window
+// at its scroll offset, or while finding the window of matches around the
+// cursor
+func (c column) visibleRows() []int {
+	visibleHeight := len(c.items)
+	if c.height > 2 {
+		visibleHeight = c.height - 2 // Account for borders
+	}
+	rows := c.findMatches()
+	if rows == nil {
+		end := min(c.scrollOffset+visibleHeight, len(c.items))
+		for i := c.scrollOffset; i < end; i++ {
+			rows = append(rows, i)
+		}
+		return rows
+	}
+	start := 0
+	for pos, i := range rows {
+		if i == c.cursor && pos >= visibleHeight {
+			start = pos - visibleHeight + 1
+		}
+	}
+	return rows[start:min(start+visibleHeight, len(rows))]
+}
+
+// highlightMatch underlines the runes of item matched by the find query
+func highlightMatch(item, query string) string {
+	positions, ok := fuzzyMatch(item, query)
+	if !ok || len(positions) == 0 {
+		return item
+	}
+	style := lipgloss.NewStyle().Underline(true).Foreground(theme.Warning)
+	var b strings.Builder
+	runes := []rune(item)
+	next := 0
+	for i, r := range runes {
+		if next < len(positions) && positions[next] == i {
+			b.WriteString(style.Render(string(r)))
+			next++
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// startFind starts typing a find query that narrows the items of the
+// active column
+func (m model) startFind() model {
+	if m.activeColumn >= len(m.columns) || len(m.columns[m.activeColumn].items) == 0 {
+		return m
+	}
+	m.finding = true
+	return m
+}
+
+// updateFind handles key presses while a find query is typed: the column
+// narrows with every key, Enter keeps the query and Esc clears it
+func (m model) updateFind(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	col := &m.columns[m.activeColumn]
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.finding = false
+		return m.clearFind(), nil
+	case "enter":
+		m.finding = false
+		return m, nil
+	case "up", "down", "pgup", "pgdown", "home", "end":
+		m.moveInMatches(msg.String())
+		return m, m.findPreview()
+	case "backspace":
+		if len(col.find) > 0 {
+			runes := []rune(col.find)
+			col.find = string(runes[:len(runes)-1])
+		}
+	default:
+		col.find += typedText(msg)
+	}
+	m.snapToMatch()
+	return m, m.findPreview()
+}
+
+// clearFind drops the find query of the active column, keeping the cursor
+// on the item it was on
+func (m model) clearFind() model {
+	col := &m.columns[m.activeColumn]
+	col.find = ""
+	visibleHeight := col.height - 2
+	if col.cursor < col.scrollOffset || col.cursor >= col.scrollOffset+visibleHeight {
+		col.scrollOffset = max(0, col.cursor-visibleHeight/2)
+	}
+	return m
+}
+
+// snapToMatch moves the cursor to the first match when the item under it
+// no longer matches
+func (m *model) snapToMatch() {
+	col := &m.columns[m.activeColumn]
+	matches := col.findMatches()
+	if len(matches) == 0 {
+		return
+	}
+	for _, i := range matches {
+		if i == col.cursor {
+			return
+		}
+	}
+	col.cursor = matches[0]
+}
+
+// moveInMatches moves the cursor of the active column among the items
+// matching its find query
+func (m *model) moveInMatches(key string) {
+	col := &m.columns[m.activeColumn]
+	matches := col.findMatches()
+	if len(matches) == 0 {
+		return
+	}
+	pos := 0
+	for p, i := range matches {
+		if i <= col.cursor {
+			pos = p
+		}
+	}
+	page := max(col.height-2, 1)
+	switch key {
+	case "up", "k":
+		if matches[pos] == col.cursor {
+			pos--
+		}
+	case "down", "j":
+		pos++
+	case "pgup":
+		pos -= page
+	case "pgdown":
+		pos += page
+	case "home":
+		pos = 0
+	case "end":
+		pos = len(matches) - 1
+	}
+	pos = max(0, min(pos, len(matches)-1))
+	col.cursor = matches[pos]
+}
+
+// findPreview updates the preview for the item the cursor moved to
+func (m model) findPreview() tea.Cmd {
+	if m.columns[m.activeColumn].isDetails {
+		return nil
+	}
+	return m.updatePreview()
+}
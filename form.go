@@ -0,0 +1,438 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// entityForm edits the entity of the modal editor field by field: one input
+// per property of its entity type, with the type, length, nullability and
+// label the metadata declares. It reads the JSON of the editor when opened
+// and writes it back on Ctrl+E, or on F2 once every field checks out.
+// Properties the service sets itself and __metadata are left out.
+type entityForm struct {
+	active    bool
+	entitySet string
+	fields    []formField
+	cursor    int
+	scroll    int
+	rest      map[string]interface{} // Members of the JSON without a field, sent as they are
+	hidden    int                    // Read-only properties left out
+}
+
+// formField is the input of one property
+type formField struct {
+	prop   Property
+	key    bool
+	locked bool   // Key of the entity updated, which can't change
+	value  string // As typed
+	err    string // Why the value can't be sent, set once the field was edited
+}
+
+// openEntityForm shows the JSON of the modal editor as a form, when the
+// metadata declares the entity type written to
+func (m model) openEntityForm() model {
+	entitySet := m.editorEntitySet()
+	et := m.metadata().EntityTypeOf(entitySet)
+	if et == nil {
+		m.logs = append(m.logs, fmt.Sprintf("No metadata for %s - edit it as JSON", entitySet))
+		return m
+	}
+	var entity map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(m.editor.Text()))
+	decoder.UseNumber()
+	if err := decoder.Decode(&entity); err != nil {
+		m.logs = append(m.logs, fmt.Sprintf("Form: the JSON is not a single entity (%v) - fix it or save it as it is", err))
+		return m
+	}
+	if entity == nil {
+		entity = make(map[string]interface{})
+	}
+
+	keys := make(map[string]bool)
+	for _, k := range et.Keys {
+		keys[k] = true
+	}
+	update := m.modalOperation == "update"
+	form := entityForm{active: true, entitySet: entitySet, rest: make(map[string]interface{})}
+	for _, p := range et.Properties {
+		value, present := entity[p.Name]
+		switch {
+		case update && keys[p.Name]:
+			// The key is sent as it is, in the form only to be seen
+			form.fields = append(form.fields, formField{prop: p, key: true, locked: true, value: formValue(value)})
+			if present {
+				form.rest[p.Name] = value
+			}
+		case p.Computed || (update && p.Immutable):
+			form.hidden++
+		case !strings.HasPrefix(p.Type, "Edm."):
+			// Complex values stay JSON
+			if present {
+				form.rest[p.Name] = value
+			}
+		default:
+			form.fields = append(form.fields, formField{prop: p, key: keys[p.Name], value: formValue(value)})
+		}
+		delete(entity, p.Name)
+	}
+	for name, value := range entity {
+		if strings.HasPrefix(name, "__") {
+			continue
+		}
+		if nested, ok := value.(map[string]interface{}); ok && nested["__deferred"] != nil {
+			continue
+		}
+		form.rest[name] = value
+	}
+	// A copy starts on the key that needs a new value
+	if m.modalOperation == "copy" {
+		for i, f := range form.fields {
+			if f.key {
+				form.cursor = i
+				break
+			}
+		}
+	}
+
+	m.form = form
+	m.form.follow(m.formHeight())
+	m.logs = append(m.logs, fmt.Sprintf("Form for %s - F2 to save, Ctrl+E to edit the JSON instead, ESC to cancel", entitySet))
+	return m
+}
+
+// formValue is the text a form field shows for a JSON value
+func formValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// fieldValue converts what a field holds to the JSON of its property. It
+// returns false for an empty field left out of the entity: nullable fields
+// of a new entity, and strings, which the service fills in. Empty nullable
+// fields of an update are sent as null.
+func (m model) fieldValue(f formField) (interface{}, bool, error) {
+	text, missing := expandVariables(f.value, m.variables)
+	if len(missing) > 0 {
+		return nil, false, fmt.Errorf("unknown variables: {{%s}}", strings.Join(missing, "}}, {{"))
+	}
+	if strings.TrimSpace(text) == "" && (text == "" || f.prop.Type != "Edm.String") {
+		switch {
+		case f.prop.Type == "Edm.String" && m.modalOperation == "update" && !f.prop.Nullable:
+			return "", true, nil
+		case !f.prop.Nullable && f.prop.Type != "Edm.String":
+			return nil, false, errors.New("required")
+		case m.modalOperation == "update":
+			return nil, true, nil
+		}
+		return nil, false, nil
+	}
+	value, err := importValue(text, f.prop, m.odata.Version())
+	return value, err == nil, err
+}
+
+// formJSON writes the entity of the form as JSON, fields in the order of
+// the metadata and the other members after them. Checked, it fails on the
+// first field that can't be sent and marks every such field; otherwise
+// those fields are written as typed, to be fixed in the JSON.
+func (m *model) formJSON(checked bool) (string, error) {
+	values := make(map[string]interface{}, len(m.form.fields)+len(m.form.rest))
+	var names []string
+	var first error
+	for i := range m.form.fields {
+		f := &m.form.fields[i]
+		if f.locked {
+			continue
+		}
+		value, send, err := m.fieldValue(*f)
+		f.err = ""
+		if err != nil {
+			if checked {
+				f.err = err.Error()
+				if first == nil {
+					first = fmt.Errorf("%s: %v", f.prop.Name, err)
+					m.form.cursor = i
+				}
+				continue
+			}
+			value, send = f.value, f.value != ""
+		}
+		if send {
+			names = append(names, f.prop.Name)
+			values[f.prop.Name] = value
+		}
+	}
+	if first != nil {
+		m.form.follow(m.formHeight())
+		return "", first
+	}
+
+	// The keys of an update come first, the members without a field last
+	var order []string
+	for _, f := range m.form.fields {
+		if _, ok := m.form.rest[f.prop.Name]; ok && f.locked {
+			order = append(order, f.prop.Name)
+		}
+	}
+	order = append(order, names...)
+	var others []string
+	for name, value := range m.form.rest {
+		values[name] = value
+		if !m.form.hasField(name) {
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+	order = append(order, others...)
+
+	members := make([]string, 0, len(order))
+	for _, name := range order {
+		data, err := json.MarshalIndent(values[name], "  ", "  ")
+		if err != nil {
+			return "", fmt.Errorf("%s: %v", name, err)
+		}
+		members = append(members, fmt.Sprintf("  %q: %s", name, data))
+	}
+	if len(members) == 0 {
+		return "{\n  \n}", nil
+	}
+	return "{\n" + strings.Join(members, ",\n") + "\n}", nil
+}
+
+// formHeight is the number of fields the form shows at once
+func (m model) formHeight() int {
+	return max(m.height-16, 3)
+}
+
+// follow scrolls so the field of the cursor is among the height shown
+func (f *entityForm) follow(height int) {
+	if f.cursor < f.scroll {
+		f.scroll = f.cursor
+	}
+	if f.cursor >= f.scroll+height {
+		f.scroll = f.cursor - height + 1
+	}
+}
+
+// updateEntityForm handles key presses while the form is open: up and down
+// pick a field, typing edits it, F2 saves and Ctrl+E goes back to the JSON
+func (m model) updateEntityForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	form := &m.form
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.closeModalEditor()
+		m.logs = append(m.logs, "Modal editor cancelled")
+		return m, nil
+	case "f1":
+		return m.openPropertyDoc(), nil
+	case "f2":
+		text, err := m.formJSON(true)
+		if err != nil {
+			m.logs = append(m.logs, fmt.Sprintf("Cannot save - %v", err))
+			return m, nil
+		}
+		m.editor = newTextEditor(strings.Split(text, "\n"), 0, 0)
+		return m.saveModalChanges()
+	case "ctrl+e":
+		text, _ := m.formJSON(false)
+		m.editor = newTextEditor(strings.Split(text, "\n"), 0, 0)
+		m.form = entityForm{}
+		m.checkEditorJSON()
+		m.logs = append(m.logs, "Editing the JSON - Ctrl+E returns to the form")
+		return m, nil
+	}
+	if len(form.fields) == 0 {
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "up", "shift+tab":
+		form.cursor = (form.cursor + len(form.fields) - 1) % len(form.fields)
+	case "down", "tab", "enter":
+		form.cursor = (form.cursor + 1) % len(form.fields)
+	case "pgup":
+		form.cursor = max(form.cursor-m.formHeight(), 0)
+	case "pgdown":
+		form.cursor = min(form.cursor+m.formHeight(), len(form.fields)-1)
+	default:
+		f := &form.fields[form.cursor]
+		typed := typedText(msg)
+		key := msg.String()
+		if key != "ctrl+u" && key != "backspace" && typed == "" {
+			return m, nil
+		}
+		if f.locked {
+			m.logs = append(m.logs, fmt.Sprintf("%s is a key of the entity and can't change", f.prop.Name))
+			return m, nil
+		}
+		switch key {
+		case "ctrl+u":
+			f.value = ""
+		case "backspace":
+			if runes := []rune(f.value); len(runes) > 0 {
+				f.value = string(runes[:len(runes)-1])
+			}
+		default:
+			f.value += typed
+		}
+		f.err = ""
+		if _, _, err := m.fieldValue(*f); err != nil {
+			f.err = err.Error()
+		}
+	}
+	form.follow(m.formHeight())
+	return m, nil
+}
+
+// fieldType describes the type of a property in a form row, e.g. String(40)
+func fieldType(f formField) string {
+	text := strings.TrimPrefix(f.prop.Type, "Edm.")
+	switch {
+	case f.prop.MaxLength != "" && f.prop.MaxLength != "max":
+		text += "(" + f.prop.MaxLength + ")"
+	case f.prop.Precision != "" && f.prop.Scale != "":
+		text += "(" + f.prop.Precision + "," + f.prop.Scale + ")"
+	}
+	if f.key {
+		text += " key"
+	}
+	if !f.prop.Nullable && f.prop.Type != "Edm.String" {
+		text += " *"
+	}
+	return text
+}
+
+// renderEntityForm draws the form over the modal editor
+func (m model) renderEntityForm() string {
+	form := m.form
+	hint := lipgloss.NewStyle().Foreground(theme.Muted)
+	selected := lipgloss.NewStyle().Background(theme.Selection).Foreground(theme.AccentText)
+	failed := lipgloss.NewStyle().Foreground(theme.Error)
+	width := min(110, m.width-4)
+
+	operation := strings.ToUpper(m.modalOperation[:1]) + m.modalOperation[1:]
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Background(theme.Accent).
+		Foreground(theme.AccentText).
+		Padding(0, 1).
+		Render(operation + " " + form.entitySet)
+
+	nameWidth, typeWidth := 4, 4
+	for _, f := range form.fields {
+		nameWidth = max(nameWidth, len(f.prop.Name))
+		typeWidth = max(typeWidth, len(fieldType(f)))
+	}
+	nameWidth = min(nameWidth, 28)
+	typeWidth = min(typeWidth, 24)
+	valueWidth := max(width-nameWidth-typeWidth-10, 10)
+
+	var lines []string
+	if len(form.fields) == 0 {
+		lines = append(lines, hint.Render("(no property of the entity type can be set)"))
+	}
+	for i := form.scroll; i < len(form.fields) && i < form.scroll+m.formHeight(); i++ {
+		f := form.fields[i]
+		name := fmt.Sprintf("%-*s", nameWidth, fitItem(f.prop.Name, nameWidth))
+		value := f.value
+		if i == form.cursor && !f.locked {
+			value += "█"
+		}
+		// The end of a long value, where typing goes, stays in sight
+		if w := lipgloss.Width(value); w > valueWidth {
+			runes := []rune(value)
+			for lipgloss.Width(string(runes)) > valueWidth-1 {
+				runes = runes[1:]
+			}
+			value = "…" + string(runes)
+		}
+		value += strings.Repeat(" ", max(valueWidth-lipgloss.Width(value), 0))
+		if f.locked {
+			value = hint.Render(value)
+		}
+		kind := hint.Render(fitItem(fieldType(f), typeWidth))
+		if f.err != "" {
+			kind = failed.Render(fitItem("✗ "+fieldType(f), typeWidth))
+		}
+		if i == form.cursor {
+			lines = append(lines, selected.Render("► "+name)+" "+value+" "+kind)
+		} else {
+			lines = append(lines, "  "+name+" "+value+" "+kind)
+		}
+	}
+
+	// What the metadata says about the field under the cursor
+	var about []string
+	if form.cursor < len(form.fields) {
+		p := form.fields[form.cursor].prop
+		for _, text := range []string{p.Label, p.QuickInfo, p.Description} {
+			if text != "" && !strings.Contains(strings.Join(about, " - "), text) {
+				about = append(about, text)
+			}
+		}
+		if p.Nullable {
+			about = append(about, "may be empty")
+		}
+	}
+	notes := hint.Render(fitItem(strings.Join(about, " - "), width-4))
+	if form.cursor < len(form.fields) && form.fields[form.cursor].err != "" {
+		notes = failed.Render(fitItem("✗ "+form.fields[form.cursor].err, width-4)) + "\n" + notes
+	}
+	if form.hidden > 0 {
+		notes += "\n" + hint.Render(fmt.Sprintf("%d read-only properties left out", form.hidden))
+	}
+	if len(form.rest) > 0 {
+		var names []string
+		for name := range form.rest {
+			if !form.hasField(name) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		if len(names) > 0 {
+			notes += "\n" + hint.Render(fitItem("Sent as they are: "+strings.Join(names, ", "), width-4))
+		}
+	}
+
+	content := title + "\n\n" +
+		strings.Join(lines, "\n") + "\n\n" +
+		notes + "\n\n" +
+		hint.Render("* required | empty fields are left out, or null on update | {{Name}} inserts a variable") + "\n" +
+		hint.Render("F2: Save | Ctrl+E: Edit JSON | Up/Down: Field | Ctrl+U: Clear | F1: Property Info | ESC: Cancel")
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(theme.Accent).
+		Padding(0, 1).
+		Width(width).
+		Render(content)
+}
+
+// hasField reports whether the form has a field for the property
+func (f entityForm) hasField(name string) bool {
+	for _, field := range f.fields {
+		if field.prop.Name == name {
+			return true
+		}
+	}
+	return false
+}
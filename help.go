@@ -0,0 +1,134 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// helpKeys is the Keys page, made from the key map
+var helpKeys = keysPage()
+
+var (
+	//go:embed help/quickstart.md
+	helpQuickStart string
+	//go:embed help/cheatsheet.md
+	helpCheatSheet string
+)
+
+// helpPages are the pages of the help viewer, in tab order
+var helpPages = []struct {
+	title string
+	text  *string
+}{
+	{"Keys", &helpKeys},
+	{"Quick Start", &helpQuickStart},
+	{"Query Cheat Sheet", &helpCheatSheet},
+}
+
+// Pages the help viewer opens at
+const (
+	helpPageKeys = iota
+	helpPageQuickStart
+	helpPageCheatSheet
+)
+
+// helpViewer is the state of the F1 help overlay
+type helpViewer struct {
+	active bool
+	page   int
+	scroll int
+}
+
+// openHelp shows a page of the help viewer. It opens over other dialogs,
+// so the query cheat sheet can be read while a query is built.
+func (m model) openHelp(page int) model {
+	m.help = helpViewer{active: true, page: page}
+	return m
+}
+
+// helpLines returns the lines of the page shown
+func (h helpViewer) helpLines() []string {
+	return strings.Split(strings.TrimRight(*helpPages[h.page].text, "\n"), "\n")
+}
+
+// helpHeight is the number of page lines the viewer shows at once
+func (m model) helpHeight() int {
+	return max(m.height-10, 5)
+}
+
+// updateHelp handles key presses while the help viewer is open: Tab and
+// Left/Right switch pages, the movement keys scroll
+func (m model) updateHelp(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	h := &m.help
+	last := max(len(h.helpLines())-m.helpHeight(), 0)
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "f1", "q":
+		h.active = false
+	case "tab", "right", "l":
+		h.page = (h.page + 1) % len(helpPages)
+		h.scroll = 0
+	case "shift+tab", "left", "h":
+		h.page = (h.page + len(helpPages) - 1) % len(helpPages)
+		h.scroll = 0
+	case "up", "k":
+		h.scroll = max(h.scroll-1, 0)
+	case "down", "j":
+		h.scroll = min(h.scroll+1, last)
+	case "pgup":
+		h.scroll = max(h.scroll-m.helpHeight(), 0)
+	case "pgdown", " ":
+		h.scroll = min(h.scroll+m.helpHeight(), last)
+	case "home":
+		h.scroll = 0
+	case "end":
+		h.scroll = last
+	}
+	return m, nil
+}
+
+// renderHelp renders the page shown with tabs for the other pages
+func (m model) renderHelp() string {
+	h := m.help
+	hint := lipgloss.NewStyle().Foreground(theme.Muted)
+
+	var tabs []string
+	for i, page := range helpPages {
+		if i == h.page {
+			tabs = append(tabs, lipgloss.NewStyle().Bold(true).Underline(true).Render(page.title))
+		} else {
+			tabs = append(tabs, hint.Render(page.title))
+		}
+	}
+
+	lines := h.helpLines()
+	end := min(h.scroll+m.helpHeight(), len(lines))
+	body := lines[h.scroll:end]
+	position := ""
+	if len(lines) > m.helpHeight() {
+		position = hint.Render(fmt.Sprintf(" | Line %d of %d", end, len(lines)))
+	}
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Background(theme.Accent).
+		Foreground(theme.AccentText).
+		Padding(0, 1).
+		Render("Help")
+
+	content := title + "  " + strings.Join(tabs, " | ") + "\n\n" +
+		strings.Join(body, "\n") + "\n\n" +
+		hint.Render("Tab/Left/Right: Page | Up/Down/PgUp/PgDn: Scroll | ESC: Close") + position
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(theme.Accent).
+		Padding(0, 1).
+		Width(min(90, m.width-4)).
+		Render(content)
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// navHistory is the tree of the locations visited in the connected
+// service. Every location keeps the columns it was last left with, so it
+// can be returned to without loading it again.
+type navHistory struct {
+	odata   *ODataService // Service the history belongs to
+	nodes   []historyNode
+	current int // Node of the location shown, -1 before any
+
+	active bool // The history panel is open
+	cursor int  // Node under the cursor of the panel
+}
+
+// historyNode is a visited location: an entity list, an entity or a
+// navigation property reached from its parent
+type historyNode struct {
+	label    string
+	parent   int // -1 for the service root
+	children []int
+	columns  []column
+	active   int
+}
+
+// locationColumn returns the index of the last column that makes up the
+// location shown, skipping result and raw response columns, or -1
+func (m model) locationColumn() int {
+	last := min(m.activeColumn, len(m.columns)-1)
+	for last > 0 && (m.columns[last].isResult || m.columns[last].raw != nil) {
+		last--
+	}
+	return last
+}
+
+// locationLabel names a column in the history: entity lists by their
+// title, entities by their key
+func (m model) locationLabel(i int) string {
+	col := m.columns[i]
+	if i == 1 && m.serviceIndex >= 0 && m.serviceIndex < len(m.services) {
+		return m.services[m.serviceIndex].Name
+	}
+	if col.isDetails && len(col.entities) > 0 {
+		entitySet := m.detailsEntitySet(i)
+		if key := extractEntityKey(m.metadata(), entitySet, col.entities[0]); key != "" {
+			return fmt.Sprintf("%s(%s)", entitySet, key)
+		}
+		return formatEntityForDisplay(col.entities[0])
+	}
+	return col.title
+}
+
+// recordHistory adds the location shown to the history, or updates the
+// columns kept for it. Locations still loading are recorded once loaded.
+func (m *model) recordHistory() {
+	last := m.locationColumn()
+	if m.odata == nil || last < 1 || m.columns[last].state == stateLoading {
+		return
+	}
+	h := &m.history
+	if h.odata != m.odata {
+		*h = navHistory{odata: m.odata, current: -1}
+	}
+
+	node := -1
+	for i := 1; i <= last; i++ {
+		node = h.child(node, m.locationLabel(i))
+	}
+	h.current = node
+	h.nodes[node].columns = append([]column(nil), m.columns[:last+1]...)
+	h.nodes[node].active = last
+}
+
+// child returns the node for the location label under parent, adding it
+// when it was not visited before
+func (h *navHistory) child(parent int, label string) int {
+	var siblings []int
+	if parent >= 0 {
+		siblings = h.nodes[parent].children
+	} else {
+		for i, n := range h.nodes {
+			if n.parent == -1 {
+				siblings = append(siblings, i)
+			}
+		}
+	}
+	for _, i := range siblings {
+		if h.nodes[i].label == label {
+			return i
+		}
+	}
+	h.nodes = append(h.nodes, historyNode{label: label, parent: parent})
+	node := len(h.nodes) - 1
+	if parent >= 0 {
+		h.nodes[parent].children = append(h.nodes[parent].children, node)
+	}
+	return node
+}
+
+// order returns the nodes depth first in visit order, with the tree
+// branches drawn in front of each
+func (h navHistory) order() (nodes []int, branches []string) {
+	var walk func(node int, indent, branch string)
+	walk = func(node int, indent, branch string) {
+		nodes = append(nodes, node)
+		branches = append(branches, indent+branch)
+		if branch == "├─ " {
+			indent += "│  "
+		} else if branch == "└─ " {
+			indent += "   "
+		}
+		children := h.nodes[node].children
+		for i, child := range children {
+			if i == len(children)-1 {
+				walk(child, indent, "└─ ")
+			} else {
+				walk(child, indent, "├─ ")
+			}
+		}
+	}
+	for i, n := range h.nodes {
+		if n.parent == -1 {
+			walk(i, "", "")
+		}
+	}
+	return nodes, branches
+}
+
+// path returns the labels from the root to a node
+func (h navHistory) path(node int) []string {
+	var labels []string
+	for ; node >= 0; node = h.nodes[node].parent {
+		labels = append([]string{h.nodes[node].label}, labels...)
+	}
+	return labels
+}
+
+// openHistory shows the history panel with the cursor on the location shown
+func (m model) openHistory() model {
+	if len(m.history.nodes) == 0 || m.history.odata != m.odata {
+		m.logs = append(m.logs, "No navigation history yet - connect to a service and open an entity set")
+		return m
+	}
+	m.history.active = true
+	m.history.cursor = m.history.current
+	return m
+}
+
+// updateHistory handles key presses while the history panel is open:
+// Enter returns to the location under the cursor
+func (m model) updateHistory(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	h := &m.history
+	nodes, _ := h.order()
+	pos := 0
+	for p, node := range nodes {
+		if node == h.cursor {
+			pos = p
+		}
+	}
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "g":
+		h.active = false
+	case "up", "k":
+		h.cursor = nodes[max(pos-1, 0)]
+	case "down", "j":
+		h.cursor = nodes[min(pos+1, len(nodes)-1)]
+	case "home":
+		h.cursor = nodes[0]
+	case "end":
+		h.cursor = nodes[len(nodes)-1]
+	case "enter":
+		h.active = false
+		return m.jumpToHistory(h.cursor)
+	}
+	return m, nil
+}
+
+// jumpToHistory restores the columns of a visited location
+func (m model) jumpToHistory(node int) (tea.Model, tea.Cmd) {
+	n := m.history.nodes[node]
+	m.columns = append([]column(nil), n.columns...)
+	m.activeColumn = n.active
+	for i := range m.columns {
+		m.columns[i].focused = i == m.activeColumn
+	}
+	m.history.current = node
+	m.updateColumnSizes()
+	m.logs = append(m.logs, "Back at "+strings.Join(m.history.path(node), " → "))
+	return m, m.updatePreview()
+}
+
+// renderHistory draws the visited locations as a tree under the path that
+// led to the location shown
+func (m model) renderHistory() string {
+	h := m.history
+	hint := lipgloss.NewStyle().Foreground(theme.Muted)
+
+	nodes, branches := h.order()
+	choices := make([]string, len(nodes))
+	cursor := 0
+	for p, node := range nodes {
+		marker := "  "
+		if node == h.current {
+			marker = "● "
+		}
+		choices[p] = marker + branches[p] + h.nodes[node].label
+		if node == h.cursor {
+			cursor = p
+		}
+	}
+
+	var lines []string
+	if h.current >= 0 {
+		lines = append(lines, hint.Render("You are at ")+strings.Join(h.path(h.current), " → "), "")
+	}
+	lines = append(lines, renderChoiceList(choices, cursor, max(m.height-12, 3))...)
+	lines = append(lines, "", hint.Render("Enter: Go there | ●: You are here | ESC: Close"))
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Background(theme.Accent).
+		Foreground(theme.AccentText).
+		Padding(0, 1).
+		Render("Navigation History")
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(theme.Accent).
+		Padding(0, 1).
+		Width(min(90, m.width-4)).
+		Render(title + "\n\n" + strings.Join(lines, "\n"))
+}
@@ -0,0 +1,649 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// importBatchSize is the number of entities created per $batch request
+const importBatchSize = 100
+
+// Steps of the import dialog
+const (
+	importStepFile = iota
+	importStepMapping
+)
+
+// importDialog is the state of the "I" overlay creating entities from the
+// rows of a JSON or CSV file
+type importDialog struct {
+	active    bool
+	step      int
+	entitySet string
+	path      string
+
+	// Read from the file
+	headers []string                 // Source fields in file order
+	records []map[string]interface{} // Values of each row by source field
+	fromCSV bool                     // Values are text, converted by property type
+
+	properties []Property // Properties of the entity type, none without metadata
+	mapping    []int      // Index into properties for each header, -1 to skip
+	batch      bool       // Send the rows in $batch requests
+	cursor     int        // 0 is the send mode, then one row per header
+}
+
+// importMsg carries the outcome of creating the rows of an import
+type importMsg struct {
+	entitySet string
+	rows      []int // Row number of each request in the file, from 1
+	results   []*OperationResult
+	errs      []error
+	batched   bool
+}
+
+// guidPattern matches the 8-4-4-4-12 hex digits of an Edm.Guid
+var guidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// importDateLayouts are the date and time formats import accepts, besides
+// the V2 /Date(…)/ form
+var importDateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"02.01.2006 15:04:05",
+	"02.01.2006",
+}
+
+// importTarget returns the entity set new entities are imported into: that
+// of the active entity list, or the one under the cursor of the entity sets
+func (m model) importTarget() string {
+	if m.activeColumn >= len(m.columns) {
+		return ""
+	}
+	col := m.columns[m.activeColumn]
+	switch {
+	case col.entitySet != "" && !col.isDetails && !col.isResult && col.raw == nil:
+		return col.entitySet
+	case m.activeColumn == 1 && !col.isDetails && col.cursor < len(col.items):
+		if name := itemEntitySet(col.items[col.cursor]); name != "" && !strings.HasPrefix(name, "$") && !strings.Contains(name, "(") {
+			return name
+		}
+	}
+	return ""
+}
+
+// openImport asks for the file to create entities from
+func (m model) openImport() model {
+	entitySet := m.importTarget()
+	if m.odata == nil || entitySet == "" {
+		m.logs = append(m.logs, "Import needs an entity set - open its list or put the cursor on it")
+		return m
+	}
+	if !m.metadata().Capabilities(entitySet).Creatable {
+		m.logs = append(m.logs, fmt.Sprintf("%s does not allow creating entities", entitySet))
+		return m
+	}
+	m.importDialog = importDialog{active: true, entitySet: entitySet, path: m.importDialog.path, batch: true}
+	return m
+}
+
+// updateImport handles key presses while the import dialog is open: first
+// the file name is typed, then the source fields are mapped to properties
+func (m model) updateImport(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	d := &m.importDialog
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		d.active = false
+		return m, nil
+	}
+
+	if d.step == importStepFile {
+		switch msg.String() {
+		case "enter":
+			return m.readImport(), nil
+		case "backspace":
+			if len(d.path) > 0 {
+				runes := []rune(d.path)
+				d.path = string(runes[:len(runes)-1])
+			}
+		default:
+			d.path += typedText(msg)
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "up", "k":
+		if d.cursor > 0 {
+			d.cursor--
+		}
+	case "down", "j", "tab":
+		if d.cursor < len(d.headers) {
+			d.cursor++
+		}
+	case "left", "right":
+		step := 1
+		if msg.String() == "left" {
+			step = -1
+		}
+		if d.cursor == 0 {
+			d.batch = !d.batch
+		} else if len(d.properties) > 0 {
+			// Cycle through the properties and "skip", stored as -1
+			n := len(d.properties) + 1
+			d.mapping[d.cursor-1] = (d.mapping[d.cursor-1]+1+step+n)%n - 1
+		}
+	case "backspace":
+		d.step = importStepFile
+	case "enter":
+		return m.startImport()
+	}
+	return m, nil
+}
+
+// readImport reads the file of the dialog and maps its fields to the
+// properties of the same name or label
+func (m model) readImport() model {
+	d := &m.importDialog
+	path := strings.TrimSpace(d.path)
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+	headers, records, fromCSV, err := readImportFile(path)
+	if err != nil {
+		m.logs = append(m.logs, fmt.Sprintf("ERROR [import %s]: %v", filepath.Base(path), err))
+		return m
+	}
+	if len(records) == 0 {
+		m.logs = append(m.logs, fmt.Sprintf("%s has no rows to import", path))
+		return m
+	}
+
+	d.headers, d.records, d.fromCSV = headers, records, fromCSV
+	d.properties = nil
+	if et := m.metadata().EntityTypeOf(d.entitySet); et != nil {
+		d.properties = et.Properties
+	}
+	d.mapping = make([]int, len(headers))
+	for i, h := range headers {
+		d.mapping[i] = -1
+		for j, p := range d.properties {
+			if strings.EqualFold(p.Name, h) || (p.Label != "" && strings.EqualFold(p.Label, h)) {
+				d.mapping[i] = j
+				break
+			}
+		}
+	}
+	d.step = importStepMapping
+	d.cursor = 0
+	return m
+}
+
+// readImportFile reads a JSON array of objects, or a CSV file with a header
+// row, returning the source fields and the values of every row. CSV files
+// may be UTF-8 or UTF-16 with a byte order mark, and separated by commas,
+// semicolons or tabs.
+func readImportFile(path string) (headers []string, records []map[string]interface{}, fromCSV bool, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		data = data[3:]
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}) && len(data)%2 == 0:
+		units := make([]uint16, (len(data)-2)/2)
+		for i := range units {
+			units[i] = uint16(data[2+2*i]) | uint16(data[3+2*i])<<8
+		}
+		data = []byte(string(utf16.Decode(units)))
+	}
+	if !utf8.Valid(data) {
+		return nil, nil, false, errors.New("the file is not UTF-8 or UTF-16 text")
+	}
+
+	if text := bytes.TrimSpace(data); len(text) > 0 && (text[0] == '[' || text[0] == '{') {
+		headers, records, err = readImportJSON(text)
+		return headers, records, false, err
+	}
+	headers, records, err = readImportCSV(string(data))
+	return headers, records, true, err
+}
+
+// readImportJSON reads a JSON array of objects, or a V2 or V4 response
+// holding one. Numbers keep all their digits, for 64-bit keys.
+func readImportJSON(data []byte) ([]string, []map[string]interface{}, error) {
+	unmarshal := func(v interface{}) error {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		return dec.Decode(v)
+	}
+	var rows []map[string]interface{}
+	if data[0] == '{' {
+		var wrapped struct {
+			D struct {
+				Results []map[string]interface{} `json:"results"`
+			} `json:"d"`
+			Value []map[string]interface{} `json:"value"`
+		}
+		if err := unmarshal(&wrapped); err != nil {
+			return nil, nil, err
+		}
+		rows = append(wrapped.D.Results, wrapped.Value...)
+	} else if err := unmarshal(&rows); err != nil {
+		return nil, nil, fmt.Errorf("expected a JSON array of objects: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	var headers []string
+	for _, row := range rows {
+		for k := range row {
+			if !seen[k] && !strings.HasPrefix(k, "__") && !strings.Contains(k, "@odata.") {
+				seen[k] = true
+				headers = append(headers, k)
+			}
+		}
+	}
+	sort.Strings(headers)
+	return headers, rows, nil
+}
+
+// readImportCSV reads CSV with a header row, guessing the separator from it
+func readImportCSV(text string) ([]string, []map[string]interface{}, error) {
+	first, _, _ := strings.Cut(text, "\n")
+	comma, most := ',', strings.Count(first, ",")
+	for _, sep := range []rune{';', '\t'} {
+		if n := strings.Count(first, string(sep)); n > most {
+			comma, most = sep, n
+		}
+	}
+
+	r := csv.NewReader(strings.NewReader(text))
+	r.Comma = comma
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil, errors.New("the file is empty")
+	}
+
+	headers := rows[0]
+	for i := range headers {
+		headers[i] = strings.TrimSpace(headers[i])
+	}
+	var records []map[string]interface{}
+	for _, row := range rows[1:] {
+		record := make(map[string]interface{})
+		for i, value := range row {
+			if i < len(headers) && headers[i] != "" {
+				record[headers[i]] = value
+			}
+		}
+		records = append(records, record)
+	}
+	return headers, records, nil
+}
+
+// importBodies converts the rows of the dialog into the entities to create,
+// by the mapping and the property types. Rows that don't convert are left
+// out and described in problems.
+func (d importDialog) importBodies(version string) (bodies []map[string]interface{}, rows []int, problems []string) {
+	for i, record := range d.records {
+		body := make(map[string]interface{})
+		var errs []string
+		for j, h := range d.headers {
+			value, ok := record[h]
+			if !ok {
+				continue
+			}
+			text, isText := value.(string)
+			if len(d.properties) == 0 {
+				// Without metadata fields are sent as named, CSV text as the
+				// closest JSON value
+				if isText && d.fromCSV {
+					if text = strings.TrimSpace(text); text == "" {
+						continue
+					}
+					value = coercePastedValue(text)
+				}
+				body[h] = value
+				continue
+			}
+			if d.mapping[j] < 0 {
+				continue
+			}
+			p := d.properties[d.mapping[j]]
+			if value == nil || (isText && strings.TrimSpace(text) == "") {
+				if !p.Nullable {
+					errs = append(errs, p.Name+" is required")
+				}
+				continue
+			}
+			converted, err := importValue(value, p, version)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", p.Name, err))
+				continue
+			}
+			body[p.Name] = converted
+		}
+		if len(errs) > 0 {
+			problems = append(problems, fmt.Sprintf("row %d: %s", i+1, strings.Join(errs, "; ")))
+			continue
+		}
+		if len(body) > 0 {
+			bodies = append(bodies, body)
+			rows = append(rows, i+1)
+		}
+	}
+	return bodies, rows, problems
+}
+
+// importValue converts a value read from a file to the JSON the service
+// expects for the property in its protocol version, or says why it can't
+func importValue(value interface{}, p Property, version string) (interface{}, error) {
+	var text string
+	switch v := value.(type) {
+	case string:
+		text = strings.TrimSpace(v)
+	case json.Number:
+		text = v.String()
+	case bool:
+		text = strconv.FormatBool(v)
+	default:
+		// Complex values are sent as they are
+		return value, nil
+	}
+	v4 := strings.HasPrefix(version, "4")
+
+	switch p.Type {
+	case "Edm.String":
+		if limit, err := strconv.Atoi(p.MaxLength); err == nil && limit > 0 && utf8.RuneCountInString(text) > limit {
+			return nil, fmt.Errorf("longer than %d characters", limit)
+		}
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+		return text, nil
+	case "Edm.Boolean":
+		b, err := strconv.ParseBool(strings.ToLower(text))
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", text)
+		}
+		return b, nil
+	case "Edm.Byte", "Edm.SByte", "Edm.Int16", "Edm.Int32":
+		n, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", text)
+		}
+		return n, nil
+	case "Edm.Int64":
+		if _, err := strconv.ParseInt(text, 10, 64); err != nil {
+			return nil, fmt.Errorf("%q is not an integer", text)
+		}
+		// V2 sends 64-bit integers as strings
+		if v4 {
+			return json.Number(text), nil
+		}
+		return text, nil
+	case "Edm.Decimal", "Edm.Double", "Edm.Single":
+		// A decimal comma, as written by European spreadsheets
+		if !strings.Contains(text, ".") {
+			text = strings.Replace(text, ",", ".", 1)
+		}
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil || strings.Trim(text, "+-0123456789.eE") != "" {
+			return nil, fmt.Errorf("%q is not a number", text)
+		}
+		if p.Type != "Edm.Decimal" {
+			return f, nil
+		}
+		if v4 {
+			return json.Number(text), nil
+		}
+		return text, nil
+	case "Edm.Date", "Edm.DateTime", "Edm.DateTimeOffset":
+		return importDate(text, p.Type, v4)
+	case "Edm.Guid":
+		if !guidPattern.MatchString(text) {
+			return nil, fmt.Errorf("%q is not a GUID", text)
+		}
+		return text, nil
+	}
+	return value, nil
+}
+
+// importDate converts a date in one of the accepted layouts to the form of
+// the property type: /Date(…)/ in V2, ISO 8601 in V4
+func importDate(text, edmType string, v4 bool) (interface{}, error) {
+	t, _, ok := parseV2Date(text)
+	if ok && !v4 {
+		return text, nil
+	}
+	for _, layout := range importDateLayouts {
+		if ok {
+			break
+		}
+		var err error
+		t, err = time.Parse(layout, text)
+		ok = err == nil
+	}
+	if !ok {
+		return nil, fmt.Errorf("%q is not a date", text)
+	}
+	switch {
+	case edmType == "Edm.Date":
+		return t.Format("2006-01-02"), nil
+	case v4:
+		return t.UTC().Format(time.RFC3339Nano), nil
+	case edmType == "Edm.DateTimeOffset":
+		return fmt.Sprintf("/Date(%d+0000)/", t.UnixMilli()), nil
+	}
+	// Edm.DateTime has no time zone; keep the wall clock time as written
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return fmt.Sprintf("/Date(%d)/", wall.UnixMilli()), nil
+}
+
+// startImport validates the rows, logs the ones that can't be sent and
+// creates an entity for each of the others
+func (m model) startImport() (tea.Model, tea.Cmd) {
+	d := m.importDialog
+	bodies, rows, problems := d.importBodies(m.odata.Version())
+	for _, problem := range problems {
+		row, reason, _ := strings.Cut(problem, ": ")
+		m.logs = append(m.logs, fmt.Sprintf("ERROR [import %s %s]: %s", d.entitySet, row, reason))
+	}
+	if len(bodies) == 0 {
+		m.logs = append(m.logs, "Nothing to import - no row has a value for a mapped property")
+		return m, nil
+	}
+
+	p := m.startProgress("Importing into "+d.entitySet, "rows", len(bodies))
+	if p == nil {
+		return m, nil
+	}
+	m.importDialog.active = false
+	via := "one request per row"
+	if d.batch {
+		via = "$batch"
+	}
+	m.logs = append(m.logs, fmt.Sprintf("Importing %d rows into %s via %s...", len(bodies), d.entitySet, via))
+	return m, importEntities(p, d.entitySet, bodies, rows, d.batch)
+}
+
+// importEntities creates the entities in $batch requests of importBatchSize,
+// or one request after another, reporting the rows sent so far. Once
+// cancelled, the rows not sent yet fail as cancelled.
+func importEntities(p *progressState, entitySet string, bodies []map[string]interface{}, rows []int, batch bool) tea.Cmd {
+	odata := p.odata
+	return p.run(func() tea.Msg {
+		msg := importMsg{entitySet: entitySet, rows: rows, results: make([]*OperationResult, len(bodies)), errs: make([]error, len(bodies))}
+		if !batch {
+			for i, body := range bodies {
+				if p.cancelled() {
+					msg.errs[i] = errRequestCancelled
+					continue
+				}
+				msg.results[i], msg.errs[i] = odata.CreateEntity(entitySet, body)
+				p.report(i+1, len(bodies))
+			}
+			return msg
+		}
+
+		for start := 0; start < len(bodies); start += importBatchSize {
+			end := min(start+importBatchSize, len(bodies))
+			requests := make([]BatchRequest, 0, end-start)
+			for _, body := range bodies[start:end] {
+				requests = append(requests, BatchRequest{Method: "POST", EntitySet: entitySet, Body: body})
+			}
+			if p.cancelled() {
+				for i := start; i < end; i++ {
+					msg.errs[i] = errRequestCancelled
+				}
+				continue
+			}
+			results, batched, err := odata.ExecuteBulk(requests, func(done int) { p.report(start+done, len(bodies)) })
+			msg.batched = batched
+			for n := range requests {
+				i := start + n
+				if results == nil || results[n] == nil {
+					msg.errs[i] = err
+					continue
+				}
+				msg.results[i] = results[n]
+				if r := results[n]; r.StatusCode < 200 || r.StatusCode >= 300 {
+					msg.errs[i] = errors.New(r.Status)
+					if message := errorMessage(r.Body); r.Body != "" && message != "" {
+						msg.errs[i] = fmt.Errorf("%s - %s", r.Status, strings.ReplaceAll(message, "\n", " "))
+					}
+				}
+			}
+		}
+		return msg
+	})
+}
+
+// applyImport reports every imported row in the log, adds the created
+// entities to their lists and shows the responses in the Result column
+func (m *model) applyImport(msg importMsg) {
+	m.loading = false
+	var results []*OperationResult
+	created := 0
+	for i, row := range msg.rows {
+		if msg.results[i] != nil {
+			results = append(results, msg.results[i])
+		}
+		if msg.errs[i] != nil {
+			m.logs = append(m.logs, fmt.Sprintf("ERROR [import %s row %d]: %v", msg.entitySet, row, msg.errs[i]))
+			continue
+		}
+		created++
+		entity := msg.results[i].Entity
+		if entity == nil {
+			m.logs = append(m.logs, fmt.Sprintf("Imported row %d into %s", row, msg.entitySet))
+			continue
+		}
+		m.insertCreatedEntity(msg.entitySet, entity)
+		m.logs = append(m.logs, fmt.Sprintf("Imported row %d as %s(%s)", row, msg.entitySet, extractEntityKey(m.metadata(), msg.entitySet, entity)))
+	}
+
+	via := "one request per row"
+	if msg.batched {
+		via = "$batch"
+	}
+	m.logs = append(m.logs, fmt.Sprintf("Import into %s via %s: %d of %d rows created", msg.entitySet, via, created, len(msg.rows)))
+	m.openResultColumn(msg.entitySet, results)
+}
+
+// renderImport renders the import dialog: the file prompt, then the
+// mapping of the source fields to properties
+func (m model) renderImport() string {
+	d := m.importDialog
+	hint := lipgloss.NewStyle().Foreground(theme.Muted)
+	selected := lipgloss.NewStyle().Background(theme.Selection).Foreground(theme.AccentText)
+
+	var lines []string
+	if d.step == importStepFile {
+		lines = append(lines,
+			"Create an entity from every row of a JSON array or a CSV file with a header row:",
+			lipgloss.NewStyle().Background(theme.Panel).Render("> "+d.path+"█"),
+			"",
+			hint.Render("Enter: Read the file | ESC: Cancel"),
+		)
+	} else {
+		bodies, _, problems := d.importBodies(m.odata.Version())
+		lines = append(lines, fmt.Sprintf("%d rows read, %d ready to create", len(d.records), len(bodies)))
+		if len(problems) > 0 {
+			lines = append(lines, lipgloss.NewStyle().Foreground(theme.Error).Render(fmt.Sprintf("%d rows skipped, e.g. %s", len(problems), problems[0])))
+		}
+		lines = append(lines, "")
+
+		mode := "One request per row"
+		if d.batch {
+			mode = fmt.Sprintf("$batch (%d rows per request)", importBatchSize)
+		}
+		line := "Send: ◂ " + mode + " ▸"
+		if d.cursor == 0 {
+			line = selected.Render(line)
+		}
+		lines = append(lines, line, "")
+
+		width := 0
+		for _, h := range d.headers {
+			width = max(width, len([]rune(h)))
+		}
+		width = min(width, 30)
+		choices := make([]string, len(d.headers))
+		for i, h := range d.headers {
+			target := h + " (sent as named)"
+			if len(d.properties) > 0 {
+				target = "(skip)"
+				if j := d.mapping[i]; j >= 0 {
+					target = fmt.Sprintf("%s (%s)", d.properties[j].Name, d.properties[j].Type)
+				}
+			}
+			if i == d.cursor-1 && len(d.properties) > 0 {
+				target = "◂ " + target + " ▸"
+			}
+			choices[i] = fmt.Sprintf("%-*s → %s", width, truncateRunes(h, width), target)
+		}
+		lines = append(lines, renderChoiceList(choices, d.cursor-1, max(m.height-18, 3))...)
+		lines = append(lines, "", hint.Render("Left/Right: Property | Enter: Import | Backspace: Other file | ESC: Cancel"))
+	}
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Background(theme.Accent).
+		Foreground(theme.AccentText).
+		Padding(0, 1).
+		Render("Import into " + d.entitySet)
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(theme.Accent).
+		Padding(0, 1).
+		Width(min(90, m.width-4)).
+		Render(title + "\n\n" + strings.Join(lines, "\n"))
+}
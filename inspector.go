@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxExchangeBody limits how much of each request and response body the
+// inspector keeps
+const maxExchangeBody = 1 << 20
+
+// Exchange is one HTTP request and its response as sent over the wire, for
+// the F6 inspector
+type Exchange struct {
+	Method          string
+	URL             string // Redacted
+	RequestHeaders  http.Header
+	RequestBody     []byte
+	StatusCode      int
+	Status          string
+	ResponseHeaders http.Header
+	Body            []byte
+	Truncated       bool // Body is longer than maxExchangeBody
+	Start           time.Time
+	Duration        time.Duration // Until the body was read
+	Err             string
+	done            bool
+}
+
+// exchangeRecorder keeps the most recent exchanges
+type exchangeRecorder struct {
+	mu        sync.Mutex
+	exchanges []*Exchange
+	limit     int
+}
+
+// requestExchanges records every request made by the OData clients
+var requestExchanges = &exchangeRecorder{limit: 50}
+
+func (r *exchangeRecorder) add(e *Exchange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exchanges = append(r.exchanges, e)
+	if len(r.exchanges) > r.limit {
+		r.exchanges = r.exchanges[len(r.exchanges)-r.limit:]
+	}
+}
+
+// Snapshot returns copies of the recorded exchanges, oldest first
+func (r *exchangeRecorder) Snapshot() []Exchange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]Exchange, len(r.exchanges))
+	for i, e := range r.exchanges {
+		out[i] = *e
+	}
+	return out
+}
+
+// exchangeTransport records the headers and bodies of each request and
+// its response. It wraps the caching layers so cache hits show up too.
+type exchangeTransport struct {
+	base     http.RoundTripper
+	recorder *exchangeRecorder
+}
+
+func (t *exchangeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	e := &Exchange{
+		Method:         req.Method,
+		URL:            redactURL(req.URL.String()),
+		RequestHeaders: req.Header.Clone(),
+		Start:          time.Now(),
+	}
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			e.RequestBody, _ = io.ReadAll(io.LimitReader(body, maxExchangeBody))
+			body.Close()
+		}
+	}
+	t.recorder.add(e)
+
+	resp, err := t.base.RoundTrip(req)
+	t.recorder.mu.Lock()
+	defer t.recorder.mu.Unlock()
+	if err != nil {
+		e.Err = redactText(err.Error())
+		e.Duration = time.Since(e.Start)
+		e.done = true
+		return nil, err
+	}
+	e.StatusCode = resp.StatusCode
+	e.Status = resp.Status
+	e.ResponseHeaders = resp.Header.Clone()
+	resp.Body = &recordedBody{ReadCloser: resp.Body, exchange: e, recorder: t.recorder}
+	return resp, nil
+}
+
+// recordedBody keeps what is read of a response body for its exchange
+type recordedBody struct {
+	io.ReadCloser
+	exchange *Exchange
+	recorder *exchangeRecorder
+}
+
+func (b *recordedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.recorder.mu.Lock()
+	e := b.exchange
+	if room := maxExchangeBody - len(e.Body); room < n {
+		e.Body = append(e.Body, p[:max(room, 0)]...)
+		e.Truncated = true
+	} else {
+		e.Body = append(e.Body, p[:n]...)
+	}
+	if err == io.EOF {
+		b.finish()
+	}
+	b.recorder.mu.Unlock()
+	return n, err
+}
+
+func (b *recordedBody) Close() error {
+	b.recorder.mu.Lock()
+	b.finish()
+	b.recorder.mu.Unlock()
+	return b.ReadCloser.Close()
+}
+
+// finish stops the clock of the exchange; the recorder lock must be held
+func (b *recordedBody) finish() {
+	if !b.exchange.done {
+		b.exchange.done = true
+		b.exchange.Duration = time.Since(b.exchange.Start)
+	}
+}
+
+// inspector is the state of the F6 overlay showing the raw request and
+// response behind the active column
+type inspector struct {
+	active    bool
+	exchanges []Exchange // Snapshot taken when the inspector opened
+	index     int        // Exchange shown
+	scroll    int
+}
+
+// openInspector shows the latest exchange that read what the active column
+// displays, else the latest exchange of all
+func (m model) openInspector() model {
+	exchanges := requestExchanges.Snapshot()
+	if len(exchanges) == 0 {
+		m.logs = append(m.logs, "No requests sent yet")
+		return m
+	}
+	m.inspector = inspector{active: true, exchanges: exchanges, index: len(exchanges) - 1}
+	for column := m.activeColumn; column > 0 && column < len(m.columns); column-- {
+		view := m
+		view.activeColumn = column
+		_, columnURL, _, _ := view.requestURLs()
+		if i := findExchange(exchanges, columnURL); i >= 0 {
+			m.inspector.index = i
+			break
+		}
+	}
+	return m
+}
+
+// findExchange returns the index of the latest exchange reading the
+// resource of the URL, whatever its query options; -1 when there is none
+func findExchange(exchanges []Exchange, u string) int {
+	path, _, _ := strings.Cut(u, "?")
+	if path == "" {
+		return -1
+	}
+	for i := len(exchanges) - 1; i >= 0; i-- {
+		if exchangePath, _, _ := strings.Cut(exchanges[i].URL, "?"); exchangePath == path {
+			return i
+		}
+	}
+	return -1
+}
+
+// inspectorWidth is the number of cells the lines of the inspector wrap at
+func (m model) inspectorWidth() int {
+	return max(min(140, m.width-4)-4, 10)
+}
+
+// inspectorHeight is the number of lines the inspector shows
+func (m model) inspectorHeight() int {
+	return max(m.height-10, 3)
+}
+
+// inspectorLines renders the exchange shown: request line, headers, status,
+// timing and body, with credentials redacted
+func (m model) inspectorLines() []string {
+	e := m.inspector.exchanges[m.inspector.index]
+	section := lipgloss.NewStyle().Bold(true).Foreground(theme.Accent)
+
+	lines := []string{section.Render("Request"), e.Method + " " + e.URL}
+	lines = append(lines, headerLines(e.RequestHeaders)...)
+	if len(e.RequestBody) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, wrapText(redactText(string(e.RequestBody)), m.inspectorWidth())...)
+	}
+
+	lines = append(lines, "", section.Render("Response"))
+	switch {
+	case e.Err != "":
+		lines = append(lines, "Error: "+e.Err)
+	case e.Status == "":
+		lines = append(lines, "Waiting for the response...")
+	default:
+		timing := "still reading"
+		if e.done {
+			timing = formatMillis(e.Duration)
+		}
+		lines = append(lines, fmt.Sprintf("%s (%s, %s)", e.Status, timing, formatBytes(int64(len(e.Body)))))
+		lines = append(lines, headerLines(e.ResponseHeaders)...)
+	}
+	if len(e.Body) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, wrapText(redactText(string(e.Body)), m.inspectorWidth())...)
+		if e.Truncated {
+			lines = append(lines, fmt.Sprintf("... cut at %s", formatBytes(maxExchangeBody)))
+		}
+	}
+	return lines
+}
+
+// headerLines lists headers sorted by name, with credentials redacted
+func headerLines(headers http.Header) []string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var lines []string
+	for _, name := range names {
+		for _, value := range headers[name] {
+			lines = append(lines, name+": "+redactHeader(name, value))
+		}
+	}
+	return lines
+}
+
+// updateInspector handles key presses while the inspector is open: left
+// and right step through older and newer exchanges
+func (m model) updateInspector(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	in := &m.inspector
+	last := max(len(m.inspectorLines())-m.inspectorHeight(), 0)
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "f6", "q":
+		in.active = false
+	case "left", "h":
+		if in.index > 0 {
+			in.index--
+			in.scroll = 0
+		}
+	case "right", "l":
+		if in.index < len(in.exchanges)-1 {
+			in.index++
+			in.scroll = 0
+		}
+	case "up", "k":
+		in.scroll = max(in.scroll-1, 0)
+	case "down", "j":
+		in.scroll = min(in.scroll+1, last)
+	case "pgup":
+		in.scroll = max(in.scroll-m.inspectorHeight(), 0)
+	case "pgdown", " ":
+		in.scroll = min(in.scroll+m.inspectorHeight(), last)
+	case "home":
+		in.scroll = 0
+	case "end":
+		in.scroll = last
+	case "y":
+		body := in.exchanges[in.index].Body
+		copyToClipboard(string(bytes.TrimSpace(body)))
+		m.logs = append(m.logs, fmt.Sprintf("Copied the response body (%s) to the clipboard", formatBytes(int64(len(body)))))
+	}
+	return m, nil
+}
+
+// renderInspector renders the exchange shown from its scroll position
+func (m model) renderInspector() string {
+	in := m.inspector
+	hint := lipgloss.NewStyle().Foreground(theme.Muted)
+
+	lines := m.inspectorLines()
+	end := min(in.scroll+m.inspectorHeight(), len(lines))
+	position := ""
+	if len(lines) > m.inspectorHeight() {
+		position = hint.Render(fmt.Sprintf(" | Line %d of %d", end, len(lines)))
+	}
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Background(theme.Accent).
+		Foreground(theme.AccentText).
+		Padding(0, 1).
+		Render("Request Inspector")
+	e := in.exchanges[in.index]
+	counter := hint.Render(fmt.Sprintf("  Request %d of %d, sent %s", in.index+1, len(in.exchanges), e.Start.Format("15:04:05")))
+
+	content := title + counter + "\n\n" +
+		strings.Join(lines[in.scroll:end], "\n") + "\n\n" +
+		hint.Render("Left/Right: Older/newer request | Up/Down/PgUp/PgDn: Scroll | y: Copy body | F6/ESC: Close") + position
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(theme.Accent).
+		Padding(0, 1).
+		Width(min(140, m.width-4)).
+		Render(content)
+}
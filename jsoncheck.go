@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// jsonCheckDelay is how long the modal editor waits after the last change
+// before parsing its text, so typing isn't slowed down by large entities
+const jsonCheckDelay = 300 * time.Millisecond
+
+// jsonProblem is where the text of the modal editor stops being JSON that
+// can be saved, and why
+type jsonProblem struct {
+	editorMark
+	message string
+}
+
+// jsonCheckMsg parses the text of the modal editor unless it changed again
+// since the check was scheduled
+type jsonCheckMsg struct {
+	seq int
+}
+
+// scheduleJSONCheck checks the text of the modal editor once typing pauses
+func (m *model) scheduleJSONCheck() tea.Cmd {
+	m.jsonCheckSeq++
+	seq := m.jsonCheckSeq
+	return tea.Tick(jsonCheckDelay, func(time.Time) tea.Msg { return jsonCheckMsg{seq: seq} })
+}
+
+// checkEditorJSON parses the text of the modal editor now
+func (m *model) checkEditorJSON() {
+	m.jsonProblem = editorJSONProblem(m.editor.content, m.modalOperation)
+}
+
+// editorJSONProblem returns where the text stops being what the operation
+// saves: a JSON object, for a create also an array of them or pasted
+// field<TAB>value rows, and for a resubmit an array of operations. It
+// returns nil for text that can be saved.
+func editorJSONProblem(content []string, operation string) *jsonProblem {
+	text := checkedText(content)
+	if operation == "create" {
+		if _, ok := parsePastedFields(text); ok {
+			return nil
+		}
+	}
+
+	var value interface{}
+	err := json.Unmarshal([]byte(text), &value)
+	if err == nil {
+		start := len(text) - len(strings.TrimLeft(text, " \t\r\n"))
+		_, object := value.(map[string]interface{})
+		_, array := value.([]interface{})
+		switch {
+		case operation == "resubmit" && !array:
+			return problemAt(text, start, "expected a JSON array of operations")
+		case operation == "create" && !object && !array:
+			return problemAt(text, start, "expected an entity as a JSON object, or an array of them")
+		case operation != "resubmit" && operation != "create" && !object:
+			return problemAt(text, start, "expected an entity as a JSON object")
+		}
+		return nil
+	}
+	message := strings.TrimPrefix(err.Error(), "json: ")
+	var syntax *json.SyntaxError
+	if !errors.As(err, &syntax) {
+		return problemAt(text, len(text), message)
+	}
+	// The offset is past the character that made the text invalid
+	pos := int(syntax.Offset)
+	if pos > 0 && !strings.Contains(message, "end of JSON input") {
+		pos--
+	}
+	return problemAt(text, min(pos, len(text)), message)
+}
+
+// checkedText is the text of the editor to parse. Variables are expanded
+// on save; a number as long stands in for them, valid in and out of
+// strings, so positions still match the editor.
+func checkedText(content []string) string {
+	return variableRef.ReplaceAllStringFunc(strings.Join(content, "\n"), func(ref string) string {
+		return "0" + strings.Repeat(" ", len(ref)-1)
+	})
+}
+
+// problemAt turns a byte offset into the text into a line and column
+func problemAt(text string, pos int, message string) *jsonProblem {
+	lineStart := strings.LastIndex(text[:pos], "\n") + 1
+	return &jsonProblem{
+		editorMark: editorMark{line: strings.Count(text[:pos], "\n"), col: pos - lineStart},
+		message:    message,
+	}
+}
+
+// String describes the problem with the line and column as counted on screen
+func (p jsonProblem) String(content []string) string {
+	col := p.col
+	if p.line < len(content) {
+		col = utf8.RuneCountInString(content[p.line][:min(p.col, len(content[p.line]))])
+	}
+	return fmt.Sprintf("line %d, column %d: %s", p.line+1, col+1, p.message)
+}
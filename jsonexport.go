@@ -0,0 +1,328 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// JSON export scopes
+const (
+	jsonScopeLoaded = "loaded"
+	jsonScopeMarked = "marked"
+	jsonScopeAll    = "all"
+	jsonScopeEntity = "entity"
+)
+
+// Rows of the JSON export dialog
+const (
+	jsonRowFile = iota
+	jsonRowScope
+	jsonRowMetadata
+	jsonRowFormat
+	jsonRowCount
+)
+
+// jsonFormats are the OData versions an export can be converted to, ""
+// to keep the format of the service
+var jsonFormats = []string{"", "2.0", "4.0"}
+
+// jsonExportDialog is the state of the "J" overlay exporting an entity
+// list as a JSON array or a Details entity as a JSON object
+type jsonExportDialog struct {
+	active  bool
+	column  int // Index of the exported column
+	row     int
+	path    string
+	scope   int  // Index into the scopes of the column
+	compact bool // Drop __metadata, deferred links and @odata annotations
+	format  int  // Index into jsonFormats
+}
+
+// jsonExportMsg carries the entities of every page read for an export
+type jsonExportMsg struct {
+	path      string
+	entitySet string
+	compact   bool
+	format    string // OData version to convert to, "" to keep as read
+	entities  []map[string]interface{}
+	err       error
+}
+
+// jsonScopes returns what the column can export: a Details entity, or the
+// loaded, marked or all entities of a list
+func (col column) jsonScopes() []string {
+	if col.isDetails {
+		return []string{jsonScopeEntity}
+	}
+	scopes := []string{jsonScopeLoaded}
+	if len(col.selected) > 0 {
+		scopes = []string{jsonScopeMarked, jsonScopeLoaded}
+	}
+	if col.hasMore {
+		scopes = append(scopes, jsonScopeAll)
+	}
+	return scopes
+}
+
+// openJSONExport asks where to export the active entity list or entity
+func (m model) openJSONExport() model {
+	if m.activeColumn >= len(m.columns) {
+		return m
+	}
+	col := m.columns[m.activeColumn]
+	entitySet := col.entitySet
+	switch {
+	case col.isEntityList():
+	case col.isDetails && !col.isResult && col.raw == nil && len(col.entities) > 0:
+		entitySet = m.detailsEntitySet(m.activeColumn)
+		if key := extractEntityKey(m.metadata(), entitySet, col.entities[0]); key != "" {
+			entitySet += "_" + key
+		}
+	default:
+		m.logs = append(m.logs, "JSON export is only available on an entity list or entity")
+		return m
+	}
+	name := strings.Trim(unsafeFileChars.ReplaceAllString(entitySet, "_"), "_")
+	if name == "" {
+		name = "export"
+	}
+	m.jsonExport = jsonExportDialog{
+		active:  true,
+		column:  m.activeColumn,
+		path:    name + ".json",
+		compact: m.jsonExport.compact,
+		format:  m.jsonExport.format,
+	}
+	return m
+}
+
+// updateJSONExport handles key presses while the JSON export dialog is
+// open: up and down pick a row, left and right change it, typing edits
+// the file name
+func (m model) updateJSONExport(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	d := &m.jsonExport
+	scopes := m.columns[d.column].jsonScopes()
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		d.active = false
+	case "enter":
+		d.active = false
+		return m.exportJSON(scopes[d.scope])
+	case "up":
+		d.row = (d.row + jsonRowCount - 1) % jsonRowCount
+	case "down", "tab":
+		d.row = (d.row + 1) % jsonRowCount
+	case "left", "right":
+		step := 1
+		if msg.String() == "left" {
+			step = -1
+		}
+		switch d.row {
+		case jsonRowScope:
+			d.scope = (d.scope + step + len(scopes)) % len(scopes)
+		case jsonRowMetadata:
+			d.compact = !d.compact
+		case jsonRowFormat:
+			d.format = (d.format + step + len(jsonFormats)) % len(jsonFormats)
+		}
+	case "backspace":
+		if d.row == jsonRowFile && len(d.path) > 0 {
+			runes := []rune(d.path)
+			d.path = string(runes[:len(runes)-1])
+		}
+	default:
+		if d.row == jsonRowFile {
+			d.path += typedText(msg)
+		}
+	}
+	return m, nil
+}
+
+// exportJSON writes the entities of the scope, reading the remaining pages
+// first when all entities are asked for
+func (m model) exportJSON(scope string) (tea.Model, tea.Cmd) {
+	d := m.jsonExport
+	col := m.columns[d.column]
+	var data interface{}
+	count := 1
+	switch scope {
+	case jsonScopeEntity:
+		data = col.entities[0]
+	case jsonScopeMarked:
+		var entities []map[string]interface{}
+		for _, i := range col.selectedIndexes() {
+			entities = append(entities, col.entities[i])
+		}
+		data, count = entities, len(entities)
+	case jsonScopeLoaded:
+		data, count = col.entities, len(col.entities)
+	case jsonScopeAll:
+		total := -1
+		if col.counted {
+			total = col.total
+		}
+		p := m.startProgress("Exporting "+col.entitySet, "entities", total)
+		if p == nil {
+			return m, nil
+		}
+		m.logs = append(m.logs, fmt.Sprintf("Reading all pages of %s for the JSON export...", col.entitySet))
+		return m, fetchAllEntities(p, col, d)
+	}
+	m.writeJSONExport(d.path, col.entitySet, data, count, d.compact, jsonFormats[d.format])
+	return m, nil
+}
+
+// fetchAllEntities reads every page of an entity list from the start, as
+// its column reads them, reporting the entities read so far
+func fetchAllEntities(p *progressState, col column, d jsonExportDialog) tea.Cmd {
+	return p.run(func() tea.Msg {
+		msg := jsonExportMsg{path: d.path, entitySet: col.entitySet, compact: d.compact, format: jsonFormats[d.format]}
+		opts := col.query
+		page, err := p.odata.GetEntitiesPage(col.resource(), opts)
+		for err == nil {
+			msg.entities = append(msg.entities, page.Entities...)
+			p.report(len(msg.entities), p.total)
+			if !page.HasMore || len(page.Entities) == 0 {
+				break
+			}
+			if page.NextLink != "" {
+				page, err = p.odata.GetNextPage(page.NextLink, opts)
+			} else {
+				opts.Skip += len(page.Entities)
+				page, err = p.odata.GetEntitiesPage(col.resource(), opts)
+			}
+		}
+		msg.err = err
+		return msg
+	})
+}
+
+// applyJSONExport writes the entities read for an export
+func (m *model) applyJSONExport(msg jsonExportMsg) {
+	m.loading = false
+	if msg.err != nil {
+		m.logs = append(m.logs, fmt.Sprintf("ERROR [JSON export %s]: %v", msg.entitySet, msg.err))
+		return
+	}
+	m.writeJSONExport(msg.path, msg.entitySet, msg.entities, len(msg.entities), msg.compact, msg.format)
+}
+
+// writeJSONExport writes entities, or a single entity, as indented JSON,
+// converted to the value formats of the OData version unless it is ""
+func (m *model) writeJSONExport(input, entitySet string, data interface{}, count int, compact bool, format string) {
+	if format != "" {
+		var changes []string
+		switch v := data.(type) {
+		case map[string]interface{}:
+			data, changes = convertPayload(v, m.metadata(), entitySet, format)
+		case []map[string]interface{}:
+			data, changes = convertPayloads(v, m.metadata(), entitySet, format)
+		}
+		if len(changes) > 0 {
+			m.logs = append(m.logs, fmt.Sprintf("Converted for OData %s: %s", format, strings.Join(changes, ", ")))
+		}
+	}
+	if compact {
+		data = compactJSON(data)
+	}
+	out, err := json.MarshalIndent(data, "", "  ")
+	if err == nil {
+		path := exportFilePath(input, "export.json")
+		if err = writeExport(path, append(out, '\n')); err == nil {
+			m.logs = append(m.logs, fmt.Sprintf("Exported %d %s entities to %s", count, entitySet, path))
+			return
+		}
+	}
+	m.logs = append(m.logs, fmt.Sprintf("ERROR [JSON export %s]: %v", entitySet, err))
+}
+
+// compactJSON drops what only matters to OData clients from exported
+// entities: __metadata, deferred navigation links and @odata annotations
+func compactJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []map[string]interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = compactJSON(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = compactJSON(item)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			if k == "__metadata" || strings.Contains(k, "@odata.") || isNavigationValue(item) {
+				continue
+			}
+			// V2 expanded collections are wrapped in {"results": [...]}
+			if nested, ok := item.(map[string]interface{}); ok && len(nested) == 1 {
+				if results, ok := nested["results"].([]interface{}); ok {
+					item = results
+				}
+			}
+			out[k] = compactJSON(item)
+		}
+		return out
+	}
+	return value
+}
+
+// renderJSONExport renders the JSON export dialog
+func (m model) renderJSONExport() string {
+	d := m.jsonExport
+	col := m.columns[d.column]
+
+	scopes := map[string]string{
+		jsonScopeEntity: "This entity, as an object",
+		jsonScopeLoaded: fmt.Sprintf("%d loaded entities", len(col.entities)),
+		jsonScopeMarked: fmt.Sprintf("%d marked entities", len(col.selected)),
+		jsonScopeAll:    "All pages (read from the service)",
+	}
+	metadata := "Keep as sent"
+	if d.compact {
+		metadata = "Drop __metadata, links and @odata annotations"
+	}
+	format := "As read from the service"
+	if v := jsonFormats[d.format]; v != "" {
+		format = "Convert to OData " + v
+	}
+	rows := []struct{ label, value string }{
+		{"File", d.path + "█"},
+		{"Entities", "◂ " + scopes[col.jsonScopes()[d.scope]] + " ▸"},
+		{"Metadata", "◂ " + metadata + " ▸"},
+		{"Format", "◂ " + format + " ▸"},
+	}
+	var lines []string
+	for i, row := range rows {
+		line := fmt.Sprintf("%-10s %s", row.label+":", row.value)
+		if i == d.row {
+			line = lipgloss.NewStyle().Background(theme.Selection).Foreground(theme.AccentText).Render(line)
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "", lipgloss.NewStyle().Foreground(theme.Muted).Render("Up/Down: Option | Left/Right: Change | Enter: Export | ESC: Cancel"))
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Background(theme.Accent).
+		Foreground(theme.AccentText).
+		Padding(0, 1).
+		Render("Export as JSON")
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(theme.Accent).
+		Padding(0, 1).
+		Width(min(70, m.width-4)).
+		Render(title + "\n\n" + strings.Join(lines, "\n"))
+}
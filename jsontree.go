@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// treeLine is a line of the JSON tree of a Details column. Lines opening
+// an object or array carry its path, property names and array indexes
+// joined by "/", and whether it is shown open.
+type treeLine struct {
+	text string
+	path string
+	open bool
+	full string // Whole string value of a line that shows it cut short
+	name string // Path of that value
+}
+
+// jsonTree renders an entity as a JSON tree whose objects and arrays can
+// be collapsed to a one-line summary
+type jsonTree struct {
+	isNav map[string]bool // Navigation properties of the entity type
+	open  map[string]bool // Objects and arrays opened or collapsed, by path
+	lines []treeLine
+}
+
+// formatDetailsJSON renders an entity as indented JSON lines. Objects and
+// arrays are open unless collapsed in open; expanded navigation properties
+// and __metadata start collapsed.
+func formatDetailsJSON(entity map[string]interface{}, navigation []string, open map[string]bool) []treeLine {
+	if len(entity) == 0 {
+		return []treeLine{{text: "{}"}}
+	}
+
+	t := &jsonTree{isNav: make(map[string]bool), open: open}
+	for _, name := range navigation {
+		t.isNav[name] = true
+	}
+	t.add("{", "", false)
+	keys := sortedKeys(entity)
+	for i, k := range keys {
+		t.value("  ", k, entity[k], displayDates(entity[k]), k, i == len(keys)-1)
+	}
+	t.add("}", "", false)
+	return t.lines
+}
+
+func (t *jsonTree) add(text, path string, open bool) {
+	t.lines = append(t.lines, treeLine{text: text, path: path, open: open})
+}
+
+// value writes a property or array item. name is empty for array items,
+// raw is the value as sent, shown the value with dates converted.
+func (t *jsonTree) value(indent, name string, raw, shown interface{}, path string, last bool) {
+	prefix := indent
+	if name != "" {
+		quoted, _ := json.Marshal(name)
+		prefix += string(quoted) + ": "
+	}
+	comma := ","
+	if last {
+		comma = ""
+	}
+
+	summary, isOpen, ok := t.node(name, raw, path)
+	if s, long := raw.(string); long && !ok {
+		if cut, more := truncateValue(s); more > 0 {
+			quoted, _ := json.Marshal(cut + "…")
+			text := fmt.Sprintf("%s%s%s (+%s chars, press Enter to expand)", prefix, quoted, comma, formatCount(more))
+			t.lines = append(t.lines, treeLine{text: text, full: s, name: path})
+			return
+		}
+	}
+	if !ok {
+		data, err := json.MarshalIndent(shown, indent, "  ")
+		if err != nil {
+			data = []byte(fmt.Sprintf("%q", fmt.Sprint(shown)))
+		}
+		for _, line := range strings.Split(prefix+string(data)+comma, "\n") {
+			t.add(line, "", false)
+		}
+		return
+	}
+	if !isOpen {
+		t.add(prefix+"▸ "+summary+comma, path, false)
+		return
+	}
+
+	switch v := shown.(type) {
+	case map[string]interface{}:
+		t.add(prefix+"▾ {", path, true)
+		rawMap, _ := raw.(map[string]interface{})
+		keys := sortedKeys(v)
+		for i, k := range keys {
+			t.value(indent+"  ", k, rawMap[k], v[k], path+"/"+k, i == len(keys)-1)
+		}
+		t.add(indent+"}"+comma, "", false)
+	case []interface{}:
+		t.add(prefix+"▾ [", path, true)
+		rawItems, _ := raw.([]interface{})
+		for i, item := range v {
+			var rawItem interface{}
+			if i < len(rawItems) {
+				rawItem = rawItems[i]
+			}
+			t.value(indent+"  ", "", rawItem, item, fmt.Sprintf("%s/%d", path, i), i == len(v)-1)
+		}
+		t.add(indent+"]"+comma, "", false)
+	}
+}
+
+// node reports whether a value is an object or array that can be collapsed,
+// with its summary and whether it is shown open. Empty ones and V2 deferred
+// navigation links are plain values, so Enter still follows the link.
+func (t *jsonTree) node(name string, v interface{}, path string) (string, bool, bool) {
+	var summary string
+	switch value := v.(type) {
+	case map[string]interface{}:
+		if len(value) == 0 || isNavigationValue(value) {
+			return "", false, false
+		}
+		summary = fmt.Sprintf("{%d properties}", len(value))
+		if entityType, ok := value["type"].(string); ok && name == "__metadata" {
+			summary = fmt.Sprintf("{%s}", entityType)
+		}
+	case []interface{}:
+		if len(value) == 0 {
+			return "", false, false
+		}
+		summary = fmt.Sprintf("[%d items]", len(value))
+	default:
+		return "", false, false
+	}
+
+	isOpen := name != "__metadata"
+	if !strings.Contains(path, "/") {
+		// V4 expanded single entities carry no __metadata, so rely on the metadata
+		if section, ok := expandedSummary(v); ok {
+			summary, isOpen = section, false
+		} else if nested, ok := v.(map[string]interface{}); ok && t.isNav[name] {
+			summary, isOpen = fmt.Sprintf("{%s}", formatEntityForDisplay(nested)), false
+		}
+	}
+	if open, ok := t.open[path]; ok {
+		isOpen = open
+	}
+	return summary, isOpen, true
+}
+
+// detailsTree renders an entity of the given entity set for a Details or
+// preview column
+func (m model) detailsTree(entitySet string, entity map[string]interface{}, open map[string]bool) []treeLine {
+	var navigation []string
+	if et := m.odata.metadata().EntityTypeOf(entitySet); et != nil {
+		navigation = et.Navigation
+	}
+	return formatDetailsJSON(entity, navigation, open)
+}
+
+// detailsLines renders an entity of the given entity set for a Details or
+// preview column
+func (m model) detailsLines(entitySet string, entity map[string]interface{}, open map[string]bool) []string {
+	tree := m.detailsTree(entitySet, entity, open)
+	lines := make([]string, len(tree))
+	for i, line := range tree {
+		lines[i] = line.text
+	}
+	return lines
+}
+
+// toggleSection opens or collapses the object or array whose line is under
+// the cursor of a Details column. With enclosing set, a cursor inside an
+// open object or array collapses it. It reports false when there is
+// nothing to open or collapse.
+func (m *model) toggleSection(enclosing bool) bool {
+	col := &m.columns[m.activeColumn]
+	if len(col.entities) == 0 || col.isResult || col.raw != nil {
+		return false
+	}
+	entitySet := m.detailsEntitySet(m.activeColumn)
+	tree := m.detailsTree(entitySet, col.entities[0], col.openSections)
+	if col.cursor >= len(tree) {
+		return false
+	}
+	if full := tree[col.cursor].full; full != "" && !enclosing {
+		m.openValueViewer(tree[col.cursor].name, full)
+		return true
+	}
+
+	line := col.cursor
+	if tree[line].path == "" {
+		if !enclosing {
+			return false
+		}
+		// The enclosing object or array is the closest line above indented less
+		text := strings.TrimLeft(tree[line].text, " ")
+		depth := len(tree[line].text) - len(text)
+		if strings.HasPrefix(text, "}") || strings.HasPrefix(text, "]") {
+			depth++ // A closing bracket belongs to the object it closes
+		}
+		for line >= 0 {
+			text := tree[line].text
+			if len(text)-len(strings.TrimLeft(text, " ")) < depth {
+				break
+			}
+			line--
+		}
+		if line < 0 || tree[line].path == "" {
+			return false
+		}
+	}
+
+	open := make(map[string]bool)
+	for k, v := range col.openSections {
+		open[k] = v
+	}
+	open[tree[line].path] = !tree[line].open
+	col.openSections = open
+	col.items = m.detailsLines(entitySet, col.entities[0], open)
+	col.cursor = line
+	if col.cursor < col.scrollOffset {
+		col.scrollOffset = col.cursor
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// keepAliveInterval applies to services without a keep_alive of their own;
+// 0 sends no heartbeat
+var keepAliveInterval time.Duration
+
+// KeepAliveInterval returns how often the service is pinged while
+// connected, 0 for never
+func (svc ServiceConfig) KeepAliveInterval() time.Duration {
+	if interval, err := time.ParseDuration(svc.KeepAlive); svc.KeepAlive != "" && err == nil {
+		return interval
+	}
+	return keepAliveInterval
+}
+
+// Ping sends a HEAD request for the service document, so gateways keep
+// the session and OAuth2 tokens are refreshed before they expire. Servers
+// that refuse HEAD get a GET instead.
+func (o *ODataService) Ping() error {
+	status, err := o.ping("HEAD")
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = o.ping("GET")
+	}
+	if err != nil {
+		return err
+	}
+	if status >= 400 {
+		return fmt.Errorf("HTTP %d", status)
+	}
+	return nil
+}
+
+func (o *ODataService) ping(method string) (int, error) {
+	req, err := http.NewRequest(method, o.jsonFormat(o.BuildURL("", "", QueryOptions{})), nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	// The response must come from the server, and a HEAD must not drop
+	// cached responses the way writes do
+	req.Header.Set("Cache-Control", "no-store")
+	// Cancelling loads leaves the heartbeat running
+	req = detached(req)
+	if o.username != "" && o.password != "" {
+		req.SetBasicAuth(o.username, o.password)
+	}
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// keepAliveMsg is due when the connected service should be pinged
+type keepAliveMsg struct {
+	odata *ODataService
+	seq   int // Heartbeat the ping belongs to, see model.keepAliveSeq
+}
+
+// keepAliveDoneMsg carries the outcome of a ping
+type keepAliveDoneMsg struct {
+	odata *ODataService
+	seq   int
+	err   error
+}
+
+// keepAlive schedules the next ping of the connected service, nil when the
+// service has no heartbeat
+func (m model) keepAlive() tea.Cmd {
+	if m.odata == nil || m.serviceIndex >= len(m.services) {
+		return nil
+	}
+	interval := m.services[m.serviceIndex].KeepAliveInterval()
+	if interval <= 0 {
+		return nil
+	}
+	odata, seq := m.odata, m.keepAliveSeq
+	return tea.Tick(interval, func(time.Time) tea.Msg { return keepAliveMsg{odata: odata, seq: seq} })
+}
+
+// updateKeepAlive pings the service the heartbeat was started for, and ends
+// the heartbeat once another service is connected or a new one was started
+func (m model) updateKeepAlive(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case keepAliveMsg:
+		if msg.odata != m.odata || msg.seq != m.keepAliveSeq {
+			return m, nil
+		}
+		return m, func() tea.Msg {
+			return keepAliveDoneMsg{odata: msg.odata, seq: msg.seq, err: msg.odata.Ping()}
+		}
+	case keepAliveDoneMsg:
+		if msg.odata != m.odata || msg.seq != m.keepAliveSeq {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.logs = append(m.logs, fmt.Sprintf("WARNING: Keep-alive of %s failed: %s - the session may have expired", m.services[m.serviceIndex].Name, redactText(msg.err.Error())))
+		} else {
+			m.debugf("Keep-alive of %s answered", m.services[m.serviceIndex].Name)
+		}
+		return m, m.keepAlive()
+	}
+	return m, nil
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/muesli/reflow/wordwrap"
+)
+
+// keyBinding is a key, or keys doing related things, as the help and the
+// footer describe them
+type keyBinding struct {
+	keys   string   // As the help writes them, e.g. "Up/Down, k/j"
+	help   string   // What they do; the help wraps it
+	footer []string // Entries of the footer such as "F2:Create", none to leave them out
+}
+
+// keyGroup is the key bindings of one context
+type keyGroup struct {
+	title    string
+	editor   bool   // Keys of the modal editor, whose footer shows them instead
+	keymap   string // Key profile the keys belong to, "" for every profile
+	bindings []keyBinding
+}
+
+// keyMap is every key binding, grouped by context. The Keys page of the
+// help and the footers are made from it, so it is the one place to add a
+// key to besides its handler.
+var keyMap = []keyGroup{
+	{title: "Navigation", bindings: []keyBinding{
+		{"Up/Down, k/j", "Move the cursor", nil},
+		{"PgUp/PgDn, Home/End", "Move by a page, to the first or last item", nil},
+		{"Right, l, Enter", "Open the item: service, entity set, entity, navigation", nil},
+		{"Left, h, Esc", "Go back a column", []string{"ESC:Back"}},
+		{"Ctrl+O", "Switch to another service", []string{"^O:Services"}},
+		{":, Ctrl+L", "Open a URL, or a path relative to the service such as Products(1)/Category?$expand=Products", []string{"^L:Open URL"}},
+		{"g", "Navigation history: return to any location visited", []string{"g:History"}},
+		{"[, Alt+Left", "Back to the location visited before, in any service", []string{"[/]:Back/Forward"}},
+		{"], Alt+Right", "Forward again to a location gone back from", nil},
+		{"Ctrl+R", "Reload the column, bypassing the cache", []string{"^R:Refresh"}},
+		{"Ctrl+Left/Right", "Widen / narrow the preview column; the layout is saved to the config file", nil},
+		{"Shift+Left/Right", "Narrow / widen the active column", nil},
+		{"x, Esc", "Cancel the running loads, export, import or batch submit; Esc only the load of the active column, while the other columns keep loading and can be browsed", nil},
+		{"r", "Retry a failed load or the failed operations of a bulk write", nil},
+		{"y", "Copy the URL of the column or the item under the cursor, or a curl command reading it", []string{"y:Copy URL"}},
+		{"B", "Copy the URL of the path shown under the header", []string{"B:Copy Path"}},
+		{"F1", "This help; in the query dialogs the cheat sheet", []string{"F1:Help"}},
+		{"q, F10, Ctrl+C", "Quit; in the modal editor only F10, as q is typed and Ctrl+C copies there", []string{"F10:Exit"}},
+	}},
+	{title: "Finding and querying", bindings: []keyBinding{
+		{"/", "Fuzzy find in the active column (Esc clears it)", []string{"/:Find"}},
+		{"S", "Full-text $search", []string{"S:Search"}},
+		{"F7", "Build a $filter", []string{"F7:Filter"}},
+		{"s", "Sort ($orderby)", []string{"s:Sort"}},
+		{"e", "Expand navigation properties ($expand)", []string{"e:Expand"}},
+		{"Q", "Edit $top, $skip, $filter, $orderby, $select, $expand and $format of the list; Enter reruns it with the panel open", []string{"Q:Query"}},
+		{"*", "Star an entity set, pinning it to the top", []string{"*:Star"}},
+		{"b", "Star the entity under the cursor or in Details; starred entities are listed with the starred sets, per service", []string{"b:Star Entity"}},
+	}},
+	{title: "Entity actions", bindings: []keyBinding{
+		{"F3", "Read the entity under the cursor", []string{"F3:Read"}},
+		{"F2 / F4 / F5", "Create / update / copy an entity", []string{"F2:Create", "F4:Update", "F5:Copy"}},
+		{"F8", "Delete the entity, or the marked ones (press twice)", []string{"F8:Delete"}},
+		{"Space", "Mark entities in a list; fold objects and arrays in Details", []string{"Space:Select/Fold"}},
+		{"E", "Edit and resubmit failed operations of a bulk write", nil},
+		{"C", "Stage creates, updates and deletes instead of sending them, or send them right away again", []string{"C:Stage"}},
+		{"c", "Pending changes: review the staged changes with their diffs, discard them or submit them together, optionally as one $batch changeset", []string{"c:Changes"}},
+	}},
+	{title: "Details and preview", bindings: []keyBinding{
+		{"Enter", "On a navigation property, follow it to where the preview column shows it leads; on a value cut short, show it in full (-max-value sets the length)", nil},
+		{"?", "Describe the property under the cursor; elsewhere show these keys", []string{"?:Keys/Property Info"}},
+		{"v / V", "Capture a property value as a session variable / list them", []string{"v:Capture"}},
+		{"D", "Show dates as ISO 8601 or as sent by the service", []string{"D:Dates"}},
+		{"w", "Wrap the long lines of Details instead of cutting them at the column width", []string{"w:Wrap"}},
+		{"p", "Hide or show the preview column; while hidden it loads nothing", []string{"p:Preview"}},
+	}},
+	{title: "Vim keymap (keymap \"vim\" in the config, -keymap vim or :set keymap=vim)", keymap: keymapVim, bindings: []keyBinding{
+		{"gg / G", "First / last item", []string{"gg/G:Top/Bottom"}},
+		{"dd", "Delete the entity, or the marked ones; dd again confirms", []string{"dd:Delete"}},
+		{"V", "Visual mode: mark the entities the cursor moves over; d deletes them, V or Esc keeps them marked", []string{"V:Visual"}},
+		{"gh / do", "Navigation history / download media, as g and d do otherwise", []string{"gh:History", "do:Download"}},
+		{":q", "Quit", []string{":q:Quit"}},
+	}},
+	{title: "Modal editor", editor: true, bindings: []keyBinding{
+		{"F2", "Save the entity; an update first shows what it changes, to send the whole entity or only the changed properties (the default with partial_updates in the config). When the entity changed on the server meanwhile (412/409), the server version, the edits and the merge show side by side to send again", []string{"F2:Save"}},
+		{"F1", "Describe the property on the cursor line", []string{"F1:Property Info"}},
+		{"Esc", "Close the editor, dropping the changes", []string{"ESC:Cancel"}},
+		{"F10", "Quit, dropping the changes", nil},
+		{"Tab", "After an opening quote, complete the property name; after the colon, a boolean or enumeration value. Elsewhere a tab", []string{"Tab:Complete"}},
+		{"Ctrl+E", "Switch between the JSON and a form with a field per property, made from the metadata; create, update and copy open in the form", []string{"^E:Form"}},
+		{"Ctrl+F", "Format the JSON", []string{"^F:Format"}},
+		{"Ctrl+C / Ctrl+X / Ctrl+V", "Copy / cut the selection, or the line without one, and paste from the system clipboard at the cursor", []string{"^V:Paste"}},
+		{"Shift+Arrows, Shift+Home/End", "Select text; typing replaces the selection", nil},
+		{"Ctrl+D / Ctrl+K", "Duplicate / delete the line", nil},
+		{"Alt+Up / Alt+Down", "Move the line up / down", nil},
+		{"Ctrl+T", "Turn auto-closing of brackets and quotes and auto-indent on or off", nil},
+		{"PgUp/PgDn, Home/End", "Move by a page, to the start or end of the line; Ctrl+Home/End to the first or last line", nil},
+	}},
+	{title: "Files", bindings: []keyBinding{
+		{"d / u / U", "Download media / upload media / create a media entity", []string{"d:Download", "u:Upload", "U:New Media"}},
+		{"X", "Export the list as CSV", []string{"X:CSV"}},
+		{"J", "Export the list or entity as JSON, all pages on request, optionally converted to OData V2 or V4 formats", []string{"J:JSON"}},
+		{"W", "Export the list, or every open list, as an Excel workbook", []string{"W:Excel"}},
+		{"I", "Import entities from a JSON array or CSV file", []string{"I:Import"}},
+	}},
+	{title: "Log pane", bindings: []keyBinding{
+		{"F9", "Show or hide the log", []string{"F9:Toggle Logs"}},
+		{"t / H", "Show request timings / request headers", []string{"t:Timings", "H:Headers"}},
+		{"F6", "Inspect the raw request and response behind the column", []string{"F6:Inspect"}},
+		{"L", "Cycle the log level", []string{"L:Log"}},
+	}},
+}
+
+// Layout of the Keys page of the help
+const (
+	keysColumn    = 20 // Width of the keys column
+	keysHelpWidth = 58 // Descriptions wrap at this width
+)
+
+// keysPage renders the key map for the help viewer
+func keysPage() string {
+	var b strings.Builder
+	b.WriteString("KEYS\n")
+	indent := strings.Repeat(" ", keysColumn+2)
+	for _, group := range keyMap {
+		b.WriteString("\n" + group.title + "\n")
+		for _, binding := range group.bindings {
+			lines := strings.Split(wordwrap.String(binding.help, keysHelpWidth), "\n")
+			fmt.Fprintf(&b, "  %-*s%s\n", keysColumn, binding.keys, lines[0])
+			for _, line := range lines[1:] {
+				b.WriteString(indent + line + "\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// footerKeys lists the footer entries of the main view, or of the modal
+// editor, for a key profile: the function keys in order, then the others
+// as the key map has them. Keys of the profile replace the keys doing the
+// same in every profile, function keys aside.
+func footerKeys(editor bool, keymap string) string {
+	replaced := make(map[string]bool)
+	for _, group := range keyMap {
+		if group.keymap != "" && group.keymap == keymap {
+			for _, binding := range group.bindings {
+				for _, entry := range binding.footer {
+					replaced[footerLabel(entry)] = true
+				}
+			}
+		}
+	}
+
+	var function, other []string
+	for _, group := range keyMap {
+		if group.editor != editor || (group.keymap != "" && group.keymap != keymap) {
+			continue
+		}
+		for _, binding := range group.bindings {
+			for _, entry := range binding.footer {
+				switch {
+				case functionKey(entry) > 0:
+					function = append(function, entry)
+				case group.keymap == "" && replaced[footerLabel(entry)]:
+				default:
+					other = append(other, entry)
+				}
+			}
+		}
+	}
+	sort.SliceStable(function, func(i, j int) bool { return functionKey(function[i]) < functionKey(function[j]) })
+	return strings.Join(function, " ") + " | " + strings.Join(other, " ")
+}
+
+// footerLabel returns what a footer entry such as "g:History" does
+func footerLabel(entry string) string {
+	return entry[strings.LastIndex(entry, ":")+1:]
+}
+
+// functionKey returns the number of the function key of a footer entry
+// such as "F10:Exit", 0 for other keys
+func functionKey(entry string) int {
+	key, _, _ := strings.Cut(entry, ":")
+	if !strings.HasPrefix(key, "F") {
+		return 0
+	}
+	n, _ := strconv.Atoi(key[1:])
+	return n
+}
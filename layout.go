@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Layout is how the width of the view is shared out, as fractions
+type Layout struct {
+	Preview float64 `json:"preview,omitempty"` // Of the whole width, for the preview column
+	Active  float64 `json:"active,omitempty"`  // Of the width left, for the active column
+}
+
+// Bounds and step of the layout fractions
+const (
+	minPreviewRatio = 0.1
+	maxPreviewRatio = 0.6
+	minActiveRatio  = 0.2
+	maxActiveRatio  = 0.6
+	layoutStep      = 0.05
+)
+
+// defaultLayout is the layout the navigator always had
+var defaultLayout = Layout{Preview: 0.3, Active: 0.4}
+
+// startLayout is the layout from the config file
+var startLayout = defaultLayout
+
+// configPath is the config file read at startup, where the layout is
+// saved; "" when there was none
+var configPath string
+
+// withDefaults fills in what the layout leaves out and keeps it in bounds
+func (l Layout) withDefaults() Layout {
+	if l.Preview == 0 {
+		l.Preview = defaultLayout.Preview
+	}
+	if l.Active == 0 {
+		l.Active = defaultLayout.Active
+	}
+	l.Preview = math.Min(math.Max(l.Preview, minPreviewRatio), maxPreviewRatio)
+	l.Active = math.Min(math.Max(l.Active, minActiveRatio), maxActiveRatio)
+	return l
+}
+
+// layoutSaveDelay is how long the layout stays as it is before it is saved,
+// so holding a resize key doesn't rewrite the config file on every step
+const layoutSaveDelay = time.Second
+
+// layoutSaveMsg saves the layout unless it changed again since the save was
+// scheduled
+type layoutSaveMsg struct {
+	seq int
+}
+
+// layoutSavedMsg carries the outcome of saving the layout
+type layoutSavedMsg struct {
+	layout Layout
+	err    error
+}
+
+// resizeLayout grows (step > 0) or shrinks the preview column or the
+// active column, and schedules saving the new layout to the config file
+func (m model) resizeLayout(preview bool, step float64) (model, tea.Cmd) {
+	l := m.layout
+	if preview {
+		l.Preview += step
+	} else {
+		l.Active += step
+	}
+	// Rounded, so steps back and forth end where they started
+	l.Preview = math.Round(l.Preview*100) / 100
+	l.Active = math.Round(l.Active*100) / 100
+	l = l.withDefaults()
+	if l == m.layout {
+		return m, nil
+	}
+	m.layout = l
+	m.updateColumnSizes()
+
+	if configPath == "" {
+		m.debugf("Layout: preview %.0f%%, active column %.0f%% (not saved - no config file)", l.Preview*100, l.Active*100)
+		return m, nil
+	}
+	m.layoutUnsaved = true
+	m.layoutSaveSeq++
+	seq := m.layoutSaveSeq
+	return m, tea.Tick(layoutSaveDelay, func(time.Time) tea.Msg { return layoutSaveMsg{seq: seq} })
+}
+
+// saveLayoutLater saves the layout once resizing pauses, outside the
+// update loop, as the config file may be locked by another instance
+func (m *model) saveLayoutLater(msg layoutSaveMsg) tea.Cmd {
+	if msg.seq != m.layoutSaveSeq || !m.layoutUnsaved {
+		return nil
+	}
+	m.layoutUnsaved = false
+	l := m.layout
+	return func() tea.Msg {
+		return layoutSavedMsg{layout: l, err: saveLayout(configPath, l)}
+	}
+}
+
+// layoutSaved reports the outcome of saving the layout
+func (m *model) layoutSaved(msg layoutSavedMsg) {
+	if msg.err != nil {
+		m.logs = append(m.logs, fmt.Sprintf("ERROR [layout]: %v", msg.err))
+		return
+	}
+	m.debugf("Layout: preview %.0f%%, active column %.0f%%, saved to %s", msg.layout.Preview*100, msg.layout.Active*100, configPath)
+}
+
+// saveUnsavedLayout saves a layout still waiting to be saved when the
+// navigator ends
+func saveUnsavedLayout(final tea.Model) {
+	if m, ok := final.(model); ok && m.layoutUnsaved && configPath != "" {
+		if err := saveLayout(configPath, m.layout); err != nil {
+			fmt.Fprintf(os.Stderr, "Saving the layout failed: %v\n", err)
+		}
+	}
+}
+
+// saveLayout sets the layout in a config file. Only the value of its
+// "layout" key is written, so the rest of the file keeps its order,
+// indentation and anything hand-edited in it.
+func saveLayout(path string, l Layout) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	unlock, err := lockState(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	value, err := json.MarshalIndent(l, "  ", "  ")
+	if err != nil {
+		return err
+	}
+	out, err := setJSONKey(data, "layout", value)
+	if err != nil {
+		return fmt.Errorf("%s is not valid JSON: %v", path, err)
+	}
+	return writeFileAtomic(path, out, 0o600)
+}
+
+// setJSONKey replaces the value of a key of the JSON object in data, or
+// adds the key after the last one, leaving the other bytes as they are
+func setJSONKey(data []byte, key string, value []byte) ([]byte, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return []byte("{\n  " + strconv.Quote(key) + ": " + string(value) + "\n}\n"), nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if t, err := dec.Token(); err != nil {
+		return nil, err
+	} else if t != json.Delim('{') {
+		return nil, errors.New("not a JSON object")
+	}
+	end := -1 // End of the last value
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		end = int(dec.InputOffset())
+		if t == key {
+			start := end - len(raw)
+			return append(append(append([]byte(nil), data[:start]...), value...), data[end:]...), nil
+		}
+	}
+	closing, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if closing != json.Delim('}') {
+		return nil, errors.New("not a JSON object")
+	}
+	entry := "\n  " + strconv.Quote(key) + ": " + string(value)
+	if end == -1 {
+		// An empty object: the key goes right after its brace
+		end = bytes.IndexByte(data, '{') + 1
+		entry += "\n"
+	} else {
+		entry = "," + entry
+	}
+	return append(append(append([]byte(nil), data[:end]...), entry...), data[end:]...), nil
+}
+
+// updateLayoutKey handles the keys resizing the columns: Ctrl+Left/Right
+// resize the preview and Shift+Left/Right the active column. It returns
+// false for other keys.
+func (m model) updateLayoutKey(key string) (model, tea.Cmd, bool) {
+	var cmd tea.Cmd
+	switch key {
+	case "ctrl+left":
+		// The preview grows to the left
+		m, cmd = m.resizeLayout(true, layoutStep)
+	case "ctrl+right":
+		m, cmd = m.resizeLayout(true, -layoutStep)
+	case "shift+right":
+		m, cmd = m.resizeLayout(false, layoutStep)
+	case "shift+left":
+		m, cmd = m.resizeLayout(false, -layoutStep)
+	default:
+		return m, nil, false
+	}
+	return m, cmd, true
+}
+
+// previewToggled gives the width of the preview column to the other
+// columns once p hid it, or shows it again with the item under the cursor
+func (m model) previewToggled() (tea.Model, tea.Cmd) {
+	m.updateColumnSizes()
+	if m.preview.hidden {
+		m.logs = append(m.logs, "Preview hidden - p shows it again")
+		return m, nil
+	}
+	m.logs = append(m.logs, "Preview shown")
+	return m, m.updatePreview()
+}
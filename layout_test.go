@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetJSONKey(t *testing.T) {
+	value := []byte(`{"preview": 0.35}`)
+	tests := []struct {
+		name string
+		data string
+		want string
+		err  bool
+	}{
+		{"replaced", "{\n  \"services\": [],\n  \"layout\": {\"preview\": 0.3}, \"theme\": \"dark\"\n}\n", "{\n  \"services\": [],\n  \"layout\": {\"preview\": 0.35}, \"theme\": \"dark\"\n}\n", false},
+		{"replaced number", `{"layout":1}`, `{"layout":{"preview": 0.35}}`, false},
+		{"nested key left alone", `{"theme": {"layout": 1}}`, "{\"theme\": {\"layout\": 1},\n  \"layout\": {\"preview\": 0.35}}", false},
+		{"added after the last key", "{\n  \"keymap\": \"vim\"\n}\n", "{\n  \"keymap\": \"vim\",\n  \"layout\": {\"preview\": 0.35}\n}\n", false},
+		{"empty object", "{}", "{\n  \"layout\": {\"preview\": 0.35}\n}", false},
+		{"no file", "", "{\n  \"layout\": {\"preview\": 0.35}\n}\n", false},
+		{"not an object", "[1]", "", true},
+		{"broken", `{"keymap": `, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := setJSONKey([]byte(tt.data), "layout", value)
+			if (err != nil) != tt.err {
+				t.Fatalf("err = %v, want error %v", err, tt.err)
+			}
+			if !tt.err && string(got) != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveLayoutKeepsConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "odatanavigator.json")
+	config := "{\n  \"services\": [\n    {\"name\": \"Northwind\", \"url\": \"https://services.odata.org/V2/Northwind/Northwind.svc/\"}\n  ],\n  \"keymap\": \"vim\"\n}\n"
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveLayout(path, Layout{Preview: 0.35, Active: 0.4}); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveLayout(path, Layout{Preview: 0.4, Active: 0.45}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := config[:len(config)-3] + ",\n  \"layout\": {\n    \"preview\": 0.4,\n    \"active\": 0.45\n  }\n}\n"
+	if string(data) != want {
+		t.Errorf("config file\n%s\nwant\n%s", data, want)
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// logLevel is the severity of a log line
+type logLevel int
+
+const (
+	levelDebug logLevel = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+var logLevelNames = []string{"debug", "info", "warn", "error"}
+
+func (l logLevel) String() string {
+	return logLevelNames[l]
+}
+
+// parseLogLevel reads a level name as given to -log-level
+func parseLogLevel(name string) (logLevel, bool) {
+	for i, levelName := range logLevelNames {
+		if strings.EqualFold(name, levelName) {
+			return logLevel(i), true
+		}
+	}
+	return levelInfo, false
+}
+
+// DefaultLogRetention is how many log lines are kept unless -log-lines says otherwise
+const DefaultLogRetention = 1000
+
+// logRetention is how many log lines are kept, 0 to keep them all
+var logRetention = DefaultLogRetention
+
+// startLogLevel is the lowest level the log pane shows at startup
+var startLogLevel = levelInfo
+
+// levelOf tells the level of a log line from the prefix it is written with:
+// "ERROR [...]" or "Error ...", "WARNING: ..." and "DEBUG: ...". Everything
+// else is info.
+func levelOf(line string) logLevel {
+	upper := strings.ToUpper(line)
+	switch {
+	case strings.HasPrefix(upper, "ERROR"):
+		return levelError
+	case strings.HasPrefix(upper, "WARN"):
+		return levelWarn
+	case strings.HasPrefix(upper, "DEBUG:"):
+		return levelDebug
+	}
+	return levelInfo
+}
+
+// debugf logs verbose tracing of what the navigator does, shown only at
+// the debug level
+func (m *model) debugf(format string, args ...interface{}) {
+	m.logs = append(m.logs, "DEBUG: "+fmt.Sprintf(format, args...))
+}
+
+// trimLogs drops the oldest log lines beyond the retention
+func (m *model) trimLogs() {
+	if logRetention > 0 && len(m.logs) > logRetention {
+		m.logs = m.logs[len(m.logs)-logRetention:]
+	}
+}
+
+// logPane is the pane under the columns: the log lines at the level it
+// shows, or the request timings or the headers sent instead, under the
+// status of the running transfer, operation or loads
+type logPane struct {
+	visible bool     // F9 shows or hides the pane
+	trace   bool     // Request timings instead of the log
+	headers bool     // Headers sent with every request instead of the log
+	level   logLevel // Lowest level of the log lines shown
+}
+
+// logPaneView is what the log pane draws besides its own state
+type logPaneView struct {
+	width, height int
+	logs          []string
+	headers       []string // Headers sent with every request, nil when not connected
+	status        []string // Running transfer, operation and loads, drawn under the lines
+}
+
+// Update handles the keys of the log pane: F9 shows or hides it, t switches
+// it to the request timings, H to the headers sent and L raises the lowest
+// level it shows, wrapping from error back to debug. It returns false for
+// other keys.
+func (p logPane) Update(msg tea.KeyMsg) (logPane, bool) {
+	switch msg.String() {
+	case "f9":
+		p.visible = !p.visible
+	case "t":
+		p.trace = !p.trace
+		if p.trace {
+			p.visible = true
+			p.headers = false
+		}
+	case "H":
+		p.headers = !p.headers
+		if p.headers {
+			p.visible = true
+			p.trace = false
+		}
+	case "L":
+		p.level = (p.level + 1) % logLevel(len(logLevelNames))
+	default:
+		return p, false
+	}
+	return p, true
+}
+
+// visibleLines returns the log lines at or above the level shown and how
+// many lines it hides
+func (p logPane) visibleLines(logs []string) ([]string, int) {
+	var lines []string
+	for _, line := range logs {
+		if levelOf(line) >= p.level {
+			lines = append(lines, line)
+		}
+	}
+	return lines, len(logs) - len(lines)
+}
+
+// renderLogLine colors a log line by its level
+func renderLogLine(line string) string {
+	switch levelOf(line) {
+	case levelError:
+		return lipgloss.NewStyle().Foreground(theme.Error).Render(line)
+	case levelWarn:
+		return lipgloss.NewStyle().Foreground(theme.Warning).Render(line)
+	case levelDebug:
+		return lipgloss.NewStyle().Foreground(theme.Muted).Render(line)
+	}
+	return line
+}
+
+// View draws the log pane: the last lines that fit, followed by the status
+// lines
+func (p logPane) View(v logPaneView) string {
+	logStyle := lipgloss.NewStyle().
+		Width(v.width).
+		Height(v.height).
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(theme.Border)
+
+	lines, hidden := p.visibleLines(v.logs)
+	if hidden > 0 {
+		lines = append([]string{fmt.Sprintf("DEBUG: %d lines below %s hidden (L: log level)", hidden, p.level)}, lines...)
+	}
+	if p.trace {
+		lines = []string{"Request timings (t: back to log)"}
+		for _, t := range requestTraces.Snapshot() {
+			lines = append(lines, formatTrace(t))
+		}
+	}
+	if p.headers {
+		lines = []string{"Headers sent with every request (H: back to log)"}
+		if v.headers == nil {
+			lines = append(lines, "Not connected to a service")
+		} else {
+			lines = append(lines, v.headers...)
+		}
+	}
+
+	// Get last N log entries that fit in the height
+	startIdx := 0
+	if len(lines) > v.height-2 { // -2 for border
+		startIdx = len(lines) - (v.height - 2)
+	}
+
+	var logLines []string
+	for i := startIdx; i < len(lines); i++ {
+		logLines = append(logLines, renderLogLine(redactText(lines[i])))
+	}
+
+	content := strings.Join(logLines, "\n")
+	for _, status := range v.status {
+		content += "\n[" + status + "]"
+	}
+	return logStyle.Render(content)
+}
+
+// renderLogs draws the log pane with the status of what is running
+func (m model) renderLogs(height int) string {
+	v := logPaneView{width: m.width, height: height, logs: m.logs}
+	if m.odata != nil {
+		v.headers = m.odata.RequestHeaders()
+	}
+	if m.transfer != nil {
+		v.status = append(v.status, m.transfer.status())
+	}
+	cancel := "x: Cancel"
+	if m.escCancels() {
+		cancel = "ESC/x: Cancel"
+	}
+	if m.progress != nil {
+		v.status = append(v.status, m.progress.status()+" | "+cancel)
+	}
+	if m.busy() && (m.progress == nil || m.loadingColumns() > 0) {
+		if n := m.loadingColumns(); n > 1 {
+			v.status = append(v.status, fmt.Sprintf("%s Loading %d columns... %s all", m.spinner(), n, cancel))
+		} else {
+			v.status = append(v.status, m.spinner()+" Loading... "+cancel)
+		}
+	}
+	return m.logPane.View(v)
+}
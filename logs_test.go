@@ -0,0 +1,70 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestLogPaneUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		pane    logPane
+		key     tea.KeyMsg
+		want    logPane
+		handled bool
+	}{
+		{"F9 hides", logPane{visible: true}, tea.KeyMsg{Type: tea.KeyF9}, logPane{}, true},
+		{"t shows timings", logPane{headers: true}, keyRunes("t"), logPane{visible: true, trace: true}, true},
+		{"H shows headers", logPane{visible: true, trace: true}, keyRunes("H"), logPane{visible: true, headers: true}, true},
+		{"H back to log", logPane{visible: true, headers: true}, keyRunes("H"), logPane{visible: true}, true},
+		{"L raises level", logPane{level: levelWarn}, keyRunes("L"), logPane{level: levelError}, true},
+		{"L wraps to debug", logPane{level: levelError}, keyRunes("L"), logPane{level: levelDebug}, true},
+		{"other keys", logPane{visible: true}, keyRunes("x"), logPane{visible: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, handled := tt.pane.Update(tt.key)
+			if got != tt.want || handled != tt.handled {
+				t.Errorf("Update = %+v, %v; want %+v, %v", got, handled, tt.want, tt.handled)
+			}
+		})
+	}
+}
+
+func TestLogPaneView(t *testing.T) {
+	logs := []string{"DEBUG: request sent", "Loaded 3 entity sets", "WARNING: slow", "ERROR [load]: HTTP 500"}
+	tests := []struct {
+		name    string
+		pane    logPane
+		headers []string
+		want    []string
+		hidden  []string
+	}{
+		{"info level", logPane{level: levelInfo}, nil, []string{"1 lines below info hidden", "Loaded 3 entity sets", "ERROR [load]"}, []string{"request sent"}},
+		{"error level", logPane{level: levelError}, nil, []string{"ERROR [load]"}, []string{"Loaded 3", "WARNING"}},
+		{"headers", logPane{headers: true}, []string{"User-Agent: odatanavigator"}, []string{"Headers sent", "User-Agent: odatanavigator"}, []string{"Loaded 3"}},
+		{"headers offline", logPane{headers: true}, nil, []string{"Not connected to a service"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			view := tt.pane.View(logPaneView{width: 80, height: 10, logs: logs, headers: tt.headers, status: []string{"Loading..."}})
+			for _, want := range append(tt.want, "[Loading...]") {
+				if !strings.Contains(view, want) {
+					t.Errorf("view misses %q:\n%s", want, view)
+				}
+			}
+			for _, hidden := range tt.hidden {
+				if strings.Contains(view, hidden) {
+					t.Errorf("view shows %q:\n%s", hidden, view)
+				}
+			}
+		})
+	}
+}
+
+// keyRunes is the key press of typing s
+func keyRunes(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
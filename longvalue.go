@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+// DefaultMaxValueLength is how many characters of a string value Details
+// and previews show unless -max-value says otherwise
+const DefaultMaxValueLength = 200
+
+// maxValueLength is how many characters of a string value are shown before
+// it is cut short, 0 to show values whole
+var maxValueLength = DefaultMaxValueLength
+
+// truncateValue cuts a string to maxValueLength characters, returning how
+// many characters were left out
+func truncateValue(s string) (string, int) {
+	if maxValueLength <= 0 || len(s) <= maxValueLength {
+		return s, 0
+	}
+	runes := []rune(s)
+	if len(runes) <= maxValueLength {
+		return s, 0
+	}
+	return string(runes[:maxValueLength]), len(runes) - maxValueLength
+}
+
+// formatCount writes a number with thousands separators, as 12,480
+func formatCount(n int) string {
+	digits := strconv.Itoa(n)
+	for i := len(digits) - 3; i > 0; i -= 3 {
+		digits = digits[:i] + "," + digits[i:]
+	}
+	return digits
+}
+
+// valueViewer is the state of the overlay showing a long string value in
+// full, wrapped to its width
+type valueViewer struct {
+	active bool
+	name   string // Path of the value in its entity
+	value  string
+	scroll int
+}
+
+// openValueViewer shows a value that Details cut short
+func (m *model) openValueViewer(name, value string) {
+	m.valueViewer = valueViewer{active: true, name: name, value: value}
+}
+
+// valueViewerWidth is the number of cells the lines of the viewer wrap at
+func (m model) valueViewerWidth() int {
+	return max(min(120, m.width-4)-4, 10)
+}
+
+// valueViewerHeight is the number of lines the viewer shows
+func (m model) valueViewerHeight() int {
+	return max(m.height-10, 3)
+}
+
+// valueLines wraps the value at the width of the viewer, keeping its own
+// line breaks
+func (m model) valueLines() []string {
+	return wrapText(m.valueViewer.value, m.valueViewerWidth())
+}
+
+// wrapText breaks text into lines of at most width cells, keeping its own
+// line breaks
+func wrapText(text string, width int) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.ReplaceAll(line, "\t", "    ")
+		for runewidth.StringWidth(line) > width {
+			cut := runewidth.Truncate(line, width, "")
+			if cut == "" {
+				break
+			}
+			lines = append(lines, cut)
+			line = line[len(cut):]
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// updateValueViewer handles key presses while a long value is shown
+func (m model) updateValueViewer(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	v := &m.valueViewer
+	last := max(len(m.valueLines())-m.valueViewerHeight(), 0)
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "enter", "q":
+		v.active = false
+	case "up", "k":
+		v.scroll = max(v.scroll-1, 0)
+	case "down", "j":
+		v.scroll = min(v.scroll+1, last)
+	case "pgup":
+		v.scroll = max(v.scroll-m.valueViewerHeight(), 0)
+	case "pgdown", " ":
+		v.scroll = min(v.scroll+m.valueViewerHeight(), last)
+	case "home":
+		v.scroll = 0
+	case "end":
+		v.scroll = last
+	case "y":
+		copyToClipboard(v.value)
+		m.logs = append(m.logs, fmt.Sprintf("Copied %s (%s chars) to the clipboard", v.name, formatCount(len([]rune(v.value)))))
+	}
+	return m, nil
+}
+
+// renderValueViewer renders the value shown from its scroll position
+func (m model) renderValueViewer() string {
+	v := m.valueViewer
+	hint := lipgloss.NewStyle().Foreground(theme.Muted)
+
+	lines := m.valueLines()
+	end := min(v.scroll+m.valueViewerHeight(), len(lines))
+	position := ""
+	if len(lines) > m.valueViewerHeight() {
+		position = hint.Render(fmt.Sprintf(" | Line %d of %d", end, len(lines)))
+	}
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Background(theme.Accent).
+		Foreground(theme.AccentText).
+		Padding(0, 1).
+		Render(v.name)
+
+	content := title + hint.Render(fmt.Sprintf("  %s chars", formatCount(len([]rune(v.value))))) + "\n\n" +
+		strings.Join(lines[v.scroll:end], "\n") + "\n\n" +
+		hint.Render("Up/Down/PgUp/PgDn: Scroll | y: Copy | ESC: Close") + position
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(theme.Accent).
+		Padding(0, 1).
+		Width(min(120, m.width-4)).
+		Render(content)
+}
@@ -6,7 +6,10 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -23,35 +26,112 @@ type column struct {
 	entities  []map[string]interface{} // Store actual entity data
 	isDetails bool                     // Flag to indicate if this is a details column
 	isPreview bool                     // Flag to indicate if this is a preview column
+	entitySet string                   // Entity set shown by an entities column
+	query     QueryOptions             // Active $filter, $orderby, $expand, ... of an entities column
+	openSections map[string]bool       // Objects and arrays opened or collapsed in a details column, by path
+	isResult  bool                     // Flag to indicate if this is an operation result column
+	results   []*OperationResult       // Operation results shown by a result column
+	resultLines []int                  // Index into results for each item of a result column, -1 for none
+	report      *batchReport           // Bulk write shown by a result column, for retrying failures
+	reselect    string                 // Key of the entity the cursor returns to once the list reloads after a write
+	find        string                 // Fuzzy find query narrowing the items shown ("/")
+	navURL    string                   // URL of the navigation property shown by the column
+	hasMore   bool                     // More entities are available on the server
+	nextLink  string                   // Server-driven paging link for the next page
+	selected  map[int]bool             // Entities marked with space in an entities column
+	raw       *ParseError              // Unparseable response shown by a raw response column
+	counted   bool                     // total holds the number of entities on the server
+	failure   string                   // Error of the failed load the column shows, retried with "r"
+	total     int
+	id        int                      // Routes the responses of its loads to it, 0 for columns that load nothing
+	state     columnState
+	loadStarted time.Time              // When its running load started, for the elapsed time it shows
 }
 
 type model struct {
 	columns        []column
 	activeColumn   int
-	previewColumn  *column  // Always-present preview column
+	preview        previewPane // Preview column at the right
+	wrapDetails    bool     // "w" wraps the long lines of Details columns instead of cutting them
 	width          int
 	height         int
 	odata          *ODataService
 	loading        bool
 	logs           []string
-	showLogs       bool
+	logPane        logPane // Log, request timings or headers under the columns
 	services       []ServiceConfig
 	serviceIndex   int
-	editMode       bool
-	editContent    []string
-	editCursor     int     // Current cursor position in edit mode
-	previewLoading bool
+	inlineEdit     inlineEditor // Edit mode of a Details column
 	modalEditor    bool    // Modal editor mode
-	modalContent   []string // Content being edited in modal
-	modalCursor    int     // Cursor position in modal (line)
-	modalScroll    int     // Scroll offset in modal
-	modalColCursor int     // Column cursor position within line
-	modalOperation string  // Type of operation: "create", "update", "copy"
+	editor         textEditor // Text of the modal editor
+	editorPlain    bool    // ^T turned off auto-closing and auto-indent in the modal editor
+	editorBuffered bool    // The modal editor text is kept in the temp directory of the instance
+	modalOperation string  // Type of operation: "create", "update", "copy", "bulkupdate"
+	form           entityForm // Field-by-field view of the entity in the modal editor
+	jsonProblem    *jsonProblem // Where the text of the modal editor stops parsing, nil when it parses
+	jsonCheckSeq   int          // Latest check of the modal editor text scheduled, to skip outdated ones
+	completionHint string       // Tab completions, or a warning, shown in the status line of the modal editor
+	unknownWarned  string       // Modal editor text already warned about for unknown properties
+	editorClipboard string      // Text last copied or cut in the modal editor, pasted when the clipboard can't be read
+	modalKeyFields []string // Properties highlighted in the editor: the keys of a copy, the required ones of a create
+	modalSourceKeys map[string]interface{} // Key values of the entity being copied
+	filterDialog   filterBuilder // F7 $filter builder overlay
+	sortDialog     sortPicker    // $orderby picker overlay
+	expandDialog   expandPicker  // $expand picker overlay
+	uploadDialog   uploadPrompt  // File prompt of a media upload
+	searchDialog   searchPrompt  // "S" search term prompt
+	finding        bool          // A "/" find query is being typed into the active column
+	csvExport      csvExportDialog // "X" CSV export options, kept for the session
+	propDoc        propertyDoc   // ? popup describing the property under the cursor (F1 in the modal editor)
+	help           helpViewer    // F1 keys, quick start and query cheat sheet
+	copyMenu       copyMenu      // "y" choice of a URL or curl command to copy
+	jsonExport     jsonExportDialog // "J" export of entities as JSON
+	xlsxExport     xlsxExportDialog // "W" export of entity lists as an Excel workbook
+	importDialog   importDialog     // "I" creation of entities from a JSON or CSV file
+	valueViewer    valueViewer      // Long string value shown in full from Details
+	inspector      inspector        // F6 raw request and response of the active column
+	addressBar     addressBar       // ":" / ctrl+l prompt opening any OData URL
+	queryPanel     queryPanel       // "Q" query options of an entity list, rerun on enter
+	history        navHistory    // Locations visited in the service, shown with "g"
+	visits         []visit       // Locations visited in any service, in order, for "[" and "]"
+	visitPos       int           // Entry of visits shown
+	keepAliveSeq   int           // Heartbeat running, so one replaced by a reconnect ends
+	downloadDialog downloadPrompt // File prompt of a download
+	switcher       serviceSwitcher // ctrl+o overlay connecting to another service
+	variables      map[string]string // Session variables captured with "v", used as {{Name}}
+	confirmDelete  bool              // F8 was pressed once and waits for confirmation
+	staging        bool              // "C" queues creates, updates and deletes instead of sending them
+	staged         []stagedChange    // Writes waiting to be submitted from the pending changes
+	stagedSeq      int               // Last ID handed out to a staged change
+	pending        pendingPanel      // "c" review of the staged changes
+	review         updateReview      // Changes of an update from the modal editor, confirmed before sending
+	conflict       conflictView      // Update refused as the entity changed on the server, merged to retry
+	confirmInsecure string           // Plain HTTP service waiting for Enter to send credentials anyway
+	insecureAccepted map[string]bool // Plain HTTP services whose credentials may be sent this session
+	favorites      map[string][]string // Starred entity sets and entities by service URL, pinned to the top
+	setCounts      map[string]int      // Entity set sizes by $count URL, -1 when not countable
+	pendingLink    *DeepLink           // Start location still being opened
+	transfer       *transferState    // Running $value download or upload, nil when idle
+	progress       *progressState    // Running export, bulk create, deep read or batch submit, nil when idle
+	spinnerFrame   int               // Frame of the spinner in the titles of loading columns
+	spinning       bool              // The spinner ticks, as long as something loads
+	lastColumnID   int               // Last ID handed out by newColumnID
+	keymap         string            // Key profile: keymapDefault or keymapVim
+	layout         Layout            // Shares of the preview and active column, resized with ctrl/shift+left/right
+	layoutSaveSeq  int               // Latest save of the layout scheduled, to skip outdated ones
+	layoutUnsaved  bool              // The layout changed since it was last saved
+	vim            vimState          // Pending command and visual mode of the vim profile
 }
 
 func initialModel() model {
 	// Load configuration
 	services := LoadConfig()
+	logs := []string{"Application started"}
+	if firstRun {
+		var note string
+		services, note = runSetupWizard(services)
+		logs = append(logs, note)
+	}
 	
 	// Start with service selection
 	firstColumn := column{
@@ -62,7 +142,7 @@ func initialModel() model {
 	}
 	
 	// Initialize preview column
-	previewCol := &column{
+	previewCol := column{
 		title:     "Preview",
 		items:     []string{"Select a service to preview entity sets"},
 		cursor:    0,
@@ -73,27 +153,37 @@ func initialModel() model {
 	return model{
 		columns:       []column{firstColumn},
 		activeColumn:  0,
-		previewColumn: previewCol,
+		preview:       previewPane{column: previewCol},
 		loading:       false,
-		logs:          []string{"Application started"},
-		showLogs:      true,
+		logs:          logs,
+		logPane:       logPane{visible: true, level: startLogLevel},
+		keymap:        startKeymap,
+		layout:        startLayout,
 		services:      services,
 		serviceIndex:  -1,
+		favorites:     loadFavorites(),
+		pendingLink:   startLink,
 	}
 }
 
-type entitySetsMsg []string
-type entitiesMsg struct {
-	entitySet string
-	entities  []map[string]interface{}
-	hasMore   bool
+type entitySetsMsg struct {
+	column int // ID of the EntitySets column
+	names  []string
 }
-type previewMsg struct {
-	previewType string // "entitysets", "entities", "json"
-	data        interface{}
-	errorMsg    string
+type entitiesMsg struct {
+	column     int // ID of the column the entities were loaded for
+	entitySet  string
+	entities   []map[string]interface{}
+	hasMore    bool
+	nextLink   string // Server-driven paging link for the following page
+	appendPage bool   // Append to the loaded entities instead of replacing them
+	navURL     string // Set when the entities were read via a navigation property
+	counted    bool   // The server sent the total number of entities
+	total      int
+	preferenceApplied string // Preference-Applied header of the response
 }
 type entityDetailMsg struct {
+	column    int // ID of the Details column
 	entitySet string
 	entityKey string
 	entity    map[string]interface{}
@@ -102,223 +192,184 @@ type saveSuccessMsg struct {
 	operation string
 	entitySet string
 	message   string
+	result    *OperationResult
+}
+type bulkCreateResult struct {
+	entity map[string]interface{}
+	result *OperationResult
+	err    error
+}
+type bulkCreateMsg struct {
+	entitySet string
+	results   []bulkCreateResult
 }
 type errorMsg struct {
 	err     string
 	context string
+	raw     *ParseError // Set when the response arrived but couldn't be parsed
+	column  int         // ID of the column whose load failed, 0 when no column waits for it
 }
 
 func (m model) Init() tea.Cmd {
 	// Trigger initial preview update  
+	if m.pendingLink != nil {
+		return tea.Batch(m.updatePreview(), func() tea.Msg { return deepLinkMsg{} })
+	}
 	return m.updatePreview()
 }
 
-func loadEntitySets(odata *ODataService) tea.Cmd {
+func loadEntitySets(odata *ODataService, column int) tea.Cmd {
+	odata = odata.forColumn(column)
 	return func() tea.Msg {
+		defer odata.loaded()
 		entitySets, err := odata.GetEntitySets()
 		if err != nil {
-			return errorMsg{err: err.Error(), context: "loadEntitySets"}
+			return newErrorMsg(err, "loadEntitySets").forColumn(column)
 		}
-		return entitySetsMsg(entitySets)
+		return entitySetsMsg{column: column, names: entitySets}
 	}
 }
 
-func loadEntities(odata *ODataService, entitySet string) tea.Cmd {
+// loadEntities fetches the first page of an entity list column with its filter and sort order
+func loadEntities(odata *ODataService, col column) tea.Cmd {
+	odata = odata.forColumn(col.id)
 	return func() tea.Msg {
-		entities, hasMore, err := odata.GetEntitiesWithCount(entitySet, 10) // Default to 10 entities
+		defer odata.loaded()
+		page, err := odata.GetCountedPage(col.resource(), col.query) // Default to 10 entities
+		if err != nil {
+			return newErrorMsg(err, fmt.Sprintf("loadEntities(%s)", col.entitySet)).forColumn(col.id)
+		}
+		return entitiesMsg{column: col.id, entitySet: col.entitySet, entities: page.Entities, hasMore: page.HasMore, nextLink: page.NextLink, navURL: col.navURL, counted: page.Count >= 0, total: page.Count, preferenceApplied: page.PreferenceApplied}
+	}
+}
+
+// loadMoreEntities fetches the page following the entities already loaded in
+// col, using the server's next link when it paged on its own and $skip otherwise
+func loadMoreEntities(odata *ODataService, col column) tea.Cmd {
+	odata = odata.forColumn(col.id)
+	return func() tea.Msg {
+		defer odata.loaded()
+		var page *EntityPage
+		var err error
+		if col.nextLink != "" {
+			page, err = odata.GetNextPage(col.nextLink, col.query)
+		} else {
+			opts := col.query
+			opts.Skip = col.query.Skip + len(col.entities)
+			page, err = odata.GetEntitiesPage(col.resource(), opts)
+		}
 		if err != nil {
-			return errorMsg{err: err.Error(), context: fmt.Sprintf("loadEntities(%s)", entitySet)}
+			return newErrorMsg(err, fmt.Sprintf("loadMoreEntities(%s)", col.entitySet)).forColumn(col.id)
 		}
-		return entitiesMsg{entitySet: entitySet, entities: entities, hasMore: hasMore}
+		return entitiesMsg{column: col.id, entitySet: col.entitySet, entities: page.Entities, hasMore: page.HasMore, nextLink: page.NextLink, appendPage: true, navURL: col.navURL, counted: page.Count >= 0, total: page.Count, preferenceApplied: page.PreferenceApplied}
 	}
 }
 
-func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case entitySetsMsg:
-		m.loading = false
-		m.logs = append(m.logs, fmt.Sprintf("Loaded %d entity sets", len(msg)))
-		
-		// Find the EntitySets column and update it
-		for i := range m.columns {
-			if m.columns[i].title == "EntitySets" {
-				m.columns[i].items = []string{}
-				
-				// Add $metadata as first entry
-				m.columns[i].items = append(m.columns[i].items, "$metadata [META]")
-				
-				for _, entitySet := range msg {
-					capabilities := GetEntitySetCapabilities(entitySet)
-					displayText := fmt.Sprintf("%s %s", entitySet, capabilities.String())
-					m.columns[i].items = append(m.columns[i].items, displayText)
-				}
-				if len(m.columns[i].items) == 1 { // Only $metadata
-					m.columns[i].items = append(m.columns[i].items, "(No entity sets)")
-				}
-				break
-			}
-		}
+		return m.applyEntitySets(msg)
+
+	case deepLinkMsg:
+		return m.openLinkService()
+
+	case entitySetCountMsg:
+		m.applyEntitySetCount(msg)
 
 	case entitiesMsg:
 		m.loading = false
-		m.logs = append(m.logs, fmt.Sprintf("Loaded %d entities from %s", len(msg.entities), msg.entitySet))
+		m.debugf("Loaded %d entities from %s", len(msg.entities), msg.entitySet)
+		if msg.preferenceApplied != "" {
+			m.logs = append(m.logs, fmt.Sprintf("%s: server applied %s", msg.entitySet, msg.preferenceApplied))
+		}
 		
-		// Find the column with matching title
-		for i := range m.columns {
-			if m.columns[i].title == msg.entitySet || m.columns[i].title == "Metadata" {
-				m.columns[i].entities = msg.entities
-				
-				// Handle metadata specially
-				if msg.entitySet == "Metadata" && len(msg.entities) > 0 {
-					if metadataStr, ok := msg.entities[0]["metadata"].(string); ok {
-						// Format metadata for better display with word wrapping
-						m.columns[i].items = formatMetadataForDisplay(metadataStr, m.columns[i].width-4) // Account for borders and padding
-					} else {
-						m.columns[i].items = []string{"Error: Could not parse metadata"}
-					}
-				} else {
-					// Regular entity list
-					m.columns[i].items = []string{}
-					for _, entity := range msg.entities {
-						m.columns[i].items = append(m.columns[i].items, formatEntityForDisplay(entity))
-					}
-					// Add "more" indicator if truncated
-					if msg.hasMore {
-						m.columns[i].items = append(m.columns[i].items, "[...more items]")
-					}
-					if len(m.columns[i].items) == 0 {
-						m.columns[i].items = []string{"(No items)"}
-					}
-				}
-				break
-			}
+		// The column may have been closed while its entities were loading
+		if col := m.columnByID(msg.column); col != nil {
+			*col, _ = col.Update(msg)
+			m.reselectEntity(col)
 		}
 
 	case previewMsg:
-		m.previewLoading = false
-		if m.previewColumn != nil {
-			if msg.errorMsg != "" {
-				m.previewColumn.items = []string{fmt.Sprintf("Error: %s", msg.errorMsg)}
-			} else {
-				switch msg.previewType {
-				case "entitysets":
-					if entitySets, ok := msg.data.([]string); ok {
-						m.previewColumn.title = "EntitySets Preview"
-						m.previewColumn.items = []string{}
-						for _, es := range entitySets {
-							caps := GetEntitySetCapabilities(es)
-							m.previewColumn.items = append(m.previewColumn.items, fmt.Sprintf("%s %s", es, caps.String()))
-						}
-					}
-				case "entities":
-					if entities, ok := msg.data.([]map[string]interface{}); ok {
-						m.previewColumn.title = "Entities Preview"
-						m.previewColumn.items = []string{}
-						for _, entity := range entities {
-							m.previewColumn.items = append(m.previewColumn.items, formatEntityForDisplay(entity))
-						}
-						m.previewColumn.entities = entities
-					}
-				case "json":
-					if entityData, ok := msg.data.(map[string]interface{}); ok {
-						m.previewColumn.title = "JSON Preview"
-						jsonData, err := json.MarshalIndent(entityData, "", "  ")
-						if err != nil {
-							m.previewColumn.items = []string{fmt.Sprintf("Error formatting JSON: %v", err)}
-						} else {
-							m.previewColumn.items = strings.Split(string(jsonData), "\n")
-						}
-					}
-				case "function":
-					if funcData, ok := msg.data.(map[string]interface{}); ok {
-						m.previewColumn.title = "Function Preview"
-						m.previewColumn.items = []string{
-							fmt.Sprintf("Name: %v", funcData["name"]),
-							fmt.Sprintf("Type: %v", funcData["type"]),
-							"",
-							fmt.Sprintf("Description: %v", funcData["description"]),
-							"",
-							fmt.Sprintf("Parameters: %v", funcData["parameters"]),
-							"",
-							fmt.Sprintf("%v", funcData["note"]),
-						}
-					}
-				case "metadata":
-					if metaData, ok := msg.data.(map[string]interface{}); ok {
-						m.previewColumn.title = "Metadata Preview"
-						m.previewColumn.items = []string{
-							fmt.Sprintf("Type: %v", metaData["type"]),
-							"",
-							fmt.Sprintf("URL: %v", metaData["url"]),
-							"",
-							fmt.Sprintf("%v", metaData["note"]),
-							"",
-							"Contains:",
-							"• Entity Types and Sets",
-							"• Function Imports",
-							"• Complex Types",
-							"• Associations",
-							"• Service Operations",
-						}
-					}
-				case "navigation":
-					if navData, ok := msg.data.(map[string]interface{}); ok {
-						m.previewColumn.title = "Navigation"
-						m.previewColumn.items = []string{
-							fmt.Sprintf("URI: %v", navData["uri"]),
-							"",
-							fmt.Sprintf("%v", navData["note"]),
-						}
-					}
-				case "none":
-					m.previewColumn.title = "Preview"
-					m.previewColumn.items = []string{"No preview available at this level"}
-				}
-			}
-		}
+		m.preview, _ = m.preview.Update(msg, m.previewDetails)
 
-	case saveSuccessMsg:
+	case navigationMsg:
 		m.loading = false
-		m.modalEditor = false
-		m.modalContent = nil
-		m.modalCursor = 0
-		m.modalScroll = 0
-		m.modalColCursor = 0
-		m.modalOperation = ""
-		m.logs = append(m.logs, fmt.Sprintf("SUCCESS: %s operation completed - %s", msg.operation, msg.message))
+		m.applyNavigation(msg)
+
+	case saveSuccessMsg:
+		return m.applySave(msg)
+
+	case progressMsg, progressDoneMsg:
+		return m.updateProgress(msg)
+
+	case csvExportMsg:
+		m.applyCSVExport(msg)
+
+	case xlsxExportMsg:
+		m.applyXLSXExport(msg)
+
+	case transferProgressMsg, downloadDoneMsg, uploadDoneMsg:
+		cmd := m.applyTransfer(msg)
+		return m, cmd
+
+	case jsonExportMsg:
+		m.applyJSONExport(msg)
+		return m, nil
+
+	case jsonCheckMsg:
+		if msg.seq == m.jsonCheckSeq && m.modalEditor {
+			m.checkEditorJSON()
+			m.saveEditorBuffer()
+		}
+
+	case clipboardMsg:
+		return m.pasteIntoEditor(msg)
+
+	case layoutSaveMsg:
+		cmd := m.saveLayoutLater(msg)
+		return m, cmd
+
+	case layoutSavedMsg:
+		m.layoutSaved(msg)
+
+	case keepAliveMsg, keepAliveDoneMsg:
+		return m.updateKeepAlive(msg)
+
+	case importMsg:
+		m.applyImport(msg)
+
+	case stagedSubmitMsg:
+		cmd := m.applyStagedSubmit(msg)
+		return m, cmd
+
+	case bulkWriteMsg:
+		// A batch that never ran keeps the patch template for another try
+		if msg.results != nil && m.modalEditor && (m.modalOperation == "bulkupdate" || m.modalOperation == "resubmit") {
+			m.closeModalEditor()
+		}
+		m.applyBulkWrite(msg)
+		if msg.results != nil {
+			cmd := m.reloadAfterWrite(msg.entitySet, "")
+			return m, cmd
+		}
+
+	case bulkCreateMsg:
+		m.applyBulkCreate(msg)
+
+	case conflictMsg:
+		return m.openConflict(msg), nil
 
 	case entityDetailMsg:
 		m.loading = false
-		m.logs = append(m.logs, fmt.Sprintf("Read detailed entity %s from %s", msg.entityKey, msg.entitySet))
+		m.debugf("Read detailed entity %s from %s", msg.entityKey, msg.entitySet)
 		
 		// Update the details column with the detailed entity
-		for i := range m.columns {
-			if m.columns[i].title == "Details" && m.columns[i].isDetails {
-				// Replace the stored entity with the detailed one
-				m.columns[i].entities = []map[string]interface{}{msg.entity}
-				
-				// Update JSON display
-				jsonData, err := json.MarshalIndent(msg.entity, "", "  ")
-				if err != nil {
-					m.columns[i].items = []string{fmt.Sprintf("Error formatting JSON: %v", err)}
-				} else {
-					m.columns[i].items = strings.Split(string(jsonData), "\n")
-				}
-				
-				// Reset cursor and scroll
-				m.columns[i].cursor = 0
-				m.columns[i].scrollOffset = 0
-				break
-			}
+		if i := m.columnIndex(msg.column); i != -1 {
+			m.columns[i].showDetails(msg.entity, m.detailsLines(m.detailsEntitySet(i), msg.entity, nil))
 		}
 
 	case errorMsg:
-		m.loading = false
-		m.logs = append(m.logs, fmt.Sprintf("ERROR [%s]: %s", msg.context, msg.err))
-		// Keep only last 100 log entries
-		if len(m.logs) > 100 {
-			m.logs = m.logs[len(m.logs)-100:]
-		}
+		m.applyError(msg)
 
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
@@ -326,313 +377,157 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.updateColumnSizes()
 
 	case tea.KeyMsg:
-		// Handle modal editor first
-		if m.modalEditor {
-			switch msg.String() {
-			case "ctrl+c", "q", "f10":
-				return m, tea.Quit
-			case "esc":
-				// Cancel modal editor
-				m.modalEditor = false
-				m.modalContent = nil
-				m.modalCursor = 0
-				m.modalScroll = 0
-				m.modalColCursor = 0
-				m.modalOperation = ""
-				m.logs = append(m.logs, "Modal editor cancelled")
-				return m, nil
-			case "f2":
-				// Save changes and close modal
-				return m.saveModalChanges()
-			case "up", "k":
-				if m.modalCursor > 0 {
-					m.modalCursor--
-					if m.modalCursor < m.modalScroll {
-						m.modalScroll = m.modalCursor
-					}
-					// Adjust column cursor if new line is shorter
-					if m.modalCursor < len(m.modalContent) && m.modalColCursor > len(m.modalContent[m.modalCursor]) {
-						m.modalColCursor = len(m.modalContent[m.modalCursor])
-					}
-				}
-			case "down", "j":
-				if m.modalCursor < len(m.modalContent)-1 {
-					m.modalCursor++
-					modalHeight := int(float64(m.height) * 0.95) - 4
-					if m.modalCursor >= m.modalScroll+modalHeight {
-						m.modalScroll = m.modalCursor - modalHeight + 1
-					}
-					// Adjust column cursor if new line is shorter
-					if m.modalCursor < len(m.modalContent) && m.modalColCursor > len(m.modalContent[m.modalCursor]) {
-						m.modalColCursor = len(m.modalContent[m.modalCursor])
-					}
-				}
-			case "left":
-				if m.modalColCursor > 0 {
-					m.modalColCursor--
-				} else if m.modalCursor > 0 {
-					// Move to end of previous line
-					m.modalCursor--
-					if m.modalCursor < len(m.modalContent) {
-						m.modalColCursor = len(m.modalContent[m.modalCursor])
-					}
-				}
-			case "right":
-				if m.modalCursor < len(m.modalContent) && m.modalColCursor < len(m.modalContent[m.modalCursor]) {
-					m.modalColCursor++
-				} else if m.modalCursor < len(m.modalContent)-1 {
-					// Move to beginning of next line
-					m.modalCursor++
-					m.modalColCursor = 0
-				}
-			case "enter":
-				// Insert new line
-				if m.modalCursor < len(m.modalContent) {
-					currentLine := m.modalContent[m.modalCursor]
-					beforeCursor := currentLine[:m.modalColCursor]
-					afterCursor := currentLine[m.modalColCursor:]
-					
-					// Replace current line with part before cursor
-					m.modalContent[m.modalCursor] = beforeCursor
-					
-					// Insert new line with part after cursor
-					newContent := make([]string, len(m.modalContent)+1)
-					copy(newContent[:m.modalCursor+1], m.modalContent[:m.modalCursor+1])
-					newContent[m.modalCursor+1] = afterCursor
-					copy(newContent[m.modalCursor+2:], m.modalContent[m.modalCursor+1:])
-					m.modalContent = newContent
-					
-					// Move to next line, beginning
-					m.modalCursor++
-					m.modalColCursor = 0
-				}
-			case "backspace":
-				if m.modalColCursor > 0 {
-					// Delete character before cursor
-					if m.modalCursor < len(m.modalContent) {
-						line := m.modalContent[m.modalCursor]
-						m.modalContent[m.modalCursor] = line[:m.modalColCursor-1] + line[m.modalColCursor:]
-						m.modalColCursor--
-					}
-				} else if m.modalCursor > 0 {
-					// Join with previous line
-					if m.modalCursor < len(m.modalContent) {
-						prevLine := m.modalContent[m.modalCursor-1]
-						currentLine := m.modalContent[m.modalCursor]
-						m.modalColCursor = len(prevLine)
-						m.modalContent[m.modalCursor-1] = prevLine + currentLine
-						
-						// Remove current line
-						newContent := make([]string, len(m.modalContent)-1)
-						copy(newContent[:m.modalCursor], m.modalContent[:m.modalCursor])
-						copy(newContent[m.modalCursor:], m.modalContent[m.modalCursor+1:])
-						m.modalContent = newContent
-						m.modalCursor--
-					}
-				}
-			case "delete":
-				if m.modalCursor < len(m.modalContent) {
-					line := m.modalContent[m.modalCursor]
-					if m.modalColCursor < len(line) {
-						// Delete character at cursor
-						m.modalContent[m.modalCursor] = line[:m.modalColCursor] + line[m.modalColCursor+1:]
-					} else if m.modalCursor < len(m.modalContent)-1 {
-						// Join with next line
-						nextLine := m.modalContent[m.modalCursor+1]
-						m.modalContent[m.modalCursor] = line + nextLine
-						
-						// Remove next line
-						newContent := make([]string, len(m.modalContent)-1)
-						copy(newContent[:m.modalCursor+1], m.modalContent[:m.modalCursor+1])
-						copy(newContent[m.modalCursor+1:], m.modalContent[m.modalCursor+2:])
-						m.modalContent = newContent
-					}
-				}
-			case "pgup":
-				modalHeight := int(float64(m.height) * 0.95) - 4
-				newCursor := m.modalCursor - modalHeight
-				if newCursor < 0 {
-					newCursor = 0
-				}
-				m.modalCursor = newCursor
-				m.modalScroll = newCursor
-			case "pgdown":
-				modalHeight := int(float64(m.height) * 0.95) - 4
-				newCursor := m.modalCursor + modalHeight
-				if newCursor >= len(m.modalContent) {
-					newCursor = len(m.modalContent) - 1
-				}
-				m.modalCursor = newCursor
-				if m.modalCursor >= m.modalScroll+modalHeight {
-					m.modalScroll = m.modalCursor - modalHeight + 1
-				}
-			case "home":
-				m.modalColCursor = 0
-			case "end":
-				if m.modalCursor < len(m.modalContent) {
-					m.modalColCursor = len(m.modalContent[m.modalCursor])
-				}
-			case "ctrl+home":
-				m.modalCursor = 0
-				m.modalColCursor = 0
-				m.modalScroll = 0
-			case "ctrl+end":
-				if len(m.modalContent) > 0 {
-					m.modalCursor = len(m.modalContent) - 1
-					m.modalColCursor = len(m.modalContent[m.modalCursor])
-					modalHeight := int(float64(m.height) * 0.95) - 4
-					if len(m.modalContent) > modalHeight {
-						m.modalScroll = len(m.modalContent) - modalHeight
-					} else {
-						m.modalScroll = 0
-					}
-				}
-			default:
-				// Handle regular character input
-				if len(msg.String()) == 1 {
-					char := msg.String()
-					if m.modalCursor >= len(m.modalContent) {
-						// Add new line if needed
-						m.modalContent = append(m.modalContent, "")
-					}
-					
-					line := m.modalContent[m.modalCursor]
-					// Insert character at cursor position
-					m.modalContent[m.modalCursor] = line[:m.modalColCursor] + char + line[m.modalColCursor:]
-					m.modalColCursor++
-				}
+		// The service switcher opens on top of everything, editors included
+		if msg.String() == "ctrl+o" && !m.switcher.active {
+			return m.openServiceSwitcher(), nil
+		}
+		// An open overlay takes all key presses
+		if o := m.activeOverlay(); o != nil {
+			return o.update(m, msg)
+		}
+		if m.keymap == keymapVim {
+			return m.updateVimKey(msg)
+		}
+		return m.updateKey(msg)
+	}
+
+	return m, nil
+}
+
+// updateKey handles a key press in the columns while no overlay is open.
+// The components take their keys first: the inline edit mode, a kept find
+// query, the log pane, the preview, the cursor of the active column and
+// the keys opening the editor or a dialog; the rest act on the column.
+func (m model) updateKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	key := msg.String()
+	// Any key but F8 cancels a pending delete
+	if m.confirmDelete && key != "f8" {
+		m.confirmDelete = false
+		m.logs = append(m.logs, "Delete cancelled")
+	}
+	// Likewise any key but Enter cancels connecting to a plain HTTP service
+	if m.confirmInsecure != "" && key != "enter" {
+		m.confirmInsecure = ""
+		m.logs = append(m.logs, "Connection cancelled")
+	}
+
+	if next, handled := m.rawResponseAction(key); handled {
+		return next, nil
+	}
+	if m.inlineEdit.active {
+		if edit, handled := m.inlineEdit.Update(msg); handled {
+			m.inlineEdit = edit
+			if !edit.active {
+				m.logs = append(m.logs, "Edit cancelled")
 			}
 			return m, nil
 		}
+	}
 
-		switch msg.String() {
-		case "ctrl+c", "q", "f10":
-			return m, tea.Quit
+	// A kept find query narrows what the cursor moves over; Esc clears it
+	if !m.inlineEdit.active && m.activeColumn < len(m.columns) && m.columns[m.activeColumn].find != "" {
+		switch key {
+		case "esc":
+			return m.clearFind(), nil
+		case "up", "k", "down", "j", "pgup", "pgdown", "home", "end":
+			m.moveInMatches(key)
+			return m, m.findPreview()
+		}
+	}
 
-		case "up", "k":
-			if m.editMode {
-				// In edit mode, move cursor up in text
-				if m.editCursor > 0 {
-					m.editCursor--
-				}
-			} else if m.activeColumn < len(m.columns) {
-				col := &m.columns[m.activeColumn]
-				if col.cursor > 0 {
-					col.cursor--
-					// Ensure cursor is visible in viewport for all columns
-					if col.cursor < col.scrollOffset {
-						col.scrollOffset = col.cursor
-					}
-					// Update preview when cursor moves (except in details view)
-					if !col.isDetails {
-						return m, m.updatePreview()
-					}
-				}
+	if pane, handled := m.logPane.Update(msg); handled {
+		m.logPane = pane
+		return m, nil
+	}
+	if preview, handled := m.preview.Update(msg, m.previewDetails); handled {
+		m.preview = preview
+		return m.previewToggled()
+	}
+	if next, cmd, handled := m.updateLayoutKey(key); handled {
+		return next, cmd
+	}
+	if m.activeColumn < len(m.columns) {
+		col := &m.columns[m.activeColumn]
+		cursor := col.cursor
+		if moved, handled := col.Update(msg); handled {
+			*col = moved
+			// The preview follows the cursor, except in Details
+			if col.cursor != cursor && !col.isDetails {
+				return m, m.updatePreview()
 			}
+			return m, nil
+		}
+	}
+	if o := overlayFor(key); o != nil {
+		return o.open(m, key), nil
+	}
 
-		case "down", "j":
-			if m.editMode {
-				// In edit mode, move cursor down in text
-				if m.editCursor < len(m.editContent)-1 {
-					m.editCursor++
-				}
-			} else if m.activeColumn < len(m.columns) {
-				col := &m.columns[m.activeColumn]
-				if col.cursor < len(col.items)-1 {
-					col.cursor++
-					// Ensure cursor is visible in viewport for all columns
-					visibleHeight := col.height - 2 // Account for borders
-					if col.cursor >= col.scrollOffset+visibleHeight {
-						col.scrollOffset = col.cursor - visibleHeight + 1
-					}
-					// Update preview when cursor moves (except in details view)
-					if !col.isDetails {
-						return m, m.updatePreview()
-					}
-				}
-			}
+	switch key {
+	case "ctrl+c", "q", "f10":
+		return m, tea.Quit
 
-		case "right", "l", "enter":
-			if !m.editMode {
-				return m.drillDown()
-			}
+	case "right", "l", "enter":
+		return m.drillDown()
 
-		case "left", "h", "esc":
-			if m.editMode {
-				// Cancel edit mode
-				m.editMode = false
-				m.logs = append(m.logs, "Edit cancelled")
-				return m, nil
-			}
-			newModel := m.goBack()
-			return newModel, newModel.updatePreview()
-
-		case "f2":
-			// Create entity - open modal editor with empty template
-			return m.openModalEditor("create"), nil
-		case "f3":
-			return m.readEntityDetails()
-		case "f4":
-			// Update entity - open modal editor with current entity
-			return m.openModalEditor("update"), nil
-		case "f5":
-			// Copy entity - open modal editor with copy of current entity
-			return m.openModalEditor("copy"), nil
-		case "f7":
-			// TODO: Filter
-		case "f8":
-			// TODO: Delete entity
-		case "f9":
-			m.showLogs = !m.showLogs
-			
-		case "pgup":
-			if m.activeColumn < len(m.columns) {
-				col := &m.columns[m.activeColumn]
-				pageSize := col.height - 2 // Account for borders
-				newCursor := col.cursor - pageSize
-				if newCursor < 0 {
-					newCursor = 0
-				}
-				col.cursor = newCursor
-				col.scrollOffset = newCursor
-			}
-			
-		case "pgdown":
-			if m.activeColumn < len(m.columns) {
-				col := &m.columns[m.activeColumn]
-				pageSize := col.height - 2
-				newCursor := col.cursor + pageSize
-				if newCursor >= len(col.items) {
-					newCursor = len(col.items) - 1
-				}
-				col.cursor = newCursor
-				visibleHeight := col.height - 2
-				if col.cursor >= col.scrollOffset+visibleHeight {
-					col.scrollOffset = col.cursor - visibleHeight + 1
-				}
-			}
-			
-		case "home":
-			if m.activeColumn < len(m.columns) {
-				col := &m.columns[m.activeColumn]
-				col.cursor = 0
-				col.scrollOffset = 0
-			}
-			
-		case "end":
-			if m.activeColumn < len(m.columns) {
-				col := &m.columns[m.activeColumn]
-				if len(col.items) > 0 {
-					col.cursor = len(col.items) - 1
-					visibleHeight := col.height - 2
-					if len(col.items) > visibleHeight {
-						col.scrollOffset = len(col.items) - visibleHeight
-					} else {
-						col.scrollOffset = 0
-					}
-				}
-			}
+	case "left", "h", "esc":
+		newModel := m.goBack()
+		return newModel, newModel.updatePreview()
+
+	case "f3":
+		return m.readEntityDetails()
+	case "?":
+		if _, _, ok := m.propertyUnderCursor(); !ok {
+			return m.openHelp(helpPageKeys), nil
+		}
+		return m.openPropertyDoc(), nil
+	case "ctrl+r":
+		return m.refreshColumn()
+	case "r":
+		if m.activeColumn < len(m.columns) && m.columns[m.activeColumn].report != nil {
+			return m.retryFailedOperations()
 		}
+		return m.retryColumn()
+	case "C":
+		return m.toggleStaging()
+	case "c":
+		return m.openPendingPanel()
+	case "E":
+		if m.activeColumn < len(m.columns) && m.columns[m.activeColumn].report != nil {
+			return m.editFailedOperations(), nil
+		}
+	case "D":
+		return m.toggleRawDates(), m.updatePreview()
+	case "w":
+		return m.toggleWrap()
+	case "B":
+		return m.copyBreadcrumb(), nil
+	case "[", "alt+left":
+		return m.stepVisit(-1)
+	case "]", "alt+right":
+		return m.stepVisit(1)
+	case "/":
+		return m.startFind(), nil
+	case "v":
+		return m.captureVariable(), nil
+	case "V":
+		if len(m.variables) == 0 {
+			m.logs = append(m.logs, "No session variables - press v on a Details property to capture one")
+		} else {
+			m.logs = append(m.logs, "Session variables:")
+			m.logs = append(m.logs, variableSummary(m.variables)...)
+		}
+	case "f8":
+		return m.deleteEntities()
+	case " ":
+		if m.activeColumn < len(m.columns) && m.columns[m.activeColumn].isDetails {
+			m.toggleSection(true)
+			return m, nil
+		}
+		return m.toggleSelection(), nil
+	case "*":
+		return m.toggleFavorite(), nil
+	case "b":
+		return m.toggleEntityFavorite(), nil
 	}
 
 	return m, nil
@@ -643,12 +538,14 @@ func (m *model) updateColumnSizes() {
 		return
 	}
 
-	// Reserve space for preview column (30% of total width)
-	previewWidth := int(float64(m.width) * 0.3)
-	if m.previewColumn != nil {
-		m.previewColumn.width = previewWidth
-		m.previewColumn.height = m.height - 4
+	// Reserve space for preview column (30% of total width unless resized)
+	layout := m.layout.withDefaults()
+	previewWidth := int(float64(m.width) * layout.Preview)
+	if m.preview.hidden {
+		previewWidth = 0
 	}
+	m.preview.column.width = previewWidth
+	m.preview.column.height = m.height - 4
 
 	totalWidth := m.width - previewWidth
 	numColumns := len(m.columns)
@@ -657,16 +554,16 @@ func (m *model) updateColumnSizes() {
 	if numColumns == 1 {
 		m.columns[0].width = totalWidth
 	} else if numColumns == 2 {
-		// 40% for first, 60% for second
-		m.columns[0].width = int(float64(totalWidth) * 0.4)
+		// 40% for first, 60% for second, or as much more as the active column was widened
+		m.columns[0].width = int(float64(totalWidth) * (0.8 - layout.Active))
 		m.columns[1].width = totalWidth - m.columns[0].width
 	} else {
 		// For 3+ columns: earlier columns get progressively smaller
-		// Active column gets 40%, previous gets 30%, others share the rest
+		// Active column gets 40% (or its resized share), previous gets 30%, others share the rest
 		
 		for i := 0; i < numColumns; i++ {
 			if i == m.activeColumn {
-				m.columns[i].width = int(float64(totalWidth) * 0.4)
+				m.columns[i].width = int(float64(totalWidth) * layout.Active)
 			} else if i == m.activeColumn-1 {
 				m.columns[i].width = int(float64(totalWidth) * 0.3)
 			} else {
@@ -675,7 +572,7 @@ func (m *model) updateColumnSizes() {
 				if m.activeColumn == 0 {
 					otherCount = numColumns - 1
 				}
-				m.columns[i].width = int(float64(totalWidth) * 0.3 / float64(otherCount))
+				m.columns[i].width = int(float64(totalWidth) * (1 - layout.Active - 0.3) / float64(otherCount))
 			}
 			
 			// Ensure minimum width
@@ -700,8 +597,42 @@ func (m model) drillDown() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	if currentCol.isResult {
+		return m.jumpToResult()
+	}
+	if currentCol.raw != nil {
+		return m, nil
+	}
+
+	// The "more" row of an entity list fetches the next page in place
+	if currentCol.entitySet != "" && currentCol.hasMore && currentCol.cursor == len(currentCol.entities) {
+		m.columns[m.activeColumn].items[currentCol.cursor] = "[...loading more items]"
+		m.columns[m.activeColumn].state = stateLoading
+		m.loading = true
+		return m, loadMoreEntities(m.odata, currentCol)
+	}
+
 	selectedItem := currentCol.items[currentCol.cursor]
-	
+
+	// Credentials only go out over plain HTTP once the user agreed to it
+	if m.activeColumn == 0 {
+		for _, svc := range m.services {
+			if svc.Name != selectedItem || !m.needsInsecureConfirmation(svc) {
+				continue
+			}
+			if m.confirmInsecure != svc.Name {
+				m.confirmInsecure = svc.Name
+				m.logs = append(m.logs, fmt.Sprintf("WARNING: %s uses plain HTTP - credentials would be sent in cleartext. Press Enter again to connect anyway (or set accept_insecure in the config)", svc.Name))
+				return m, nil
+			}
+			m.confirmInsecure = ""
+			if m.insecureAccepted == nil {
+				m.insecureAccepted = make(map[string]bool)
+			}
+			m.insecureAccepted[svc.Name] = true
+		}
+	}
+
 	// Clear focus from current column
 	for i := range m.columns {
 		m.columns[i].focused = false
@@ -721,7 +652,7 @@ func (m model) drillDown() (tea.Model, tea.Cmd) {
 		for i, svc := range m.services {
 			if svc.Name == selectedItem {
 				m.serviceIndex = i
-				m.odata = NewODataServiceWithAuth(svc.URL, svc.Username, svc.Password)
+				m.odata = NewODataServiceForConfig(svc)
 				m.logs = append(m.logs, fmt.Sprintf("Connected to %s", svc.Name))
 				break
 			}
@@ -732,17 +663,23 @@ func (m model) drillDown() (tea.Model, tea.Cmd) {
 			items:   []string{"Loading..."},
 			cursor:  0,
 			focused: false,
+			id:      m.newColumnID(),
+			state:   stateLoading,
 		}
 		m.columns = append(m.columns, newColumn)
 		m.activeColumn++
 		m.columns[m.activeColumn].focused = true
 		m.updateColumnSizes()
 		m.loading = true
-		cmd = tea.Batch(loadEntitySets(m.odata), m.updatePreview())
+		m.keepAliveSeq++
+		cmd = tea.Batch(loadEntitySets(m.odata, newColumn.id), m.updatePreview(), m.keepAlive())
 		
 	case 1: // EntitySets -> Entities or Metadata
 		// Extract entity set name from display text (remove capabilities part)
-		entitySetName := strings.Split(selectedItem, " [")[0]
+		entitySetName := itemEntitySet(selectedItem)
+		if entitySet, key, ok := favoriteEntity(entitySetName); ok {
+			return m.openFavoriteEntity(entitySet, key)
+		}
 		
 		// Handle $metadata specially
 		if entitySetName == "$metadata" {
@@ -752,6 +689,8 @@ func (m model) drillDown() (tea.Model, tea.Cmd) {
 				cursor:    0,
 				focused:   false,
 				isDetails: true,
+				id:        m.newColumnID(),
+				state:     stateLoading,
 			}
 			m.columns = append(m.columns, newColumn)
 			m.activeColumn++
@@ -760,53 +699,67 @@ func (m model) drillDown() (tea.Model, tea.Cmd) {
 			m.loading = true
 			
 			// Load metadata
+			id := newColumn.id
+			odata := m.odata.forColumn(id)
 			cmd = func() tea.Msg {
-				metadataURL := strings.TrimSuffix(m.odata.baseURL, "/") + "/$metadata"
+				defer odata.loaded()
+				metadataURL := odata.BuildURL("$metadata", "", QueryOptions{})
 				req, err := http.NewRequest("GET", metadataURL, nil)
 				if err != nil {
-					return errorMsg{err: err.Error(), context: "metadata"}
+					return newErrorMsg(err, "metadata").forColumn(id)
 				}
-				if m.odata.username != "" && m.odata.password != "" {
-					req.SetBasicAuth(m.odata.username, m.odata.password)
+				if odata.username != "" && odata.password != "" {
+					req.SetBasicAuth(odata.username, odata.password)
 				}
 				
-				resp, err := m.odata.client.Do(req)
+				resp, err := odata.client.Do(req)
 				if err != nil {
-					return errorMsg{err: err.Error(), context: "metadata"}
+					return newErrorMsg(err, "metadata").forColumn(id)
 				}
 				defer resp.Body.Close()
 				
 				body, err := io.ReadAll(resp.Body)
 				if err != nil {
-					return errorMsg{err: err.Error(), context: "metadata"}
+					return newErrorMsg(err, "metadata").forColumn(id)
 				}
 				
-				return entitiesMsg{entitySet: "Metadata", entities: []map[string]interface{}{
+				return entitiesMsg{column: id, entitySet: "Metadata", entities: []map[string]interface{}{
 					{"metadata": string(body)}}, hasMore: false}
 			}
 		} else {
 			newColumn = column{
-				title:   entitySetName,
-				items:   []string{"Loading..."},
-				cursor:  0,
-				focused: false,
+				title:     entitySetName,
+				items:     []string{"Loading..."},
+				cursor:    0,
+				focused:   false,
+				entitySet: entitySetName,
+				id:        m.newColumnID(),
+				state:     stateLoading,
 			}
 			m.columns = append(m.columns, newColumn)
 			m.activeColumn++
 			m.columns[m.activeColumn].focused = true
 			m.updateColumnSizes()
 			m.loading = true
-			cmd = tea.Batch(loadEntities(m.odata, entitySetName), m.updatePreview())
+			cmd = tea.Batch(loadEntities(m.odata, newColumn), m.updatePreview())
 		}
 		
-	case 2: // Entities -> JSON Details
+	default: // Entities -> JSON Details, Details -> navigation property
+		if currentCol.isDetails {
+			m.columns[m.activeColumn].focused = true
+			if m.toggleSection(false) {
+				return m, nil
+			}
+			return m.followNavigation()
+		}
+
 		// Get the actual entity data from the previous column
 		prevCol := m.columns[m.activeColumn]
 		if prevCol.cursor < len(prevCol.entities) {
 			selectedEntity := prevCol.entities[prevCol.cursor]
 			
 			// Format entity as JSON
-			jsonData, err := json.MarshalIndent(selectedEntity, "", "  ")
+			_, err := json.Marshal(selectedEntity)
 			if err != nil {
 				newColumn = column{
 					title:     "Details",
@@ -817,7 +770,7 @@ func (m model) drillDown() (tea.Model, tea.Cmd) {
 				}
 			} else {
 				// Split JSON into lines for display
-				lines := strings.Split(string(jsonData), "\n")
+				lines := m.detailsLines(currentCol.entitySet, selectedEntity, nil)
 				newColumn = column{
 					title:     "Details",
 					items:     lines,
@@ -840,11 +793,6 @@ func (m model) drillDown() (tea.Model, tea.Cmd) {
 		m.activeColumn++
 		m.columns[m.activeColumn].focused = true
 		m.updateColumnSizes()
-		
-	default:
-		// We're already at JSON level (column 3), don't create more columns
-		// TODO: Handle navigation properties here
-		return m, nil
 	}
 	
 	return m, cmd
@@ -882,282 +830,305 @@ func (m model) readEntityDetails() (tea.Model, tea.Cmd) {
 	// Get the selected entity
 	selectedEntity := currentCol.entities[currentCol.cursor]
 	entitySetName := currentCol.title
+	if currentCol.entitySet != "" {
+		entitySetName = currentCol.entitySet
+	}
 	
 	// Extract the key value(s) from the entity
-	entityKey := extractEntityKey(selectedEntity)
+	entityKey := extractEntityKey(m.metadata(), entitySetName, selectedEntity)
 	if entityKey == "" {
 		m.logs = append(m.logs, "F3: Could not determine entity key for detailed read")
 		return m, nil
 	}
 	
-	m.loading = true
-	m.logs = append(m.logs, fmt.Sprintf("Reading detailed entity %s from %s...", entityKey, entitySetName))
+	// A deep read, with its expanded entities, can take a while
+	p := m.startProgress(fmt.Sprintf("Reading %s(%s)", entitySetName, entityKey), "bytes", -1)
+	if p == nil {
+		return m, nil
+	}
+	p.countBytes()
+
+	// The entity is read into the Details column next to the list, opened if need be
+	next := m.activeColumn + 1
+	if next >= len(m.columns) || !m.columns[next].isDetails || m.columns[next].isResult || m.columns[next].raw != nil {
+		m.columns = append(m.columns[:next], column{
+			title:     "Details",
+			items:     []string{"Loading..."},
+			isDetails: true,
+		})
+		m.updateColumnSizes()
+	}
+	details := &m.columns[next]
+	if details.id == 0 {
+		details.id = m.newColumnID()
+	}
+	details.state = stateLoading
+	id := details.id
+
+	m.debugf("Reading detailed entity %s from %s...", entityKey, entitySetName)
 	
-	return m, func() tea.Msg {
-		entity, err := m.odata.GetEntity(entitySetName, entityKey)
+	odata := p.odata.forColumn(id)
+	return m, p.run(func() tea.Msg {
+		defer odata.loaded()
+		entity, err := odata.GetEntity(entitySetName, entityKey, QueryOptions{})
 		if err != nil {
-			return errorMsg{err: err.Error(), context: fmt.Sprintf("readEntity(%s, %s)", entitySetName, entityKey)}
+			return newErrorMsg(err, fmt.Sprintf("readEntity(%s, %s)", entitySetName, entityKey)).forColumn(id)
 		}
-		return entityDetailMsg{entitySet: entitySetName, entityKey: entityKey, entity: entity}
-	}
+		return entityDetailMsg{column: id, entitySet: entitySetName, entityKey: entityKey, entity: entity}
+	})
 }
 
-// extractEntityKey extracts the primary key value from an entity
-func extractEntityKey(entity map[string]interface{}) string {
+// extractEntityKey returns the key predicate of an entity as written between
+// the parentheses of its URL. The entity URI sent by the server wins; else
+// the key values are formatted by their Edm type from the metadata, or by a
+// type guessed from the value when there is no metadata.
+func extractEntityKey(md *Metadata, entitySet string, entity map[string]interface{}) string {
 	// First, check for __metadata.id or __metadata.uri which contains the proper key
 	if metadata, ok := entity["__metadata"].(map[string]interface{}); ok {
-		if id, ok := metadata["id"].(string); ok {
+		for _, field := range []string{"id", "uri"} {
 			// Extract key from URI like "https://host/service/EntitySet('key')"
-			if lastParen := strings.LastIndex(id, "("); lastParen != -1 {
-				if endParen := strings.Index(id[lastParen:], ")"); endParen != -1 {
-					return id[lastParen+1 : lastParen+endParen]
+			if uri, ok := metadata[field].(string); ok {
+				if key, ok := keyFromURI(uri); ok {
+					return key
 				}
 			}
 		}
-		if uri, ok := metadata["uri"].(string); ok {
-			// Extract key from URI like "https://host/service/EntitySet('key')"
-			if lastParen := strings.LastIndex(uri, "("); lastParen != -1 {
-				if endParen := strings.Index(uri[lastParen:], ")"); endParen != -1 {
-					return uri[lastParen+1 : lastParen+endParen]
+	}
+
+	version := "2.0"
+	if md != nil {
+		version = md.Version
+	}
+	et := md.EntityTypeOf(entitySet)
+
+	fields := entityKeyFields(md, entitySet, entity)
+	if len(fields) == 0 {
+		// Last fallback: look for any field that might be a key
+		for _, k := range sortedKeys(entity) {
+			if v := entity[k]; v != nil && !strings.HasPrefix(k, "__") && !strings.Contains(k, "@") && !strings.Contains(strings.ToLower(k), "date") {
+				if keyLiteral(et, k, v, version) != "" {
+					fields = []string{k}
+					break
 				}
 			}
 		}
 	}
-	
-	// Fallback: Common key field patterns
-	keyFields := []string{"Program", "Class", "Interface", "Package", "Function", 
-		"ID", "Id", "Key", "Code", "Number", 
-		"ProductID", "CategoryID", "CustomerID", "OrderID", "EmployeeID"}
-	
-	// Check for key fields
-	for _, field := range keyFields {
-		if val := entity[field]; val != nil {
-			// Format the key value for OData URL
-			if str, ok := val.(string); ok {
-				// String keys need to be quoted
-				return fmt.Sprintf("'%s'", str)
-			} else {
-				// Numeric keys don't need quotes
-				return fmt.Sprintf("%v", val)
-			}
+
+	if len(fields) == 1 {
+		return keyLiteral(et, fields[0], entity[fields[0]], version)
+	}
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		literal := keyLiteral(et, field, entity[field], version)
+		if literal == "" {
+			return ""
 		}
+		parts = append(parts, field+"="+literal)
 	}
-	
-	// Last fallback: look for any field that might be a key
-	for k, v := range entity {
-		if v != nil && !strings.HasPrefix(k, "__") && !strings.Contains(strings.ToLower(k), "date") {
-			if str, ok := v.(string); ok && str != "" {
-				return fmt.Sprintf("'%s'", str)
-			} else if num := v; num != nil {
-				return fmt.Sprintf("%v", num)
+	return strings.Join(parts, ",")
+}
+
+// keyLiteral formats the value of a key property as an OData URL literal of
+// its declared Edm type, so GUID and date keys get their guid'…' and
+// datetime'…' forms. It returns "" for values that can't be a key.
+func keyLiteral(et *EntityType, name string, value interface{}, version string) string {
+	edmType := inferEdmType(value)
+	if p := et.Property(name); p != nil {
+		edmType = p.Type
+	}
+
+	var text string
+	switch v := value.(type) {
+	case string:
+		text = v
+		// V2 JSON writes dates as /Date(ms)/, URL literals want ISO 8601
+		if t, _, ok := parseV2Date(v); ok {
+			t = t.UTC()
+			if edmType == "Edm.DateTimeOffset" {
+				text = t.Format(time.RFC3339)
+			} else {
+				text = t.Format("2006-01-02T15:04:05")
 			}
 		}
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		text = strconv.FormatBool(v)
+	default:
+		return ""
 	}
-	
-	return ""
+	return formatODataLiteral(edmType, text, version)
 }
 
-// updatePreview generates a preview based on current cursor position
-func (m model) updatePreview() tea.Cmd {
-	if m.activeColumn >= len(m.columns) {
+// metadata returns the metadata of the connected service, or nil
+func (m model) metadata() *Metadata {
+	if m.odata == nil {
 		return nil
 	}
+	return m.odata.metadata()
+}
 
-	currentCol := m.columns[m.activeColumn]
-	if currentCol.cursor >= len(currentCol.items) {
-		return nil
+// entityKeyFields returns the names of the key properties of an entity, taken
+// from the metadata when available and otherwise guessed from the entity URI
+// or common key field names
+func entityKeyFields(md *Metadata, entitySet string, entity map[string]interface{}) []string {
+	if et := md.EntityTypeOf(entitySet); et != nil && len(et.Keys) > 0 {
+		return et.Keys
 	}
 
-	selectedItem := currentCol.items[currentCol.cursor]
-	m.previewLoading = true
-
-	switch m.activeColumn {
-	case 0: // Service selection - preview entity sets
-		return func() tea.Msg {
-			for _, svc := range m.services {
-				if svc.Name == selectedItem {
-					odataService := NewODataServiceWithAuth(svc.URL, svc.Username, svc.Password)
-					entitySets, err := odataService.GetEntitySets()
-					if err != nil {
-						return previewMsg{errorMsg: err.Error()}
-					}
-					return previewMsg{previewType: "entitysets", data: entitySets}
-				}
+	// Composite keys appear as (Name='x',Other=1) in the entity URI
+	uri, _ := entity["@odata.id"].(string)
+	if metadata, ok := entity["__metadata"].(map[string]interface{}); ok {
+		uri, _ = metadata["uri"].(string)
+	}
+	if literal, ok := keyFromURI(uri); ok {
+		var keys []string
+		for _, part := range splitKeyPredicate(literal) {
+			if eq := strings.Index(part, "="); eq != -1 {
+				keys = append(keys, part[:eq])
 			}
-			return previewMsg{errorMsg: "Service not found"}
 		}
-
-	case 1: // EntitySets - preview entities
-		if m.odata != nil {
-			entitySetName := strings.Split(selectedItem, " [")[0]
-			
-			// Check if this is $metadata
-			if entitySetName == "$metadata" {
-				return func() tea.Msg {
-					// Fetch and preview metadata
-					metadataURL := strings.TrimSuffix(m.odata.baseURL, "/") + "/$metadata"
-					// For now, just show the URL and info
-					return previewMsg{previewType: "metadata", data: map[string]interface{}{
-						"url": metadataURL,
-						"note": "Service Metadata - press Enter to view full metadata document",
-						"type": "OData Service Metadata"}}
-				}
-			}
-			
-			// Check if this is a function import
-			if strings.HasPrefix(entitySetName, "[FUNC] ") {
-				funcName := strings.TrimPrefix(entitySetName, "[FUNC] ")
-				return func() tea.Msg {
-					// Get function metadata if available
-					return previewMsg{previewType: "function", data: map[string]interface{}{
-						"name": funcName,
-						"note": "Function Import - press Enter to view parameters and execute",
-						"type": "Function Import",
-						"description": fmt.Sprintf("OData Function Import: %s", funcName),
-						"parameters": "Parameters will be shown when metadata is loaded"}}
-				}
-			}
-			
-			return func() tea.Msg {
-				entities, _, err := m.odata.GetEntitiesWithCount(entitySetName, 10) // Default to 10 for preview
-				if err != nil {
-					return previewMsg{errorMsg: err.Error()}
-				}
-				return previewMsg{previewType: "entities", data: entities}
-			}
+		if len(keys) > 0 {
+			return keys
 		}
-
-	default: // Entity list or JSON details
-		if currentCol.isDetails {
-			// We're in JSON view - only preview if cursor is on a navigation association
-			if currentCol.cursor < len(currentCol.items) {
-				currentLine := currentCol.items[currentCol.cursor]
-				// Check if this line contains a deferred navigation property
-				if strings.Contains(currentLine, "__deferred") && strings.Contains(currentLine, "uri") {
-					// Extract URI from the line
-					if uriStart := strings.Index(currentLine, "https://"); uriStart != -1 {
-						uriEnd := strings.Index(currentLine[uriStart:], `"`)
-						if uriEnd != -1 {
-							uri := currentLine[uriStart : uriStart+uriEnd]
-							return func() tea.Msg {
-								// For now, show the URI as preview
-								// TODO: Actually fetch the related entity
-								return previewMsg{previewType: "navigation", data: map[string]interface{}{"uri": uri, "note": "Navigation property - press Enter to follow"}}
-							}
-						}
-					}
-				}
-			}
-			// No preview for regular JSON lines
-			return func() tea.Msg {
-				return previewMsg{previewType: "none", data: nil}
-			}
-		} else if currentCol.entities != nil && currentCol.cursor < len(currentCol.entities) {
-			// Entity list - preview JSON
-			selectedEntity := currentCol.entities[currentCol.cursor]
-			return func() tea.Msg {
-				return previewMsg{previewType: "json", data: selectedEntity}
+		// A single key is written without its name, so find the property holding it
+		literal = strings.Trim(literal, "'")
+		for _, field := range sortedKeys(entity) {
+			if value := entity[field]; value != nil && fmt.Sprint(value) == literal {
+				return []string{field}
 			}
 		}
 	}
 
-	return nil
-}
-
-func (m model) toggleEditMode() model {
-	// Only allow edit mode when viewing details of an entity
-	if m.activeColumn >= 0 && m.activeColumn < len(m.columns) {
-		currentCol := m.columns[m.activeColumn]
-		if currentCol.isDetails && len(currentCol.entities) > 0 {
-			m.editMode = !m.editMode
-			if m.editMode {
-				// Copy current JSON content for editing
-				m.editContent = make([]string, len(currentCol.items))
-				copy(m.editContent, currentCol.items)
-				m.editCursor = currentCol.cursor
-				m.logs = append(m.logs, "Entered EDIT mode - F5 to save, ESC to cancel")
-			} else {
-				m.logs = append(m.logs, "Exited EDIT mode")
-			}
-		} else {
-			m.logs = append(m.logs, "Edit mode only available for entity details")
+	keyFields := []string{"Program", "Class", "Interface", "Package", "Function",
+		"ID", "Id", "Key", "Code", "Number",
+		"ProductID", "CategoryID", "CustomerID", "OrderID", "EmployeeID"}
+	for _, field := range keyFields {
+		if _, ok := entity[field]; ok {
+			return []string{field}
 		}
 	}
-	return m
+	return nil
 }
 
-func (m model) saveChanges() model {
-	if !m.editMode || m.activeColumn >= len(m.columns) {
-		return m
-	}
-	
-	currentCol := &m.columns[m.activeColumn]
-	if !currentCol.isDetails || len(currentCol.entities) == 0 {
-		m.logs = append(m.logs, "No entity data to save")
-		return m
+// sortedKeys returns the property names of an entity in sorted order
+func sortedKeys(entity map[string]interface{}) []string {
+	keys := make([]string, 0, len(entity))
+	for k := range entity {
+		keys = append(keys, k)
 	}
+	sort.Strings(keys)
+	return keys
+}
 
-	// Try to parse the edited JSON
-	jsonContent := strings.Join(m.editContent, "\n")
-	var updatedEntity map[string]interface{}
-	if err := json.Unmarshal([]byte(jsonContent), &updatedEntity); err != nil {
-		m.logs = append(m.logs, fmt.Sprintf("Invalid JSON: %v", err))
-		return m
+// emptyKeyValue returns a blank placeholder for a key property, typed from the
+// metadata when known and otherwise from the source value
+func emptyKeyValue(prop *Property, source interface{}) interface{} {
+	if prop != nil {
+		switch prop.Type {
+		case "Edm.Int16", "Edm.Int32", "Edm.Int64", "Edm.Byte", "Edm.SByte", "Edm.Double", "Edm.Single":
+			return 0
+		}
+		return ""
 	}
-
-	// Update the stored entity
-	currentCol.entities[0] = updatedEntity
-	
-	// Update the display
-	jsonData, err := json.MarshalIndent(updatedEntity, "", "  ")
-	if err != nil {
-		m.logs = append(m.logs, fmt.Sprintf("Error formatting JSON: %v", err))
-		return m
+	if _, ok := source.(float64); ok {
+		return 0
 	}
-	
-	currentCol.items = strings.Split(string(jsonData), "\n")
-	m.editMode = false
-	m.logs = append(m.logs, "Changes saved locally (not persisted to server)")
-	
-	return m
+	return ""
 }
 
 // openModalEditor opens a full-screen modal editor for entity operations
 func (m model) openModalEditor(operation string) model {
 	m.modalEditor = true
 	m.modalOperation = operation
-	m.modalCursor = 0
-	m.modalColCursor = 0
-	m.modalScroll = 0
+	m.editor = textEditor{}
 	
 	switch operation {
 	case "create":
 		// Create empty JSON template for new entity
-		m.modalContent = []string{
+		m.editor = newTextEditor([]string{
 			"{",
 			"  ",
 			"}",
+		}, 1, 2)
+		m.logs = append(m.logs, "Create mode - F2 to save new entity (JSON object, JSON array, or pasted field<TAB>value rows), ESC to cancel")
+
+		// Start from the properties the metadata declares, the cursor in
+		// the value of the first required one
+		if lines, required := m.createSkeleton(m.editorEntitySet()); lines != nil {
+			m.editor = newTextEditor(lines, 1, strings.Index(lines[1], ":")+2)
+			m.modalKeyFields = required
+			if len(required) > 0 {
+				m.editor.moveToValue(required[0])
+				m.logs = append(m.logs, fmt.Sprintf("Required properties, highlighted: %s", strings.Join(required, ", ")))
+			}
 		}
-		m.modalCursor = 1
-		m.modalColCursor = 2
-		m.logs = append(m.logs, "Create mode - F2 to save new entity, ESC to cancel")
 		
-	case "update", "copy":
-		// Use current entity for update or copy
-		if m.activeColumn >= 0 && m.activeColumn < len(m.columns) {
+	case "copy":
+		// Start from the current entity with its key fields cleared
+		if m.activeColumn >= 0 && m.activeColumn < len(m.columns) && m.activeColumn > 0 {
 			currentCol := m.columns[m.activeColumn]
 			if currentCol.isDetails && len(currentCol.entities) > 0 {
-				// Copy current JSON content for editing
-				m.modalContent = make([]string, len(currentCol.items))
-				copy(m.modalContent, currentCol.items)
-				m.modalCursor = 0
-				m.modalColCursor = 0
-				
-				if operation == "update" {
-					m.logs = append(m.logs, "Update mode - F2 to save changes, ESC to cancel")
+				entitySetName := m.detailsEntitySet(m.activeColumn)
+				source := currentCol.entities[0]
+				keyFields := entityKeyFields(m.metadata(), entitySetName, source)
+
+				clone := make(map[string]interface{})
+				m.modalSourceKeys = make(map[string]interface{})
+				for k, v := range source {
+					// Metadata and deferred navigation properties can't be posted back
+					if strings.HasPrefix(k, "__") {
+						continue
+					}
+					if nested, ok := v.(map[string]interface{}); ok && nested["__deferred"] != nil {
+						continue
+					}
+					clone[k] = v
+				}
+				for _, key := range keyFields {
+					m.modalSourceKeys[key] = source[key]
+					clone[key] = emptyKeyValue(m.metadata().EntityTypeOf(entitySetName).Property(key), source[key])
+				}
+
+				jsonData, _ := json.MarshalIndent(clone, "", "  ")
+				m.editor.content = strings.Split(string(jsonData), "\n")
+				m.modalKeyFields = keyFields
+
+				if len(keyFields) > 0 {
+					// Put the cursor inside the first key value
+					m.editor.moveToValue(keyFields[0])
+					m.logs = append(m.logs, fmt.Sprintf("Copy mode - enter new key values for %s, F2 to save as new entity, ESC to cancel", strings.Join(keyFields, ", ")))
 				} else {
 					m.logs = append(m.logs, "Copy mode - F2 to save as new entity, ESC to cancel")
 				}
+				return m
+			}
+		}
+		m.modalEditor = false
+		m.logs = append(m.logs, "Update/Copy only available for entity details")
+		return m
+
+	case "bulkupdate":
+		// Start from an empty patch template applied to every marked entity
+		col := m.columns[m.activeColumn]
+		m.editor = newTextEditor([]string{
+			"{",
+			"  ",
+			"}",
+		}, 1, 2)
+		m.logs = append(m.logs, fmt.Sprintf("Bulk update mode - enter the properties to set on %d selected %s entities, F2 to apply, ESC to cancel", len(col.selected), col.entitySet))
+
+	case "update":
+		// Use current entity for update or copy
+		if m.activeColumn >= 0 && m.activeColumn < len(m.columns) {
+			currentCol := m.columns[m.activeColumn]
+			if currentCol.isDetails && len(currentCol.entities) > 0 {
+				// Copy current JSON content for editing, fully open and with dates
+				// written back the way the service sent them
+				data, _ := json.MarshalIndent(currentCol.entities[0], "", "  ")
+				m.editor = newTextEditor(strings.Split(string(data), "\n"), 0, 0)
+				
+				m.logs = append(m.logs, "Update mode - F2 to save changes, ESC to cancel")
 			} else {
 				m.modalEditor = false
 				m.logs = append(m.logs, "Update/Copy only available for entity details")
@@ -1169,7 +1140,12 @@ func (m model) openModalEditor(operation string) model {
 			return m
 		}
 	}
-	
+
+	m.checkEditorJSON()
+	// Single entities are edited in a form where the metadata describes them
+	if operation != "bulkupdate" && m.metadata().EntityTypeOf(m.editorEntitySet()) != nil {
+		m = m.openEntityForm()
+	}
 	return m
 }
 
@@ -1180,26 +1156,52 @@ func (m model) saveModalChanges() (tea.Model, tea.Cmd) {
 	}
 
 	// Try to parse the edited JSON
-	jsonContent := strings.Join(m.modalContent, "\n")
+	jsonContent, missing := expandVariables(m.editor.Text(), m.variables)
+	if len(missing) > 0 {
+		m.logs = append(m.logs, fmt.Sprintf("Unknown variables: {{%s}}", strings.Join(missing, "}}, {{")))
+		return m, nil
+	}
+
+	if m.modalOperation == "resubmit" {
+		return m.saveModalResubmit(jsonContent)
+	}
+
+	// A JSON array in create mode creates one entity per element
+	if m.modalOperation == "create" && strings.HasPrefix(strings.TrimSpace(jsonContent), "[") {
+		var entities []map[string]interface{}
+		if err := json.Unmarshal([]byte(jsonContent), &entities); err != nil {
+			m.logs = append(m.logs, fmt.Sprintf("Invalid JSON array: %v", err))
+			return m, nil
+		}
+		return m.saveModalBulkCreate(entities)
+	}
+
 	var updatedEntity map[string]interface{}
 	if err := json.Unmarshal([]byte(jsonContent), &updatedEntity); err != nil {
+		// Pasted "field<TAB>value" rows are converted to JSON for review first
+		if m.modalOperation == "create" {
+			if pasted, ok := parsePastedFields(jsonContent); ok {
+				jsonData, _ := json.MarshalIndent(pasted, "", "  ")
+				m.editor = newTextEditor(strings.Split(string(jsonData), "\n"), 0, 0)
+				m.logs = append(m.logs, fmt.Sprintf("Converted %d pasted fields to JSON - review and press F2 again to save", len(pasted)))
+				return m, nil
+			}
+		}
 		m.logs = append(m.logs, fmt.Sprintf("Invalid JSON: %v", err))
 		return m, nil
 	}
 
+	if m.modalOperation == "bulkupdate" {
+		return m.saveModalBulkUpdate(updatedEntity)
+	}
+
 	// Determine the entity set name
 	var entitySetName string
 	var entityKey string
 	
 	// For create operations, we need to find the current entity set
 	if m.modalOperation == "create" {
-		// Look for an entity set column
-		for _, col := range m.columns {
-			if col.title != "OData Services" && col.title != "EntitySets" && col.title != "Details" && col.title != "Metadata" {
-				entitySetName = col.title
-				break
-			}
-		}
+		entitySetName = m.createTargetEntitySet()
 		if entitySetName == "" {
 			m.logs = append(m.logs, "Cannot determine entity set for create operation")
 			return m, nil
@@ -1217,14 +1219,21 @@ func (m model) saveModalChanges() (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
-		// Find the entity set from the column before the details column
-		if m.activeColumn > 0 {
-			entitySetName = m.columns[m.activeColumn-1].title
-		}
+		entitySetName = m.detailsEntitySet(m.activeColumn)
 		
+		// A copy that still carries the source key would fail with a duplicate key
+		if m.modalOperation == "copy" {
+			for key, sourceValue := range m.modalSourceKeys {
+				if sourceValue != nil && fmt.Sprintf("%v", updatedEntity[key]) == fmt.Sprintf("%v", sourceValue) {
+					m.logs = append(m.logs, fmt.Sprintf("Key field %s still has the value of the copied entity - enter a new key", key))
+					return m, nil
+				}
+			}
+		}
+
 		// For update operations, extract the key from the original entity
 		if m.modalOperation == "update" {
-			entityKey = extractEntityKey(currentCol.entities[0])
+			entityKey = extractEntityKey(m.metadata(), entitySetName, currentCol.entities[0])
 			if entityKey == "" {
 				m.logs = append(m.logs, "Cannot determine entity key for update operation")
 				return m, nil
@@ -1237,6 +1246,16 @@ func (m model) saveModalChanges() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	updatedEntity = m.convertForService(entitySetName, updatedEntity)
+	// An update shows what it changes and is sent from the review
+	if m.modalOperation == "update" {
+		return m.openUpdateReview(entitySetName, entityKey, m.columns[m.activeColumn].entities[0], updatedEntity), nil
+	}
+	if m.staging {
+		m.closeModalEditor()
+		m.stage([]BatchRequest{{Method: "POST", EntitySet: entitySetName, Body: updatedEntity}}, nil)
+		return m, nil
+	}
 	m.loading = true
 	m.logs = append(m.logs, fmt.Sprintf("Performing %s operation on %s...", m.modalOperation, entitySetName))
 
@@ -1245,24 +1264,21 @@ func (m model) saveModalChanges() (tea.Model, tea.Cmd) {
 	return m, func() tea.Msg {
 		switch operation {
 		case "create", "copy":
-			err := m.odata.CreateEntity(entitySetName, updatedEntity)
+			result, err := m.odata.CreateEntity(entitySetName, updatedEntity)
 			if err != nil {
-				return errorMsg{err: err.Error(), context: fmt.Sprintf("%s operation", operation)}
+				return newErrorMsg(err, fmt.Sprintf("%s operation", operation))
 			}
-			return saveSuccessMsg{
-				operation: operation,
-				entitySet: entitySetName,
-				message:   "Entity created successfully",
-			}
-		case "update":
-			err := m.odata.UpdateEntity(entitySetName, entityKey, updatedEntity)
-			if err != nil {
-				return errorMsg{err: err.Error(), context: fmt.Sprintf("%s operation", operation)}
+			// Services answering 204 only tell us where the new entity lives
+			if result.Entity == nil && result.Location != "" {
+				if entity, err := m.odata.GetEntityByURL(result.Location); err == nil {
+					result.Entity = entity
+				}
 			}
 			return saveSuccessMsg{
 				operation: operation,
 				entitySet: entitySetName,
-				message:   "Entity updated successfully",
+				message:   "Entity created successfully",
+				result:    result,
 			}
 		default:
 			return errorMsg{err: "Unknown operation: " + operation, context: "saveModalChanges"}
@@ -1270,6 +1286,139 @@ func (m model) saveModalChanges() (tea.Model, tea.Cmd) {
 	}
 }
 
+// applySave closes the modal editor after a successful write and shows
+// what the server made of it
+func (m model) applySave(msg saveSuccessMsg) (tea.Model, tea.Cmd) {
+	m.loading = false
+	m.closeModalEditor()
+	m.logs = append(m.logs, fmt.Sprintf("SUCCESS: %s operation completed - %s", msg.operation, msg.message))
+	if msg.result != nil {
+		// The lists, and the Details of an update, are read again for
+		// what the server made of the write
+		var key string
+		var reread tea.Cmd
+		if (msg.operation == "create" || msg.operation == "copy") && msg.result.Entity != nil {
+			m.insertCreatedEntity(msg.entitySet, msg.result.Entity)
+			key = extractEntityKey(m.metadata(), msg.entitySet, msg.result.Entity)
+		}
+		if msg.operation == "update" && m.activeColumn < len(m.columns) && len(m.columns[m.activeColumn].entities) > 0 {
+			key = extractEntityKey(m.metadata(), msg.entitySet, m.columns[m.activeColumn].entities[0])
+			reread = m.rereadDetails(msg.entitySet, key)
+		}
+		m.openResultColumn(msg.entitySet, []*OperationResult{msg.result})
+		reload := m.reloadAfterWrite(msg.entitySet, key)
+		return m, tea.Batch(reload, reread)
+	}
+	return m, nil
+}
+
+// closeModalEditor closes the modal editor and resets its state
+func (m *model) closeModalEditor() {
+	m.dropEditorBuffer()
+	m.modalEditor = false
+	m.editor = textEditor{}
+	m.modalOperation = ""
+	m.form = entityForm{}
+	m.jsonProblem = nil
+	m.completionHint = ""
+	m.unknownWarned = ""
+	m.modalKeyFields = nil
+	m.modalSourceKeys = nil
+}
+
+// createTargetEntitySet returns the entity set that new entities are created in
+func (m model) createTargetEntitySet() string {
+	// Look for an entity set column
+	for _, col := range m.columns {
+		if col.entitySet != "" && !col.isDetails {
+			return col.entitySet
+		}
+	}
+	return ""
+}
+
+// saveModalBulkCreate posts each element of a JSON array as a new entity,
+// one request after another, and reports the outcome per element
+func (m model) saveModalBulkCreate(entities []map[string]interface{}) (tea.Model, tea.Cmd) {
+	entitySetName := m.createTargetEntitySet()
+	if entitySetName == "" {
+		m.logs = append(m.logs, "Cannot determine entity set for create operation")
+		return m, nil
+	}
+	if len(entities) == 0 {
+		m.logs = append(m.logs, "JSON array is empty - nothing to create")
+		return m, nil
+	}
+	entities, changes := convertPayloads(entities, m.metadata(), entitySetName, m.odata.Version())
+	if len(changes) > 0 {
+		m.logs = append(m.logs, fmt.Sprintf("Converted for OData %s: %s", m.odata.Version(), strings.Join(changes, ", ")))
+	}
+	if m.staging {
+		requests := make([]BatchRequest, len(entities))
+		for i, entity := range entities {
+			requests[i] = BatchRequest{Method: "POST", EntitySet: entitySetName, Body: entity}
+		}
+		m.closeModalEditor()
+		m.stage(requests, nil)
+		return m, nil
+	}
+
+	p := m.startProgress("Creating in "+entitySetName, "entities", len(entities))
+	if p == nil {
+		return m, nil
+	}
+	m.logs = append(m.logs, fmt.Sprintf("Creating %d entities in %s...", len(entities), entitySetName))
+
+	return m, p.run(func() tea.Msg {
+		results := make([]bulkCreateResult, len(entities))
+		for i, entity := range entities {
+			if p.cancelled() {
+				results[i] = bulkCreateResult{entity: entity, err: errRequestCancelled}
+				continue
+			}
+			result, err := p.odata.CreateEntity(entitySetName, entity)
+			results[i] = bulkCreateResult{entity: entity, result: result, err: err}
+			p.report(i+1, len(entities))
+		}
+		return bulkCreateMsg{entitySet: entitySetName, results: results}
+	})
+}
+
+// applyBulkCreate logs the outcome of each entity of a bulk create and
+// leaves the failed ones in the editor
+func (m *model) applyBulkCreate(msg bulkCreateMsg) {
+	m.loading = false
+	var failed []map[string]interface{}
+	for i, result := range msg.results {
+		if result.err != nil {
+			failed = append(failed, result.entity)
+			m.logs = append(m.logs, fmt.Sprintf("ERROR [create %s #%d]: %v", msg.entitySet, i+1, result.err))
+		} else {
+			m.logs = append(m.logs, fmt.Sprintf("Created %s #%d", msg.entitySet, i+1))
+		}
+	}
+	created := len(msg.results) - len(failed)
+	var results []*OperationResult
+	for _, r := range msg.results {
+		if r.result != nil {
+			results = append(results, r.result)
+			if r.err == nil && r.result.Entity != nil {
+				m.insertCreatedEntity(msg.entitySet, r.result.Entity)
+			}
+		}
+	}
+	m.openResultColumn(msg.entitySet, results)
+	if len(failed) == 0 {
+		m.closeModalEditor()
+		m.logs = append(m.logs, fmt.Sprintf("SUCCESS: create operation completed - %d entities created in %s", created, msg.entitySet))
+	} else {
+		// Keep only the failed elements in the editor so they can be fixed and resubmitted
+		jsonData, _ := json.MarshalIndent(failed, "", "  ")
+		m.editor = newTextEditor(strings.Split(string(jsonData), "\n"), 0, 0)
+		m.logs = append(m.logs, fmt.Sprintf("%d of %d entities created - failed elements left in the editor", created, len(msg.results)))
+	}
+}
+
 func (m model) View() string {
 	if m.width == 0 {
 		return "Loading..."
@@ -1281,9 +1430,13 @@ func (m model) View() string {
 
 	// Calculate dimensions
 	bodyHeight := m.height - 5 // header(1) + spacing(2) + footer(1) + spacing(1)
+	banner := m.renderSecurityBanner()
+	if banner != "" {
+		bodyHeight--
+	}
 	logHeight := 0
 	
-	if m.showLogs {
+	if m.logPane.visible {
 		logHeight = min(10, bodyHeight/3)
 		bodyHeight = bodyHeight - logHeight - 1
 	}
@@ -1292,25 +1445,17 @@ func (m model) View() string {
 	for i := range m.columns {
 		m.columns[i].height = bodyHeight
 	}
-	if m.previewColumn != nil {
-		m.previewColumn.height = bodyHeight
-	}
+	m.preview.column.height = bodyHeight
 
 	var columns []string
 	
 	for i, col := range m.columns {
-		columns = append(columns, m.renderColumn(col, i == m.activeColumn))
+		columns = append(columns, col.View(m.columnView(i)))
 	}
 	
 	// Add preview column
-	if m.previewColumn != nil {
-		previewTitle := m.previewColumn.title
-		if m.previewLoading {
-			previewTitle += " (Loading...)"
-		}
-		previewCol := *m.previewColumn
-		previewCol.title = previewTitle
-		columns = append(columns, m.renderColumn(previewCol, false))
+	if preview := m.renderPreview(); preview != "" {
+		columns = append(columns, preview)
 	}
 
 	headerText := "OData Navigator"
@@ -1321,25 +1466,37 @@ func (m model) View() string {
 	
 	header := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("99")).
+		Foreground(theme.Accent).
 		Render(headerText)
 
-	footerText := "F2:Create F3:Read F4:Update F5:Copy F7:Filter F8:Delete F9:Toggle Logs F10:Exit | ESC:Back"
+	footerText := strings.Replace(footerKeys(false, m.keymap), "L:Log", "L:Log("+m.logPane.level.String()+")", 1)
 	if m.modalEditor {
-		footerText = "MODAL EDITOR - F2:Save ESC:Cancel | Navigation: Up/Down/PgUp/PgDown/Home/End"
-	} else if m.editMode {
+		footerText = "MODAL EDITOR - " + footerKeys(true, m.keymap)
+	} else if m.finding {
+		footerText = "FIND - type to narrow the column | Up/Down: Move | Enter: Keep | ESC: Clear"
+	} else if m.inlineEdit.active {
 		footerText = "EDIT MODE - F5:Save ESC:Cancel | " + footerText
+	} else if m.transfer != nil {
+		footerText = m.transfer.status() + " | " + footerText
+	} else if m.progress != nil {
+		footerText = m.progress.status() + " | " + footerText
+	} else if m.staging || len(m.staged) > 0 {
+		footerText = m.stagingStatus() + " | " + footerText
 	}
 	footer := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("241")).
+		Foreground(theme.Muted).
 		Render(footerText)
 
 	body := lipgloss.JoinHorizontal(lipgloss.Top, columns...)
 	
-	// Build the complete view
-	parts := []string{header, "", body}
+	// Build the complete view; the breadcrumb takes the line under the header
+	breadcrumb := m.renderBreadcrumb()
+	parts := []string{header, breadcrumb, body}
+	if banner != "" {
+		parts = []string{header, banner, breadcrumb, body}
+	}
 	
-	if m.showLogs {
+	if m.logPane.visible {
 		logView := m.renderLogs(logHeight)
 		parts = append(parts, logView)
 	}
@@ -1348,40 +1505,12 @@ func (m model) View() string {
 	
 	view := lipgloss.JoinVertical(lipgloss.Left, parts...)
 	
-	// Overlay modal editor if active
-	if m.modalEditor {
-		view = m.renderModalOverlay(view)
-	}
+	// Dialogs and the modal editor go on top
+	view = m.renderOverlays(view)
 	
 	return view
 }
 
-func (m model) renderLogs(height int) string {
-	logStyle := lipgloss.NewStyle().
-		Width(m.width).
-		Height(height).
-		Border(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color("241"))
-	
-	// Get last N log entries that fit in the height
-	startIdx := 0
-	if len(m.logs) > height-2 { // -2 for border
-		startIdx = len(m.logs) - (height - 2)
-	}
-	
-	var logLines []string
-	for i := startIdx; i < len(m.logs); i++ {
-		logLines = append(logLines, m.logs[i])
-	}
-	
-	content := strings.Join(logLines, "\n")
-	if m.loading {
-		content += "\n[Loading...]"
-	}
-	
-	return logStyle.Render(content)
-}
-
 // renderModalOverlay renders a modal editor overlay on top of the main view
 func (m model) renderModalOverlay(baseView string) string {
 	// Calculate modal dimensions (95% of screen)
@@ -1389,57 +1518,18 @@ func (m model) renderModalOverlay(baseView string) string {
 	modalHeight := int(float64(m.height) * 0.95)
 	
 	// Calculate content dimensions
-	contentHeight := modalHeight - 4 // Account for borders and header
+	contentHeight := m.editorHeight()
 	
-	// Prepare modal content
-	var visibleContent []string
-	if len(m.modalContent) > 0 {
-		endIdx := m.modalScroll + contentHeight
-		if endIdx > len(m.modalContent) {
-			endIdx = len(m.modalContent)
-		}
-		visibleContent = m.modalContent[m.modalScroll:endIdx]
+	var mark *editorMark
+	status := lipgloss.NewStyle().Foreground(theme.Success).Render(" ✓ JSON parses")
+	if m.jsonProblem != nil {
+		mark = &m.jsonProblem.editorMark
+		status = lipgloss.NewStyle().Foreground(theme.Error).Render(" ✗ " + m.jsonProblem.String(m.editor.content) + " - F2 saves once it is fixed")
 	}
-	
-	// Add cursor indicator and line numbers
-	var renderedLines []string
-	for i, line := range visibleContent {
-		lineNum := m.modalScroll + i
-		prefix := fmt.Sprintf("%4d ", lineNum+1)
-		
-		if lineNum == m.modalCursor {
-			// Show column cursor position within line
-			displayLine := line
-			if m.modalColCursor <= len(line) {
-				// Insert cursor marker
-				before := line[:m.modalColCursor]
-				after := line[m.modalColCursor:]
-				if m.modalColCursor < len(line) {
-					// Show cursor as background highlight on character
-					cursorChar := string(line[m.modalColCursor])
-					displayLine = before + lipgloss.NewStyle().Background(lipgloss.Color("226")).Foreground(lipgloss.Color("0")).Render(cursorChar) + after[1:]
-				} else {
-					// Show cursor at end of line
-					displayLine = line + lipgloss.NewStyle().Background(lipgloss.Color("226")).Render(" ")
-				}
-			}
-			
-			line = lipgloss.NewStyle().
-				Background(lipgloss.Color("99")).
-				Foreground(lipgloss.Color("15")).
-				Render(prefix) + displayLine
-		} else {
-			line = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("241")).
-				Render(prefix) + line
-		}
-		renderedLines = append(renderedLines, line)
-	}
-	
-	// Fill remaining space with empty lines
-	for len(renderedLines) < contentHeight {
-		renderedLines = append(renderedLines, "")
+	if m.completionHint != "" {
+		status = lipgloss.NewStyle().Foreground(theme.Warning).Render(" " + m.completionHint)
 	}
+	renderedLines := append(m.editor.View(contentHeight, m.editorWidth(), m.isModalKeyLine, mark), fitItem(status, modalWidth))
 	
 	content := strings.Join(renderedLines, "\n")
 	
@@ -1448,15 +1538,19 @@ func (m model) renderModalOverlay(baseView string) string {
 		Width(modalWidth).
 		Height(modalHeight).
 		Border(lipgloss.DoubleBorder()).
-		BorderForeground(lipgloss.Color("99")).
-		Background(lipgloss.Color("0")).
-		Foreground(lipgloss.Color("15"))
+		BorderForeground(theme.Accent).
+		Background(theme.Background).
+		Foreground(theme.Text)
 	
-	title := " Modal Editor - F2: Save | ESC: Cancel "
+	assist := "on"
+	if m.editorPlain {
+		assist = "off"
+	}
+	title := " Modal Editor - F2: Save | ^E: Form | ^F: Format JSON | ^C/^X/^V: Copy/Cut/Paste | ^D/^K: Duplicate/Delete Line | Alt+↑↓: Move Line | ^T: Auto-close " + assist + " | ESC: Cancel "
 	titleStyle := lipgloss.NewStyle().
 		Bold(true).
-		Background(lipgloss.Color("99")).
-		Foreground(lipgloss.Color("0")).
+		Background(theme.Accent).
+		Foreground(theme.AccentText).
 		Padding(0, 1)
 	
 	// Render modal with title
@@ -1497,6 +1591,17 @@ func (m model) renderModalOverlay(baseView string) string {
 	return strings.Join(baseLines, "\n")
 }
 
+// isModalKeyLine reports whether an editor line holds one of the highlighted fields
+func (m model) isModalKeyLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	for _, key := range m.modalKeyFields {
+		if strings.HasPrefix(trimmed, fmt.Sprintf("%q:", key)) {
+			return true
+		}
+	}
+	return false
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -1504,148 +1609,6 @@ func min(a, b int) int {
 	return b
 }
 
-func (m model) renderColumn(col column, isActive bool) string {
-	var items []string
-	
-	titleStyle := lipgloss.NewStyle().
-		Bold(true).
-		Padding(0, 1)
-	
-	if isActive {
-		titleStyle = titleStyle.Foreground(lipgloss.Color("99"))
-	} else {
-		titleStyle = titleStyle.Foreground(lipgloss.Color("241"))
-	}
-
-	// If in edit mode and this is the active column with details
-	if m.editMode && isActive && col.isDetails {
-		// Show editable content with EDIT indicator in title
-		titleStyle = titleStyle.Background(lipgloss.Color("208")).Foreground(lipgloss.Color("0"))
-		
-		for i, item := range m.editContent {
-			style := lipgloss.NewStyle().Padding(0, 1)
-			
-			if i == m.editCursor {
-				// Highlight current edit line with different color
-				style = style.Background(lipgloss.Color("208")).Foreground(lipgloss.Color("0"))
-				item = "► " + item // Add edit cursor indicator
-			} else {
-				// Make non-cursor lines stand out as editable
-				style = style.Background(lipgloss.Color("235")).Foreground(lipgloss.Color("15"))
-			}
-			
-			items = append(items, style.Render(item))
-		}
-	} else {
-		// Normal display mode
-		// Calculate viewport for scrolling on all columns
-		startIdx := 0
-		endIdx := len(col.items)
-		
-		if col.height > 2 {
-			// Implement viewport scrolling for all columns
-			visibleHeight := col.height - 2 // Account for borders
-			startIdx = col.scrollOffset
-			endIdx = startIdx + visibleHeight
-			if endIdx > len(col.items) {
-				endIdx = len(col.items)
-			}
-		}
-		
-		for i := startIdx; i < endIdx; i++ {
-			if i >= len(col.items) {
-				break
-			}
-			item := col.items[i]
-			style := lipgloss.NewStyle().Padding(0, 1)
-			
-			// Color function imports and more indicators differently
-			if strings.HasPrefix(item, "[FUNC]") {
-				if i == col.cursor && isActive {
-					style = style.Background(lipgloss.Color("99")).Foreground(lipgloss.Color("0"))
-				} else if i == col.cursor {
-					style = style.Background(lipgloss.Color("241")).Foreground(lipgloss.Color("15"))
-				} else {
-					// Function imports in purple/magenta
-					style = style.Foreground(lipgloss.Color("13"))
-				}
-			} else if strings.HasPrefix(item, "[...more") {
-				// More indicator in gray/dimmed
-				if i == col.cursor && isActive {
-					style = style.Background(lipgloss.Color("99")).Foreground(lipgloss.Color("0"))
-				} else if i == col.cursor {
-					style = style.Background(lipgloss.Color("241")).Foreground(lipgloss.Color("15"))
-				} else {
-					style = style.Foreground(lipgloss.Color("8")) // Gray/dimmed
-				}
-			} else {
-				if i == col.cursor && isActive {
-					style = style.Background(lipgloss.Color("99")).Foreground(lipgloss.Color("0"))
-				} else if i == col.cursor {
-					style = style.Background(lipgloss.Color("241")).Foreground(lipgloss.Color("15"))
-				}
-				
-				// Handle grayed out additional info
-				if strings.Contains(item, " | ") {
-					parts := strings.SplitN(item, " | ", 2)
-					if len(parts) == 2 {
-						// Style: key (normal) + " | " + description (grayed)
-						mainPart := parts[0]
-						grayPart := " | " + parts[1]
-						
-						if i == col.cursor && isActive {
-							item = mainPart + lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(grayPart)
-						} else if i == col.cursor {
-							item = mainPart + lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(grayPart)
-						} else {
-							item = mainPart + lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(grayPart)
-						}
-					}
-				}
-			}
-			
-			items = append(items, style.Render(item))
-		}
-	}
-
-	content := lipgloss.JoinVertical(lipgloss.Left, items...)
-	
-	columnStyle := lipgloss.NewStyle().
-		Width(col.width).
-		Height(col.height).
-		Border(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color("241"))
-	
-	if isActive {
-		columnStyle = columnStyle.BorderForeground(lipgloss.Color("99"))
-	}
-
-	// Modify title for edit mode and add scroll indicator
-	title := col.title
-	if m.editMode && isActive && col.isDetails {
-		title = "[EDIT] " + col.title
-	}
-	// Add scroll indicator for any column with large content
-	if len(col.items) > col.height-2 && col.height > 2 {
-		totalLines := len(col.items)
-		visibleHeight := col.height - 2
-		currentPos := col.scrollOffset + 1
-		endPos := currentPos + visibleHeight - 1
-		if endPos > totalLines {
-			endPos = totalLines
-		}
-		title = fmt.Sprintf("%s (%d-%d/%d)", col.title, currentPos, endPos, totalLines)
-	}
-	
-	return columnStyle.Render(
-		lipgloss.JoinVertical(lipgloss.Left,
-			titleStyle.Render(title),
-			"",
-			content,
-		),
-	)
-}
-
 // formatMetadataForDisplay formats XML metadata with proper line wrapping and formatting
 func formatMetadataForDisplay(metadata string, maxWidth int) []string {
 	if maxWidth < 20 {
@@ -1725,8 +1688,37 @@ func wrapLine(line string, maxWidth int) []string {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "update" {
+		if err := runUpdate(); err != nil {
+			fmt.Printf("Update failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "register-scheme" {
+		if err := runRegisterScheme(); err != nil {
+			fmt.Printf("Registering %s:// links failed: %v\n", linkScheme, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "export-metadata" {
+		err := runExportMetadata()
+		closeTunnels()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Exporting metadata failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	p := tea.NewProgram(initialModel(), tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	saveUnsavedLayout(final)
+	// SSH tunnels to jump hosts end with the navigator
+	closeTunnels()
+	removeInstanceTempDir()
+	if err != nil {
 		fmt.Printf("Error: %v", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// DefaultMemoryCacheTTL is how long responses are reused in memory before
+// they are fetched again
+const DefaultMemoryCacheTTL = 5 * time.Minute
+
+// memoryCacheMaxBody keeps large responses such as media streams out of memory
+const memoryCacheMaxBody = 8 << 20
+
+// memoryCache keeps GET responses of the running session, so that moving
+// the cursor back and forth doesn't fetch entity set lists, metadata and
+// entity pages again
+type memoryCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*cacheEntry
+}
+
+// memoryResponses is the memory cache shared by all OData clients, nil when disabled
+var memoryResponses *memoryCache
+
+// enableMemoryCache turns on the memory cache for clients created afterwards
+func enableMemoryCache(ttl time.Duration) {
+	memoryResponses = &memoryCache{ttl: ttl, entries: make(map[string]*cacheEntry)}
+}
+
+// key tells apart responses to different logins to the same URL
+func (c *memoryCache) key(req *http.Request) string {
+	return requestIdentity(req) + " " + req.URL.String()
+}
+
+// load returns the entry of a request while it is fresh
+func (c *memoryCache) load(req *http.Request) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := c.entries[c.key(req)]
+	if entry == nil || time.Since(entry.Stored) >= c.ttl {
+		return nil
+	}
+	return entry
+}
+
+func (c *memoryCache) store(req *http.Request, entry *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, old := range c.entries {
+		if time.Since(old.Stored) >= c.ttl {
+			delete(c.entries, key)
+		}
+	}
+	c.entries[c.key(req)] = entry
+}
+
+// drop removes the entries whose URL starts with prefix
+func (c *memoryCache) drop(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, entry := range c.entries {
+		if strings.HasPrefix(entry.URL, prefix) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// memoryTransport answers GET requests from a memoryCache and forgets the
+// entity sets that writes go to
+type memoryTransport struct {
+	base  http.RoundTripper
+	cache *memoryCache
+}
+
+func (t *memoryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Cache-Control") == "no-store" {
+		return t.base.RoundTrip(req)
+	}
+	if req.Method != http.MethodGet {
+		resp, err := t.base.RoundTrip(req)
+		if err == nil && resp.StatusCode < 300 {
+			t.cache.drop(writePrefix(req))
+		}
+		return resp, err
+	}
+
+	if entry := t.cache.load(req); entry != nil {
+		requestTraces.addCached(req, entry, "memory hit")
+		return entry.response(req, "memory"), nil
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK || resp.ContentLength > memoryCacheMaxBody {
+		return resp, err
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, memoryCacheMaxBody+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if len(body) > memoryCacheMaxBody {
+		// Too large after all; hand on what was read followed by the rest
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	t.cache.store(req, &cacheEntry{
+		URL:        req.URL.String(),
+		Stored:     time.Now(),
+		StatusCode: resp.StatusCode,
+		Header:     storedHeader(resp.Header),
+		Body:       body,
+	})
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// Refresh forgets the cached responses of URLs starting with prefix, in
+// memory and on disk, so the next requests for them go to the server
+func (o *ODataService) Refresh(prefix string) {
+	if memoryResponses != nil {
+		memoryResponses.drop(prefix)
+	}
+	if responseCache != nil {
+		responseCache.drop(prefix)
+	}
+}
+
+// refreshColumn reloads the active column from the server, bypassing the
+// cached responses it was built from
+func (m model) refreshColumn() (tea.Model, tea.Cmd) {
+	if m.odata == nil || m.activeColumn <= 0 || m.activeColumn >= len(m.columns) {
+		m.logs = append(m.logs, "ctrl+r: Nothing to refresh in this column")
+		return m, nil
+	}
+	i := m.activeColumn
+	m.columns[i].failure = ""
+	col := m.columns[i]
+	m.columns = m.columns[:i+1]
+
+	switch {
+	case col.title == "EntitySets":
+		// $metadata types everything below it, so the whole service is reloaded
+		m.odata.Refresh(m.odata.BuildURL("", "", QueryOptions{}))
+		m.setCounts = nil
+		m.columns[i].items = []string{"Loading..."}
+		m.columns[i].state = stateLoading
+		m.columns[i].cursor = 0
+		m.columns[i].scrollOffset = 0
+		m.updateColumnSizes()
+		m.loading = true
+		m.logs = append(m.logs, "Refreshing entity sets")
+		return m, tea.Batch(loadEntitySets(m.odata, col.id), m.updatePreview())
+
+	case (col.entitySet != "" || col.navURL != "") && !col.isDetails && col.raw == nil && !col.isResult:
+		m.odata.Refresh(m.odata.BuildURL(col.resource(), "", QueryOptions{}))
+		m.columns[i].items = []string{"Loading..."}
+		m.columns[i].state = stateLoading
+		m.columns[i].entities = nil
+		m.columns[i].selected = nil
+		m.columns[i].cursor = 0
+		m.columns[i].scrollOffset = 0
+		m.updateColumnSizes()
+		m.loading = true
+		m.logs = append(m.logs, fmt.Sprintf("Refreshing %s", col.title))
+		return m, loadEntities(m.odata, col)
+
+	case col.title == "Metadata":
+		m.odata.Refresh(m.odata.BuildURL("$metadata", "", QueryOptions{}))
+		m.columns = m.columns[:i]
+		m.activeColumn = i - 1
+		m.logs = append(m.logs, "Refreshing metadata")
+		return m.drillDown()
+
+	case col.isDetails && len(m.columns[i-1].entities) > 0 && !m.columns[i-1].isDetails:
+		parent := m.columns[i-1]
+		m.odata.Refresh(m.odata.BuildURL(parent.resource(), "", QueryOptions{}))
+		m.activeColumn = i - 1
+		next, cmd := m.readEntityDetails()
+		nm := next.(model)
+		nm.activeColumn = i
+		return nm, cmd
+	}
+	m.logs = append(m.logs, "ctrl+r: Nothing to refresh in this column")
+	return m, nil
+}
@@ -0,0 +1,559 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Metadata is the parsed form of a service's $metadata document
+type Metadata struct {
+	Version         string // OData protocol version: "2.0", "3.0" or "4.0"
+	EntityTypes     map[string]*EntityType
+	EnumTypes       map[string][]string // Member names of the V4 enumeration types, by qualified name
+	EntitySets      []*EntitySet
+	FunctionImports []string
+	Functions       []FunctionImport // Signatures of the function and action imports
+	Associations    []Association    // V2/V3 only; V4 navigation properties name their target type
+}
+
+type EntityType struct {
+	Name                 string               `json:"name"`
+	Namespace            string               `json:"namespace"`
+	Keys                 []string             `json:"keys"`
+	Properties           []Property           `json:"properties"`
+	HasStream            bool                 `json:"hasStream,omitempty"`
+	Navigation           []string             `json:"-"` // Navigation property names
+	NavigationProperties []NavigationProperty `json:"navigationProperties,omitempty"`
+}
+
+// NavigationProperty is a navigation property with the entity type it leads to
+type NavigationProperty struct {
+	Name         string `json:"name"`
+	Target       string `json:"target"`                 // Qualified entity type name
+	Many         bool   `json:"many"`                   // Leads to a collection
+	Relationship string `json:"relationship,omitempty"` // V2/V3 association, if any
+	Partner      string `json:"partner,omitempty"`      // V4 navigation property back
+	toRole       string // V2/V3 association end it leads to
+}
+
+// Association is a V2/V3 relationship between two entity types
+type Association struct {
+	Name string           `json:"name"` // Qualified name
+	Ends []AssociationEnd `json:"ends"`
+}
+
+type AssociationEnd struct {
+	Role         string `json:"role"`
+	Type         string `json:"type"`
+	Multiplicity string `json:"multiplicity"` // "1", "0..1" or "*"
+}
+
+// FunctionImport is a service operation or V4 function or action import
+type FunctionImport struct {
+	Name       string      `json:"name"`
+	Kind       string      `json:"kind"`                 // "function" or "action"
+	HTTPMethod string      `json:"httpMethod,omitempty"` // V2 m:HttpMethod
+	ReturnType string      `json:"returnType,omitempty"`
+	EntitySet  string      `json:"entitySet,omitempty"`
+	Parameters []Parameter `json:"parameters,omitempty"`
+}
+
+type Parameter struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+}
+
+type Property struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Nullable    bool   `json:"nullable"`
+	MaxLength   string `json:"maxLength,omitempty"`
+	Precision   string `json:"precision,omitempty"`
+	Scale       string `json:"scale,omitempty"`
+	Label       string `json:"label,omitempty"`
+	Heading     string `json:"heading,omitempty"`     // SAP column heading
+	QuickInfo   string `json:"quickInfo,omitempty"`   // SAP tooltip text
+	Description string `json:"description,omitempty"` // V4 Core.Description
+	Computed    bool   `json:"computed,omitempty"`    // Set by the service only: sap:creatable and sap:updatable false, or Core.Computed
+	Immutable   bool   `json:"immutable,omitempty"`   // Set on create only: sap:updatable false, or Core.Immutable
+}
+
+type EntitySet struct {
+	Name         string             `json:"name"`
+	EntityType   string             `json:"entityType"` // Qualified entity type name as written in the metadata
+	Capabilities EntityCapabilities `json:"capabilities"`
+}
+
+// Raw EDMX structures used for decoding. Attributes are matched by local name,
+// so sap:creatable and plain creatable decode into the same field.
+type edmxDocument struct {
+	Version      string `xml:"Version,attr"`
+	DataServices struct {
+		DataServiceVersion string       `xml:"DataServiceVersion,attr"`
+		Schemas            []edmxSchema `xml:"Schema"`
+	} `xml:"DataServices"`
+}
+
+type edmxSchema struct {
+	Namespace   string `xml:"Namespace,attr"`
+	Alias       string `xml:"Alias,attr"`
+	EntityTypes []struct {
+		Name      string `xml:"Name,attr"`
+		HasStream string `xml:"HasStream,attr"`
+		Key       struct {
+			PropertyRefs []struct {
+				Name string `xml:"Name,attr"`
+			} `xml:"PropertyRef"`
+		} `xml:"Key"`
+		Properties []struct {
+			Name        string           `xml:"Name,attr"`
+			Type        string           `xml:"Type,attr"`
+			Nullable    string           `xml:"Nullable,attr"`
+			MaxLength   string           `xml:"MaxLength,attr"`
+			Precision   string           `xml:"Precision,attr"`
+			Scale       string           `xml:"Scale,attr"`
+			Label       string           `xml:"label,attr"`
+			Heading     string           `xml:"heading,attr"`
+			QuickInfo   string           `xml:"quickinfo,attr"`
+			Creatable   string           `xml:"creatable,attr"`
+			Updatable   string           `xml:"updatable,attr"`
+			Annotations []edmxAnnotation `xml:"Annotation"`
+		} `xml:"Property"`
+		NavigationProperties []struct {
+			Name         string `xml:"Name,attr"`
+			Relationship string `xml:"Relationship,attr"`
+			ToRole       string `xml:"ToRole,attr"`
+			Type         string `xml:"Type,attr"`
+			Partner      string `xml:"Partner,attr"`
+		} `xml:"NavigationProperty"`
+	} `xml:"EntityType"`
+	EnumTypes []struct {
+		Name    string `xml:"Name,attr"`
+		Members []struct {
+			Name string `xml:"Name,attr"`
+		} `xml:"Member"`
+	} `xml:"EnumType"`
+	Associations []struct {
+		Name string `xml:"Name,attr"`
+		Ends []struct {
+			Role         string `xml:"Role,attr"`
+			Type         string `xml:"Type,attr"`
+			Multiplicity string `xml:"Multiplicity,attr"`
+		} `xml:"End"`
+	} `xml:"Association"`
+	Functions        []edmxOperation `xml:"Function"`
+	Actions          []edmxOperation `xml:"Action"`
+	EntityContainers []struct {
+		Name       string `xml:"Name,attr"`
+		EntitySets []struct {
+			Name        string           `xml:"Name,attr"`
+			EntityType  string           `xml:"EntityType,attr"`
+			Creatable   string           `xml:"creatable,attr"`
+			Updatable   string           `xml:"updatable,attr"`
+			Deletable   string           `xml:"deletable,attr"`
+			Searchable  string           `xml:"searchable,attr"`
+			Annotations []edmxAnnotation `xml:"Annotation"`
+		} `xml:"EntitySet"`
+		FunctionImports []struct {
+			edmxOperation
+			HTTPMethod string `xml:"HttpMethod,attr"`
+			EntitySet  string `xml:"EntitySet,attr"`
+			Function   string `xml:"Function,attr"`
+		} `xml:"FunctionImport"`
+		ActionImports []struct {
+			Name      string `xml:"Name,attr"`
+			EntitySet string `xml:"EntitySet,attr"`
+			Action    string `xml:"Action,attr"`
+		} `xml:"ActionImport"`
+	} `xml:"EntityContainer"`
+	Annotations []struct {
+		Target      string           `xml:"Target,attr"`
+		Annotations []edmxAnnotation `xml:"Annotation"`
+	} `xml:"Annotations"`
+}
+
+// edmxOperation is a V2 function import or a V4 function or action, which
+// carry their parameters and return type alike
+type edmxOperation struct {
+	Name       string `xml:"Name,attr"`
+	ReturnType string `xml:"ReturnType,attr"` // V2
+	Return     struct {
+		Type string `xml:"Type,attr"`
+	} `xml:"ReturnType"` // V4
+	Parameters []struct {
+		Name     string `xml:"Name,attr"`
+		Type     string `xml:"Type,attr"`
+		Nullable string `xml:"Nullable,attr"`
+	} `xml:"Parameter"`
+}
+
+// signature returns the operation as a FunctionImport of the given kind
+func (op edmxOperation) signature(name, kind string) FunctionImport {
+	fi := FunctionImport{Name: name, Kind: kind, ReturnType: op.ReturnType}
+	if fi.ReturnType == "" {
+		fi.ReturnType = op.Return.Type
+	}
+	for _, p := range op.Parameters {
+		fi.Parameters = append(fi.Parameters, Parameter{Name: p.Name, Type: p.Type, Nullable: p.Nullable != "false"})
+	}
+	return fi
+}
+
+type edmxAnnotation struct {
+	Term   string `xml:"Term,attr"`
+	String string `xml:"String,attr"`
+	Bool   string `xml:"Bool,attr"`
+	Text   string `xml:"String"` // Element form of String
+	Record struct {
+		PropertyValues []struct {
+			Property string `xml:"Property,attr"`
+			Bool     string `xml:"Bool,attr"`
+		} `xml:"PropertyValue"`
+	} `xml:"Record"`
+}
+
+// ParseMetadata parses an EDMX $metadata document (OData V2, V3 or V4)
+func ParseMetadata(data []byte) (*Metadata, error) {
+	var doc edmxDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata: %w", err)
+	}
+
+	md := &Metadata{
+		Version:     "2.0",
+		EntityTypes: make(map[string]*EntityType),
+		EnumTypes:   make(map[string][]string),
+	}
+	if doc.Version == "4.0" || strings.HasPrefix(doc.Version, "4.") {
+		md.Version = "4.0"
+	} else if doc.DataServices.DataServiceVersion != "" {
+		md.Version = doc.DataServices.DataServiceVersion
+	}
+
+	// V4 annotations may live outside the entity set, targeting "Container/Set"
+	external := make(map[string][]edmxAnnotation)
+	for _, schema := range doc.DataServices.Schemas {
+		for _, group := range schema.Annotations {
+			external[group.Target] = append(external[group.Target], group.Annotations...)
+		}
+	}
+
+	for _, schema := range doc.DataServices.Schemas {
+		for _, et := range schema.EntityTypes {
+			entityType := &EntityType{
+				Name:      et.Name,
+				Namespace: schema.Namespace,
+				HasStream: et.HasStream == "true",
+			}
+			for _, ref := range et.Key.PropertyRefs {
+				entityType.Keys = append(entityType.Keys, ref.Name)
+			}
+			for _, p := range et.Properties {
+				property := Property{
+					Name:      p.Name,
+					Type:      p.Type,
+					Nullable:  p.Nullable != "false",
+					MaxLength: p.MaxLength,
+					Precision: p.Precision,
+					Scale:     p.Scale,
+					Label:     p.Label,
+					Heading:   p.Heading,
+					QuickInfo: p.QuickInfo,
+				}
+				if !attrBool(p.Updatable, true) {
+					property.Immutable = true
+					property.Computed = !attrBool(p.Creatable, true)
+				}
+				annotations := p.Annotations
+				annotations = append(annotations, external[schema.Namespace+"."+et.Name+"/"+p.Name]...)
+				if schema.Alias != "" {
+					annotations = append(annotations, external[schema.Alias+"."+et.Name+"/"+p.Name]...)
+				}
+				applyPropertyAnnotations(&property, annotations)
+				entityType.Properties = append(entityType.Properties, property)
+			}
+			for _, np := range et.NavigationProperties {
+				entityType.Navigation = append(entityType.Navigation, np.Name)
+				entityType.NavigationProperties = append(entityType.NavigationProperties, NavigationProperty{
+					Name:         np.Name,
+					Target:       strings.TrimSuffix(strings.TrimPrefix(np.Type, "Collection("), ")"),
+					Many:         strings.HasPrefix(np.Type, "Collection("),
+					Relationship: np.Relationship,
+					Partner:      np.Partner,
+					toRole:       np.ToRole,
+				})
+			}
+			md.EntityTypes[schema.Namespace+"."+et.Name] = entityType
+			if schema.Alias != "" {
+				md.EntityTypes[schema.Alias+"."+et.Name] = entityType
+			}
+		}
+		for _, enum := range schema.EnumTypes {
+			var members []string
+			for _, member := range enum.Members {
+				members = append(members, member.Name)
+			}
+			md.EnumTypes[schema.Namespace+"."+enum.Name] = members
+			if schema.Alias != "" {
+				md.EnumTypes[schema.Alias+"."+enum.Name] = members
+			}
+		}
+	}
+
+	// V2 navigation properties get their target from the association end
+	// they lead to
+	roles := make(map[string]AssociationEnd)
+	operations := make(map[string]FunctionImport)
+	for _, schema := range doc.DataServices.Schemas {
+		for _, a := range schema.Associations {
+			association := Association{Name: schema.Namespace + "." + a.Name}
+			for _, end := range a.Ends {
+				e := AssociationEnd{Role: end.Role, Type: end.Type, Multiplicity: end.Multiplicity}
+				association.Ends = append(association.Ends, e)
+				roles[schema.Namespace+"."+a.Name+"/"+end.Role] = e
+				if schema.Alias != "" {
+					roles[schema.Alias+"."+a.Name+"/"+end.Role] = e
+				}
+			}
+			md.Associations = append(md.Associations, association)
+		}
+		// The first overload of a V4 function or action describes its import
+		for _, kind := range []struct {
+			name string
+			ops  []edmxOperation
+		}{{"function", schema.Functions}, {"action", schema.Actions}} {
+			for _, op := range kind.ops {
+				if _, ok := operations[schema.Namespace+"."+op.Name]; !ok {
+					operations[schema.Namespace+"."+op.Name] = op.signature(op.Name, kind.name)
+				}
+			}
+		}
+	}
+	for _, et := range md.EntityTypes {
+		for i := range et.NavigationProperties {
+			np := &et.NavigationProperties[i]
+			if end, ok := roles[np.Relationship+"/"+np.toRole]; ok && np.Target == "" {
+				np.Target = end.Type
+				np.Many = end.Multiplicity == "*"
+			}
+		}
+	}
+
+	for _, schema := range doc.DataServices.Schemas {
+		for _, container := range schema.EntityContainers {
+			for _, es := range container.EntitySets {
+				set := &EntitySet{Name: es.Name, EntityType: es.EntityType}
+
+				caps := defaultCapabilities(md.Version)
+				caps.Creatable = attrBool(es.Creatable, caps.Creatable)
+				caps.Updatable = attrBool(es.Updatable, caps.Updatable)
+				caps.Deletable = attrBool(es.Deletable, caps.Deletable)
+				caps.Searchable = attrBool(es.Searchable, caps.Searchable)
+
+				annotations := es.Annotations
+				annotations = append(annotations, external[schema.Namespace+"."+container.Name+"/"+es.Name]...)
+				annotations = append(annotations, external[container.Name+"/"+es.Name]...)
+				applyCapabilityAnnotations(&caps, annotations)
+
+				if et := md.EntityTypes[es.EntityType]; et != nil {
+					caps.MediaType = et.HasStream
+				}
+				set.Capabilities = caps
+				md.EntitySets = append(md.EntitySets, set)
+			}
+			for _, fi := range container.FunctionImports {
+				md.FunctionImports = append(md.FunctionImports, fi.Name)
+				signature := fi.edmxOperation.signature(fi.Name, "function")
+				if op, ok := operations[fi.Function]; ok {
+					signature = op
+					signature.Name = fi.Name
+				}
+				signature.HTTPMethod = fi.HTTPMethod
+				signature.EntitySet = fi.EntitySet
+				md.Functions = append(md.Functions, signature)
+			}
+			for _, ai := range container.ActionImports {
+				signature := FunctionImport{Name: ai.Name, Kind: "action"}
+				if op, ok := operations[ai.Action]; ok {
+					signature = op
+					signature.Name = ai.Name
+				}
+				signature.EntitySet = ai.EntitySet
+				md.Functions = append(md.Functions, signature)
+			}
+		}
+	}
+
+	return md, nil
+}
+
+// defaultCapabilities returns what a service allows when its metadata says
+// nothing: OData permits everything by default, while SAP V2 services only
+// support search when sap:searchable="true" is declared.
+func defaultCapabilities(version string) EntityCapabilities {
+	return EntityCapabilities{
+		Searchable: strings.HasPrefix(version, "4"),
+		Filterable: true,
+		Creatable:  true,
+		Updatable:  true,
+		Deletable:  true,
+	}
+}
+
+// applyCapabilityAnnotations applies V4 Org.OData.Capabilities.V1 restrictions
+func applyCapabilityAnnotations(caps *EntityCapabilities, annotations []edmxAnnotation) {
+	for _, a := range annotations {
+		term := a.Term[strings.LastIndex(a.Term, ".")+1:]
+		for _, pv := range a.Record.PropertyValues {
+			switch {
+			case term == "InsertRestrictions" && pv.Property == "Insertable":
+				caps.Creatable = attrBool(pv.Bool, caps.Creatable)
+			case term == "UpdateRestrictions" && pv.Property == "Updatable":
+				caps.Updatable = attrBool(pv.Bool, caps.Updatable)
+			case term == "DeleteRestrictions" && pv.Property == "Deletable":
+				caps.Deletable = attrBool(pv.Bool, caps.Deletable)
+			case term == "SearchRestrictions" && pv.Property == "Searchable":
+				caps.Searchable = attrBool(pv.Bool, caps.Searchable)
+			case term == "FilterRestrictions" && pv.Property == "Filterable":
+				caps.Filterable = attrBool(pv.Bool, caps.Filterable)
+			}
+		}
+	}
+}
+
+// applyPropertyAnnotations fills the texts of a property from V4 Common and
+// Core annotations where the SAP attributes left them empty
+func applyPropertyAnnotations(p *Property, annotations []edmxAnnotation) {
+	for _, a := range annotations {
+		text := a.String
+		if text == "" {
+			text = strings.TrimSpace(a.Text)
+		}
+		var field *string
+		switch a.Term[strings.LastIndex(a.Term, ".")+1:] {
+		case "Label":
+			field = &p.Label
+		case "Heading":
+			field = &p.Heading
+		case "QuickInfo":
+			field = &p.QuickInfo
+		case "Description", "LongDescription":
+			field = &p.Description
+		case "Computed":
+			p.Computed = attrBool(a.Bool, true)
+			continue
+		case "Immutable":
+			p.Immutable = attrBool(a.Bool, true)
+			continue
+		default:
+			continue
+		}
+		if *field == "" {
+			*field = text
+		}
+	}
+}
+
+func attrBool(value string, def bool) bool {
+	switch value {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return def
+}
+
+// EntitySet returns the entity set with the given name, or nil
+func (md *Metadata) EntitySet(name string) *EntitySet {
+	if md == nil {
+		return nil
+	}
+	for _, es := range md.EntitySets {
+		if es.Name == name {
+			return es
+		}
+	}
+	return nil
+}
+
+// EntitySetNames lists the entity sets followed by function imports with a [FUNC] prefix
+func (md *Metadata) EntitySetNames() []string {
+	var names []string
+	for _, es := range md.EntitySets {
+		names = append(names, es.Name)
+	}
+	for _, fi := range md.FunctionImports {
+		names = append(names, "[FUNC] "+fi)
+	}
+	return names
+}
+
+// Capabilities returns the capability flags for an entity set. Without
+// metadata the OData defaults are assumed.
+func (md *Metadata) Capabilities(entitySet string) EntityCapabilities {
+	if es := md.EntitySet(entitySet); es != nil {
+		return es.Capabilities
+	}
+	if md == nil {
+		return defaultCapabilities("2.0")
+	}
+	return defaultCapabilities(md.Version)
+}
+
+// EntityTypeOf returns the entity type of an entity set, or nil when unknown
+func (md *Metadata) EntityTypeOf(entitySet string) *EntityType {
+	es := md.EntitySet(entitySet)
+	if es == nil {
+		return nil
+	}
+	return md.EntityTypes[es.EntityType]
+}
+
+// Property returns the named property of the entity type, or nil
+func (et *EntityType) Property(name string) *Property {
+	if et == nil {
+		return nil
+	}
+	for i := range et.Properties {
+		if et.Properties[i].Name == name {
+			return &et.Properties[i]
+		}
+	}
+	return nil
+}
+
+var (
+	guidValue     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	isoDateTime   = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}(:\d{2}(\.\d+)?)?(Z|[+-]\d{2}:\d{2})$`)
+	v2DateLiteral = regexp.MustCompile(`^/Date\(-?\d+([+-]\d+)?\)/$`)
+)
+
+// inferEdmType guesses the Edm type of a JSON value for services whose
+// metadata can't be read. It returns "" when nothing useful can be said.
+func inferEdmType(v interface{}) string {
+	switch value := v.(type) {
+	case bool:
+		return "Edm.Boolean"
+	case float64:
+		if value == math.Trunc(value) {
+			return "Edm.Int32"
+		}
+		return "Edm.Double"
+	case string:
+		switch {
+		case guidValue.MatchString(value):
+			return "Edm.Guid"
+		case v2DateLiteral.MatchString(value):
+			return "Edm.DateTime"
+		case isoDateTime.MatchString(value):
+			return "Edm.DateTimeOffset"
+		}
+		return "Edm.String"
+	}
+	return ""
+}
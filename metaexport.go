@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// exportPath is the file export-metadata writes to, "" for standard output
+var exportPath string
+
+// metadataExport is the JSON form of a service's parsed metadata, for
+// tools that want the model without parsing EDMX themselves
+type metadataExport struct {
+	Service         string           `json:"service"`
+	URL             string           `json:"url"`
+	Version         string           `json:"version"`
+	EntitySets      []*EntitySet     `json:"entitySets"`
+	EntityTypes     []*EntityType    `json:"entityTypes"`
+	Associations    []Association    `json:"associations,omitempty"`
+	FunctionImports []FunctionImport `json:"functionImports"`
+}
+
+// newMetadataExport lists the entity types of the metadata once each, in
+// name order; the metadata also files them under their schema alias
+func newMetadataExport(svc ServiceConfig, md *Metadata) metadataExport {
+	export := metadataExport{
+		Service:         svc.Name,
+		URL:             svc.URL,
+		Version:         md.Version,
+		EntitySets:      md.EntitySets,
+		EntityTypes:     []*EntityType{},
+		Associations:    md.Associations,
+		FunctionImports: md.Functions,
+	}
+	if export.EntitySets == nil {
+		export.EntitySets = []*EntitySet{}
+	}
+	if export.FunctionImports == nil {
+		export.FunctionImports = []FunctionImport{}
+	}
+
+	seen := make(map[*EntityType]bool)
+	for _, et := range md.EntityTypes {
+		if !seen[et] {
+			seen[et] = true
+			export.EntityTypes = append(export.EntityTypes, et)
+		}
+	}
+	sort.Slice(export.EntityTypes, func(i, j int) bool {
+		a, b := export.EntityTypes[i], export.EntityTypes[j]
+		return a.Namespace+"."+a.Name < b.Namespace+"."+b.Name
+	})
+	return export
+}
+
+// runExportMetadata implements "odatanavigator export-metadata": it reads the
+// $metadata of the service chosen with -service, -url or an OData URL and
+// writes the parsed model as JSON to standard output or the -o file
+func runExportMetadata() error {
+	os.Args = append(os.Args[:1], os.Args[2:]...)
+	services := LoadConfig()
+
+	svc, err := exportService(services)
+	if err != nil {
+		return err
+	}
+	o := NewODataServiceForConfig(svc)
+	if _, err := o.GetEntitySets(); err != nil {
+		return fmt.Errorf("cannot read the metadata of %s: %w", svc.Name, err)
+	}
+	if o.metadata() == nil {
+		problem := o.MetadataProblem()
+		if problem == "" {
+			problem = "the document could not be parsed"
+		}
+		return fmt.Errorf("no metadata for %s: %s", svc.Name, problem)
+	}
+
+	data, err := json.MarshalIndent(newMetadataExport(svc, o.metadata()), "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if exportPath == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := writeFileAtomic(exportPath, data, 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote the metadata of %s to %s\n", svc.Name, exportPath)
+	return nil
+}
+
+// exportService picks the service to export: the one named on the command
+// line, the -url service, or the only configured one
+func exportService(services []ServiceConfig) (ServiceConfig, error) {
+	name := ""
+	switch {
+	case startLink != nil:
+		name = startLink.Service
+	case len(services) == 1:
+		name = services[0].Name
+	default:
+		for _, svc := range services {
+			if svc.Name == "CLI Service" {
+				name = svc.Name
+			}
+		}
+	}
+	for _, svc := range services {
+		if name != "" && svc.Name == name {
+			return svc, nil
+		}
+	}
+
+	var names []string
+	for _, svc := range services {
+		names = append(names, svc.Name)
+	}
+	return ServiceConfig{}, fmt.Errorf("choose a service with -service, -url or an OData URL; configured services: %s", strings.Join(names, ", "))
+}
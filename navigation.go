@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type navigationMsg struct {
+	column   int    // ID of the column opened for the navigation property
+	name     string // Navigation property that was followed
+	uri      string
+	entities []map[string]interface{}
+	single   bool // The navigation property points to a single entity
+	hasMore  bool
+	nextLink string
+}
+
+// resource returns what the entities of a column are read from: the
+// navigation URL for related collections, otherwise the entity set name
+func (c column) resource() string {
+	if c.navURL != "" {
+		return c.navURL
+	}
+	return c.entitySet
+}
+
+// detailsEntitySet returns the entity set of the entity shown in the Details
+// column at index i
+func (m model) detailsEntitySet(i int) string {
+	if i < len(m.columns) && m.columns[i].entitySet != "" {
+		return m.columns[i].entitySet
+	}
+	if i > 0 {
+		prev := m.columns[i-1]
+		if prev.entitySet != "" {
+			return prev.entitySet
+		}
+		return prev.title
+	}
+	return ""
+}
+
+// navigationTarget finds the navigation property under the cursor of a
+// Details column: a V2 {"__deferred": {"uri": ...}} object or a V4
+// "Name@odata.navigationLink" annotation
+func navigationTarget(col column) (string, string, bool) {
+	if !col.isDetails || len(col.entities) == 0 || col.cursor >= len(col.items) {
+		return "", "", false
+	}
+	_, name, ok := topLevelProperty(col.items, col.cursor)
+	if !ok {
+		return "", "", false
+	}
+	entity := col.entities[0]
+
+	if strings.HasSuffix(name, "@odata.navigationLink") {
+		if uri, ok := entity[name].(string); ok {
+			return strings.TrimSuffix(name, "@odata.navigationLink"), uri, true
+		}
+	}
+	if nested, ok := entity[name].(map[string]interface{}); ok {
+		if deferred, ok := nested["__deferred"].(map[string]interface{}); ok {
+			if uri, ok := deferred["uri"].(string); ok {
+				return name, uri, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// topLevelProperty walks up from the cursor to the top-level property line of
+// an entity's JSON lines and returns its index and property name
+func topLevelProperty(items []string, cursor int) (int, string, bool) {
+	for i := cursor; i >= 0 && i < len(items); i-- {
+		line := items[i]
+		if !strings.HasPrefix(line, `  "`) || strings.HasPrefix(line, `   `) {
+			continue
+		}
+		end := strings.Index(line[3:], `"`)
+		if end == -1 {
+			return 0, "", false
+		}
+		return i, line[3 : 3+end], true
+	}
+	return 0, "", false
+}
+
+// followNavigation opens the related entity or collection of the navigation
+// property under the cursor in a new column
+func (m model) followNavigation() (tea.Model, tea.Cmd) {
+	name, uri, ok := navigationTarget(m.columns[m.activeColumn])
+	if !ok {
+		return m, nil
+	}
+
+	// V4 navigation links may be relative to the service root
+	uri = m.odata.BuildURL(uri, "", QueryOptions{})
+
+	m.columns[m.activeColumn].focused = false
+	col := column{
+		title:   name,
+		items:   []string{"Loading..."},
+		focused: true,
+		navURL:  uri,
+		id:      m.newColumnID(),
+		state:   stateLoading,
+	}
+	m.columns = append(m.columns, col)
+	m.activeColumn++
+	m.updateColumnSizes()
+	m.loading = true
+	m.debugf("Following navigation property %s", name)
+
+	return m, loadNavigation(m.odata, col)
+}
+
+// loadNavigation fetches the entity or first page of entities the navigation
+// property of a column points to
+func loadNavigation(odata *ODataService, col column) tea.Cmd {
+	odata = odata.forColumn(col.id)
+	return func() tea.Msg {
+		defer odata.loaded()
+		page, single, err := odata.GetNavigation(col.navURL, QueryOptions{Top: 10})
+		if err != nil {
+			return newErrorMsg(err, fmt.Sprintf("navigate(%s)", col.title)).forColumn(col.id)
+		}
+		return navigationMsg{column: col.id, name: col.title, uri: col.navURL, entities: page.Entities, single: single, hasMore: page.HasMore, nextLink: page.NextLink}
+	}
+}
+
+// applyNavigation fills the column opened by followNavigation: a single
+// related entity becomes a Details column, a collection an entity list that
+// can be drilled into further
+func (m *model) applyNavigation(msg navigationMsg) {
+	col := m.columnByID(msg.column)
+	if col == nil {
+		return
+	}
+	col.state = stateLoaded
+
+	m.debugf("Loaded %d related entities via %s", len(msg.entities), msg.name)
+	if len(msg.entities) > 0 {
+		col.entitySet = entitySetFromEntity(msg.entities[0])
+	}
+
+	if msg.single {
+		col.isDetails = true
+		col.navURL = ""
+		if len(msg.entities) == 0 {
+			col.items = []string{"(No related entity)"}
+			return
+		}
+		col.entities = msg.entities
+		col.items = m.detailsLines(col.entitySet, msg.entities[0], nil)
+		return
+	}
+
+	col.entities = msg.entities
+	col.hasMore = msg.hasMore
+	col.nextLink = msg.nextLink
+	col.items = nil
+	for _, entity := range msg.entities {
+		col.items = append(col.items, formatEntityForDisplay(entity))
+	}
+	if msg.hasMore {
+		col.items = append(col.items, col.moreItem())
+	}
+	if len(col.items) == 0 {
+		col.items = []string{"(No items)"}
+	}
+}
+
+// entitySetFromEntity derives the entity set name from the entity's own URI
+// (V2 __metadata.uri, V4 @odata.id or @odata.editLink)
+func entitySetFromEntity(entity map[string]interface{}) string {
+	var uri string
+	if metadata, ok := entity["__metadata"].(map[string]interface{}); ok {
+		uri, _ = metadata["uri"].(string)
+	}
+	if uri == "" {
+		uri, _ = entity["@odata.id"].(string)
+	}
+	if uri == "" {
+		uri, _ = entity["@odata.editLink"].(string)
+	}
+
+	paren := strings.LastIndex(uri, "(")
+	if paren == -1 {
+		return ""
+	}
+	uri = uri[:paren]
+	return uri[strings.LastIndex(uri, "/")+1:]
+}
@@ -2,11 +2,17 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/cookiejar"
+	"net/url"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -18,6 +24,15 @@ type ODataService struct {
 	client   *http.Client
 	username string
 	password string
+	state    *serviceState // What the requests learned about the service, shared with its views
+	tokens   *tokenSource // OAuth2 access tokens, nil unless the service uses oauth2
+	problems *connectionProblems // Certificate errors and mixed content seen so far
+	sendsCredentials bool        // Requests carry basic auth, a token or API keys
+	headers  http.Header         // Fixed headers added to every request, for the headers panel
+	cancels  *canceller          // Aborts requests in flight when a load is cancelled
+	release  func()              // Drops the context of the column load the service runs, see forColumn
+	prefer     *PreferConfig     // Prefer header settings, nil for the defaults
+	partialUpdates bool          // Updates send the changed properties with MERGE or PATCH rather than PUT
 }
 
 // OData V2 response structures
@@ -25,39 +40,146 @@ type ODataV2Response struct {
 	D []map[string]interface{} `json:"d"`
 }
 
-// SAP OData V2 response structure (with results wrapper)
-type SAPODataV2Response struct {
-	D struct {
-		Results []map[string]interface{} `json:"results"`
-	} `json:"d"`
+// newHTTPClient returns the client used for all OData requests, with
+// per-request timing captured for the trace viewer and the exchanges for
+// the inspector. A nil proxy means the
+// proxy settings of the environment.
+func newHTTPClient(proxy *ProxyConfig, timeout time.Duration) *http.Client {
+	var transport http.RoundTripper = newTracingTransport(newBaseTransport(proxy, timeout))
+	if responseCache != nil {
+		transport = newCachingTransport(transport, responseCache)
+	}
+	if memoryResponses != nil {
+		transport = &memoryTransport{base: transport, cache: memoryResponses}
+	}
+	transport = &exchangeTransport{base: transport, recorder: requestExchanges}
+	return &http.Client{Transport: transport}
+}
+
+// headerTransport adds fixed headers to every request that does not set them itself
+type headerTransport struct {
+	base    http.RoundTripper
+	headers http.Header
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, values := range t.headers {
+		if req.Header.Get(name) == "" {
+			req.Header[name] = values
+		}
+	}
+	return t.base.RoundTrip(req)
 }
 
 func NewODataService() *ODataService {
 	return &ODataService{
 		baseURL: BaseURL,
-		client:  &http.Client{},
+		client:  newHTTPClient(nil, requestTimeout),
+		state:   &serviceState{},
 	}
 }
 
 func NewODataServiceWithURL(url string) *ODataService {
 	return &ODataService{
 		baseURL: url,
-		client:  &http.Client{},
+		client:  newHTTPClient(nil, requestTimeout),
+		state:   &serviceState{},
 	}
 }
 
-func NewODataServiceWithAuth(url, username, password string) *ODataService {
-	return &ODataService{
-		baseURL:  url,
-		client:   &http.Client{},
-		username: username,
-		password: password,
+// NewODataServiceWithAuth connects to a service with basic auth, or with the
+// authentication of an auth block when auth is set: OAuth2 client
+// credentials ("oauth2") or a static token and API key headers ("token")
+func NewODataServiceWithAuth(url, username, password string, auth *AuthConfig) *ODataService {
+	return newODataService(ServiceConfig{URL: url, Username: username, Password: password, Auth: auth})
+}
+
+// newODataService is NewODataServiceWithAuth for a configured service,
+// reached through its proxy and with its timeout
+func newODataService(svc ServiceConfig) *ODataService {
+	timeout := svc.RequestTimeout()
+	auth := svc.Auth
+	o := &ODataService{
+		baseURL:  svc.URL,
+		client:   newHTTPClient(svc.Proxy, timeout),
+		username: svc.Username,
+		password: svc.Password,
+		problems: &connectionProblems{},
+		state:    &serviceState{},
+		sendsCredentials: svc.hasCredentials(),
+		headers:  make(http.Header),
+		cancels:  newCanceller(),
+		prefer:   svc.Prefer,
+		partialUpdates: svc.PartialUpdates,
+	}
+	o.client.Transport = &securityCheckTransport{
+		base:     o.client.Transport,
+		https:    strings.HasPrefix(strings.ToLower(svc.URL), "https://"),
+		problems: o.problems,
+	}
+	o.client.Transport = &cancelTransport{base: o.client.Transport, parent: o.cancels.current}
+	// Pinged services keep their session cookies, so the session the
+	// heartbeat keeps alive is the one the next request uses
+	if svc.KeepAliveInterval() > 0 {
+		o.client.Jar, _ = cookiejar.New(nil)
+	}
+	if auth == nil {
+		return o
+	}
+	switch auth.Type {
+	case "oauth2":
+		o.tokens = newTokenSource(*auth, newBaseTransport(svc.Proxy, timeout))
+		o.client.Transport = &bearerTransport{base: o.client.Transport, source: o.tokens}
+	case "token":
+		o.addHeaders(auth.tokenHeaders())
+	}
+	return o
+}
+
+// NewODataServiceForConfig connects to a configured service through its
+// proxy, identifying the client with the service's User-Agent and client
+// identification headers
+func NewODataServiceForConfig(svc ServiceConfig) *ODataService {
+	o := newODataService(svc)
+	o.addHeaders(svc.ClientHeaders())
+	return o
+}
+
+// addHeaders sends fixed headers with every request to the service
+func (o *ODataService) addHeaders(headers http.Header) {
+	for name, values := range headers {
+		o.headers[name] = values
 	}
+	o.client.Transport = &headerTransport{base: o.client.Transport, headers: headers}
+}
+
+// RequestHeaders lists the headers sent with every request, with
+// credentials redacted, for the headers panel
+func (o *ODataService) RequestHeaders() []string {
+	var lines []string
+	switch {
+	case o.tokens != nil:
+		lines = append(lines, "Authorization: Bearer (OAuth2 access token)")
+	case o.username != "" && o.password != "":
+		lines = append(lines, "Authorization: "+redactHeader("Authorization", "Basic "+o.username+":"+o.password))
+	}
+	names := make([]string, 0, len(o.headers))
+	for name := range o.headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range o.headers[name] {
+			lines = append(lines, name+": "+redactHeader(name, value))
+		}
+	}
+	return lines
 }
 
 func (o *ODataService) GetEntitySets() ([]string, error) {
 	// First try to get metadata and parse entity sets
-	metadataURL := strings.TrimSuffix(o.baseURL, "/") + "/$metadata"
+	metadataURL := o.BuildURL("$metadata", "", QueryOptions{})
 	
 	req, err := http.NewRequest("GET", metadataURL, nil)
 	if err != nil {
@@ -69,30 +191,43 @@ func (o *ODataService) GetEntitySets() ([]string, error) {
 	}
 	
 	resp, err := o.client.Do(req)
+	if errors.Is(err, errRequestCancelled) {
+		return nil, err
+	}
 	if err != nil {
-		// Fallback to hardcoded entity sets for demo services
-		return []string{"Categories", "Products", "Suppliers", "Persons", "Advertisements", "ProductDetails"}, nil
+		return nil, fmt.Errorf("failed to fetch metadata: %w", err)
 	}
 	defer resp.Body.Close()
+	o.setServerInfo(serverInfoFromHeaders(resp.Header))
 
 	if resp.StatusCode != http.StatusOK {
-		// Fallback to hardcoded entity sets
-		return []string{"Categories", "Products", "Suppliers", "Persons", "Advertisements", "ProductDetails"}, nil
+		// Some services forbid $metadata but allow data reads, so list the
+		// entity sets from the service document instead
+		problem := fmt.Sprintf("HTTP %d", resp.StatusCode)
+		o.setMetadata(nil, problem)
+		entitySets, err := o.getServiceDocument()
+		if err != nil {
+			return nil, fmt.Errorf("$metadata returned %s and the service document could not be read: %w", problem, err)
+		}
+		return entitySets, nil
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read metadata: %w", err)
 	}
-
-	// Parse entity sets from metadata using regex (simple approach)
-	entitySets := parseEntitySetsFromMetadata(string(body))
-	if len(entitySets) == 0 {
-		// Fallback to hardcoded entity sets
-		return []string{"Categories", "Products", "Suppliers", "Persons", "Advertisements", "ProductDetails"}, nil
-	}
-
-
+	// Parse the metadata model; fall back to a regex scan for documents the
+	// XML decoder can't handle
+	var entitySets []string
+	if md, err := ParseMetadata(body); err == nil {
+		o.setMetadata(md, "")
+		entitySets = md.EntitySetNames()
+	} else {
+		o.setMetadata(nil, "")
+		entitySets = parseEntitySetsFromMetadata(string(body))
+	}
+	// A document listing no entity sets leaves the column with $metadata
+	// only, to look into what the service sent
 	return entitySets, nil
 }
 
@@ -120,17 +255,125 @@ func parseEntitySetsFromMetadata(metadata string) []string {
 	return entitySets
 }
 
-func (o *ODataService) GetEntities(entitySet string, top int) ([]map[string]interface{}, error) {
+// Version returns the OData protocol version of the service, assuming V2
+// until the metadata says otherwise
+func (o *ODataService) Version() string {
+	if md := o.metadata(); md != nil {
+		return md.Version
+	}
+	return "2.0"
+}
+
+// getServiceDocument lists the entity sets of the service root document
+func (o *ODataService) getServiceDocument() ([]string, error) {
+	req, err := http.NewRequest("GET", o.jsonFormat(o.BuildURL("", "", QueryOptions{})), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	if o.username != "" && o.password != "" {
+		req.SetBasicAuth(o.username, o.password)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch service document: %w", err)
+	}
+	defer resp.Body.Close()
+	// The service document is served by the same stack as the data, so its
+	// headers describe the service better than a $metadata error page
+	o.setServerInfo(serverInfoFromHeaders(resp.Header))
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	entitySets := parseServiceDocument(body)
+	if len(entitySets) == 0 {
+		return nil, fmt.Errorf("no entity sets in service document")
+	}
+	return entitySets, nil
+}
+
+// parseServiceDocument reads entity set names from a V2 JSON ({"d": {"EntitySets": [...]}}),
+// V4 JSON ({"value": [{"name": ..., "kind": ...}]}) or AtomPub service document
+func parseServiceDocument(body []byte) []string {
+	var v2 struct {
+		D struct {
+			EntitySets []string `json:"EntitySets"`
+		} `json:"d"`
+	}
+	if err := json.Unmarshal(body, &v2); err == nil && len(v2.D.EntitySets) > 0 {
+		return v2.D.EntitySets
+	}
+
+	var v4 struct {
+		Value []struct {
+			Name string `json:"name"`
+			Kind string `json:"kind"`
+			URL  string `json:"url"`
+		} `json:"value"`
+	}
+	if err := json.Unmarshal(body, &v4); err == nil && len(v4.Value) > 0 {
+		var entitySets []string
+		for _, v := range v4.Value {
+			switch v.Kind {
+			case "", "EntitySet":
+				entitySets = append(entitySets, v.URL)
+			case "FunctionImport":
+				entitySets = append(entitySets, "[FUNC] "+v.URL)
+			}
+		}
+		return entitySets
+	}
+
+	re := regexp.MustCompile(`<(?:app:)?collection[^>]+href="([^"]+)"`)
+	var entitySets []string
+	for _, match := range re.FindAllStringSubmatch(string(body), -1) {
+		entitySets = append(entitySets, match[1])
+	}
+	return entitySets
+}
+
+// EntityPage is one page of an entity collection
+type EntityPage struct {
+	Entities []map[string]interface{}
+	HasMore  bool
+	NextLink string // Server-driven paging link (__next / @odata.nextLink), if any
+	Count    int    // Total number of entities when requested with QueryOptions.Count, -1 otherwise
+	PreferenceApplied string // Preferences the server honoured, from the Preference-Applied header
+}
+
+func (o *ODataService) GetEntities(entitySet string, opts QueryOptions) ([]map[string]interface{}, error) {
+	page, err := o.getEntities(entitySet, opts)
+	if err != nil {
+		return nil, err
+	}
+	return page.Entities, nil
+}
+
+// getEntities fetches one page of an entity set as described by opts
+func (o *ODataService) getEntities(entitySet string, opts QueryOptions) (*EntityPage, error) {
 	// Default to 10 if not specified
-	if top <= 0 {
-		top = 10
+	if opts.Top <= 0 {
+		opts.Top = 10
 	}
-	url := fmt.Sprintf("%s/%s?$top=%d&$format=json", o.baseURL, entitySet, top)
-	
+	return o.fetchEntityCollection(o.jsonFormat(o.BuildURL(entitySet, "", opts)))
+}
+
+// fetchEntityCollection GETs a collection URL and returns its entities, next link and count
+func (o *ODataService) fetchEntityCollection(url string) (*EntityPage, error) {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	req.Header.Set("Accept", "application/json")
+	o.setPrefer(req)
 	
 	if o.username != "" && o.password != "" {
 		req.SetBasicAuth(o.username, o.password)
@@ -152,49 +395,192 @@ func (o *ODataService) GetEntities(entitySet string, top int) ([]map[string]inte
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
+	page, err := parseEntityCollection(body)
+	if err != nil {
+		return nil, newParseError(req, resp, body, err)
+	}
+	page.PreferenceApplied = resp.Header.Get("Preference-Applied")
+	return page, nil
+}
+
+// parseEntityCollection parses the V2 ({"d": [...]} or {"d": {"results": [...]}})
+// and V4 ({"value": [...]}) collection formats
+func parseEntityCollection(body []byte) (*EntityPage, error) {
 	// Try parsing as standard OData V2 first
 	var odataResp ODataV2Response
 	if err := json.Unmarshal(body, &odataResp); err == nil && len(odataResp.D) > 0 {
-		return odataResp.D, nil
+		return &EntityPage{Entities: odataResp.D, Count: -1}, nil
 	}
 
-	// Try parsing as SAP OData V2 (with results wrapper)
-	var sapResp SAPODataV2Response
-	if err := json.Unmarshal(body, &sapResp); err == nil {
-		return sapResp.D.Results, nil
+	// OData V4 uses a "value" array, @odata.nextLink and @odata.count
+	var v4Resp struct {
+		Value    []map[string]interface{} `json:"value"`
+		NextLink string                   `json:"@odata.nextLink"`
+		Count    *int                     `json:"@odata.count"`
+	}
+	if err := json.Unmarshal(body, &v4Resp); err == nil && v4Resp.Value != nil {
+		page := &EntityPage{Entities: v4Resp.Value, NextLink: v4Resp.NextLink, Count: -1}
+		if v4Resp.Count != nil {
+			page.Count = *v4Resp.Count
+		}
+		return page, nil
+	}
+
+	// Try parsing as SAP OData V2 (with results wrapper); $inlinecount adds
+	// __count as a string
+	var sapResp struct {
+		D struct {
+			Results []map[string]interface{} `json:"results"`
+			Next    string                   `json:"__next"`
+			Count   string                   `json:"__count"`
+		} `json:"d"`
+	}
+	err := json.Unmarshal(body, &sapResp)
+	if err == nil {
+		page := &EntityPage{Entities: sapResp.D.Results, NextLink: sapResp.D.Next, Count: -1}
+		if count, err := strconv.Atoi(sapResp.D.Count); err == nil {
+			page.Count = count
+		}
+		return page, nil
 	}
 
-	return nil, fmt.Errorf("failed to parse JSON: %w\nBody: %s", err, string(body))
+	return nil, fmt.Errorf("failed to parse JSON: %w", err)
 }
 
 // GetEntitiesWithCount returns entities and checks if there are more
-func (o *ODataService) GetEntitiesWithCount(entitySet string, top int) (entities []map[string]interface{}, hasMore bool, err error) {
+func (o *ODataService) GetEntitiesWithCount(entitySet string, opts QueryOptions) (entities []map[string]interface{}, hasMore bool, err error) {
+	page, err := o.GetEntitiesPage(entitySet, opts)
+	if err != nil {
+		return nil, false, err
+	}
+	return page.Entities, page.HasMore, nil
+}
+
+// GetEntitiesPage returns opts.Top entities starting at opts.Skip and whether
+// more exist. When the server pages on its own, the returned NextLink must be
+// used to continue instead of $skip.
+func (o *ODataService) GetEntitiesPage(entitySet string, opts QueryOptions) (*EntityPage, error) {
 	// Default to 10 if not specified
+	top := opts.Top
 	if top <= 0 {
 		top = 10
 	}
 	// Request one extra to check if there are more
-	entities, err = o.GetEntities(entitySet, top+1)
+	opts.Top = top + 1
+	page, err := o.getEntities(entitySet, opts)
 	if err != nil {
-		return nil, false, err
+		return nil, err
 	}
-	
-	// Check if we got more than requested
-	if len(entities) > top {
-		hasMore = true
-		entities = entities[:top] // Return only requested amount
+	return trimEntityPage(page, top), nil
+}
+
+// GetNextPage follows a server-driven paging link; only opts.Top is used, as
+// the link already carries the query
+func (o *ODataService) GetNextPage(nextLink string, opts QueryOptions) (*EntityPage, error) {
+	// V4 next links may be relative to the service root
+	page, err := o.fetchEntityCollection(o.jsonFormat(o.BuildURL(nextLink, "", QueryOptions{})))
+	if err != nil {
+		return nil, err
 	}
-	
-	return entities, hasMore, nil
+	return trimEntityPage(page, opts.Top), nil
 }
 
-func (o *ODataService) GetEntity(entitySet, id string) (map[string]interface{}, error) {
-	url := fmt.Sprintf("%s/%s(%s)?$format=json", o.baseURL, entitySet, id)
-	
+// trimEntityPage cuts a page down to top entities and works out whether more exist
+func trimEntityPage(page *EntityPage, top int) *EntityPage {
+	page.HasMore = page.NextLink != ""
+	// Check if we got more than requested; the next page then continues via $skip
+	if top > 0 && len(page.Entities) > top {
+		page.HasMore = true
+		page.NextLink = ""
+		page.Entities = page.Entities[:top] // Return only requested amount
+	}
+	return page
+}
+
+// escapeQueryValue escapes a query option value, encoding spaces as %20
+// since not every gateway decodes '+' in OData system query options
+func escapeQueryValue(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
+// dateOnly matches a date given without a time, as in 2024-01-31
+var dateOnly = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+// formatODataLiteral formats a value as an OData URL literal of the given
+// Edm type, using the V2 prefixed/suffixed forms (guid'…', datetime'…', 1.5M)
+// or the bare V4 forms depending on version. Unknown types are guessed from
+// the value itself.
+func formatODataLiteral(edmType, value, version string) string {
+	v4 := strings.HasPrefix(version, "4")
+	quoted := "'" + strings.ReplaceAll(value, "'", "''") + "'"
+
+	if value == "null" {
+		return value
+	}
+
+	switch edmType {
+	case "Edm.String":
+		return quoted
+	case "Edm.Int16", "Edm.Int32", "Edm.Byte", "Edm.SByte", "Edm.Boolean", "Edm.Double", "Edm.Single":
+		return value
+	case "Edm.Int64":
+		if v4 {
+			return value
+		}
+		return value + "L"
+	case "Edm.Decimal":
+		if v4 {
+			return value
+		}
+		return value + "M"
+	case "Edm.Guid":
+		// GUIDs copied from Windows tools come in braces
+		value = strings.TrimSuffix(strings.TrimPrefix(value, "{"), "}")
+		if v4 {
+			return value
+		}
+		return "guid'" + value + "'"
+	case "Edm.DateTime":
+		if dateOnly.MatchString(value) {
+			value += "T00:00:00"
+		}
+		return "datetime'" + value + "'"
+	case "Edm.DateTimeOffset":
+		if dateOnly.MatchString(value) {
+			value += "T00:00:00Z"
+		}
+		if v4 {
+			return value
+		}
+		return "datetimeoffset'" + value + "'"
+	case "Edm.Time":
+		return "time" + quoted
+	case "Edm.Date", "Edm.TimeOfDay":
+		return value
+	}
+
+	if value == "true" || value == "false" {
+		return value
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value
+	}
+	return quoted
+}
+
+// GetEntity reads a single entity by key; opts may add $select or $expand
+func (o *ODataService) GetEntity(entitySet, id string, opts QueryOptions) (map[string]interface{}, error) {
+	return o.GetEntityByURL(o.jsonFormat(o.BuildURL(entitySet, id, opts)))
+}
+
+// GetEntityByURL reads a single entity from an absolute URL, such as the
+// Location header of a create response
+func (o *ODataService) GetEntityByURL(url string) (map[string]interface{}, error) {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	req.Header.Set("Accept", "application/json")
 	
 	if o.username != "" && o.password != "" {
 		req.SetBasicAuth(o.username, o.password)
@@ -216,14 +602,108 @@ func (o *ODataService) GetEntity(entitySet, id string) (map[string]interface{},
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	var result struct {
+	entity, err := parseEntityBody(body)
+	if err != nil {
+		return nil, newParseError(req, resp, body, err)
+	}
+	return entity, nil
+}
+
+// GetNavigation reads the target of a navigation property URL, which is
+// either a single entity or a collection
+func (o *ODataService) GetNavigation(uri string, opts QueryOptions) (*EntityPage, bool, error) {
+	// The target may be a single entity, which rejects paging options, so
+	// opts.Top only sizes the returned page
+	top := opts.Top
+	opts.Top, opts.Skip = 0, 0
+	req, err := http.NewRequest("GET", o.jsonFormat(o.BuildURL(uri, "", opts)), nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	if o.username != "" && o.password != "" {
+		req.SetBasicAuth(o.username, o.password)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to fetch navigation: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read response: %w", err)
+	}
+	// An unset to-one navigation property comes back as 204 or 404
+	if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotFound {
+		return &EntityPage{Count: -1}, true, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	if isEntityCollection(body) {
+		page, err := parseEntityCollection(body)
+		if err != nil {
+			return nil, false, newParseError(req, resp, body, err)
+		}
+		return trimEntityPage(page, top), false, nil
+	}
+
+	entity, err := parseEntityBody(body)
+	if err != nil {
+		return nil, true, newParseError(req, resp, body, err)
+	}
+	return &EntityPage{Entities: []map[string]interface{}{entity}, Count: -1}, true, nil
+}
+
+// isEntityCollection reports whether a response body holds a collection
+// (V2 d array or d.results, V4 value) rather than a single entity
+func isEntityCollection(body []byte) bool {
+	var probe struct {
+		D     json.RawMessage `json:"d"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(body, &probe); err != nil {
+		return false
+	}
+	if len(probe.Value) > 0 && probe.Value[0] == '[' {
+		return true
+	}
+	if len(probe.D) == 0 {
+		return false
+	}
+	if probe.D[0] == '[' {
+		return true
+	}
+	var d struct {
+		Results json.RawMessage `json:"results"`
+	}
+	return json.Unmarshal(probe.D, &d) == nil && len(d.Results) > 0 && d.Results[0] == '['
+}
+
+// parseEntityBody parses a single entity in V2 ({"d": {...}}) or V4 ({...}) format
+func parseEntityBody(body []byte) (map[string]interface{}, error) {
+	var wrapped struct {
 		D map[string]interface{} `json:"d"`
 	}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	if err := json.Unmarshal(body, &wrapped); err != nil {
+		return nil, err
+	}
+	if wrapped.D != nil {
+		return wrapped.D, nil
 	}
 
-	return result.D, nil
+	var entity map[string]interface{}
+	if err := json.Unmarshal(body, &entity); err != nil {
+		return nil, err
+	}
+	if entity["error"] != nil {
+		return nil, fmt.Errorf("response contains an error")
+	}
+	return entity, nil
 }
 
 func formatEntityForDisplay(entity map[string]interface{}) string {
@@ -242,12 +722,12 @@ func formatEntityForDisplay(entity map[string]interface{}) string {
 	// Check for key fields
 	for _, field := range keyFields {
 		if val := entity[field]; val != nil {
-			keyValue = fmt.Sprintf("%v", val)
+			keyValue = fmt.Sprintf("%v", displayDates(val))
 			// Look for descriptive fields to append
 			descFields := []string{"Title", "Name", "Description", "Text"}
 			for _, descField := range descFields {
 				if desc := entity[descField]; desc != nil && desc != "" {
-					additionalInfo = fmt.Sprintf(" | %v", desc)
+					additionalInfo = fmt.Sprintf(" | %v", displayDates(desc))
 					break
 				}
 			}
@@ -259,7 +739,7 @@ func formatEntityForDisplay(entity map[string]interface{}) string {
 	if keyValue == "" {
 		for k, v := range entity {
 			if v != nil && !strings.HasPrefix(k, "__") {
-				keyValue = fmt.Sprintf("%s: %v", k, v)
+				keyValue = fmt.Sprintf("%s: %v", k, displayDates(v))
 				break
 			}
 		}
@@ -277,7 +757,7 @@ func formatEntityDetails(entity map[string]interface{}) []string {
 	
 	for key, value := range entity {
 		if value != nil && !strings.HasPrefix(key, "__") {
-			details = append(details, fmt.Sprintf("%s: %v", key, value))
+			details = append(details, fmt.Sprintf("%s: %v", key, displayDates(value)))
 		}
 	}
 	
@@ -285,55 +765,36 @@ func formatEntityDetails(entity map[string]interface{}) []string {
 }
 
 type EntityCapabilities struct {
-	Searchable  bool
-	Filterable  bool
-	Creatable   bool
-	Updatable   bool
-	Deletable   bool
-	MediaType   bool
-}
-
-func GetEntitySetCapabilities(entitySet string) EntityCapabilities {
-	// For demo purposes, return capabilities based on entity set
-	// In a real implementation, this would parse the OData $metadata
-	switch entitySet {
-	case "Categories":
-		return EntityCapabilities{
-			Searchable: true,
-			Filterable: true,
-			Creatable:  true,
-			Updatable:  true,
-			Deletable:  true,
-			MediaType:  false,
-		}
-	case "Products":
-		return EntityCapabilities{
-			Searchable: true,
-			Filterable: true,
-			Creatable:  true,
-			Updatable:  true,
-			Deletable:  false, // Products might not be deletable
-			MediaType:  false,
-		}
-	case "Advertisements":
-		return EntityCapabilities{
-			Searchable: true,
-			Filterable: true,
-			Creatable:  true,
-			Updatable:  true,
-			Deletable:  true,
-			MediaType:  true, // Advertisements might have media
+	Searchable bool `json:"searchable"`
+	Filterable bool `json:"filterable"`
+	Creatable  bool `json:"creatable"`
+	Updatable  bool `json:"updatable"`
+	Deletable  bool `json:"deletable"`
+	MediaType  bool `json:"mediaType"`
+}
+
+// Capabilities returns the capability flags of an entity set as declared in
+// the service metadata
+func (o *ODataService) Capabilities(entitySet string) EntityCapabilities {
+	return o.metadata().Capabilities(entitySet)
+}
+
+// EntitySetDisplayItems formats entity set names with their capability badges
+func (o *ODataService) EntitySetDisplayItems(entitySets []string) []string {
+	items := make([]string, 0, len(entitySets))
+	for _, entitySet := range entitySets {
+		if strings.HasPrefix(entitySet, "[FUNC] ") {
+			items = append(items, entitySet)
+			continue
 		}
-	default:
-		return EntityCapabilities{
-			Searchable: true,
-			Filterable: true,
-			Creatable:  false,
-			Updatable:  false,
-			Deletable:  false,
-			MediaType:  false,
+		// Without metadata the capabilities are unknown rather than the defaults
+		if o.metadata() == nil && o.MetadataProblem() != "" {
+			items = append(items, entitySet+" [?]")
+			continue
 		}
+		items = append(items, fmt.Sprintf("%s %s", entitySet, o.Capabilities(entitySet).String()))
 	}
+	return items
 }
 
 func (c EntityCapabilities) String() string {
@@ -359,88 +820,152 @@ func (c EntityCapabilities) String() string {
 	return fmt.Sprintf("[%s]", strings.Join(caps, ""))
 }
 
-// CreateEntity creates a new entity in the specified entity set
-func (o *ODataService) CreateEntity(entitySet string, entity map[string]interface{}) error {
-	url := fmt.Sprintf("%s/%s", o.baseURL, entitySet)
-	
-	// Remove metadata fields that shouldn't be sent
-	cleanEntity := make(map[string]interface{})
-	for k, v := range entity {
-		if !strings.HasPrefix(k, "__") {
-			cleanEntity[k] = v
-		}
+// OperationResult describes the server's answer to a write operation
+type OperationResult struct {
+	Operation  string // "create", "update", "delete"
+	Method     string
+	URL        string
+	StatusCode int
+	Status     string
+	Location   string                 // Location header of a created entity
+	Entity     map[string]interface{} // Entity returned in the response body, if any
+	Messages   []string               // Server messages (sap-message header)
+	PreferenceApplied string          // Preferences the server honoured, from the Preference-Applied header
+	Body       string
+}
+
+// newOperationResult collects status, headers and the returned entity of a write response
+func newOperationResult(operation string, req *http.Request, resp *http.Response, body []byte) *OperationResult {
+	result := &OperationResult{
+		Operation:  operation,
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Location:   resp.Header.Get("Location"),
+		Body:       string(body),
+		PreferenceApplied: resp.Header.Get("Preference-Applied"),
 	}
-	
-	jsonData, err := json.Marshal(cleanEntity)
-	if err != nil {
-		return fmt.Errorf("failed to marshal entity: %w", err)
+
+	// V2 wraps the entity in "d", V4 returns it directly
+	if entity, err := parseEntityBody(body); err == nil {
+		result.Entity = entity
 	}
-	
-	req, err := http.NewRequest("POST", url, strings.NewReader(string(jsonData)))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+
+	result.Messages = parseSAPMessages(resp.Header.Get("sap-message"))
+	return result
+}
+
+// parseSAPMessages extracts the texts of a SAP Gateway sap-message header
+func parseSAPMessages(header string) []string {
+	if header == "" {
+		return nil
 	}
-	
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	
-	if o.username != "" && o.password != "" {
-		req.SetBasicAuth(o.username, o.password)
+	type sapMessage struct {
+		Code     string `json:"code"`
+		Message  string `json:"message"`
+		Severity string `json:"severity"`
 	}
-	
-	resp, err := o.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to create entity: %w", err)
+	var msg struct {
+		sapMessage
+		Details []sapMessage `json:"details"`
 	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	if err := json.Unmarshal([]byte(header), &msg); err != nil {
+		return []string{header}
 	}
-	
-	return nil
+	var messages []string
+	for _, m := range append([]sapMessage{msg.sapMessage}, msg.Details...) {
+		if m.Message != "" {
+			messages = append(messages, fmt.Sprintf("[%s] %s %s", m.Severity, m.Code, m.Message))
+		}
+	}
+	return messages
 }
 
-// UpdateEntity updates an existing entity
-func (o *ODataService) UpdateEntity(entitySet, entityKey string, entity map[string]interface{}) error {
-	url := fmt.Sprintf("%s/%s(%s)", o.baseURL, entitySet, entityKey)
-	
-	// Remove metadata fields that shouldn't be sent
-	cleanEntity := make(map[string]interface{})
+// CreateEntity creates a new entity in the specified entity set
+func (o *ODataService) CreateEntity(entitySet string, entity map[string]interface{}) (*OperationResult, error) {
+	return o.writeEntity("create", "POST", o.BuildURL(entitySet, "", QueryOptions{}), entity, "")
+}
+
+// UpdateEntity updates an existing entity; with an etag, only while the
+// entity on the server still has it
+func (o *ODataService) UpdateEntity(entitySet, entityKey string, entity map[string]interface{}, etag string) (*OperationResult, error) {
+	return o.writeEntity("update", "PUT", o.BuildURL(entitySet, entityKey, QueryOptions{}), entity, etag)
+}
+
+// PatchEntity changes only the given properties of an existing entity, using
+// MERGE on V2/V3 services and PATCH on V4; with an etag, only while the
+// entity on the server still has it
+func (o *ODataService) PatchEntity(entitySet, entityKey string, changes map[string]interface{}, etag string) (*OperationResult, error) {
+	return o.writeEntity("update", o.patchMethod(), o.BuildURL(entitySet, entityKey, QueryOptions{}), changes, etag)
+}
+
+// DeleteEntity deletes an existing entity; with an etag, only while the
+// entity on the server still has it ("*" deletes any version)
+func (o *ODataService) DeleteEntity(entitySet, entityKey string, etag string) (*OperationResult, error) {
+	return o.writeEntity("delete", "DELETE", o.BuildURL(entitySet, entityKey, QueryOptions{}), nil, etag)
+}
+
+// patchMethod is the HTTP method for partial updates in the service's version
+func (o *ODataService) patchMethod() string {
+	if strings.HasPrefix(o.Version(), "4") {
+		return "PATCH"
+	}
+	return "MERGE"
+}
+
+// stripMetadata returns the entity without the metadata fields, such as
+// __metadata and __deferred, that shouldn't be sent
+func stripMetadata(entity map[string]interface{}) map[string]interface{} {
+	clean := make(map[string]interface{}, len(entity))
 	for k, v := range entity {
 		if !strings.HasPrefix(k, "__") {
-			cleanEntity[k] = v
+			clean[k] = v
 		}
 	}
-	
-	jsonData, err := json.Marshal(cleanEntity)
-	if err != nil {
-		return fmt.Errorf("failed to marshal entity: %w", err)
+	return clean
+}
+
+// writeEntity sends a write request with an optional JSON entity body, and
+// an If-Match header when ifMatch is set
+func (o *ODataService) writeEntity(operation, method, url string, entity map[string]interface{}, ifMatch string) (*OperationResult, error) {
+	var body io.Reader
+	if entity != nil {
+		jsonData, err := json.Marshal(stripMetadata(entity))
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal entity: %w", err)
+		}
+		body = strings.NewReader(string(jsonData))
 	}
-	
-	req, err := http.NewRequest("PUT", url, strings.NewReader(string(jsonData)))
+
+	req, err := http.NewRequest(method, url, body)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+		o.setPrefer(req)
 	}
-	
-	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
-	
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+
 	if o.username != "" && o.password != "" {
 		req.SetBasicAuth(o.username, o.password)
 	}
-	
+
 	resp, err := o.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to update entity: %w", err)
+		return nil, fmt.Errorf("failed to %s entity: %w", operation, err)
 	}
 	defer resp.Body.Close()
-	
+
+	respBody, _ := io.ReadAll(resp.Body)
+	result := newOperationResult(operation, req, resp, respBody)
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+		return result, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(respBody))
 	}
-	
-	return nil
-}
\ No newline at end of file
+
+	return result, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestDeleteEntityIfMatch(t *testing.T) {
+	tests := []struct {
+		name string
+		etag string
+		want string
+	}{
+		{"etag", `W/"42"`, `W/"42"`},
+		{"any version", "*", "*"},
+		{"none", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var method, ifMatch string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				method, ifMatch = r.Method, r.Header.Get("If-Match")
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer server.Close()
+
+			o := NewODataServiceWithURL(server.URL + "/")
+			if _, err := o.DeleteEntity("Products", "1", tt.etag); err != nil {
+				t.Fatal(err)
+			}
+			if method != "DELETE" || ifMatch != tt.want {
+				t.Errorf("sent %s with If-Match %q, want DELETE with %q", method, ifMatch, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripMetadata(t *testing.T) {
+	tests := []struct {
+		name   string
+		entity map[string]interface{}
+		want   map[string]interface{}
+	}{
+		{"v2 metadata", map[string]interface{}{"__metadata": map[string]interface{}{"uri": "Products(1)"}, "ID": 1.0}, map[string]interface{}{"ID": 1.0}},
+		{"deferred navigation", map[string]interface{}{"Category": map[string]interface{}{"__deferred": "x"}, "__count": "3"}, map[string]interface{}{"Category": map[string]interface{}{"__deferred": "x"}}},
+		{"nothing to strip", map[string]interface{}{"Name": "Chai"}, map[string]interface{}{"Name": "Chai"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripMetadata(tt.entity); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("stripMetadata = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetEntitySetsFailures(t *testing.T) {
+	empty := `<?xml version="1.0" encoding="utf-8"?><edmx:Edmx Version="1.0" xmlns:edmx="http://schemas.microsoft.com/ado/2007/06/edmx"><edmx:DataServices><Schema Namespace="Empty" xmlns="http://schemas.microsoft.com/ado/2008/09/edm"></Schema></edmx:DataServices></edmx:Edmx>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(empty))
+	}))
+	entitySets, err := NewODataServiceWithURL(server.URL + "/").GetEntitySets()
+	if err != nil || len(entitySets) != 0 {
+		t.Errorf("empty $metadata: %q, %v; want no entity sets", entitySets, err)
+	}
+
+	server.Close()
+	entitySets, err = NewODataServiceWithURL(server.URL + "/").GetEntitySets()
+	if err == nil || entitySets != nil {
+		t.Errorf("unreachable service: %q, %v; want an error", entitySets, err)
+	}
+}
+
+func TestFormatODataLiteral(t *testing.T) {
+	tests := []struct {
+		edmType string
+		value   string
+		version string
+		want    string
+	}{
+		{"Edm.String", "Chai", "2.0", "'Chai'"},
+		{"Edm.String", "O'Brien", "4.0", "'O''Brien'"},
+		{"Edm.String", "42", "2.0", "'42'"},
+		{"Edm.Int32", "42", "2.0", "42"},
+		{"Edm.Boolean", "true", "2.0", "true"},
+		{"Edm.Int64", "42", "2.0", "42L"},
+		{"Edm.Int64", "42", "4.0", "42"},
+		{"Edm.Decimal", "1.5", "2.0", "1.5M"},
+		{"Edm.Decimal", "1.5", "4.01", "1.5"},
+		{"Edm.Guid", "{0a1b2c3d-0000-0000-0000-000000000001}", "2.0", "guid'0a1b2c3d-0000-0000-0000-000000000001'"},
+		{"Edm.Guid", "0a1b2c3d-0000-0000-0000-000000000001", "4.0", "0a1b2c3d-0000-0000-0000-000000000001"},
+		{"Edm.DateTime", "2024-01-31", "2.0", "datetime'2024-01-31T00:00:00'"},
+		{"Edm.DateTime", "2024-01-31T12:30:00", "2.0", "datetime'2024-01-31T12:30:00'"},
+		{"Edm.DateTimeOffset", "2024-01-31", "2.0", "datetimeoffset'2024-01-31T00:00:00Z'"},
+		{"Edm.DateTimeOffset", "2024-01-31T12:30:00+01:00", "4.0", "2024-01-31T12:30:00+01:00"},
+		{"Edm.Time", "PT12H30M", "2.0", "time'PT12H30M'"},
+		{"Edm.Date", "2024-01-31", "4.0", "2024-01-31"},
+		{"Edm.String", "null", "2.0", "null"},
+		{"", "3.14", "2.0", "3.14"},
+		{"", "false", "2.0", "false"},
+		{"", "ALFKI", "2.0", "'ALFKI'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.edmType+" "+tt.value+" V"+tt.version, func(t *testing.T) {
+			if got := formatODataLiteral(tt.edmType, tt.value, tt.version); got != tt.want {
+				t.Errorf("formatODataLiteral = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
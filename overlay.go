@@ -0,0 +1,212 @@
+package main
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+	"github.com/muesli/reflow/truncate"
+)
+
+// placeOverlay draws box centered on top of baseView. Unlike plain string
+// splicing it is aware of ANSI escape sequences, so the styled background
+// on either side of the box stays intact.
+func placeOverlay(baseView, box string, width, height int) string {
+	boxLines := strings.Split(box, "\n")
+	boxWidth := lipgloss.Width(box)
+	x := (width - boxWidth) / 2
+	y := (height - len(boxLines)) / 2
+	if x < 0 {
+		x = 0
+	}
+	if y < 0 {
+		y = 0
+	}
+
+	baseLines := strings.Split(baseView, "\n")
+	for len(baseLines) < height {
+		baseLines = append(baseLines, "")
+	}
+
+	for i, boxLine := range boxLines {
+		row := y + i
+		if row >= len(baseLines) {
+			break
+		}
+		line := baseLines[row]
+		left := truncate.String(line, uint(x))
+		if pad := x - lipgloss.Width(left); pad > 0 {
+			left += strings.Repeat(" ", pad)
+		}
+		right := skipCells(line, x+lipgloss.Width(boxLine))
+		baseLines[row] = left + "\x1b[0m" + boxLine + "\x1b[0m" + right
+	}
+
+	return strings.Join(baseLines, "\n")
+}
+
+// skipCells drops the first n printable cells of s while keeping every ANSI
+// escape sequence, so the remainder is rendered with the right styling
+func skipCells(s string, n int) string {
+	var b strings.Builder
+	skipped := 0
+	inEscape := false
+	for _, r := range s {
+		switch {
+		case r == '\x1b':
+			inEscape = true
+			b.WriteRune(r)
+		case inEscape:
+			b.WriteRune(r)
+			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+				inEscape = false
+			}
+		case skipped < n:
+			skipped += runewidth.RuneWidth(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// renderChoiceList renders a scrolled list with the cursor entry highlighted
+func renderChoiceList(choices []string, cursor, maxVisible int) []string {
+	if maxVisible < 3 {
+		maxVisible = 3
+	}
+	start := 0
+	if cursor >= maxVisible {
+		start = cursor - maxVisible + 1
+	}
+	var lines []string
+	for i := start; i < len(choices) && i < start+maxVisible; i++ {
+		if i == cursor {
+			lines = append(lines, lipgloss.NewStyle().Background(theme.Selection).Foreground(theme.AccentText).Render("► "+choices[i]))
+		} else {
+			lines = append(lines, "  "+choices[i])
+		}
+	}
+	return lines
+}
+
+// typedText returns the text a key press types into a single-line input,
+// or "" for keys that don't insert text
+func typedText(msg tea.KeyMsg) string {
+	if (msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace) && !msg.Alt {
+		return string(msg.Runes)
+	}
+	return ""
+}
+
+// overlay is a component drawn on top of the columns that takes all key
+// presses while it is open: a dialog, a prompt or the modal editor. keys
+// open it from the columns.
+type overlay struct {
+	active func(m model) bool
+	keys   []string
+	open   func(m model, key string) model
+	update func(m model, msg tea.KeyMsg) (tea.Model, tea.Cmd)
+	view   func(m model, baseView string) string
+}
+
+// boxOverlay draws the box a dialog renders centered on the view
+func boxOverlay(render func(m model) string) func(model, string) string {
+	return func(m model, baseView string) string {
+		return placeOverlay(baseView, render(m), m.width, m.height)
+	}
+}
+
+// opens adapts an open function that doesn't care which key opened it
+func opens(open func(m model) model) func(model, string) model {
+	return func(m model, _ string) model { return open(m) }
+}
+
+// openModalEditorFor opens the modal editor for the keys that start a
+// write: F2 creates an entity, F4 updates the entity or patches the marked
+// ones and F5 copies the entity
+func openModalEditorFor(m model, key string) model {
+	switch key {
+	case "f2":
+		return m.openModalEditor("create")
+	case "f4":
+		if m.activeColumn < len(m.columns) && len(m.columns[m.activeColumn].selected) > 0 {
+			return m.openModalEditor("bulkupdate")
+		}
+		return m.openModalEditor("update")
+	}
+	return m.openModalEditor("copy")
+}
+
+// overlays lists the overlays from the topmost down: the first open one
+// gets the key presses, and all open ones are drawn from the bottom up
+var overlays = []overlay{
+	{func(m model) bool { return m.switcher.active }, nil, nil, model.updateServiceSwitcher, boxOverlay(model.renderServiceSwitcher)},
+	{func(m model) bool { return m.help.active }, []string{"f1"}, func(m model, _ string) model { return m.openHelp(helpPageKeys) }, model.updateHelp, boxOverlay(model.renderHelp)},
+	{func(m model) bool { return m.propDoc.active }, nil, nil, model.updatePropertyDoc, boxOverlay(model.renderPropertyDoc)},
+	{func(m model) bool { return m.valueViewer.active }, nil, nil, model.updateValueViewer, boxOverlay(model.renderValueViewer)},
+	{func(m model) bool { return m.inspector.active }, []string{"f6"}, opens(model.openInspector), model.updateInspector, boxOverlay(model.renderInspector)},
+	{func(m model) bool { return m.conflict.active }, nil, nil, model.updateConflict, boxOverlay(model.renderConflict)},
+	{func(m model) bool { return m.review.active }, nil, nil, model.updateUpdateReview, boxOverlay(model.renderUpdateReview)},
+	{func(m model) bool { return m.form.active }, nil, nil, model.updateEntityForm, boxOverlay(model.renderEntityForm)},
+	{func(m model) bool { return m.modalEditor }, []string{"f2", "f4", "f5"}, openModalEditorFor, model.updateModalEditor, model.renderModalOverlay},
+	{func(m model) bool { return m.finding }, nil, nil, model.updateFind, func(m model, baseView string) string { return baseView }},
+	{func(m model) bool { return m.filterDialog.active }, []string{"f7"}, opens(model.openFilterDialog), model.updateFilterDialog, boxOverlay(model.renderFilterDialog)},
+	{func(m model) bool { return m.searchDialog.active }, []string{"S"}, opens(model.openSearchPrompt), model.updateSearchPrompt, boxOverlay(model.renderSearchPrompt)},
+	{func(m model) bool { return m.addressBar.active }, []string{":", "ctrl+l"}, opens(model.openAddressBar), model.updateAddressBar, boxOverlay(model.renderAddressBar)},
+	{func(m model) bool { return m.queryPanel.active }, []string{"Q"}, opens(model.openQueryPanel), model.updateQueryPanel, boxOverlay(model.renderQueryPanel)},
+	{func(m model) bool { return m.sortDialog.active }, []string{"s"}, opens(model.openSortDialog), model.updateSortDialog, boxOverlay(model.renderSortDialog)},
+	{func(m model) bool { return m.expandDialog.active }, []string{"e"}, opens(model.openExpandDialog), model.updateExpandDialog, boxOverlay(model.renderExpandDialog)},
+	{func(m model) bool { return m.history.active }, []string{"g"}, opens(model.openHistory), model.updateHistory, boxOverlay(model.renderHistory)},
+	{func(m model) bool { return m.pending.active && len(m.staged) > 0 }, nil, nil, model.updatePendingPanel, boxOverlay(model.renderPendingPanel)},
+	{func(m model) bool { return m.copyMenu.active }, []string{"y"}, opens(model.openCopyMenu), model.updateCopyMenu, boxOverlay(model.renderCopyMenu)},
+	{func(m model) bool { return m.csvExport.active }, []string{"X"}, opens(model.openCSVExport), model.updateCSVExport, boxOverlay(model.renderCSVExport)},
+	{func(m model) bool { return m.jsonExport.active }, []string{"J"}, opens(model.openJSONExport), model.updateJSONExport, boxOverlay(model.renderJSONExport)},
+	{func(m model) bool { return m.xlsxExport.active }, []string{"W"}, opens(model.openXLSXExport), model.updateXLSXExport, boxOverlay(model.renderXLSXExport)},
+	{func(m model) bool { return m.importDialog.active }, []string{"I"}, opens(model.openImport), model.updateImport, boxOverlay(model.renderImport)},
+	{func(m model) bool { return m.uploadDialog.active }, []string{"u", "U"}, openUploadPromptFor, model.updateUploadPrompt, boxOverlay(model.renderUploadPrompt)},
+	{func(m model) bool { return m.downloadDialog.active }, []string{"d"}, opens(model.openDownloadPrompt), model.updateDownloadPrompt, boxOverlay(model.renderDownloadPrompt)},
+}
+
+// openUploadPromptFor asks for the file of a media upload: u replaces the
+// media of the entity, U creates a media entity
+func openUploadPromptFor(m model, key string) model {
+	if key == "U" {
+		return m.openMediaCreatePrompt()
+	}
+	return m.openUploadPrompt()
+}
+
+// overlayFor returns the overlay a key opens from the columns, nil when it
+// opens none
+func overlayFor(key string) *overlay {
+	for i := range overlays {
+		for _, k := range overlays[i].keys {
+			if k == key {
+				return &overlays[i]
+			}
+		}
+	}
+	return nil
+}
+
+// activeOverlay returns the topmost open overlay, nil when none is open
+func (m model) activeOverlay() *overlay {
+	for i := range overlays {
+		if overlays[i].active(m) {
+			return &overlays[i]
+		}
+	}
+	return nil
+}
+
+// renderOverlays draws the open overlays over the view
+func (m model) renderOverlays(view string) string {
+	for i := len(overlays) - 1; i >= 0; i-- {
+		if overlays[i].active(m) {
+			view = overlays[i].view(m, view)
+		}
+	}
+	return view
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// listModel is a model showing an entity list of a service that is never
+// reached
+func listModel() model {
+	entities := []map[string]interface{}{{"ID": 1.0, "Name": "Chai"}, {"ID": 2.0, "Name": "Chang"}}
+	col := column{title: "Products", entitySet: "Products", entities: entities, width: 40, height: 10, state: stateLoaded}
+	for _, entity := range entities {
+		col.items = append(col.items, formatEntityForDisplay(entity))
+	}
+	return model{
+		columns: []column{col},
+		odata:   NewODataServiceWithURL("http://odata.invalid/service/"),
+		width:   120,
+		height:  40,
+	}
+}
+
+func TestOverlayKeys(t *testing.T) {
+	tests := []struct {
+		key  tea.KeyMsg
+		open func(m model) bool
+		view string // Text of the dialog drawn over the columns
+	}{
+		{keyRunes("s"), func(m model) bool { return m.sortDialog.active }, "Name"},
+		{keyRunes("X"), func(m model) bool { return m.csvExport.active }, "Products.csv"},
+		{keyRunes("W"), func(m model) bool { return m.xlsxExport.active }, "Products.xlsx"},
+		{keyRunes(":"), func(m model) bool { return m.addressBar.active }, ""},
+		{tea.KeyMsg{Type: tea.KeyCtrlL}, func(m model) bool { return m.addressBar.active }, ""},
+		{tea.KeyMsg{Type: tea.KeyF1}, func(m model) bool { return m.help.active }, ""},
+		{tea.KeyMsg{Type: tea.KeyF7}, func(m model) bool { return m.filterDialog.active }, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key.String(), func(t *testing.T) {
+			o := overlayFor(tt.key.String())
+			if o == nil {
+				t.Fatalf("%s opens no overlay", tt.key)
+			}
+			next, _ := listModel().Update(tt.key)
+			m := next.(model)
+			if !tt.open(m) || m.activeOverlay() != o {
+				t.Fatalf("%s did not open its overlay", tt.key)
+			}
+			if view := m.View(); !strings.Contains(view, tt.view) {
+				t.Errorf("view misses %q:\n%s", tt.view, view)
+			}
+			next, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+			if m = next.(model); tt.open(m) {
+				t.Errorf("Esc did not close the overlay of %s", tt.key)
+			}
+		})
+	}
+}
+
+func TestOverlayForColumnKeys(t *testing.T) {
+	for _, key := range []string{"j", "enter", "left", "r", "q"} {
+		if o := overlayFor(key); o != nil {
+			t.Errorf("%s opens an overlay", key)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// parsePastedFields converts a block of "field<TAB>value" rows (as copied from
+// Excel or another spreadsheet) into an entity map. Two layouts are accepted:
+// one row per property with the name in the first cell, or a header row of
+// property names followed by a single row of values. If the block contains no
+// tabs, semicolons and then commas are tried as separators. It returns false
+// when the text doesn't look like such a block.
+func parsePastedFields(text string) (map[string]interface{}, bool) {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r", ""), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return nil, false
+	}
+
+	sep := detectPasteSeparator(lines)
+	if sep == "" {
+		return nil, false
+	}
+
+	var rows [][]string
+	for _, line := range lines {
+		cells := strings.Split(line, sep)
+		for i := range cells {
+			cells[i] = strings.TrimSpace(cells[i])
+		}
+		rows = append(rows, cells)
+	}
+
+	entity := make(map[string]interface{})
+
+	// Horizontal layout: header row with names, second row with values
+	if len(rows) == 2 && len(rows[0]) > 2 && len(rows[0]) == len(rows[1]) {
+		for i, name := range rows[0] {
+			if name != "" {
+				entity[name] = coercePastedValue(rows[1][i])
+			}
+		}
+		return entity, len(entity) > 0
+	}
+
+	// Drop a "Field | Value" style header row if one was copied along
+	if len(rows) > 1 && len(rows[0]) == 2 && strings.EqualFold(rows[0][1], "value") {
+		rows = rows[1:]
+	}
+
+	// Vertical layout: one "field<sep>value" row per property
+	for _, cells := range rows {
+		if len(cells) < 2 || cells[0] == "" {
+			return nil, false
+		}
+		value := strings.Join(cells[1:], sep)
+		entity[cells[0]] = coercePastedValue(strings.TrimSpace(value))
+	}
+
+	return entity, len(entity) > 0
+}
+
+// detectPasteSeparator picks the cell separator used by every pasted line
+func detectPasteSeparator(lines []string) string {
+	for _, sep := range []string{"\t", ";", ","} {
+		found := true
+		for _, line := range lines {
+			if !strings.Contains(line, sep) {
+				found = false
+				break
+			}
+		}
+		if found {
+			return sep
+		}
+	}
+	return ""
+}
+
+// coercePastedValue turns a pasted cell into the closest JSON value:
+// booleans, null, integers and floats are converted, everything else stays a
+// string. Quoted cells are always kept as strings.
+func coercePastedValue(s string) interface{} {
+	if len(s) >= 2 && strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`) {
+		return strings.ReplaceAll(s[1:len(s)-1], `""`, `"`)
+	}
+
+	switch strings.ToLower(s) {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+
+	// Keep values with leading zeros (material numbers, postal codes) as strings
+	if len(s) > 1 && strings.HasPrefix(s, "0") && !strings.HasPrefix(s, "0.") {
+		return s
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
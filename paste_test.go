@@ -0,0 +1,38 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePastedFields(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want map[string]interface{}
+		ok   bool
+	}{
+		{"tab rows", "Name\tChai\nPrice\t18.5\nDiscontinued\tfalse", map[string]interface{}{"Name": "Chai", "Price": 18.5, "Discontinued": false}, true},
+		{"windows line ends", "Name\tChai\r\nUnits\t39\r\n", map[string]interface{}{"Name": "Chai", "Units": int64(39)}, true},
+		{"field value header dropped", "Field\tValue\nName\tChai", map[string]interface{}{"Name": "Chai"}, true},
+		{"header row and values", "ID\tName\tPrice\n1\tChai\t18", map[string]interface{}{"ID": int64(1), "Name": "Chai", "Price": int64(18)}, true},
+		{"semicolons", "Name;Chai\nCity;Berlin", map[string]interface{}{"Name": "Chai", "City": "Berlin"}, true},
+		{"commas", "Name,Chai\nZip,01069", map[string]interface{}{"Name": "Chai", "Zip": "01069"}, true},
+		{"value with the separator", "Name;Chai;Tea", map[string]interface{}{"Name": "Chai;Tea"}, true},
+		{"blank lines skipped", "\nName\tChai\n\n", map[string]interface{}{"Name": "Chai"}, true},
+		{"quoted cells stay strings", "Code\t\"007\"\nNote\t\"say \"\"hi\"\"\"", map[string]interface{}{"Code": "007", "Note": `say "hi"`}, true},
+		{"null", "Category\tnull", map[string]interface{}{"Category": nil}, true},
+		{"JSON", `{"Name": "Chai"}`, nil, false},
+		{"plain text", "just some words", nil, false},
+		{"missing field name", "Name\tChai\n\tvalue", nil, false},
+		{"empty", "  \n", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parsePastedFields(tt.text)
+			if ok != tt.ok || (tt.ok && !reflect.DeepEqual(got, tt.want)) {
+				t.Errorf("parsePastedFields = %#v, %v; want %#v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
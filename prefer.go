@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// PreferConfig sets the Prefer header sent to a service, trading response
+// size against what the navigator can show after a write
+type PreferConfig struct {
+	// "representation" to have writes answer with the entity, "minimal"
+	// for an empty 204, or "none" to send no return preference. V4
+	// services default to representation, V2 services to none.
+	Return string `json:"return,omitempty"`
+
+	// Largest page the server should send for entity lists, as
+	// odata.maxpagesize; 0 leaves paging to the server
+	MaxPageSize int `json:"max_page_size,omitempty"`
+}
+
+// returnPreference is the return preference writes ask for, "" for none
+func (o *ODataService) returnPreference() string {
+	var configured string
+	if o.prefer != nil {
+		configured = o.prefer.Return
+	}
+	switch configured {
+	case "minimal", "representation":
+		return configured
+	case "none":
+		return ""
+	}
+	if strings.HasPrefix(o.Version(), "4") {
+		return "representation"
+	}
+	return ""
+}
+
+// setPrefer adds the preferences of the service to a request: the return
+// preference to writes with a body, the page size to collection reads
+func (o *ODataService) setPrefer(req *http.Request) {
+	if req.Header.Get("Prefer") != "" {
+		return
+	}
+	var preferences []string
+	switch req.Method {
+	case "GET":
+		if o.prefer != nil && o.prefer.MaxPageSize > 0 {
+			preferences = append(preferences, "odata.maxpagesize="+strconv.Itoa(o.prefer.MaxPageSize))
+		}
+	case "POST", "PUT", "PATCH", "MERGE":
+		if preference := o.returnPreference(); preference != "" {
+			preferences = append(preferences, "return="+preference)
+		}
+	}
+	if len(preferences) > 0 {
+		req.Header.Set("Prefer", strings.Join(preferences, ", "))
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// previewPane is the column at the right showing what the item under the
+// cursor of the active column holds, read as the cursor moves
+type previewPane struct {
+	column  column
+	loading bool // A preview is being read
+	hidden  bool // "p" collapsed the pane, which then reads nothing
+}
+
+// previewMsg carries what the preview column shows for the item under the
+// cursor of the active column
+type previewMsg struct {
+	previewType string // "entitysets", "entities", "json"
+	data        interface{}
+	errorMsg    string
+}
+
+// updatePreview generates a preview based on current cursor position
+func (m model) updatePreview() tea.Cmd {
+	// A hidden preview costs no requests
+	if m.preview.hidden || m.activeColumn >= len(m.columns) {
+		return nil
+	}
+
+	currentCol := m.columns[m.activeColumn]
+	if currentCol.cursor >= len(currentCol.items) {
+		return nil
+	}
+
+	selectedItem := currentCol.items[currentCol.cursor]
+	m.preview.loading = true
+
+	switch m.activeColumn {
+	case 0: // Service selection - preview entity sets
+		// No credentials go out over plain HTTP before the user agreed to it
+		for _, svc := range m.services {
+			if svc.Name == selectedItem && m.needsInsecureConfirmation(svc) {
+				items := []string{svc.URL, "", "Plain HTTP - its credentials would be sent in cleartext", "Not previewed until you connect with Enter and confirm"}
+				return func() tea.Msg {
+					return previewMsg{previewType: "entitysets", data: items}
+				}
+			}
+		}
+		return func() tea.Msg {
+			for _, svc := range m.services {
+				if svc.Name == selectedItem {
+					odataService := NewODataServiceForConfig(svc)
+					entitySets, err := odataService.GetEntitySets()
+					if err != nil {
+						return previewMsg{errorMsg: err.Error()}
+					}
+					items := append(odataService.ServiceSummary(), "")
+					items = append(items, odataService.EntitySetDisplayItems(entitySets)...)
+					return previewMsg{previewType: "entitysets", data: items}
+				}
+			}
+			return previewMsg{errorMsg: "Service not found"}
+		}
+
+	case 1: // EntitySets - preview entities
+		if m.odata != nil {
+			entitySetName := itemEntitySet(selectedItem)
+
+			// Check if this is $metadata
+			if entitySetName == "$metadata" {
+				return func() tea.Msg {
+					// Fetch and preview metadata
+					metadataURL := m.odata.BuildURL("$metadata", "", QueryOptions{})
+					// For now, just show the URL and info
+					return previewMsg{previewType: "metadata", data: map[string]interface{}{
+						"url":  metadataURL,
+						"note": "Service Metadata - press Enter to view full metadata document",
+						"type": "OData Service Metadata"}}
+				}
+			}
+
+			// Check if this is a function import
+			if strings.HasPrefix(entitySetName, "[FUNC] ") {
+				funcName := strings.TrimPrefix(entitySetName, "[FUNC] ")
+				return func() tea.Msg {
+					// Get function metadata if available
+					return previewMsg{previewType: "function", data: map[string]interface{}{
+						"name":        funcName,
+						"note":        "Function Import - press Enter to view parameters and execute",
+						"type":        "Function Import",
+						"description": fmt.Sprintf("OData Function Import: %s", funcName),
+						"parameters":  "Parameters will be shown when metadata is loaded"}}
+				}
+			}
+
+			// A starred entity previews as itself
+			if entitySet, key, ok := favoriteEntity(entitySetName); ok {
+				odata := m.odata
+				return func() tea.Msg {
+					entity, err := odata.GetEntity(entitySet, odata.KeyPredicate(entitySet, key), QueryOptions{})
+					if err != nil {
+						return previewMsg{errorMsg: err.Error()}
+					}
+					return previewMsg{previewType: "json", data: entity}
+				}
+			}
+
+			preview := func() tea.Msg {
+				entities, _, err := m.odata.GetEntitiesWithCount(entitySetName, QueryOptions{Top: 10}) // Default to 10 for preview
+				if err != nil {
+					return previewMsg{errorMsg: err.Error()}
+				}
+				return previewMsg{previewType: "entities", data: entities}
+			}
+			if strings.HasPrefix(entitySetName, "(") {
+				return preview
+			}
+			return tea.Batch(preview, m.loadEntitySetCount(entitySetName))
+		}
+
+	default: // Entity list or JSON details
+		if currentCol.isDetails {
+			// We're in JSON view - only preview if cursor is on a navigation association
+			if name, uri, ok := navigationTarget(currentCol); ok {
+				return func() tea.Msg {
+					return previewMsg{previewType: "navigation", data: map[string]interface{}{"uri": uri, "note": fmt.Sprintf("Navigation property %s - press Enter to follow", name)}}
+				}
+			}
+			// No preview for regular JSON lines
+			return func() tea.Msg {
+				return previewMsg{previewType: "none", data: nil}
+			}
+		} else if currentCol.entities != nil && currentCol.cursor < len(currentCol.entities) {
+			// Entity list - preview JSON
+			selectedEntity := currentCol.entities[currentCol.cursor]
+			return func() tea.Msg {
+				return previewMsg{previewType: "json", data: selectedEntity}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Update handles the messages of the preview: a preview read for the item
+// under the cursor is shown, with the lines of an entity made by details,
+// and p hides or shows the pane. It returns false for other messages.
+func (p previewPane) Update(msg tea.Msg, details func(entity map[string]interface{}) []string) (previewPane, bool) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if msg.String() != "p" {
+			return p, false
+		}
+		p.hidden = !p.hidden
+	case previewMsg:
+		p.show(msg, details)
+	default:
+		return p, false
+	}
+	return p, true
+}
+
+// show fills the pane with a preview read
+func (p *previewPane) show(msg previewMsg, details func(entity map[string]interface{}) []string) {
+	p.loading = false
+	if msg.errorMsg != "" {
+		p.column.items = []string{fmt.Sprintf("Error: %s", msg.errorMsg)}
+		return
+	}
+	switch msg.previewType {
+	case "entitysets":
+		if entitySets, ok := msg.data.([]string); ok {
+			p.column.title = "EntitySets Preview"
+			p.column.items = entitySets
+		}
+	case "entities":
+		if entities, ok := msg.data.([]map[string]interface{}); ok {
+			p.column.title = "Entities Preview"
+			p.column.items = []string{}
+			for _, entity := range entities {
+				p.column.items = append(p.column.items, formatEntityForDisplay(entity))
+			}
+			p.column.entities = entities
+		}
+	case "json":
+		if entityData, ok := msg.data.(map[string]interface{}); ok {
+			p.column.title = "JSON Preview"
+			_, err := json.Marshal(entityData)
+			if err != nil {
+				p.column.items = []string{fmt.Sprintf("Error formatting JSON: %v", err)}
+			} else {
+				p.column.items = details(entityData)
+			}
+		}
+	case "function":
+		if funcData, ok := msg.data.(map[string]interface{}); ok {
+			p.column.title = "Function Preview"
+			p.column.items = []string{
+				fmt.Sprintf("Name: %v", funcData["name"]),
+				fmt.Sprintf("Type: %v", funcData["type"]),
+				"",
+				fmt.Sprintf("Description: %v", funcData["description"]),
+				"",
+				fmt.Sprintf("Parameters: %v", funcData["parameters"]),
+				"",
+				fmt.Sprintf("%v", funcData["note"]),
+			}
+		}
+	case "metadata":
+		if metaData, ok := msg.data.(map[string]interface{}); ok {
+			p.column.title = "Metadata Preview"
+			p.column.items = []string{
+				fmt.Sprintf("Type: %v", metaData["type"]),
+				"",
+				fmt.Sprintf("URL: %v", metaData["url"]),
+				"",
+				fmt.Sprintf("%v", metaData["note"]),
+				"",
+				"Contains:",
+				"• Entity Types and Sets",
+				"• Function Imports",
+				"• Complex Types",
+				"• Associations",
+				"• Service Operations",
+			}
+		}
+	case "navigation":
+		if navData, ok := msg.data.(map[string]interface{}); ok {
+			p.column.title = "Navigation"
+			p.column.items = []string{
+				fmt.Sprintf("URI: %v", navData["uri"]),
+				"",
+				fmt.Sprintf("%v", navData["note"]),
+			}
+		}
+	case "none":
+		p.column.title = "Preview"
+		p.column.items = []string{"No preview available at this level"}
+	}
+}
+
+// View draws the pane, with the spinner in its title while it loads; ""
+// when it is hidden
+func (p previewPane) View(v columnView) string {
+	if p.hidden {
+		return ""
+	}
+	preview := p.column
+	if p.loading {
+		preview.title = v.spinner + " " + preview.title
+	}
+	return preview.View(v)
+}
+
+// renderPreview draws the preview pane
+func (m model) renderPreview() string {
+	return m.preview.View(columnView{spinner: m.spinner()})
+}
+
+// previewDetails returns the lines of an entity previewed from the active
+// column, as its Details column would show them
+func (m model) previewDetails(entity map[string]interface{}) []string {
+	entitySet := ""
+	if m.activeColumn < len(m.columns) {
+		entitySet = m.columns[m.activeColumn].entitySet
+	}
+	return m.detailsLines(entitySet, entity, nil)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPreviewPaneUpdate(t *testing.T) {
+	details := func(entity map[string]interface{}) []string {
+		return []string{"details of " + entity["Name"].(string)}
+	}
+	tests := []struct {
+		name      string
+		msg       tea.Msg
+		wantTitle string
+		wantItems []string
+		handled   bool
+	}{
+		{"entity sets", previewMsg{previewType: "entitysets", data: []string{"Products", "Orders"}}, "EntitySets Preview", []string{"Products", "Orders"}, true},
+		{"entities", previewMsg{previewType: "entities", data: []map[string]interface{}{{"Name": "Chai"}}}, "Entities Preview", []string{formatEntityForDisplay(map[string]interface{}{"Name": "Chai"})}, true},
+		{"entity", previewMsg{previewType: "json", data: map[string]interface{}{"Name": "Chai"}}, "JSON Preview", []string{"details of Chai"}, true},
+		{"nothing", previewMsg{previewType: "none"}, "Preview", []string{"No preview available at this level"}, true},
+		{"error", previewMsg{errorMsg: "HTTP 500"}, "Preview", []string{"Error: HTTP 500"}, true},
+		{"other keys", keyRunes("x"), "Preview", []string{"Select a service"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := previewPane{column: column{title: "Preview", items: []string{"Select a service"}}, loading: true}
+			got, handled := p.Update(tt.msg, details)
+			if handled != tt.handled || got.column.title != tt.wantTitle || !reflect.DeepEqual(got.column.items, tt.wantItems) {
+				t.Errorf("Update = %q %q, handled %v; want %q %q, %v", got.column.title, got.column.items, handled, tt.wantTitle, tt.wantItems, tt.handled)
+			}
+			if _, read := tt.msg.(previewMsg); read && got.loading {
+				t.Error("still loading after the preview was read")
+			}
+		})
+	}
+}
+
+func TestPreviewPaneView(t *testing.T) {
+	p := previewPane{column: column{title: "Entities Preview", width: 30, height: 6, items: []string{"Chai"}}}
+	if view := p.View(columnView{spinner: "*"}); !strings.Contains(view, "Entities Preview") || strings.Contains(view, "* Entities") {
+		t.Errorf("view:\n%s", view)
+	}
+	p.loading = true
+	if view := p.View(columnView{spinner: "*"}); !strings.Contains(view, "* Entities Preview") {
+		t.Errorf("loading view lacks the spinner:\n%s", view)
+	}
+	p, _ = p.Update(keyRunes("p"), nil)
+	if !p.hidden || p.View(columnView{}) != "" {
+		t.Error("p did not hide the preview")
+	}
+	p, _ = p.Update(keyRunes("p"), nil)
+	if p.hidden {
+		t.Error("p did not show the preview again")
+	}
+}
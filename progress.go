@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// progressBarWidth is the number of cells of the bar a running operation draws
+const progressBarWidth = 20
+
+// progressFrameInterval is how long the busy indicator takes for each step
+const progressFrameInterval = 100 * time.Millisecond
+
+// progressState is a long operation running in the background: an export,
+// a bulk create, a deep read or the submit of a batch of writes. It is
+// drawn as a bar when the number of items is known and as a busy indicator
+// while it isn't, with the items per second and the time left. Its
+// requests run under a context of their own, so x cancels it.
+type progressState struct {
+	label   string // "Creating in Products"
+	unit    string // "entities", or "bytes" for a read of one large response
+	done    int
+	total   int // -1 while unknown
+	started time.Time
+	odata   *ODataService // Service the operation sends its requests through
+	ctx     context.Context
+	cancel  context.CancelFunc
+	updates chan tea.Msg // progressMsg values, then one progressDoneMsg
+}
+
+type progressMsg struct {
+	done  int
+	total int
+}
+
+// progressDoneMsg ends a running operation with its outcome
+type progressDoneMsg struct {
+	msg tea.Msg
+}
+
+// newProgress starts tracking an operation over total items, -1 when the
+// number isn't known yet. Its requests go through the service it holds.
+func newProgress(odata *ODataService, label, unit string, total int) *progressState {
+	parent := context.Background()
+	if odata != nil && odata.cancels != nil {
+		parent = odata.cancels.current()
+	}
+	p := &progressState{label: label, unit: unit, total: total, started: time.Now(), updates: make(chan tea.Msg, 1)}
+	p.ctx, p.cancel = context.WithCancel(parent)
+	if odata != nil {
+		p.odata = odata.withContext(p.ctx)
+	}
+	return p
+}
+
+// startProgress tracks a new operation, or logs why not and returns nil
+// while another one runs
+func (m *model) startProgress(label, unit string, total int) *progressState {
+	if m.progress != nil {
+		m.logs = append(m.logs, fmt.Sprintf("%s is still running - wait for it, or cancel it with x", m.progress.label))
+		return nil
+	}
+	m.progress = newProgress(m.odata, label, unit, total)
+	m.loading = true
+	return m.progress
+}
+
+// report records the items done so far; it drops updates the UI hasn't
+// picked up yet rather than stall the operation
+func (p *progressState) report(done, total int) {
+	select {
+	case p.updates <- progressMsg{done: done, total: total}:
+	default:
+	}
+}
+
+// cancelled reports whether the operation was cancelled; it stops before
+// the next item once it is
+func (p *progressState) cancelled() bool {
+	return p.ctx.Err() != nil
+}
+
+// countBytes has the operation count the bytes of the responses it reads
+// instead of items, for a read of one large response
+func (p *progressState) countBytes() {
+	scoped := *p.odata
+	client := *p.odata.client
+	client.Transport = &countingTransport{base: client.Transport, report: p.report}
+	scoped.client = &client
+	p.odata = &scoped
+}
+
+// run starts the operation in the background; the message it returns is
+// delivered once it ends
+func (p *progressState) run(work func() tea.Msg) tea.Cmd {
+	go func() {
+		defer p.cancel()
+		p.updates <- progressDoneMsg{msg: work()}
+	}()
+	return waitForProgress(p.updates)
+}
+
+// waitForProgress delivers the next update of a running operation
+func waitForProgress(updates chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-updates
+	}
+}
+
+// updateProgress applies an update of the running operation, and once it
+// ends handles its outcome
+func (m model) updateProgress(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case progressMsg:
+		if m.progress == nil {
+			return m, nil
+		}
+		m.progress.done, m.progress.total = msg.done, msg.total
+		return m, waitForProgress(m.progress.updates)
+	case progressDoneMsg:
+		m.progress = nil
+		return m.update(msg.msg)
+	}
+	return m, nil
+}
+
+// amount renders a number of the items of the operation
+func (p *progressState) amount(n int) string {
+	if p.unit == "bytes" {
+		return formatBytes(int64(n))
+	}
+	return fmt.Sprintf("%d %s", n, p.unit)
+}
+
+// status renders a running operation: "Creating in Products
+// [██████░░░░] 120/500 entities 35.2/s 11s left"
+func (p *progressState) status() string {
+	if p.cancelled() {
+		return fmt.Sprintf("%s: cancelling after %s...", p.label, p.amount(p.done))
+	}
+	rate := ""
+	perSecond := 0.0
+	if seconds := time.Since(p.started).Seconds(); seconds > 0 && p.done > 0 {
+		perSecond = float64(p.done) / seconds
+		rate = fmt.Sprintf(" %.1f/s", perSecond)
+		if p.unit == "bytes" {
+			rate = " " + formatThroughput(int64(p.done), time.Since(p.started))
+		}
+	}
+	if p.total <= 0 {
+		return fmt.Sprintf("%s [%s] %s%s", p.label, busyBar(int(time.Since(p.started)/progressFrameInterval)), p.amount(p.done), rate)
+	}
+	done := min(p.done, p.total)
+	filled := done * progressBarWidth / p.total
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", progressBarWidth-filled)
+	text := fmt.Sprintf("%s [%s] %s/%s%s", p.label, bar, strings.TrimSuffix(p.amount(done), " "+p.unit), p.amount(p.total), rate)
+	if perSecond > 0 && done < p.total {
+		left := time.Duration(math.Ceil(float64(p.total-done)/perSecond)) * time.Second
+		text += fmt.Sprintf(" %s left", left)
+	}
+	return text
+}
+
+// busyBar draws a block moving back and forth across the bar as the frames
+// go by, for an operation whose number of items isn't known
+func busyBar(frame int) string {
+	const block = 4
+	span := progressBarWidth - block
+	pos := frame % (2 * span)
+	if pos > span {
+		pos = 2*span - pos
+	}
+	return strings.Repeat("░", pos) + strings.Repeat("█", block) + strings.Repeat("░", span-pos)
+}
+
+// countingTransport reports the bytes of the response bodies read through
+// it, with their length when the server sends one
+type countingTransport struct {
+	base   http.RoundTripper
+	report func(done, total int)
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	w := &progressWriter{total: resp.ContentLength, report: func(written, total int64) {
+		t.report(int(written), int(total))
+	}}
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.TeeReader(resp.Body, w), resp.Body}
+	return resp, nil
+}
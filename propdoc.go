@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// propertyDoc is the popup describing the property under the cursor with
+// what the metadata says about it
+type propertyDoc struct {
+	active    bool
+	entitySet string
+	name      string
+	lines     []string
+}
+
+// openPropertyDoc describes the property under the cursor of a Details
+// column, or of the modal editor when it is open
+func (m model) openPropertyDoc() model {
+	entitySet, name, ok := m.propertyUnderCursor()
+	if !ok {
+		m.logs = append(m.logs, "Property info: place the cursor on a property in the Details column or the editor")
+		return m
+	}
+	m.propDoc = propertyDoc{active: true, entitySet: entitySet, name: name, lines: m.describeProperty(entitySet, name)}
+	return m
+}
+
+// propertyUnderCursor returns the entity set and name of the property on
+// the cursor of the entity form, the cursor line of the modal editor or the
+// active Details column
+func (m model) propertyUnderCursor() (string, string, bool) {
+	if m.form.active && m.form.cursor < len(m.form.fields) {
+		return m.form.entitySet, m.form.fields[m.form.cursor].prop.Name, true
+	}
+	if m.modalEditor {
+		if m.editor.cursor >= len(m.editor.content) {
+			return "", "", false
+		}
+		line := strings.TrimSpace(m.editor.content[m.editor.cursor])
+		if !strings.HasPrefix(line, `"`) {
+			return "", "", false
+		}
+		name, _, found := strings.Cut(line[1:], `"`)
+		if !found || name == "" {
+			return "", "", false
+		}
+		return m.editorEntitySet(), name, true
+	}
+
+	if m.activeColumn >= len(m.columns) || !m.columns[m.activeColumn].isDetails {
+		return "", "", false
+	}
+	col := m.columns[m.activeColumn]
+	if _, name, found := topLevelProperty(col.items, col.cursor); found {
+		return m.detailsEntitySet(m.activeColumn), name, true
+	}
+	return "", "", false
+}
+
+// editorEntitySet returns the entity set the modal editor writes to
+func (m model) editorEntitySet() string {
+	switch m.modalOperation {
+	case "create":
+		return m.createTargetEntitySet()
+	case "bulkupdate":
+		if m.activeColumn < len(m.columns) {
+			return m.columns[m.activeColumn].entitySet
+		}
+		return ""
+	}
+	return m.detailsEntitySet(m.activeColumn)
+}
+
+// describeProperty lists what the metadata declares about a property
+func (m model) describeProperty(entitySet, name string) []string {
+	et := m.metadata().EntityTypeOf(entitySet)
+	if et == nil {
+		return []string{fmt.Sprintf("The service publishes no metadata for %s.", entitySet)}
+	}
+	for _, np := range et.NavigationProperties {
+		if np.Name == name {
+			target := np.Target
+			if np.Many {
+				target = "Collection(" + target + ")"
+			}
+			return []string{"Navigation property to " + target}
+		}
+	}
+	p := et.Property(name)
+	if p == nil {
+		return []string{fmt.Sprintf("%s.%s declares no property %s.", et.Namespace, et.Name, name)}
+	}
+
+	lines := []string{"Type:        " + p.Type}
+	for _, k := range et.Keys {
+		if k == name {
+			lines[0] += " (key)"
+		}
+	}
+	if p.MaxLength != "" {
+		lines = append(lines, "Max length:  "+p.MaxLength)
+	}
+	if p.Precision != "" {
+		precision := p.Precision
+		if p.Scale != "" {
+			precision += ", scale " + p.Scale
+		}
+		lines = append(lines, "Precision:   "+precision)
+	}
+	nullable := "no"
+	if p.Nullable {
+		nullable = "yes"
+	}
+	lines = append(lines, "Nullable:    "+nullable)
+	switch {
+	case p.Computed:
+		lines = append(lines, "Read-only:   set by the service")
+	case p.Immutable:
+		lines = append(lines, "Read-only:   once created")
+	}
+	for _, text := range []struct{ label, value string }{
+		{"Label:       ", p.Label},
+		{"Heading:     ", p.Heading},
+		{"Quick info:  ", p.QuickInfo},
+		{"Description: ", p.Description},
+	} {
+		if text.value != "" {
+			lines = append(lines, text.label+text.value)
+		}
+	}
+	return lines
+}
+
+// updatePropertyDoc closes the popup on any key
+func (m model) updatePropertyDoc(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "ctrl+c" {
+		return m, tea.Quit
+	}
+	m.propDoc.active = false
+	return m, nil
+}
+
+// renderPropertyDoc renders the property popup box
+func (m model) renderPropertyDoc() string {
+	pd := m.propDoc
+	lines := append([]string{}, pd.lines...)
+	lines = append(lines, "", lipgloss.NewStyle().Foreground(theme.Muted).Render("Any key: Close"))
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Background(theme.Accent).
+		Foreground(theme.AccentText).
+		Padding(0, 1).
+		Render(pd.entitySet + " / " + pd.name)
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(theme.Accent).
+		Padding(0, 1).
+		Width(min(70, m.width-4)).
+		Render(title + "\n\n" + strings.Join(lines, "\n"))
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// ProxyConfig routes the requests of a service through an HTTP or SOCKS5
+// proxy, or through an SSH jump host. Without it, HTTP_PROXY, HTTPS_PROXY,
+// ALL_PROXY and NO_PROXY from the environment apply.
+type ProxyConfig struct {
+	URL     string   `json:"url,omitempty"`      // http:// or socks5://[user:password@]proxy:port, or "direct" to ignore the environment
+	NoProxy []string `json:"no_proxy,omitempty"` // Hosts (optionally host:port), .domain suffixes, CIDR ranges or "*" reached directly
+
+	// Jump host as [user@]host[:port]; requests go through a dynamic port
+	// forward of the ssh command, which must log in without prompting
+	// (keys or an agent), so the service is reached as from the bastion
+	SSH     string   `json:"ssh,omitempty"`
+	SSHArgs []string `json:"ssh_args,omitempty"` // Extra ssh options, e.g. ["-i", "~/.ssh/bastion"]
+}
+
+// proxyFunc returns the proxy selection for an http.Transport
+func (p *ProxyConfig) proxyFunc() (func(*http.Request) (*url.URL, error), error) {
+	if p != nil && p.SSH != "" {
+		tunnel := sshTunnelTo(p.SSH, p.SSHArgs)
+		return func(req *http.Request) (*url.URL, error) {
+			if p.excludes(req.URL) {
+				return nil, nil
+			}
+			return tunnel.proxyURL()
+		}, nil
+	}
+	if p == nil || p.URL == "" {
+		return environmentProxy(), nil
+	}
+	if strings.EqualFold(p.URL, "direct") {
+		return nil, nil
+	}
+	proxyURL, err := url.Parse(p.URL)
+	if err != nil || proxyURL.Host == "" {
+		// A bare host:port is taken to mean an HTTP proxy, like curl does
+		if proxyURL, err = url.Parse("http://" + p.URL); err != nil {
+			return nil, fmt.Errorf("invalid proxy URL %q: %w", p.URL, err)
+		}
+	}
+	return func(req *http.Request) (*url.URL, error) {
+		if p.excludes(req.URL) {
+			return nil, nil
+		}
+		return proxyURL, nil
+	}, nil
+}
+
+// environmentProxy selects proxies like http.ProxyFromEnvironment, falling
+// back to ALL_PROXY (as curl does) for requests no HTTP(S)_PROXY covers
+func environmentProxy() func(*http.Request) (*url.URL, error) {
+	all := os.Getenv("ALL_PROXY")
+	if all == "" {
+		all = os.Getenv("all_proxy")
+	}
+	if all == "" {
+		return http.ProxyFromEnvironment
+	}
+	noProxy := os.Getenv("NO_PROXY")
+	if noProxy == "" {
+		noProxy = os.Getenv("no_proxy")
+	}
+	fallback, err := (&ProxyConfig{URL: all, NoProxy: strings.Split(noProxy, ",")}).proxyFunc()
+	if err != nil {
+		return http.ProxyFromEnvironment
+	}
+	return func(req *http.Request) (*url.URL, error) {
+		if proxyURL, err := http.ProxyFromEnvironment(req); proxyURL != nil || err != nil {
+			return proxyURL, err
+		}
+		return fallback(req)
+	}
+}
+
+// excludes reports whether a URL is reached directly. Loopback hosts never
+// go through the proxy.
+func (p *ProxyConfig) excludes(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	ip := net.ParseIP(host)
+	if host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		return true
+	}
+
+	for _, rule := range p.NoProxy {
+		rule = strings.ToLower(strings.TrimSpace(rule))
+		if rule == "*" {
+			return true
+		}
+		if _, network, err := net.ParseCIDR(rule); err == nil {
+			if ip != nil && network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		ruleHost, rulePort, err := net.SplitHostPort(rule)
+		if err != nil {
+			ruleHost, rulePort = rule, ""
+		}
+		if rulePort != "" && rulePort != port {
+			continue
+		}
+		// "example.com" and ".example.com" both match the domain and its subdomains
+		domain := strings.TrimPrefix(ruleHost, ".")
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// newBaseTransport returns a transport with the default settings that
+// reaches services through the configured proxy and gives up on servers
+// that don't start answering within timeout (0 waits forever)
+func newBaseTransport(proxy *ProxyConfig, timeout time.Duration) http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = timeout
+	// LoadConfig has already warned about a proxy URL that doesn't parse
+	if proxyFunc, err := proxy.proxyFunc(); err == nil {
+		transport.Proxy = proxyFunc
+	}
+	if traceLog != nil {
+		return &traceLogTransport{base: transport, log: traceLog}
+	}
+	return transport
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// QueryOptions are the query options of a request built with BuildURL
+type QueryOptions struct {
+	Filter  string
+	Select  string
+	Expand  string
+	OrderBy string
+	Top     int
+	Skip    int
+	Count   bool              // Request the total count ($inlinecount in V2, $count in V4)
+	Search  string            // Free-text search term, sent as searchOptions describes
+	Custom  map[string]string // Other query options such as sap-client or $search
+}
+
+// BuildURL returns the URL of an entity set, or of one of its entities when
+// key is set, with the given query options escaped for the URL. key is the
+// key predicate as written between the parentheses, e.g. 'ALFKI' or
+// OrderID=1,ProductID=2. An absolute URL may be passed as entitySet to
+// address a related collection; its own query string is kept.
+func (o *ODataService) BuildURL(entitySet, key string, opts QueryOptions) string {
+	u := entitySet
+	if !isAbsoluteURL(entitySet) {
+		u = strings.TrimSuffix(o.baseURL, "/") + "/" + strings.TrimPrefix(entitySet, "/")
+	}
+	if key != "" {
+		u += "(" + escapeKeyPredicate(key) + ")"
+	}
+	opts = o.searchOptions(entitySet, opts)
+
+	var params []string
+	add := func(name, value string) {
+		if value != "" {
+			params = append(params, name+"="+escapeQueryValue(value))
+		}
+	}
+	add("$filter", opts.Filter)
+	add("$select", opts.Select)
+	add("$expand", opts.Expand)
+	add("$orderby", opts.OrderBy)
+	if opts.Top > 0 {
+		add("$top", strconv.Itoa(opts.Top))
+	}
+	if opts.Skip > 0 {
+		add("$skip", strconv.Itoa(opts.Skip))
+	}
+	if opts.Count {
+		if strings.HasPrefix(o.Version(), "4") {
+			add("$count", "true")
+		} else {
+			add("$inlinecount", "allpages")
+		}
+	}
+	custom := make([]string, 0, len(opts.Custom))
+	for name := range opts.Custom {
+		custom = append(custom, name)
+	}
+	sort.Strings(custom)
+	for _, name := range custom {
+		// Keep the $ of system query options the typed fields don't cover, like $search
+		params = append(params, strings.ReplaceAll(url.QueryEscape(name), "%24", "$")+"="+escapeQueryValue(opts.Custom[name]))
+	}
+
+	return appendQuery(u, strings.Join(params, "&"))
+}
+
+// jsonFormat asks for a JSON response on a read URL. V4 services answer in
+// JSON by default, so only V2/V3 services get $format=json.
+func (o *ODataService) jsonFormat(u string) string {
+	if strings.HasPrefix(o.Version(), "4") || strings.Contains(u, "$format=") {
+		return u
+	}
+	return appendQuery(u, "$format=json")
+}
+
+// appendQuery adds an encoded query string to a URL that may already have one
+func appendQuery(u, query string) string {
+	if query == "" {
+		return u
+	}
+	if strings.Contains(u, "?") {
+		return u + "&" + query
+	}
+	return u + "?" + query
+}
+
+// KeyPredicate formats a key typed by the user, such as 1, ALFKI or
+// OrderID=1,ProductID=2, with the literal syntax of the types of the key
+// properties of the entity set. Values already written as literals, and
+// keys of entity sets without metadata, are kept as they are.
+func (o *ODataService) KeyPredicate(entitySet, key string) string {
+	et := o.metadata().EntityTypeOf(entitySet)
+	if et == nil || len(et.Keys) == 0 {
+		return key
+	}
+	parts := splitKeyPredicate(key)
+	if len(parts) == 1 && !strings.Contains(parts[0], "=") {
+		if len(et.Keys) != 1 {
+			return key
+		}
+		return o.keyValueLiteral(et, et.Keys[0], parts[0])
+	}
+	for i, part := range parts {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return key
+		}
+		parts[i] = name + "=" + o.keyValueLiteral(et, strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	return strings.Join(parts, ",")
+}
+
+// typedLiteral matches values written as literals of the prefixed kind,
+// like guid'…' or datetime'…'
+var typedLiteral = regexp.MustCompile(`^[a-zA-Z]+'.*'$`)
+
+// keyValueLiteral formats one key value unless it already is a literal
+func (o *ODataService) keyValueLiteral(et *EntityType, name, value string) string {
+	p := et.Property(name)
+	if p == nil || strings.HasPrefix(value, "'") || typedLiteral.MatchString(value) {
+		return value
+	}
+	switch p.Type {
+	case "Edm.Int64", "Edm.Decimal":
+		// 10L and 1.5M carry their V2 suffix already
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return value
+		}
+	}
+	return formatODataLiteral(p.Type, value, o.Version())
+}
+
+// splitKeyPredicate splits a composite key at the commas outside quotes
+func splitKeyPredicate(key string) []string {
+	var parts []string
+	quoted := false
+	start := 0
+	for i, r := range key {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			parts = append(parts, key[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, key[start:])
+}
+
+// escapeKeyPredicate percent-encodes a key predicate for use in the URL
+// path, leaving the quotes, commas and equals signs of the OData syntax
+// readable. Spaces, slashes, '?', '#' and '%' in key values are encoded, and
+// so is '+', which some gateways decode as a space even in the path. Key
+// predicates are kept unencoded everywhere else; see keyFromURI.
+func escapeKeyPredicate(key string) string {
+	escaped := url.PathEscape(key)
+	return strings.NewReplacer("%27", "'", "%2C", ",", "%3D", "=", "+", "%2B").Replace(escaped)
+}
+
+// keyFromURI returns the key predicate between the parentheses that end an
+// entity URI such as https://host/service/Orders('A%2FB'), decoded as in
+// Orders('A/B')
+func keyFromURI(uri string) (string, bool) {
+	segment := uri[strings.LastIndex(uri, "/")+1:]
+	open := strings.Index(segment, "(")
+	if open == -1 || !strings.HasSuffix(segment, ")") {
+		return "", false
+	}
+	key := segment[open+1 : len(segment)-1]
+	if unescaped, err := url.PathUnescape(key); err == nil {
+		key = unescaped
+	}
+	return key, true
+}
+
+// isAbsoluteURL reports whether a resource is addressed by a full http(s) URL
+func isAbsoluteURL(resource string) bool {
+	return strings.HasPrefix(resource, "http://") || strings.HasPrefix(resource, "https://")
+}
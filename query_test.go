@@ -0,0 +1,66 @@
+package main
+
+import "testing"
+
+func TestBuildURL(t *testing.T) {
+	v2 := NewODataServiceWithURL("https://host/service.svc/")
+	v4 := NewODataServiceWithURL("https://host/service.svc/")
+	v4.setMetadata(&Metadata{Version: "4.0"}, "")
+	tests := []struct {
+		name      string
+		o         *ODataService
+		entitySet string
+		key       string
+		opts      QueryOptions
+		want      string
+	}{
+		{"entity set", v2, "Products", "", QueryOptions{}, "https://host/service.svc/Products"},
+		{"entity", v2, "Products", "1", QueryOptions{}, "https://host/service.svc/Products(1)"},
+		{"string key", v2, "Customers", "'ALFKI'", QueryOptions{}, "https://host/service.svc/Customers('ALFKI')"},
+		{"composite key", v2, "Order_Details", "OrderID=1,ProductID=2", QueryOptions{}, "https://host/service.svc/Order_Details(OrderID=1,ProductID=2)"},
+		{"options in order", v2, "Products", "", QueryOptions{Filter: "Price gt 10", Select: "Name", Expand: "Category", OrderBy: "Name desc", Top: 5, Skip: 10},
+			"https://host/service.svc/Products?$filter=Price%20gt%2010&$select=Name&$expand=Category&$orderby=Name%20desc&$top=5&$skip=10"},
+		{"v2 count", v2, "Products", "", QueryOptions{Count: true}, "https://host/service.svc/Products?$inlinecount=allpages"},
+		{"v4 count", v4, "Products", "", QueryOptions{Count: true}, "https://host/service.svc/Products?$count=true"},
+		{"v4 search", v4, "Products", "", QueryOptions{Search: "blue chair"}, "https://host/service.svc/Products?$search=blue%20chair"},
+		{"custom options sorted", v2, "Products", "", QueryOptions{Custom: map[string]string{"sap-client": "100", "$search": "a b"}}, "https://host/service.svc/Products?$search=a%20b&sap-client=100"},
+		{"quotes and ampersands in a filter", v2, "Products", "", QueryOptions{Filter: "Name eq 'A&B'"}, "https://host/service.svc/Products?$filter=Name%20eq%20%27A%26B%27"},
+		{"absolute related collection", v2, "https://host/service.svc/Categories(1)/Products?x=1", "", QueryOptions{Top: 2}, "https://host/service.svc/Categories(1)/Products?x=1&$top=2"},
+		{"metadata", v2, "$metadata", "", QueryOptions{}, "https://host/service.svc/$metadata"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.o.BuildURL(tt.entitySet, tt.key, tt.opts); got != tt.want {
+				t.Errorf("BuildURL = %s\nwant        %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscapeKeyPredicate(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"1", "1"},
+		{"'ALFKI'", "'ALFKI'"},
+		{"OrderID=1,ProductID=2", "OrderID=1,ProductID=2"},
+		{"'A B'", "'A%20B'"},
+		{"'A/B'", "'A%2FB'"},
+		{"'a?b#c'", "'a%3Fb%23c'"},
+		{"'100%'", "'100%25'"},
+		{"'1+1'", "'1%2B1'"},
+		{"'O''Brien'", "'O''Brien'"},
+		{"guid'0001'", "guid'0001'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := escapeKeyPredicate(tt.key); got != tt.want {
+				t.Errorf("escapeKeyPredicate(%s) = %s, want %s", tt.key, got, tt.want)
+			}
+			if got, ok := keyFromURI("https://host/service.svc/Products(" + escapeKeyPredicate(tt.key) + ")"); !ok || got != tt.key {
+				t.Errorf("keyFromURI gives %s back, want %s", got, tt.key)
+			}
+		})
+	}
+}
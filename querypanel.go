@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Rows of the query panel
+const (
+	queryRowTop = iota
+	queryRowSkip
+	queryRowFilter
+	queryRowOrderBy
+	queryRowSelect
+	queryRowExpand
+	queryRowFormat
+	queryRowCount
+)
+
+// queryRowNames are the query options the rows of the panel edit
+var queryRowNames = [queryRowCount]string{"$top", "$skip", "$filter", "$orderby", "$select", "$expand", "$format"}
+
+// queryPanel is the state of the "Q" overlay editing the query options of
+// an entity list; it stays open while the list reloads, so a query can be
+// tried again and again
+type queryPanel struct {
+	active bool
+	column int // Index of the entities column queried
+	row    int
+	values [queryRowCount]string
+}
+
+// openQueryPanel shows the query options of the active entities column
+func (m model) openQueryPanel() model {
+	if m.activeColumn >= len(m.columns) || m.columns[m.activeColumn].entitySet == "" || m.columns[m.activeColumn].isDetails || m.columns[m.activeColumn].isPreview {
+		m.logs = append(m.logs, "The query panel is only available on an entity list")
+		return m
+	}
+	q := m.columns[m.activeColumn].query
+	qp := queryPanel{active: true, column: m.activeColumn, row: m.queryPanel.row}
+	if q.Top > 0 {
+		qp.values[queryRowTop] = strconv.Itoa(q.Top)
+	}
+	if q.Skip > 0 {
+		qp.values[queryRowSkip] = strconv.Itoa(q.Skip)
+	}
+	qp.values[queryRowFilter] = q.Filter
+	qp.values[queryRowOrderBy] = q.OrderBy
+	qp.values[queryRowSelect] = q.Select
+	qp.values[queryRowExpand] = q.Expand
+	qp.values[queryRowFormat] = q.Custom["$format"]
+	m.queryPanel = qp
+	return m
+}
+
+// updateQueryPanel handles key presses while the query panel is open: up
+// and down pick an option, typing edits it and enter runs the query
+func (m model) updateQueryPanel(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	qp := &m.queryPanel
+	value := &qp.values[qp.row]
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "f1":
+		return m.openHelp(helpPageCheatSheet), nil
+	case "esc":
+		qp.active = false
+	case "enter":
+		return m.applyQueryPanel()
+	case "up", "shift+tab":
+		qp.row = (qp.row + queryRowCount - 1) % queryRowCount
+	case "down", "tab":
+		qp.row = (qp.row + 1) % queryRowCount
+	case "ctrl+u":
+		*value = ""
+	case "backspace":
+		if len(*value) > 0 {
+			runes := []rune(*value)
+			*value = string(runes[:len(runes)-1])
+		}
+	default:
+		*value += typedText(msg)
+	}
+	return m, nil
+}
+
+// queryOptions turns the values of the panel into the query of the column,
+// keeping what the panel doesn't show, such as the search term
+func (qp queryPanel) queryOptions(current QueryOptions, variables map[string]string) (QueryOptions, error) {
+	var values [queryRowCount]string
+	for i, value := range qp.values {
+		value, missing := expandVariables(strings.TrimSpace(value), variables)
+		if len(missing) > 0 {
+			return current, fmt.Errorf("unknown variables: {{%s}}", strings.Join(missing, "}}, {{"))
+		}
+		values[i] = value
+	}
+	for _, row := range []int{queryRowTop, queryRowSkip} {
+		if n, err := strconv.Atoi(values[row]); values[row] != "" && (err != nil || n < 0) {
+			return current, fmt.Errorf("%s must be a whole number, not %q", queryRowNames[row], values[row])
+		}
+	}
+
+	opts := current
+	opts.Top, _ = strconv.Atoi(values[queryRowTop])
+	opts.Skip, _ = strconv.Atoi(values[queryRowSkip])
+	opts.Filter = values[queryRowFilter]
+	opts.OrderBy = values[queryRowOrderBy]
+	opts.Select = values[queryRowSelect]
+	opts.Expand = values[queryRowExpand]
+
+	// The map is shared with the copies of the query made for loading
+	custom := make(map[string]string, len(current.Custom)+1)
+	for name, value := range current.Custom {
+		custom[name] = value
+	}
+	delete(custom, "$format")
+	if values[queryRowFormat] != "" {
+		custom["$format"] = values[queryRowFormat]
+	}
+	opts.Custom = nil
+	if len(custom) > 0 {
+		opts.Custom = custom
+	}
+	return opts, nil
+}
+
+// applyQueryPanel reloads the queried column with the options of the panel,
+// which stays open to adjust them further
+func (m model) applyQueryPanel() (tea.Model, tea.Cmd) {
+	qp := m.queryPanel
+	if qp.column >= len(m.columns) {
+		m.queryPanel.active = false
+		return m, nil
+	}
+	col := &m.columns[qp.column]
+	opts, err := qp.queryOptions(col.query, m.variables)
+	if err != nil {
+		m.logs = append(m.logs, fmt.Sprintf("ERROR [query %s]: %v", col.entitySet, err))
+		return m, nil
+	}
+
+	col.query = opts
+	col.items = []string{"Loading..."}
+	col.state = stateLoading
+	col.entities = nil
+	col.selected = nil
+	col.cursor = 0
+	col.scrollOffset = 0
+
+	m.logs = append(m.logs, "Querying "+redactURL(m.odata.BuildURL(col.resource(), "", opts)))
+	m.loading = true
+	return m, loadEntities(m.odata, *col)
+}
+
+// renderQueryPanel renders the query panel box
+func (m model) renderQueryPanel() string {
+	qp := m.queryPanel
+	col := m.columns[qp.column]
+	hint := lipgloss.NewStyle().Foreground(theme.Muted)
+	selected := lipgloss.NewStyle().Background(theme.Selection).Foreground(theme.AccentText)
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Background(theme.Accent).
+		Foreground(theme.AccentText).
+		Padding(0, 1).
+		Render("Query " + col.title)
+
+	var lines []string
+	for i, name := range queryRowNames {
+		label := fmt.Sprintf("%-9s", name)
+		if i == qp.row {
+			lines = append(lines, selected.Render("► "+label)+" "+qp.values[i]+"█")
+		} else {
+			lines = append(lines, "  "+label+" "+qp.values[i])
+		}
+	}
+
+	status := fmt.Sprintf("%d entities loaded", len(col.entities))
+	switch {
+	case col.state == stateLoading:
+		status = "Loading..."
+	case col.state == stateError:
+		status = "The query failed - see the log"
+	case col.hasMore:
+		status += ", more available"
+	}
+	width := min(100, m.width-4)
+	requestURL := strings.Join(wrapText(redactURL(m.odata.BuildURL(col.resource(), "", col.query)), max(width-4, 20)), "\n")
+
+	content := title + "\n\n" +
+		strings.Join(lines, "\n") + "\n\n" +
+		hint.Render(requestURL) + "\n" +
+		hint.Render(status) + "\n\n" +
+		hint.Render("$top and $skip are numbers, empty leaves them out | {{Name}} inserts a variable") + "\n" +
+		hint.Render("Enter: Run | Up/Down: Option | Ctrl+U: Clear | F1: Cheat Sheet | ESC: Close")
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(theme.Accent).
+		Padding(0, 1).
+		Width(width).
+		Render(content)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// rawResponseLines limits how much of an unparseable body is shown
+const rawResponseLines = 2000
+
+// issuesURL is where unparseable responses can be reported
+const issuesURL = "https://github.com/oisee/odatanavigator/issues/new"
+
+// ParseError is a response that arrived fine but is none of the JSON shapes
+// the navigator understands, such as an HTML login page or an Atom feed
+type ParseError struct {
+	URL         string
+	StatusCode  int
+	ContentType string // As sent by the server
+	Body        []byte
+	Err         error
+}
+
+func newParseError(req *http.Request, resp *http.Response, body []byte, err error) *ParseError {
+	return &ParseError{
+		URL:         req.URL.String(),
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        body,
+		Err:         err,
+	}
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("cannot parse %s response (%s): %v", e.Detected(), formatBytes(int64(len(e.Body))), e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Detected names the kind of body the server actually sent, going by its
+// content rather than its Content-Type header
+func (e *ParseError) Detected() string {
+	trimmed := bytes.TrimSpace(e.Body)
+	lower := strings.ToLower(string(trimmed[:min(len(trimmed), 512)]))
+	switch {
+	case len(trimmed) == 0:
+		return "empty"
+	case strings.HasPrefix(lower, "<!doctype html") || strings.HasPrefix(lower, "<html") || strings.Contains(lower, "<head"):
+		return "HTML (login or error page?)"
+	case strings.Contains(lower, "<feed") || strings.Contains(lower, "<entry"):
+		return "Atom/XML (JSON not supported?)"
+	case strings.HasPrefix(lower, "<?xml") || strings.HasPrefix(lower, "<"):
+		return "XML"
+	case trimmed[0] == '{' || trimmed[0] == '[':
+		return "JSON of an unknown shape"
+	}
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(e.Body))
+	return mediaType
+}
+
+// newErrorMsg reports a failed command, keeping the body of responses that
+// couldn't be parsed for the raw response column
+func newErrorMsg(err error, context string) errorMsg {
+	msg := errorMsg{err: err.Error(), context: context}
+	errors.As(err, &msg.raw)
+	return msg
+}
+
+// forColumn addresses the error to the column whose load failed
+func (e errorMsg) forColumn(id int) errorMsg {
+	e.column = id
+	return e
+}
+
+// openRawResponseColumn shows an unparseable response next to the column
+// that asked for it, replacing that column if it only says "Loading..."
+func (m *model) openRawResponseColumn(raw *ParseError, context string) {
+	col := column{
+		title: "Raw Response",
+		raw:   raw,
+		items: []string{
+			"Request: " + context,
+			"URL: " + redactURL(raw.URL),
+			fmt.Sprintf("Status: %d", raw.StatusCode),
+			"Content-Type: " + raw.ContentType,
+			"Detected: " + raw.Detected(),
+			"Size: " + formatBytes(int64(len(raw.Body))),
+			"Error: " + raw.Err.Error(),
+			"",
+			"w: Save to file | c: Copy body | i: Report issue",
+			"",
+		},
+	}
+	lines := strings.Split(redactText(string(raw.Body)), "\n")
+	if len(lines) > rawResponseLines {
+		lines = append(lines[:rawResponseLines], fmt.Sprintf("... %d more lines", len(lines)-rawResponseLines))
+	}
+	col.items = append(col.items, lines...)
+
+	if m.activeColumn > 0 && m.activeColumn < len(m.columns) {
+		if m.columns[m.activeColumn].isLoadingPlaceholder() {
+			m.activeColumn--
+		}
+	}
+	if m.activeColumn < len(m.columns) {
+		m.columns = m.columns[:m.activeColumn+1]
+	}
+	for i := range m.columns {
+		m.columns[i].focused = false
+	}
+	col.focused = true
+	m.columns = append(m.columns, col)
+	m.activeColumn = len(m.columns) - 1
+	m.updateColumnSizes()
+}
+
+// rawResponseAction runs the save, copy and report keys of a raw response
+// column; it returns false for other keys
+func (m model) rawResponseAction(key string) (model, bool) {
+	if m.activeColumn >= len(m.columns) || m.columns[m.activeColumn].raw == nil {
+		return m, false
+	}
+	raw := m.columns[m.activeColumn].raw
+
+	switch key {
+	case "w":
+		name := downloadFileName("response", http.Header{"Content-Type": {raw.ContentType}})
+		path := uniqueFilePath(".", name)
+		if err := writeExport(path, raw.Body); err != nil {
+			m.logs = append(m.logs, fmt.Sprintf("ERROR [save response]: %v", err))
+		} else {
+			m.logs = append(m.logs, fmt.Sprintf("Saved response to %s", path))
+		}
+	case "c":
+		copyToClipboard(string(raw.Body))
+		m.logs = append(m.logs, fmt.Sprintf("Copied %s to the clipboard", formatBytes(int64(len(raw.Body)))))
+	case "i":
+		m.logs = append(m.logs, "Report the response at: "+raw.issueURL())
+	default:
+		return m, false
+	}
+	return m, true
+}
+
+// issueURL returns the link of a prefilled GitHub issue. The body of the response is left
+// out since it may hold business data; the user can paste what is safe.
+func (e *ParseError) issueURL() string {
+	body := fmt.Sprintf("A response could not be parsed.\n\n- Status: %d\n- Content-Type: %s\n- Detected: %s\n- Size: %d bytes\n- Error: %v\n\nResponse excerpt (remove anything confidential):\n\n```\n\n```\n",
+		e.StatusCode, e.ContentType, e.Detected(), len(e.Body), e.Err)
+	query := url.Values{
+		"title": {"Cannot parse " + e.Detected() + " response"},
+		"body":  {body},
+	}
+	return issuesURL + "?" + query.Encode()
+}
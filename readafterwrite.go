@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// reloadAfterWrite reads the entity lists of an entity set again after a
+// write, so they show the defaults, generated keys and calculated fields
+// of the server rather than what was sent. The cursor goes back to the
+// entity with the key reselect once a list has reloaded. Lists still
+// loading are left to their running load.
+func (m *model) reloadAfterWrite(entitySet, reselect string) tea.Cmd {
+	if m.odata == nil {
+		return nil
+	}
+	var cmds []tea.Cmd
+	for i := range m.columns {
+		col := &m.columns[i]
+		if col.entitySet != entitySet || col.isDetails || col.isPreview || col.isResult || col.raw != nil || col.loading() {
+			continue
+		}
+		m.odata.Refresh(m.odata.BuildURL(col.resource(), "", QueryOptions{}))
+		if col.id == 0 {
+			col.id = m.newColumnID()
+		}
+		col.state = stateLoading
+		col.reselect = reselect
+		cmds = append(cmds, loadEntities(m.odata, *col))
+	}
+	if len(cmds) > 0 {
+		m.debugf("Reloading %d lists of %s after the write", len(cmds), entitySet)
+	}
+	return tea.Batch(cmds...)
+}
+
+// rereadDetails reads the entity an update went to again into the Details
+// columns showing it, as the service may have changed more than was sent
+func (m *model) rereadDetails(entitySet, key string) tea.Cmd {
+	if m.odata == nil || key == "" {
+		return nil
+	}
+	var cmds []tea.Cmd
+	for i := range m.columns {
+		col := &m.columns[i]
+		if !col.isDetails || col.isResult || col.raw != nil || len(col.entities) == 0 || col.loading() || i == 0 {
+			continue
+		}
+		if m.detailsEntitySet(i) != entitySet || extractEntityKey(m.metadata(), entitySet, col.entities[0]) != key {
+			continue
+		}
+		if col.id == 0 {
+			col.id = m.newColumnID()
+		}
+		col.state = stateLoading
+		id, odata := col.id, m.odata.forColumn(col.id)
+		cmds = append(cmds, func() tea.Msg {
+			defer odata.loaded()
+			entity, err := odata.GetEntity(entitySet, key, QueryOptions{})
+			if err != nil {
+				return newErrorMsg(err, fmt.Sprintf("readEntity(%s, %s)", entitySet, key)).forColumn(id)
+			}
+			return entityDetailMsg{column: id, entitySet: entitySet, entityKey: key, entity: entity}
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+// reselectEntity puts the cursor of a reloaded list back on the entity it
+// was asked to return to, and keeps it within the list otherwise
+func (m model) reselectEntity(col *column) {
+	if col.reselect != "" {
+		for i, entity := range col.entities {
+			if extractEntityKey(m.metadata(), col.entitySet, entity) == col.reselect {
+				col.cursor = i
+				break
+			}
+		}
+		col.reselect = ""
+	}
+	col.cursor = max(0, min(col.cursor, len(col.items)-1))
+	visibleHeight := col.height - 2
+	if col.cursor < col.scrollOffset {
+		col.scrollOffset = col.cursor
+	} else if visibleHeight > 0 && col.cursor >= col.scrollOffset+visibleHeight {
+		col.scrollOffset = col.cursor - visibleHeight + 1
+	}
+}
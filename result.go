@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// openResultColumn shows the outcome of write operations in a transient
+// "Result" column to the right of the active column
+func (m *model) openResultColumn(entitySet string, results []*OperationResult) {
+	if len(results) == 0 {
+		return
+	}
+
+	col := column{
+		title:     "Result",
+		isDetails: true,
+		isResult:  true,
+		entitySet: entitySet,
+		results:   results,
+	}
+	for i, r := range results {
+		lines := formatOperationResult(r)
+		if len(results) > 1 {
+			lines = append([]string{fmt.Sprintf("#%d %s %s", i+1, r.Method, r.Status)}, lines[1:]...)
+			lines = append(lines, "")
+		}
+		for range lines {
+			col.resultLines = append(col.resultLines, i)
+		}
+		col.items = append(col.items, lines...)
+	}
+
+	// Replace an earlier result column instead of stacking them
+	if m.activeColumn < len(m.columns) && m.columns[m.activeColumn].isResult && m.activeColumn > 0 {
+		m.activeColumn--
+	}
+	m.columns = m.columns[:m.activeColumn+1]
+	for i := range m.columns {
+		m.columns[i].focused = false
+	}
+	col.focused = true
+	m.columns = append(m.columns, col)
+	m.activeColumn++
+	m.updateColumnSizes()
+}
+
+// formatOperationResult renders status, location, messages and the returned entity
+func formatOperationResult(r *OperationResult) []string {
+	lines := []string{
+		fmt.Sprintf("%s %s", r.Method, r.Status),
+		fmt.Sprintf("URL: %s", redactURL(r.URL)),
+	}
+	if r.Location != "" {
+		lines = append(lines, fmt.Sprintf("Location: %s", redactURL(r.Location)))
+	}
+	if r.PreferenceApplied != "" {
+		lines = append(lines, "Preference-Applied: "+r.PreferenceApplied)
+	}
+	if len(r.Messages) > 0 {
+		lines = append(lines, "", "Messages:")
+		for _, msg := range r.Messages {
+			lines = append(lines, "  "+msg)
+		}
+	}
+	if r.Entity != nil {
+		lines = append(lines, "", "Enter: open affected record", "")
+		if jsonData, err := json.MarshalIndent(r.Entity, "", "  "); err == nil {
+			lines = append(lines, strings.Split(string(jsonData), "\n")...)
+		}
+	} else if r.Body != "" {
+		lines = append(lines, "", "Response:")
+		lines = append(lines, strings.Split(redactText(r.Body), "\n")...)
+	}
+	return lines
+}
+
+// jumpToResult opens the record affected by the selected operation result as
+// a Details column next to its entity list
+func (m model) jumpToResult() (tea.Model, tea.Cmd) {
+	col := m.columns[m.activeColumn]
+	if col.cursor >= len(col.resultLines) || col.resultLines[col.cursor] < 0 {
+		return m, nil
+	}
+	result := col.results[col.resultLines[col.cursor]]
+	if result.Entity == nil {
+		m.logs = append(m.logs, "No entity returned for this operation")
+		return m, nil
+	}
+
+	// Go back to the entity list the record belongs to, if it is still open
+	listIndex := m.activeColumn - 1
+	for i := range m.columns {
+		if m.columns[i].entitySet == col.entitySet && !m.columns[i].isDetails {
+			listIndex = i
+		}
+	}
+
+	details := column{
+		title:     "Details",
+		items:     m.detailsLines(col.entitySet, result.Entity, nil),
+		isDetails: true,
+		focused:   true,
+		entities:  []map[string]interface{}{result.Entity},
+	}
+
+	m.columns = m.columns[:listIndex+1]
+	for i := range m.columns {
+		m.columns[i].focused = false
+	}
+	m.columns = append(m.columns, details)
+	m.activeColumn = len(m.columns) - 1
+	m.updateColumnSizes()
+	return m, m.updatePreview()
+}
+
+// insertCreatedEntity adds a newly created entity to the top of its entity
+// list column and selects it, so server-calculated fields can be checked
+func (m *model) insertCreatedEntity(entitySet string, entity map[string]interface{}) {
+	for i := range m.columns {
+		col := &m.columns[i]
+		if col.entitySet != entitySet || col.isDetails {
+			continue
+		}
+		// Drop the placeholder row of an empty list
+		if len(col.entities) == 0 {
+			col.items = nil
+		}
+		col.entities = append([]map[string]interface{}{entity}, col.entities...)
+		// Marks follow their entities down one row
+		if len(col.selected) > 0 {
+			selected := make(map[int]bool, len(col.selected))
+			for i := range col.selected {
+				selected[i+1] = true
+			}
+			col.selected = selected
+		}
+		col.items = append([]string{formatEntityForDisplay(entity)}, col.items...)
+		col.cursor = 0
+		col.scrollOffset = 0
+		return
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// linkScheme is the URL scheme of deep links handed over by other tools, as
+// in odata://host/service/EntitySet(key)
+const linkScheme = "odata"
+
+// runRegisterScheme implements "odatanavigator register-scheme": it makes
+// the running executable the handler of odata:// links for the current
+// user. Links open with the config file of the current directory.
+func runRegisterScheme() error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot locate the running executable: %w", err)
+	}
+	if executable, err = filepath.EvalSymlinks(executable); err != nil {
+		return fmt.Errorf("cannot locate the running executable: %w", err)
+	}
+	config, err := filepath.Abs(DefaultConfigFile)
+	if err != nil {
+		return err
+	}
+
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return registerDesktopEntry(executable, config)
+	case "windows":
+		return registerWindowsProtocol(executable, config)
+	}
+	return fmt.Errorf("registering a URL scheme is not supported on %s; pass links as arguments instead: odatanavigator %s://host/service/EntitySet(key)", runtime.GOOS, linkScheme)
+}
+
+// registerDesktopEntry installs a desktop entry handling odata:// links in
+// a terminal and makes it the default handler through xdg-mime
+func registerDesktopEntry(executable, config string) error {
+	base := os.Getenv("XDG_DATA_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		base = filepath.Join(home, ".local", "share")
+	}
+	dir := filepath.Join(base, "applications")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	entry := fmt.Sprintf(`[Desktop Entry]
+Type=Application
+Name=OData Navigator
+Exec=%q -config %q %%u
+Terminal=true
+NoDisplay=true
+MimeType=x-scheme-handler/%s;
+`, executable, config, linkScheme)
+	path := filepath.Join(dir, "odatanavigator.desktop")
+	if err := os.WriteFile(path, []byte(entry), 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s\n", path)
+
+	if out, err := exec.Command("xdg-mime", "default", "odatanavigator.desktop", "x-scheme-handler/"+linkScheme).CombinedOutput(); err != nil {
+		return fmt.Errorf("xdg-mime could not make it the %s:// handler: %v %s", linkScheme, err, out)
+	}
+	fmt.Printf("%s:// links now open in odatanavigator\n", linkScheme)
+	return nil
+}
+
+// registerWindowsProtocol registers odata:// as a URL protocol of the
+// current user
+func registerWindowsProtocol(executable, config string) error {
+	key := `HKCU\Software\Classes\` + linkScheme
+	command := fmt.Sprintf(`"%s" -config "%s" "%%1"`, executable, config)
+	for _, args := range [][]string{
+		{"add", key, "/ve", "/d", "URL:OData Navigator link", "/f"},
+		{"add", key, "/v", "URL Protocol", "/d", "", "/f"},
+		{"add", key + `\shell\open\command`, "/ve", "/d", command, "/f"},
+	} {
+		if out, err := exec.Command("reg", args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("reg %v: %v %s", args, err, out)
+		}
+	}
+	fmt.Printf("%s:// links now open in odatanavigator\n", linkScheme)
+	return nil
+}
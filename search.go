@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// searchOptions turns the Search term of a query into what the service
+// understands: $search on V4, SAP's search= on V2 entity sets declared
+// sap:searchable, and otherwise a substringof over the string properties
+// of the entity type, and-ed to the $filter
+func (o *ODataService) searchOptions(entitySet string, opts QueryOptions) QueryOptions {
+	if opts.Search == "" {
+		return opts
+	}
+	term := opts.Search
+	opts.Search = ""
+
+	version := o.Version()
+	custom := make(map[string]string, len(opts.Custom)+1)
+	for name, value := range opts.Custom {
+		custom[name] = value
+	}
+	switch {
+	case strings.HasPrefix(version, "4"):
+		custom["$search"] = term
+	case o.metadata().Capabilities(entitySet).Searchable:
+		custom["search"] = term
+	default:
+		if filter := substringSearch(o.metadata().EntityTypeOf(entitySet), term, version); filter != "" {
+			if opts.Filter != "" {
+				filter = "(" + opts.Filter + ") and " + filter
+			}
+			opts.Filter = filter
+			return opts
+		}
+		// Without metadata there are no properties to match; SAP's option is the best guess
+		custom["search"] = term
+	}
+	opts.Custom = custom
+	return opts
+}
+
+// substringSearch matches term in any Edm.String property of the entity type
+func substringSearch(et *EntityType, term, version string) string {
+	if et == nil {
+		return ""
+	}
+	var conditions []string
+	for _, p := range et.Properties {
+		if p.Type == "Edm.String" {
+			conditions = append(conditions, buildFilterExpression(p.Name, "contains", p.Type, term, version))
+		}
+	}
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(conditions, " or ") + ")"
+}
+
+// searchPrompt is the state of the "S" overlay asking for a search term
+type searchPrompt struct {
+	active bool
+	column int // Index of the entities column searched
+	term   string
+}
+
+// openSearchPrompt asks for a search term for the active entities column,
+// starting from its current one
+func (m model) openSearchPrompt() model {
+	if m.activeColumn >= len(m.columns) || !m.columns[m.activeColumn].isEntityList() {
+		m.logs = append(m.logs, "Search is only available on an entity list")
+		return m
+	}
+	col := m.columns[m.activeColumn]
+	m.searchDialog = searchPrompt{active: true, column: m.activeColumn, term: col.query.Search}
+	return m
+}
+
+// updateSearchPrompt handles key presses while the search prompt is open
+func (m model) updateSearchPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	sp := &m.searchDialog
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "f1":
+		return m.openHelp(helpPageCheatSheet), nil
+	case "esc":
+		sp.active = false
+	case "enter":
+		return m.applySearch(strings.TrimSpace(sp.term))
+	case "backspace":
+		if len(sp.term) > 0 {
+			runes := []rune(sp.term)
+			sp.term = string(runes[:len(runes)-1])
+		}
+	default:
+		sp.term += typedText(msg)
+	}
+	return m, nil
+}
+
+// applySearch sets the search term of the searched column and reloads it
+// with the matching entities; an empty term clears the search
+func (m model) applySearch(term string) (tea.Model, tea.Cmd) {
+	sp := m.searchDialog
+	m.searchDialog.active = false
+	if sp.column >= len(m.columns) {
+		return m, nil
+	}
+
+	term, missing := expandVariables(term, m.variables)
+	if len(missing) > 0 {
+		m.logs = append(m.logs, fmt.Sprintf("Unknown variables: {{%s}}", strings.Join(missing, "}}, {{")))
+		return m, nil
+	}
+
+	col := &m.columns[sp.column]
+	col.query.Search = term
+	col.items = []string{"Loading..."}
+	col.state = stateLoading
+	col.entities = nil
+	col.cursor = 0
+	col.scrollOffset = 0
+
+	if term == "" {
+		m.logs = append(m.logs, fmt.Sprintf("Cleared search on %s", col.entitySet))
+	} else {
+		m.logs = append(m.logs, fmt.Sprintf("Searching %s for %q", col.entitySet, term))
+	}
+	m.loading = true
+	return m, loadEntities(m.odata, *col)
+}
+
+// renderSearchPrompt renders the search prompt box
+func (m model) renderSearchPrompt() string {
+	sp := m.searchDialog
+	col := m.columns[sp.column]
+
+	how := "$search"
+	switch {
+	case strings.HasPrefix(m.odataVersion(), "4"):
+	case m.metadata().Capabilities(col.entitySet).Searchable:
+		how = "SAP search="
+	case substringSearch(m.metadata().EntityTypeOf(col.entitySet), "x", "2.0") != "":
+		how = "substringof on the string properties"
+	default:
+		how = "search="
+	}
+	lines := []string{
+		"Search term (empty clears the search):",
+		lipgloss.NewStyle().Background(theme.Panel).Render("> " + sp.term + "█"),
+		"",
+		lipgloss.NewStyle().Foreground(theme.Muted).Render("Sent as " + how),
+		"",
+		lipgloss.NewStyle().Foreground(theme.Muted).Render("Enter: Search | ESC: Cancel"),
+	}
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Background(theme.Accent).
+		Foreground(theme.AccentText).
+		Padding(0, 1).
+		Render("Search " + col.entitySet)
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(theme.Accent).
+		Padding(0, 1).
+		Width(min(60, m.width-4)).
+		Render(title + "\n\n" + strings.Join(lines, "\n"))
+}
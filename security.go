@@ -0,0 +1,146 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// plainHTTP reports whether the service is reached without TLS over the
+// network; services on the local machine don't count
+func (svc ServiceConfig) plainHTTP() bool {
+	return insecureURL(svc.URL)
+}
+
+// insecureURL reports whether a service URL is plain HTTP to another host
+func insecureURL(serviceURL string) bool {
+	u, err := url.Parse(serviceURL)
+	if err != nil || !strings.EqualFold(u.Scheme, "http") {
+		return false
+	}
+	switch u.Hostname() {
+	case "localhost", "127.0.0.1", "::1":
+		return false
+	}
+	return true
+}
+
+// hasCredentials reports whether requests to the service carry credentials
+func (svc ServiceConfig) hasCredentials() bool {
+	return svc.Username != "" || svc.Password != "" || svc.Auth != nil
+}
+
+// connectionProblems records transport security problems seen while talking
+// to a service: certificates that failed verification and plain HTTP
+// requests made by an HTTPS service (mixed content)
+type connectionProblems struct {
+	mu          sync.Mutex
+	certificate string
+	mixed       string
+}
+
+func (p *connectionProblems) set(field *string, problem string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if *field == "" {
+		*field = problem
+	}
+}
+
+// securityCheckTransport notes certificate errors and mixed content in the
+// connection problems of its service
+type securityCheckTransport struct {
+	base     http.RoundTripper
+	https    bool // The service URL uses https
+	problems *connectionProblems
+}
+
+func (t *securityCheckTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.https && req.URL.Scheme == "http" {
+		t.problems.set(&t.problems.mixed, fmt.Sprintf("%s was requested over plain HTTP", req.URL.Host))
+	}
+	resp, err := t.base.RoundTrip(req)
+	if problem := certificateProblem(err); problem != "" {
+		t.problems.set(&t.problems.certificate, problem)
+	}
+	return resp, err
+}
+
+// certificateProblem describes a TLS certificate verification error, or
+// returns "" when err is something else
+func certificateProblem(err error) string {
+	var unknownAuthority x509.UnknownAuthorityError
+	var invalid x509.CertificateInvalidError
+	var hostname x509.HostnameError
+	var verification *tls.CertificateVerificationError
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &unknownAuthority):
+		return "certificate signed by an unknown authority"
+	case errors.As(err, &invalid):
+		return "invalid certificate: " + invalid.Error()
+	case errors.As(err, &hostname):
+		return "certificate does not match host " + hostname.Host
+	case errors.As(err, &verification):
+		return verification.Err.Error()
+	}
+	return ""
+}
+
+// SecurityWarnings lists the transport security problems seen so far
+func (o *ODataService) SecurityWarnings() []string {
+	var warnings []string
+	if insecureURL(o.baseURL) {
+		u, _ := url.Parse(o.baseURL)
+		warning := fmt.Sprintf("Plain HTTP: traffic to %s is not encrypted", u.Host)
+		if o.sendsCredentials {
+			warning += " and credentials are sent in cleartext"
+		}
+		warnings = append(warnings, warning)
+	}
+	if o.problems != nil {
+		o.problems.mu.Lock()
+		defer o.problems.mu.Unlock()
+		if o.problems.certificate != "" {
+			warnings = append(warnings, "Certificate problem: "+o.problems.certificate)
+		}
+		if o.problems.mixed != "" {
+			warnings = append(warnings, "Mixed content: "+o.problems.mixed)
+		}
+	}
+	return warnings
+}
+
+// needsInsecureConfirmation reports whether connecting would send the
+// credentials of svc in cleartext without the user having agreed to it,
+// either with accept_insecure in the config or earlier in the session
+func (m model) needsInsecureConfirmation(svc ServiceConfig) bool {
+	return svc.plainHTTP() && svc.hasCredentials() && !svc.AcceptInsecure && !m.insecureAccepted[svc.Name]
+}
+
+// renderSecurityBanner renders the warning line shown above the columns while
+// connected to a service with security problems, or "" when there are none
+func (m model) renderSecurityBanner() string {
+	if m.odata == nil {
+		return ""
+	}
+	warnings := m.odata.SecurityWarnings()
+	if len(warnings) == 0 {
+		return ""
+	}
+	return lipgloss.NewStyle().
+		Bold(true).
+		Background(theme.Error).
+		Foreground(theme.Text).
+		Width(m.width).
+		MaxHeight(1).
+		Render("⚠ " + strings.Join(warnings, " | "))
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// bulkWriteMsg carries the outcome of a delete or patch applied to several entities
+type bulkWriteMsg struct {
+	operation string // "delete" or "update"
+	entitySet string
+	keys      []string       // Key predicate of each entity, in request order
+	requests  []BatchRequest // Requests sent, for retrying the failed ones
+	results   []*OperationResult
+	batched   bool // Sent as a single $batch request
+	err       error
+}
+
+// isEntityList reports whether a column lists the entities of an entity set
+func (col column) isEntityList() bool {
+	return col.entitySet != "" && !col.isDetails && !col.isPreview && !col.isResult && len(col.entities) > 0
+}
+
+// selectedIndexes returns the marked entities of a column in list order
+func (col column) selectedIndexes() []int {
+	indexes := make([]int, 0, len(col.selected))
+	for i := range col.selected {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+// toggleSelection marks or unmarks the entity under the cursor and moves
+// the cursor down, so a run of entities can be marked by holding space
+func (m model) toggleSelection() model {
+	if m.activeColumn >= len(m.columns) {
+		return m
+	}
+	col := &m.columns[m.activeColumn]
+	if !col.isEntityList() || col.cursor >= len(col.entities) {
+		return m
+	}
+
+	if col.selected[col.cursor] {
+		delete(col.selected, col.cursor)
+	} else {
+		if col.selected == nil {
+			col.selected = make(map[int]bool)
+		}
+		col.selected[col.cursor] = true
+	}
+	if col.cursor < len(col.entities)-1 {
+		col.cursor++
+		visibleHeight := col.height - 2
+		if col.cursor >= col.scrollOffset+visibleHeight {
+			col.scrollOffset = col.cursor - visibleHeight + 1
+		}
+	}
+	return m
+}
+
+// deleteTargets returns the entity set and the entities F8 acts on: the
+// marked entities of a list, else the one under its cursor, or the entity
+// shown in a Details column
+func (m model) deleteTargets() (string, []map[string]interface{}) {
+	if m.activeColumn >= len(m.columns) {
+		return "", nil
+	}
+	col := m.columns[m.activeColumn]
+	switch {
+	case col.isEntityList():
+		if len(col.selected) == 0 {
+			if col.cursor >= len(col.entities) {
+				return "", nil
+			}
+			return col.entitySet, col.entities[col.cursor : col.cursor+1]
+		}
+		var entities []map[string]interface{}
+		for _, i := range col.selectedIndexes() {
+			entities = append(entities, col.entities[i])
+		}
+		return col.entitySet, entities
+	case col.isDetails && !col.isResult && len(col.entities) > 0 && m.activeColumn > 0:
+		return m.detailsEntitySet(m.activeColumn), col.entities[:1]
+	}
+	return "", nil
+}
+
+// deleteEntities asks for confirmation on the first F8 and deletes the
+// targets on the second one
+func (m model) deleteEntities() (tea.Model, tea.Cmd) {
+	entitySet, entities := m.deleteTargets()
+	if entitySet == "" || len(entities) == 0 {
+		m.logs = append(m.logs, "Nothing to delete - select entities with space or open an entity")
+		return m, nil
+	}
+	unversioned := 0
+	for _, entity := range entities {
+		if entityETag(entity) == "" {
+			unversioned++
+		}
+	}
+	if !m.confirmDelete {
+		m.confirmDelete = true
+		m.logs = append(m.logs, fmt.Sprintf("Delete %d entities from %s? Press F8 again to confirm, any other key to cancel", len(entities), entitySet))
+		if unversioned > 0 {
+			m.logs = append(m.logs, fmt.Sprintf("WARNING: %d of them were read without an ETag and are deleted whatever their version on the server", unversioned))
+		}
+		return m, nil
+	}
+	m.confirmDelete = false
+
+	requests, keys, ok := m.bulkRequests("DELETE", entitySet, entities, nil)
+	if !ok {
+		return m, nil
+	}
+	// Services protecting entities with ETags refuse deletes without If-Match
+	// (428); the confirmation covers those read without one
+	for i := range requests {
+		if requests[i].IfMatch == "" {
+			requests[i].IfMatch = "*"
+		}
+	}
+	if m.staging {
+		m.stage(requests, entities)
+		return m, nil
+	}
+	m.logs = append(m.logs, fmt.Sprintf("Deleting %d entities from %s...", len(entities), entitySet))
+	return m.sendBulkWrite("delete", entitySet, requests, keys)
+}
+
+// saveModalBulkUpdate applies the properties of the patch template in the
+// modal editor to every marked entity
+func (m model) saveModalBulkUpdate(changes map[string]interface{}) (tea.Model, tea.Cmd) {
+	col := m.columns[m.activeColumn]
+	if !col.isEntityList() || len(col.selected) == 0 {
+		m.logs = append(m.logs, "No selected entities to update")
+		return m, nil
+	}
+	if len(changes) == 0 {
+		m.logs = append(m.logs, "Patch template is empty - nothing to update")
+		return m, nil
+	}
+
+	var entities []map[string]interface{}
+	for _, i := range col.selectedIndexes() {
+		entities = append(entities, col.entities[i])
+	}
+	changes = m.convertForService(col.entitySet, changes)
+	requests, keys, ok := m.bulkRequests(m.odata.patchMethod(), col.entitySet, entities, changes)
+	if !ok {
+		return m, nil
+	}
+	if m.staging {
+		m.closeModalEditor()
+		m.stage(requests, entities)
+		return m, nil
+	}
+	m.logs = append(m.logs, fmt.Sprintf("Updating %d entities in %s...", len(entities), col.entitySet))
+	return m.sendBulkWrite("update", col.entitySet, requests, keys)
+}
+
+// bulkRequests builds one request per entity, failing when a key can't be determined
+func (m *model) bulkRequests(method, entitySet string, entities []map[string]interface{}, body map[string]interface{}) ([]BatchRequest, []string, bool) {
+	requests := make([]BatchRequest, 0, len(entities))
+	keys := make([]string, 0, len(entities))
+	for _, entity := range entities {
+		key := extractEntityKey(m.metadata(), entitySet, entity)
+		if key == "" {
+			m.logs = append(m.logs, fmt.Sprintf("Cannot determine entity key of %s", formatEntityForDisplay(entity)))
+			return nil, nil, false
+		}
+		requests = append(requests, BatchRequest{Method: method, EntitySet: entitySet, Key: key, Body: body, IfMatch: entityETag(entity)})
+		keys = append(keys, key)
+	}
+	return requests, keys, true
+}
+
+// applyBulkWrite reflects a bulk delete or update in the entity lists and
+// shows the outcome of every request in the Result column
+func (m *model) applyBulkWrite(msg bulkWriteMsg) {
+	m.loading = false
+	if msg.results == nil {
+		m.logs = append(m.logs, fmt.Sprintf("ERROR [bulk %s %s]: %v", msg.operation, msg.entitySet, msg.err))
+		return
+	}
+
+	via := "one request per entity"
+	if msg.batched {
+		via = "$batch"
+	}
+	succeeded := make(map[string]int) // Request index by key
+	failed := make(map[string]bool)
+	for i, r := range msg.results {
+		if r.StatusCode >= 200 && r.StatusCode < 300 {
+			succeeded[msg.keys[i]] = i
+		} else {
+			failed[msg.keys[i]] = true
+			m.logs = append(m.logs, fmt.Sprintf("ERROR [%s %s(%s)]: %s", msg.operation, msg.entitySet, msg.keys[i], r.Status))
+		}
+	}
+	m.logs = append(m.logs, fmt.Sprintf("Bulk %s via %s: %d of %d succeeded", msg.operation, via, len(succeeded), len(msg.results)))
+
+	for i := range m.columns {
+		col := &m.columns[i]
+		if col.entitySet != msg.entitySet || !col.isEntityList() {
+			continue
+		}
+		// Entities that failed stay marked so the operation can be retried
+		var entities []map[string]interface{}
+		col.selected = nil
+		for _, entity := range col.entities {
+			key := extractEntityKey(m.metadata(), col.entitySet, entity)
+			if failed[key] {
+				if col.selected == nil {
+					col.selected = make(map[int]bool)
+				}
+				col.selected[len(entities)] = true
+			}
+			n, done := succeeded[key]
+			if done && msg.operation == "delete" {
+				continue
+			}
+			if done && msg.operation == "update" {
+				for k, v := range msg.requests[n].Body {
+					entity[k] = v
+				}
+			}
+			entities = append(entities, entity)
+		}
+		col.entities = entities
+		col.items = nil
+		for _, entity := range entities {
+			col.items = append(col.items, formatEntityForDisplay(entity))
+		}
+		if col.hasMore {
+			col.items = append(col.items, col.moreItem())
+		}
+		if len(col.items) == 0 {
+			col.items = []string{"(No items)"}
+		}
+		if col.cursor >= len(col.items) {
+			col.cursor = len(col.items) - 1
+		}
+	}
+
+	// Deleted entities can't stay open in a Details column
+	if msg.operation == "delete" && m.activeColumn < len(m.columns) && m.columns[m.activeColumn].isDetails {
+		if entity := m.columns[m.activeColumn].entities; len(entity) > 0 {
+			if _, deleted := succeeded[extractEntityKey(m.metadata(), msg.entitySet, entity[0])]; deleted {
+				*m = m.goBack()
+			}
+		}
+	}
+	m.openBatchReport(&batchReport{operation: msg.operation, entitySet: msg.entitySet, requests: msg.requests, keys: msg.keys, results: msg.results}, via)
+}
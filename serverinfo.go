@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ServerInfo is what a service tells about itself in the headers of its
+// $metadata or service document response
+type ServerInfo struct {
+	Version     string // DataServiceVersion (V2/V3) or OData-Version (V4)
+	MaxVersion  string // MaxDataServiceVersion or OData-MaxVersion
+	Product     string // Server software, from Server, X-Powered-By and SAP headers
+	ContentType string // Media type of the response
+}
+
+// serverInfoFromHeaders reads the protocol version and server product from
+// response headers
+func serverInfoFromHeaders(header http.Header) *ServerInfo {
+	info := &ServerInfo{
+		Version:    firstHeader(header, "OData-Version", "DataServiceVersion"),
+		MaxVersion: firstHeader(header, "OData-MaxVersion", "MaxDataServiceVersion"),
+	}
+	// V2 servers append the client library, as in "2.0;"
+	info.Version = strings.TrimRight(strings.SplitN(info.Version, ";", 2)[0], " ")
+	info.MaxVersion = strings.TrimRight(strings.SplitN(info.MaxVersion, ";", 2)[0], " ")
+	info.ContentType, _, _ = mime.ParseMediaType(header.Get("Content-Type"))
+
+	var product []string
+	for _, name := range []string{"Server", "X-Powered-By"} {
+		if value := header.Get(name); value != "" {
+			product = append(product, value)
+		}
+	}
+	if len(product) == 0 && header.Get("sap-server") != "" {
+		product = append(product, "SAP Gateway")
+	}
+	info.Product = strings.Join(product, ", ")
+	return info
+}
+
+func firstHeader(header http.Header, names ...string) string {
+	for _, name := range names {
+		if value := header.Get(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// ServiceSummary describes the OData version, formats and server product of
+// the service for the service preview
+func (o *ODataService) ServiceSummary() []string {
+	version := o.Version()
+	source := "assumed"
+	info := o.serverInfo()
+	switch {
+	case o.metadata() != nil:
+		source = "from $metadata"
+	case info != nil && info.Version != "":
+		version = info.Version
+		source = "from response headers"
+	}
+	lines := []string{fmt.Sprintf("OData version: %s (%s)", version, source)}
+
+	if info == nil {
+		info = &ServerInfo{}
+	}
+	if info.Version != "" {
+		header := "DataServiceVersion"
+		if strings.HasPrefix(info.Version, "4") {
+			header = "OData-Version"
+		}
+		if info.MaxVersion != "" {
+			lines = append(lines, fmt.Sprintf("%s: %s (max %s)", header, info.Version, info.MaxVersion))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s: %s", header, info.Version))
+		}
+	}
+	lines = append(lines, "Formats: "+supportedFormats(version))
+	if info.Product != "" {
+		lines = append(lines, "Server: "+info.Product)
+	}
+	if problem := o.MetadataProblem(); problem != "" {
+		lines = append(lines, "$metadata: unavailable ("+problem+")")
+	}
+	return lines
+}
+
+// supportedFormats names the payload formats a service of an OData version offers
+func supportedFormats(version string) string {
+	switch {
+	case strings.HasPrefix(version, "4"):
+		return "JSON"
+	case strings.HasPrefix(version, "3"):
+		return "JSON (light and verbose), Atom/XML"
+	}
+	return "Atom/XML, JSON (verbose, via $format=json)"
+}
@@ -0,0 +1,84 @@
+package main
+
+import "sync"
+
+// serviceState is what the requests to a service learn about it while the
+// session runs. It is shared by the service and the views made from it for
+// columns and operations, and guarded by mu since the requests run in the
+// background while the UI reads it.
+type serviceState struct {
+	mu       sync.Mutex
+	metadata *Metadata // Parsed $metadata, nil until GetEntitySets succeeds
+	// Why $metadata could not be used (e.g. "HTTP 403"); metadata-dependent
+	// features then fall back to what can be inferred from the data
+	metadataProblem  string
+	serverInfo       *ServerInfo // Version and product headers seen when loading entity sets
+	batchUnsupported bool        // $batch was rejected, so bulk writes go one by one
+	countUnsupported bool        // $inlinecount/$count was rejected, so entity lists go uncounted
+}
+
+// metadata returns the parsed $metadata of the service, nil until it was read
+func (o *ODataService) metadata() *Metadata {
+	o.state.mu.Lock()
+	defer o.state.mu.Unlock()
+	return o.state.metadata
+}
+
+// setMetadata records the $metadata read, or with md nil why it couldn't be
+func (o *ODataService) setMetadata(md *Metadata, problem string) {
+	o.state.mu.Lock()
+	defer o.state.mu.Unlock()
+	if md != nil {
+		o.state.metadata = md
+	}
+	o.state.metadataProblem = problem
+}
+
+// MetadataProblem returns why the service's $metadata is unavailable, or ""
+func (o *ODataService) MetadataProblem() string {
+	o.state.mu.Lock()
+	defer o.state.mu.Unlock()
+	return o.state.metadataProblem
+}
+
+// serverInfo returns the version and product headers seen last, nil before
+// the first response
+func (o *ODataService) serverInfo() *ServerInfo {
+	o.state.mu.Lock()
+	defer o.state.mu.Unlock()
+	return o.state.serverInfo
+}
+
+func (o *ODataService) setServerInfo(info *ServerInfo) {
+	o.state.mu.Lock()
+	defer o.state.mu.Unlock()
+	o.state.serverInfo = info
+}
+
+// batchUnsupported reports whether the service rejected $batch
+func (o *ODataService) batchUnsupported() bool {
+	o.state.mu.Lock()
+	defer o.state.mu.Unlock()
+	return o.state.batchUnsupported
+}
+
+// markBatchUnsupported sends the bulk writes one by one from now on
+func (o *ODataService) markBatchUnsupported() {
+	o.state.mu.Lock()
+	defer o.state.mu.Unlock()
+	o.state.batchUnsupported = true
+}
+
+// countUnsupported reports whether the service rejected counting
+func (o *ODataService) countUnsupported() bool {
+	o.state.mu.Lock()
+	defer o.state.mu.Unlock()
+	return o.state.countUnsupported
+}
+
+// markCountUnsupported reads entity lists without a count from now on
+func (o *ODataService) markCountUnsupported() {
+	o.state.mu.Lock()
+	defer o.state.mu.Unlock()
+	o.state.countUnsupported = true
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// placeholderText is what a required property of a new entity starts
+// with, converted to the JSON of its type like an imported value
+func placeholderText(edmType string) string {
+	switch edmType {
+	case "Edm.Boolean":
+		return "false"
+	case "Edm.Byte", "Edm.SByte", "Edm.Int16", "Edm.Int32", "Edm.Int64", "Edm.Decimal", "Edm.Double", "Edm.Single":
+		return "0"
+	case "Edm.Date", "Edm.DateTime", "Edm.DateTimeOffset":
+		return time.Now().Format("2006-01-02")
+	case "Edm.Guid":
+		return "00000000-0000-0000-0000-000000000000"
+	case "Edm.Time", "Edm.Duration":
+		return "PT0S"
+	case "Edm.TimeOfDay":
+		return "00:00:00"
+	}
+	return ""
+}
+
+// createSkeleton returns the JSON a new entity of the entity set starts
+// from: every property the service doesn't set itself, in the order of the
+// metadata. Required properties hold a placeholder of their type, the
+// others null, which a create leaves to the service. It also returns the
+// required properties, and nil lines without metadata.
+func (m model) createSkeleton(entitySet string) (lines, required []string) {
+	et := m.metadata().EntityTypeOf(entitySet)
+	if et == nil {
+		return nil, nil
+	}
+	var members []string
+	for _, p := range et.Properties {
+		// Complex properties are left to be typed as JSON
+		if p.Computed || !strings.HasPrefix(p.Type, "Edm.") {
+			continue
+		}
+		var value interface{}
+		if !p.Nullable {
+			value, _ = importValue(placeholderText(p.Type), p, m.odata.Version())
+			required = append(required, p.Name)
+		}
+		data, _ := json.Marshal(value)
+		members = append(members, fmt.Sprintf("  %q: %s", p.Name, data))
+	}
+	if len(members) == 0 {
+		return nil, nil
+	}
+	lines = append(lines, "{")
+	for i, member := range members {
+		if i < len(members)-1 {
+			member += ","
+		}
+		lines = append(lines, member)
+	}
+	return append(lines, "}"), required
+}
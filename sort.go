@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const sortClearEntry = "(clear sort)"
+
+var sortDirections = []string{"asc", "desc"}
+
+// sortPicker is the state of the $orderby picker overlay
+type sortPicker struct {
+	active     bool
+	step       int // 0: pick property, 1: pick direction
+	column     int // Index of the entities column being sorted
+	properties []string
+	cursor     int
+	property   string
+}
+
+// openSortDialog opens the $orderby picker for the active entities column
+func (m model) openSortDialog() model {
+	if m.activeColumn >= len(m.columns) || m.columns[m.activeColumn].entitySet == "" || m.columns[m.activeColumn].isDetails {
+		m.logs = append(m.logs, "Sort is only available on an entity list")
+		return m
+	}
+	col := m.columns[m.activeColumn]
+
+	sp := sortPicker{active: true, column: m.activeColumn}
+	sp.properties, _ = m.columnProperties(col)
+	if col.query.OrderBy != "" {
+		sp.properties = append([]string{sortClearEntry}, sp.properties...)
+	}
+	m.sortDialog = sp
+	return m
+}
+
+// updateSortDialog handles key presses while the sort picker is open
+func (m model) updateSortDialog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	sp := &m.sortDialog
+
+	choices := sp.properties
+	if sp.step == 1 {
+		choices = sortDirections
+	}
+
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "f1":
+		return m.openHelp(helpPageCheatSheet), nil
+	case "esc":
+		if sp.step == 0 {
+			sp.active = false
+		} else {
+			sp.step = 0
+		}
+		sp.cursor = 0
+	case "up", "k":
+		if sp.cursor > 0 {
+			sp.cursor--
+		}
+	case "down", "j":
+		if sp.cursor < len(choices)-1 {
+			sp.cursor++
+		}
+	case "enter":
+		if len(choices) == 0 {
+			return m, nil
+		}
+		if sp.step == 0 {
+			sp.property = choices[sp.cursor]
+			sp.cursor = 0
+			if sp.property == sortClearEntry {
+				return m.applySort("")
+			}
+			sp.step = 1
+			return m, nil
+		}
+		orderBy := sp.property
+		if choices[sp.cursor] == "desc" {
+			orderBy += " desc"
+		}
+		return m.applySort(orderBy)
+	}
+	return m, nil
+}
+
+// applySort sets the $orderby of the sorted column and reloads it
+func (m model) applySort(orderBy string) (tea.Model, tea.Cmd) {
+	sp := m.sortDialog
+	m.sortDialog.active = false
+	if sp.column >= len(m.columns) {
+		return m, nil
+	}
+
+	col := &m.columns[sp.column]
+	col.query.OrderBy = orderBy
+	col.items = []string{"Loading..."}
+	col.state = stateLoading
+	col.entities = nil
+	col.cursor = 0
+	col.scrollOffset = 0
+
+	if orderBy == "" {
+		m.logs = append(m.logs, fmt.Sprintf("Cleared sort order on %s", col.entitySet))
+	} else {
+		m.logs = append(m.logs, fmt.Sprintf("Sorting %s: $orderby=%s", col.entitySet, orderBy))
+	}
+	m.loading = true
+	return m, loadEntities(m.odata, *col)
+}
+
+// renderSortDialog renders the sort picker box
+func (m model) renderSortDialog() string {
+	sp := m.sortDialog
+	col := m.columns[sp.column]
+
+	var lines []string
+	if sp.step == 0 {
+		lines = append(lines, "Sort by property:")
+		lines = append(lines, renderChoiceList(sp.properties, sp.cursor, m.height/2)...)
+	} else {
+		lines = append(lines, fmt.Sprintf("Property: %s", sp.property), "Direction:")
+		lines = append(lines, renderChoiceList(sortDirections, sp.cursor, len(sortDirections))...)
+	}
+	lines = append(lines, "", lipgloss.NewStyle().Foreground(theme.Muted).Render("Enter: Select | ESC: Back"))
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Background(theme.Accent).
+		Foreground(theme.AccentText).
+		Padding(0, 1).
+		Render("Sort " + col.entitySet)
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(theme.Accent).
+		Padding(0, 1).
+		Width(min(50, m.width-4)).
+		Render(title + "\n\n" + strings.Join(lines, "\n"))
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// spinnerFrames animate the title of a column waiting for its response
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// spinnerInterval is how often the spinner, and the elapsed time of the
+// loading columns, are redrawn
+const spinnerInterval = 100 * time.Millisecond
+
+// spinnerTickMsg moves the spinner on to its next frame
+type spinnerTickMsg struct{}
+
+func spinnerTick() tea.Cmd {
+	return tea.Tick(spinnerInterval, func(time.Time) tea.Msg { return spinnerTickMsg{} })
+}
+
+// spinner is the frame of the spinner to draw
+func (m model) spinner() string {
+	return spinnerFrames[m.spinnerFrame%len(spinnerFrames)]
+}
+
+// loading reports whether a column waits for the response of a load
+func (c column) loading() bool {
+	return c.state == stateLoading || c.isLoadingPlaceholder()
+}
+
+// loadingColumns counts the columns waiting for a response. Each column
+// loads on its own, so the others can be browsed meanwhile.
+func (m model) loadingColumns() int {
+	n := 0
+	for _, col := range m.columns {
+		if col.loading() {
+			n++
+		}
+	}
+	return n
+}
+
+// busy reports whether requests that x cancels are in flight: the loads of
+// columns, or a write, import or export
+func (m model) busy() bool {
+	return m.loading || m.progress != nil || m.loadingColumns() > 0
+}
+
+// escCancels reports whether Esc cancels the requests in flight rather
+// than going back: when the active column is loading, or a load that is not
+// a column's is running
+func (m model) escCancels() bool {
+	if m.activeColumn < len(m.columns) && m.columns[m.activeColumn].loading() {
+		return true
+	}
+	return (m.loading || m.progress != nil) && m.loadingColumns() == 0
+}
+
+// trackLoading stamps the columns that started loading with the time, for
+// the elapsed time they show, and starts the spinner when something loads
+func (m *model) trackLoading() tea.Cmd {
+	for i := range m.columns {
+		col := &m.columns[i]
+		switch {
+		case !col.loading():
+			col.loadStarted = time.Time{}
+		case col.loadStarted.IsZero():
+			col.loadStarted = time.Now()
+		}
+	}
+	if m.spinning || !(m.busy() || m.preview.loading) {
+		return nil
+	}
+	m.spinning = true
+	return spinnerTick()
+}
+
+// updateSpinner advances the spinner, which stops once nothing loads
+func (m model) updateSpinner() (tea.Model, tea.Cmd) {
+	m.spinnerFrame++
+	if !m.busy() && !m.preview.loading {
+		m.spinning = false
+		return m, nil
+	}
+	return m, spinnerTick()
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// stagedChange is a create, update or delete kept back while staging is on,
+// to be reviewed in the Pending changes panel and submitted with the others
+type stagedChange struct {
+	id      int // Tells the change apart while a submit is in flight
+	request BatchRequest
+	before  map[string]interface{} // Entity as loaded, for the diff of an update or delete
+	err     string                 // Why the last submit of the change failed
+}
+
+// target is the entity set a create posts to, or the entity changed
+func (c stagedChange) target() string {
+	if c.request.Key == "" {
+		return c.request.EntitySet
+	}
+	return fmt.Sprintf("%s(%s)", c.request.EntitySet, c.request.Key)
+}
+
+// label names the change in the log
+func (c stagedChange) label() string {
+	return c.request.operation() + " " + c.target()
+}
+
+// diff lists what the change does to the entity: the properties a create
+// sets or an update changes, or the entity a delete removes
+func (c stagedChange) diff() []string {
+	if c.request.Method == "DELETE" {
+		return []string{"- " + formatEntityForDisplay(c.before)}
+	}
+	lines := entityDiff(c.before, c.request.Body, c.request.Method == "PUT")
+	if len(lines) == 0 {
+		lines = append(lines, "  (no properties changed)")
+	}
+	return lines
+}
+
+// pendingPanel lists the staged changes with the diff of the one under the
+// cursor
+type pendingPanel struct {
+	active bool
+	cursor int
+}
+
+// stagedSubmitMsg carries the outcome of submitting the staged changes
+type stagedSubmitMsg struct {
+	changes []stagedChange
+	results []*OperationResult
+	batched bool // Sent as a single $batch request
+	atomic  bool // All in one changeset, which succeeds or fails as a whole
+	err     error
+}
+
+// toggleStaging turns staging on, which queues writes instead of sending
+// them, or off; changes already staged stay pending
+func (m model) toggleStaging() (tea.Model, tea.Cmd) {
+	m.staging = !m.staging
+	if m.staging {
+		m.logs = append(m.logs, "Staging on - creates, updates and deletes wait in the pending changes (c) until submitted")
+	} else {
+		m.logs = append(m.logs, fmt.Sprintf("Staging off - writes are sent right away; %d changes still pending", len(m.staged)))
+	}
+	return m, nil
+}
+
+// stage queues write requests; befores holds the entity each one changes,
+// nil for creates
+func (m *model) stage(requests []BatchRequest, befores []map[string]interface{}) {
+	for i, r := range requests {
+		m.stagedSeq++
+		change := stagedChange{id: m.stagedSeq, request: r}
+		if i < len(befores) {
+			change.before = befores[i]
+		}
+		m.staged = append(m.staged, change)
+		m.logs = append(m.logs, "Staged "+change.label())
+	}
+	m.logs = append(m.logs, fmt.Sprintf("%d changes pending - c reviews and submits them", len(m.staged)))
+}
+
+// stagingStatus is shown in the footer while staging is on or changes wait
+func (m model) stagingStatus() string {
+	status := fmt.Sprintf("%d PENDING (c:Review)", len(m.staged))
+	if m.staging {
+		status = "STAGING - " + status
+	}
+	return status
+}
+
+// openPendingPanel shows the staged changes
+func (m model) openPendingPanel() (tea.Model, tea.Cmd) {
+	if len(m.staged) == 0 {
+		m.logs = append(m.logs, "No pending changes - C turns staging on")
+		return m, nil
+	}
+	m.pending = pendingPanel{active: true, cursor: min(m.pending.cursor, len(m.staged)-1)}
+	return m, nil
+}
+
+// updatePendingPanel handles key presses while the pending changes are shown
+func (m model) updatePendingPanel(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := &m.pending
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "c":
+		p.active = false
+	case "up", "k":
+		p.cursor = max(p.cursor-1, 0)
+	case "down", "j":
+		p.cursor = min(p.cursor+1, len(m.staged)-1)
+	case "d", "delete":
+		change := m.staged[p.cursor]
+		m.staged = append(m.staged[:p.cursor:p.cursor], m.staged[p.cursor+1:]...)
+		m.logs = append(m.logs, "Discarded "+change.label())
+		if len(m.staged) == 0 {
+			p.active = false
+			return m, nil
+		}
+		p.cursor = min(p.cursor, len(m.staged)-1)
+	case "D":
+		m.logs = append(m.logs, fmt.Sprintf("Discarded %d pending changes", len(m.staged)))
+		m.staged = nil
+		p.active = false
+	case "enter", "s":
+		return m.submitStaged(false)
+	case "a":
+		return m.submitStaged(true)
+	}
+	return m, nil
+}
+
+// submitStaged sends the staged changes: each on its own, in one $batch
+// round trip where the service accepts it, or atomic as a single changeset
+// that succeeds or fails as a whole
+func (m model) submitStaged(atomic bool) (tea.Model, tea.Cmd) {
+	if m.busy() {
+		m.logs = append(m.logs, "Wait for the running requests, or cancel them with x, before submitting")
+		return m, nil
+	}
+	if atomic && m.odata.batchUnsupported() {
+		m.logs = append(m.logs, "The service does not accept $batch - submit the changes one by one with s")
+		return m, nil
+	}
+	changes := append([]stagedChange(nil), m.staged...)
+	requests := make([]BatchRequest, len(changes))
+	for i, c := range changes {
+		requests[i] = c.request
+	}
+	p := m.startProgress("Submitting pending changes", "changes", len(changes))
+	if p == nil {
+		return m, nil
+	}
+	m.pending.active = false
+	if atomic {
+		m.logs = append(m.logs, fmt.Sprintf("Submitting %d pending changes as one changeset...", len(changes)))
+	} else {
+		m.logs = append(m.logs, fmt.Sprintf("Submitting %d pending changes...", len(changes)))
+	}
+	return m, p.run(func() tea.Msg {
+		if atomic {
+			results, err := p.odata.ExecuteBatch(requests, true)
+			return stagedSubmitMsg{changes: changes, results: results, batched: true, atomic: true, err: err}
+		}
+		results, batched, err := p.odata.ExecuteBulk(requests, func(done int) { p.report(done, len(requests)) })
+		return stagedSubmitMsg{changes: changes, results: results, batched: batched, err: err}
+	})
+}
+
+// applyStagedSubmit drops the changes that succeeded from the pending ones
+// and keeps those that failed, with the reason, to be fixed or discarded.
+// The lists of the entity sets written to are read again.
+func (m *model) applyStagedSubmit(msg stagedSubmitMsg) tea.Cmd {
+	m.loading = false
+	if msg.results == nil {
+		m.logs = append(m.logs, fmt.Sprintf("ERROR [submit pending changes]: %v", msg.err))
+		return nil
+	}
+	written := make(map[string]bool)
+	via := "one request per change"
+	if msg.batched {
+		via = "$batch"
+	}
+	failed := make(map[int]string)
+	succeeded := 0
+	for i, r := range msg.results {
+		change := msg.changes[i]
+		if r.StatusCode >= 200 && r.StatusCode < 300 {
+			succeeded++
+			written[change.request.EntitySet] = true
+			continue
+		}
+		reason := r.Status
+		if message := errorMessage(r.Body); r.Body != "" && message != "" {
+			reason += " - " + strings.ReplaceAll(message, "\n", " ")
+		}
+		failed[change.id] = reason
+		m.logs = append(m.logs, fmt.Sprintf("ERROR [%s]: %s", change.label(), reason))
+	}
+
+	sent := make(map[int]bool)
+	for _, c := range msg.changes {
+		sent[c.id] = true
+	}
+	var pending []stagedChange
+	for _, c := range m.staged {
+		if reason, ok := failed[c.id]; ok {
+			c.err = reason
+		} else if sent[c.id] {
+			continue
+		}
+		pending = append(pending, c)
+	}
+	m.staged = pending
+	m.logs = append(m.logs, fmt.Sprintf("Submitted pending changes via %s: %d of %d succeeded", via, succeeded, len(msg.results)))
+	if len(failed) > 0 {
+		m.logs = append(m.logs, fmt.Sprintf("%d failed changes stay pending - c shows why", len(failed)))
+	}
+	var cmds []tea.Cmd
+	for entitySet := range written {
+		cmds = append(cmds, m.reloadAfterWrite(entitySet, ""))
+	}
+	return tea.Batch(cmds...)
+}
+
+// renderPendingPanel draws the staged changes and the diff of the one
+// under the cursor
+func (m model) renderPendingPanel() string {
+	hint := lipgloss.NewStyle().Foreground(theme.Muted)
+	width := min(100, m.width-4)
+
+	choices := make([]string, len(m.staged))
+	for i, c := range m.staged {
+		choices[i] = c.request.Method + " " + c.target()
+		if c.err != "" {
+			choices[i] = "✗ " + choices[i]
+		}
+	}
+	lines := renderChoiceList(choices, m.pending.cursor, max(m.height/3, 3))
+
+	change := m.staged[m.pending.cursor]
+	lines = append(lines, "", hint.Render("Changes of "+change.label()+":"))
+	if change.err != "" {
+		lines = append(lines, lipgloss.NewStyle().Foreground(theme.Error).Render(fitItem("Failed: "+change.err, width-4)))
+	}
+	diff := change.diff()
+	if limit := max(m.height/3, 3); len(diff) > limit {
+		diff = append(diff[:limit-1:limit-1], fmt.Sprintf("  ... %d more", len(diff)-limit+1))
+	}
+	lines = append(lines, renderDiff(diff, width-4)...)
+	lines = append(lines, "", hint.Render("Enter/s: Submit | a: Submit all or nothing ($batch) | d: Discard | D: Discard all | ESC: Close"))
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Background(theme.Accent).
+		Foreground(theme.AccentText).
+		Padding(0, 1).
+		Render(fmt.Sprintf("Pending Changes (%d)", len(m.staged)))
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(theme.Accent).
+		Padding(0, 1).
+		Width(width).
+		Render(title + "\n\n" + strings.Join(lines, "\n"))
+}
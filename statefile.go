@@ -0,0 +1,187 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// stateLockTimeout is how long a write waits for another instance to
+	// release a state file
+	stateLockTimeout = 2 * time.Second
+	// staleLockAge is when a lock is taken to be left behind by an instance
+	// that crashed; no write holds a lock nearly as long
+	staleLockAge = 10 * time.Second
+)
+
+// lockState takes the lock of a state file shared by all instances of the
+// user, such as the favorites or the config. The lock is a file created
+// next to it, which works the same on every platform and file system. It
+// holds the pid of the instance for whoever finds it left behind; the lock
+// is only removed as the very file it was created as, by the instance
+// holding it or as stale.
+func lockState(path string) (unlock func(), err error) {
+	lock := path + ".lock"
+	deadline := time.Now().Add(stateLockTimeout)
+	for {
+		file, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			var held os.FileInfo
+			_, err = fmt.Fprintf(file, "%d\n", os.Getpid())
+			if err == nil {
+				held, err = file.Stat()
+			}
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(lock)
+				return nil, err
+			}
+			return func() { removeLock(lock, held) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+		if info, err := os.Stat(lock); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			// Another instance may have found the lock stale as well and
+			// taken a new one meanwhile, which must stay
+			removeLock(lock, info)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is locked by another instance (remove %s if none is running)", filepath.Base(path), lock)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// removeLock removes a lock file if it is still the file held. It is moved
+// aside first, in one step, and only then compared, so a lock another
+// instance took meanwhile is never removed in its place; one moved aside
+// that way is put back.
+func removeLock(lock string, held os.FileInfo) {
+	claimed := fmt.Sprintf("%s.%x", lock, rand.Uint64())
+	if err := os.Rename(lock, claimed); err != nil {
+		return
+	}
+	// A file taken for a new lock can get the number of one removed, so the
+	// time it was written tells them apart as well
+	if info, err := os.Stat(claimed); err != nil || !os.SameFile(info, held) || !info.ModTime().Equal(held.ModTime()) {
+		os.Link(claimed, lock)
+	}
+	os.Remove(claimed)
+}
+
+var (
+	tempDirOnce sync.Once
+	tempDir     string
+	tempDirErr  error
+)
+
+// instanceTempDir returns the temp directory of this instance, made on
+// first use and readable by the user only. Exports are staged and the
+// modal editor buffer is kept there, so instances never share a temp file.
+func instanceTempDir() (string, error) {
+	tempDirOnce.Do(func() {
+		tempDir, tempDirErr = os.MkdirTemp("", fmt.Sprintf("odatanavigator-%d-*", os.Getpid()))
+	})
+	return tempDir, tempDirErr
+}
+
+// removeInstanceTempDir removes the temp directory of this instance when
+// the navigator ends; one left behind by a crash keeps the editor buffer
+func removeInstanceTempDir() {
+	if tempDir != "" {
+		os.RemoveAll(tempDir)
+	}
+}
+
+// writeExport writes an export to a file that doesn't exist yet. The file
+// is written in full in the temp directory of the instance and then linked
+// into place, which fails rather than replace a file another instance
+// exported under the same name meanwhile. Where that can't be done, such
+// as across file systems, it is created in place, failing all the same
+// when the file exists.
+func writeExport(path string, data []byte) error {
+	dir, err := instanceTempDir()
+	if err != nil {
+		return writeFileExclusive(path, data, 0o644)
+	}
+	staged, err := os.CreateTemp(dir, "export-*")
+	if err != nil {
+		return writeFileExclusive(path, data, 0o644)
+	}
+	defer os.Remove(staged.Name())
+	_, err = staged.Write(data)
+	if closeErr := staged.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(staged.Name(), 0o644)
+	}
+	if err != nil {
+		return err
+	}
+	err = os.Link(staged.Name(), path)
+	if err == nil || errors.Is(err, fs.ErrExist) {
+		return err
+	}
+	return writeFileExclusive(path, data, 0o644)
+}
+
+// writeFileExclusive creates a file with data, failing with fs.ErrExist
+// when it exists already; a file it couldn't write in full is removed
+func writeFileExclusive(path string, data []byte, perm os.FileMode) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
+
+// writeFileAtomic replaces a file with data in one step: the data goes to a
+// temp file of this instance next to it, which is then renamed over it, so
+// readers and other instances never see half a file
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := os.CreateTemp(dir, "."+name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), perm)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
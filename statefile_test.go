@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockStateUnlock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "favorites.json")
+	unlock, err := lockState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+	if _, err := os.Stat(path + ".lock"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("the lock is still there: %v", err)
+	}
+}
+
+func TestLockStateKeepsNewerLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "favorites.json")
+	lock := path + ".lock"
+	unlock, err := lockState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Another instance found the lock stale and took a new one
+	if err := os.Remove(lock); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(lock, []byte("other\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+	if data, err := os.ReadFile(lock); err != nil || string(data) != "other\n" {
+		t.Errorf("the lock of the other instance was removed: %q, %v", data, err)
+	}
+	if matches, _ := filepath.Glob(lock + ".*"); len(matches) != 0 {
+		t.Errorf("left behind %v", matches)
+	}
+}
+
+func TestLockStateBreaksStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "favorites.json")
+	lock := path + ".lock"
+	if err := os.WriteFile(lock, []byte("1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * staleLockAge)
+	if err := os.Chtimes(lock, old, old); err != nil {
+		t.Fatal(err)
+	}
+	unlock, err := lockState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+}
+
+func TestWriteExportRefusesExistingFile(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name  string
+		write func(path string, data []byte) error
+	}{
+		{"linked", writeExport},
+		{"created in place", func(path string, data []byte) error { return writeFileExclusive(path, data, 0o644) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".csv")
+			if err := tt.write(path, []byte("first")); err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.write(path, []byte("second")); !errors.Is(err, fs.ErrExist) {
+				t.Errorf("second write: %v, want fs.ErrExist", err)
+			}
+			if data, _ := os.ReadFile(path); string(data) != "first" {
+				t.Errorf("file holds %q", data)
+			}
+		})
+	}
+}
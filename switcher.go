@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// serviceSwitcher is the state of the overlay that connects to another
+// configured service from any column
+type serviceSwitcher struct {
+	active  bool
+	query   string // Typed text narrowing the list of services
+	cursor  int
+	confirm bool // Enter was pressed once with unsaved editor changes
+}
+
+// openServiceSwitcher opens the service switcher on top of whatever is shown
+func (m model) openServiceSwitcher() model {
+	m.switcher = serviceSwitcher{active: true}
+	for i, name := range m.switcher.matches(m.services) {
+		if m.serviceIndex >= 0 && m.serviceIndex < len(m.services) && name == m.services[m.serviceIndex].Name {
+			m.switcher.cursor = i
+		}
+	}
+	return m
+}
+
+// matches returns the names of the services containing the typed text
+func (s serviceSwitcher) matches(services []ServiceConfig) []string {
+	var names []string
+	for _, svc := range services {
+		if strings.Contains(strings.ToLower(svc.Name), strings.ToLower(s.query)) {
+			names = append(names, svc.Name)
+		}
+	}
+	return names
+}
+
+// unsavedChanges reports whether an editor is open whose changes switching would discard
+func (m model) unsavedChanges() bool {
+	return m.modalEditor || m.inlineEdit.active
+}
+
+// updateServiceSwitcher handles key presses while the service switcher is open
+func (m model) updateServiceSwitcher(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	sw := &m.switcher
+	names := sw.matches(m.services)
+
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		if sw.confirm {
+			sw.confirm = false
+		} else {
+			sw.active = false
+		}
+	case "up":
+		if sw.cursor > 0 {
+			sw.cursor--
+		}
+		sw.confirm = false
+	case "down":
+		if sw.cursor < len(names)-1 {
+			sw.cursor++
+		}
+		sw.confirm = false
+	case "backspace":
+		if sw.query != "" {
+			sw.query = sw.query[:len(sw.query)-1]
+			sw.cursor = 0
+			sw.confirm = false
+		}
+	case "enter":
+		if sw.cursor >= len(names) {
+			return m, nil
+		}
+		if m.unsavedChanges() && !sw.confirm {
+			sw.confirm = true
+			return m, nil
+		}
+		return m.switchService(names[sw.cursor])
+	default:
+		if text := typedText(msg); text != "" {
+			sw.query += text
+			sw.cursor = 0
+			sw.confirm = false
+		}
+	}
+	return m, nil
+}
+
+// switchService drops the current service context, editors and dialogs
+// included, and connects to the named service as if it were picked in the
+// services column
+func (m model) switchService(name string) (tea.Model, tea.Cmd) {
+	m.switcher = serviceSwitcher{}
+	if m.modalEditor {
+		m.closeModalEditor()
+		m.logs = append(m.logs, "Modal editor changes discarded")
+	}
+	if m.inlineEdit.active {
+		m.inlineEdit.active = false
+		m.logs = append(m.logs, "Edit changes discarded")
+	}
+	m.filterDialog.active = false
+	m.sortDialog.active = false
+	m.searchDialog.active = false
+	m.addressBar.active = false
+	m.queryPanel.active = false
+	m.propDoc.active = false
+	m.valueViewer.active = false
+	m.inspector.active = false
+	m.help.active = false
+	m.finding = false
+	m.csvExport.active = false
+	m.jsonExport.active = false
+	m.xlsxExport.active = false
+	m.importDialog.active = false
+	m.copyMenu.active = false
+	m.history.active = false
+	m.expandDialog.active = false
+	m.uploadDialog.active = false
+	m.downloadDialog.active = false
+	m.confirmDelete = false
+	m.confirmInsecure = ""
+
+	if m.odata != nil {
+		m.odata.Cancel()
+	}
+	m.loading = false
+	m.preview.loading = false
+
+	m.columns = m.columns[:1]
+	m.activeColumn = 0
+	for i, item := range m.columns[0].items {
+		if item == name {
+			m.columns[0].cursor = i
+		}
+	}
+	m.columns[0].focused = true
+	m.updateColumnSizes()
+	return m.drillDown()
+}
+
+// renderServiceSwitcher renders the service switcher box
+func (m model) renderServiceSwitcher() string {
+	sw := m.switcher
+	names := sw.matches(m.services)
+
+	current := ""
+	if m.serviceIndex >= 0 && m.serviceIndex < len(m.services) {
+		current = m.services[m.serviceIndex].Name
+	}
+	choices := make([]string, len(names))
+	for i, name := range names {
+		choices[i] = name
+		if name == current {
+			choices[i] += " (current)"
+		}
+	}
+
+	hint := lipgloss.NewStyle().Foreground(theme.Muted)
+	lines := []string{"Service: " + sw.query + "█", ""}
+	if len(choices) == 0 {
+		lines = append(lines, hint.Render("(no matching service)"))
+	}
+	lines = append(lines, renderChoiceList(choices, sw.cursor, m.height/2)...)
+	lines = append(lines, "")
+	if sw.confirm {
+		lines = append(lines, lipgloss.NewStyle().Foreground(theme.Error).Render(
+			fmt.Sprintf("Unsaved editor changes will be lost. Enter: Switch to %s | ESC: Keep", names[sw.cursor])))
+	} else {
+		lines = append(lines, hint.Render("Type to filter | Enter: Connect | ESC: Close"))
+	}
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Background(theme.Accent).
+		Foreground(theme.AccentText).
+		Padding(0, 1).
+		Render("Switch Service")
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(theme.Accent).
+		Padding(0, 1).
+		Width(min(60, m.width-4)).
+		Render(title + "\n\n" + strings.Join(lines, "\n"))
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme is the colors of the navigator, as ANSI 256 color numbers or
+// #rrggbb. In the config it starts from a preset, and any color set
+// replaces the one of the preset:
+//
+//	"theme": {"preset": "light", "accent": "#005f87"}
+type Theme struct {
+	Preset string `json:"preset,omitempty"` // dark (the default), light or high-contrast
+
+	Accent     lipgloss.Color `json:"accent,omitempty"`      // Titles, the active column and dialog borders
+	AccentText lipgloss.Color `json:"accent_text,omitempty"` // Text on the accent, selection and edit colors
+	Selection  lipgloss.Color `json:"selection,omitempty"`   // Cursor of the active column and dialogs
+	Border     lipgloss.Color `json:"border,omitempty"`      // Other columns, and their cursor
+	Muted      lipgloss.Color `json:"muted,omitempty"`       // Hints and secondary text
+	Dim        lipgloss.Color `json:"dim,omitempty"`         // Details after the name of an item
+	Text       lipgloss.Color `json:"text,omitempty"`        // Text on the border, panel and background colors
+	Background lipgloss.Color `json:"background,omitempty"`  // The modal editor
+	Panel      lipgloss.Color `json:"panel,omitempty"`       // Input fields and editable lines
+	Edit       lipgloss.Color `json:"edit,omitempty"`        // Edit mode and changed lines
+	Warning    lipgloss.Color `json:"warning,omitempty"`     // Warnings, marked entities and find matches
+	Error      lipgloss.Color `json:"error,omitempty"`       // Errors and failed columns
+	Success    lipgloss.Color `json:"success,omitempty"`     // Successful checks
+	Cursor     lipgloss.Color `json:"cursor,omitempty"`      // Text cursor of the modal editor
+	Function   lipgloss.Color `json:"function,omitempty"`    // Function imports
+}
+
+// themePresets are the built-in themes; dark is what the navigator always looked like
+var themePresets = map[string]Theme{
+	"dark": {
+		Preset: "dark",
+		Accent: "99", AccentText: "0", Selection: "99", Border: "241", Muted: "241", Dim: "8",
+		Text: "15", Background: "0", Panel: "235", Edit: "208", Warning: "214", Error: "196",
+		Success: "42", Cursor: "226", Function: "13",
+	},
+	"light": {
+		Preset: "light",
+		Accent: "55", AccentText: "15", Selection: "61", Border: "245", Muted: "243", Dim: "246",
+		Text: "0", Background: "255", Panel: "254", Edit: "166", Warning: "130", Error: "160",
+		Success: "28", Cursor: "94", Function: "127",
+	},
+	"high-contrast": {
+		Preset: "high-contrast",
+		Accent: "51", AccentText: "0", Selection: "226", Border: "250", Muted: "252", Dim: "250",
+		Text: "15", Background: "0", Panel: "236", Edit: "208", Warning: "226", Error: "196",
+		Success: "46", Cursor: "201", Function: "207",
+	},
+}
+
+// theme is the theme in use, from the config file
+var theme = themePresets["dark"]
+
+// resolve fills the colors the theme leaves out from its preset
+func (t Theme) resolve() (Theme, error) {
+	name := t.Preset
+	if name == "" {
+		name = "dark"
+	}
+	preset, ok := themePresets[name]
+	if !ok {
+		var names []string
+		for n := range themePresets {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return theme, fmt.Errorf("unknown theme preset %q (use %s)", t.Preset, strings.Join(names, ", "))
+	}
+	resolved := preset
+	// Colors set in the config replace those of the preset
+	for _, c := range []struct{ to, from *lipgloss.Color }{
+		{&resolved.Accent, &t.Accent},
+		{&resolved.AccentText, &t.AccentText},
+		{&resolved.Selection, &t.Selection},
+		{&resolved.Border, &t.Border},
+		{&resolved.Muted, &t.Muted},
+		{&resolved.Dim, &t.Dim},
+		{&resolved.Text, &t.Text},
+		{&resolved.Background, &t.Background},
+		{&resolved.Panel, &t.Panel},
+		{&resolved.Edit, &t.Edit},
+		{&resolved.Warning, &t.Warning},
+		{&resolved.Error, &t.Error},
+		{&resolved.Success, &t.Success},
+		{&resolved.Cursor, &t.Cursor},
+		{&resolved.Function, &t.Function},
+	} {
+		if *c.from != "" {
+			*c.to = *c.from
+		}
+	}
+	return resolved, nil
+}
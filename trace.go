@@ -0,0 +1,227 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RequestTrace is the timing breakdown of one HTTP request
+type RequestTrace struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Err        string
+	Start      time.Time
+	DNS        time.Duration
+	Connect    time.Duration
+	TLS        time.Duration
+	TTFB       time.Duration // From sending the request to the first response byte
+	Transfer   time.Duration // Reading the response body
+	Total      time.Duration
+	Bytes      int64
+	Reused     bool   // Connection was reused from the pool
+	Cache      string // Set when the response came from the disk or memory cache
+	done       bool
+}
+
+// traceRecorder keeps the most recent request traces
+type traceRecorder struct {
+	mu     sync.Mutex
+	traces []*RequestTrace
+	limit  int
+}
+
+// requestTraces records every request made by the OData clients
+var requestTraces = &traceRecorder{limit: 200}
+
+func (r *traceRecorder) add(t *RequestTrace) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.traces = append(r.traces, t)
+	if len(r.traces) > r.limit {
+		r.traces = r.traces[len(r.traces)-r.limit:]
+	}
+}
+
+// addCached records a request answered from the disk or memory cache
+func (r *traceRecorder) addCached(req *http.Request, entry *cacheEntry, state string) {
+	r.add(&RequestTrace{
+		Method:     req.Method,
+		URL:        redactURL(req.URL.String()),
+		StatusCode: entry.StatusCode,
+		Start:      time.Now(),
+		Bytes:      int64(len(entry.Body)),
+		Cache:      state,
+		done:       true,
+	})
+}
+
+// Snapshot returns copies of the recorded traces, oldest first
+func (r *traceRecorder) Snapshot() []RequestTrace {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]RequestTrace, len(r.traces))
+	for i, t := range r.traces {
+		out[i] = *t
+	}
+	return out
+}
+
+// tracingTransport measures DNS, connect, TLS, TTFB and transfer times of
+// each request using net/http/httptrace
+type tracingTransport struct {
+	base     http.RoundTripper
+	recorder *traceRecorder
+}
+
+func newTracingTransport(base http.RoundTripper) *tracingTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &tracingTransport{base: base, recorder: requestTraces}
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt := &RequestTrace{Method: req.Method, URL: redactURL(req.URL.String()), Start: time.Now()}
+	var dnsStart, connectStart, tlsStart, wroteRequest time.Time
+
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.recorder.mu.Lock()
+			rt.DNS = time.Since(dnsStart)
+			t.recorder.mu.Unlock()
+		},
+		ConnectStart: func(string, string) { connectStart = time.Now() },
+		ConnectDone: func(string, string, error) {
+			t.recorder.mu.Lock()
+			rt.Connect = time.Since(connectStart)
+			t.recorder.mu.Unlock()
+		},
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.recorder.mu.Lock()
+			rt.TLS = time.Since(tlsStart)
+			t.recorder.mu.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.recorder.mu.Lock()
+			rt.Reused = info.Reused
+			t.recorder.mu.Unlock()
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) { wroteRequest = time.Now() },
+		GotFirstResponseByte: func() {
+			t.recorder.mu.Lock()
+			rt.TTFB = time.Since(wroteRequest)
+			t.recorder.mu.Unlock()
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	t.recorder.add(rt)
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.recorder.mu.Lock()
+		rt.Err = redactText(err.Error())
+		rt.Total = time.Since(rt.Start)
+		rt.done = true
+		t.recorder.mu.Unlock()
+		return nil, err
+	}
+
+	t.recorder.mu.Lock()
+	rt.StatusCode = resp.StatusCode
+	t.recorder.mu.Unlock()
+	resp.Body = &tracedBody{ReadCloser: resp.Body, trace: rt, recorder: t.recorder, start: time.Now()}
+	return resp, nil
+}
+
+// tracedBody records transfer time and size once the body is drained or closed
+type tracedBody struct {
+	io.ReadCloser
+	trace    *RequestTrace
+	recorder *traceRecorder
+	start    time.Time
+	bytes    int64
+}
+
+func (b *tracedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.bytes += int64(n)
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *tracedBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+func (b *tracedBody) finish() {
+	b.recorder.mu.Lock()
+	defer b.recorder.mu.Unlock()
+	if b.trace.done {
+		return
+	}
+	b.trace.done = true
+	b.trace.Transfer = time.Since(b.start)
+	b.trace.Total = time.Since(b.trace.Start)
+	b.trace.Bytes = b.bytes
+}
+
+// formatTrace renders a one-line timing breakdown for the trace viewer
+func formatTrace(t RequestTrace) string {
+	status := fmt.Sprintf("%d", t.StatusCode)
+	if t.Err != "" {
+		status = "ERR"
+	} else if !t.done {
+		status = "..."
+	}
+
+	conn := fmt.Sprintf("dns %s conn %s tls %s", formatMillis(t.DNS), formatMillis(t.Connect), formatMillis(t.TLS))
+	if t.Reused {
+		conn = "conn reused"
+	}
+	if t.Cache != "" {
+		conn = t.Cache
+	}
+	line := fmt.Sprintf("%s %s %s | %s ttfb %s xfer %s total %s %s",
+		t.Start.Format("15:04:05"), t.Method, status, conn,
+		formatMillis(t.TTFB), formatMillis(t.Transfer), formatMillis(t.Total), formatBytes(t.Bytes))
+	if t.Err != "" {
+		line += " " + t.Err
+	}
+	return line + " " + shortenURL(t.URL)
+}
+
+func formatMillis(d time.Duration) string {
+	return fmt.Sprintf("%dms", d.Milliseconds())
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fMB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fKB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%dB", n)
+}
+
+// shortenURL drops scheme and host so the interesting path fits on the line
+func shortenURL(u string) string {
+	if i := strings.Index(u, "://"); i != -1 {
+		if slash := strings.Index(u[i+3:], "/"); slash != -1 {
+			return u[i+3+slash:]
+		}
+	}
+	return u
+}
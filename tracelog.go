@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxTracedBody limits how much of each body is written to the trace file
+const maxTracedBody = 1 << 20
+
+// traceLog is the file -trace writes every request and response to, nil
+// when tracing is off
+var traceLog *traceFile
+
+// traceFile appends exchanges to a file, one whole exchange at a time so
+// concurrent requests don't interleave
+type traceFile struct {
+	mu   sync.Mutex
+	file *os.File
+	next int // Number of the next request
+}
+
+// enableTraceLog appends a trace of every HTTP request to the file. The
+// file is only readable by the user, as traces show business data.
+func enableTraceLog(path string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	traceLog = &traceFile{file: file, next: 1}
+	fmt.Fprintf(file, "### Trace started %s\n\n", time.Now().Format(time.RFC3339))
+	return nil
+}
+
+// number returns the number of the next request traced
+func (f *traceFile) number() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := f.next
+	f.next++
+	return n
+}
+
+// write appends an exchange to the file
+func (f *traceFile) write(entry string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.file.WriteString(entry)
+}
+
+// traceLogTransport writes each request and its response to the trace
+// file, with credentials redacted as in the log pane
+type traceLogTransport struct {
+	base http.RoundTripper
+	log  *traceFile
+}
+
+func (t *traceLogTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var b strings.Builder
+	start := time.Now()
+	fmt.Fprintf(&b, "=== #%d %s %s %s\n", t.log.number(), start.Format("2006-01-02T15:04:05.000Z07:00"), req.Method, redactURL(req.URL.String()))
+	writeTracedHeaders(&b, "> ", req.Header)
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(body)
+			body.Close()
+			writeTracedBody(&b, "> ", req.Header.Get("Content-Type"), data, 0)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		fmt.Fprintf(&b, "<<< error after %s: %s\n\n", formatMillis(time.Since(start)), redactText(err.Error()))
+		t.log.write(b.String())
+		return nil, err
+	}
+	fmt.Fprintf(&b, "<<< %s %s (headers after %s)\n", resp.Proto, resp.Status, formatMillis(time.Since(start)))
+	writeTracedHeaders(&b, "< ", resp.Header)
+	resp.Body = &tracedLogBody{ReadCloser: resp.Body, entry: &b, log: t.log, start: start, contentType: resp.Header.Get("Content-Type")}
+	return resp, nil
+}
+
+// tracedLogBody collects a response body as it is read and writes the
+// exchange once the body is drained or closed
+type tracedLogBody struct {
+	io.ReadCloser
+	entry       *strings.Builder
+	log         *traceFile
+	start       time.Time
+	contentType string
+	body        bytes.Buffer
+	skipped     int64 // Bytes past maxTracedBody
+	written     bool
+}
+
+func (b *tracedLogBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if room := maxTracedBody - b.body.Len(); room < n {
+		b.body.Write(p[:max(room, 0)])
+		b.skipped += int64(n - max(room, 0))
+	} else {
+		b.body.Write(p[:n])
+	}
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *tracedLogBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+func (b *tracedLogBody) finish() {
+	if b.written {
+		return
+	}
+	b.written = true
+	writeTracedBody(b.entry, "< ", b.contentType, b.body.Bytes(), b.skipped)
+	fmt.Fprintf(b.entry, "=== done in %s\n\n", formatMillis(time.Since(b.start)))
+	b.log.write(b.entry.String())
+}
+
+// writeTracedHeaders writes headers sorted by name, one per line
+func writeTracedHeaders(b *strings.Builder, prefix string, headers http.Header) {
+	for _, line := range headerLines(headers) {
+		b.WriteString(prefix + line + "\n")
+	}
+}
+
+// writeTracedBody writes a text body line by line after a blank line, or
+// only the size of a binary one
+func writeTracedBody(b *strings.Builder, prefix, contentType string, body []byte, skipped int64) {
+	if len(body) == 0 {
+		return
+	}
+	b.WriteString(strings.TrimSpace(prefix) + "\n")
+	if !isTextContent(contentType, body) {
+		fmt.Fprintf(b, "%s(%s of %s)\n", prefix, formatBytes(int64(len(body))+skipped), contentType)
+		return
+	}
+	text := redactText(string(body))
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == "application/x-www-form-urlencoded" {
+		// Token requests send client secrets and passwords as form fields
+		text = redactURL("?" + text)[1:]
+	}
+	for _, line := range strings.Split(strings.TrimRight(text, "\r\n"), "\n") {
+		b.WriteString(prefix + strings.TrimRight(line, "\r") + "\n")
+	}
+	if skipped > 0 {
+		fmt.Fprintf(b, "%s... %s more not traced\n", prefix, formatBytes(skipped))
+	}
+}
+
+// isTextContent reports whether a body is JSON, XML, multipart or other
+// text worth writing to a trace
+func isTextContent(contentType string, body []byte) bool {
+	if contentType == "" {
+		contentType = http.DetectContentType(body)
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return strings.HasPrefix(mediaType, "text/") || strings.HasPrefix(mediaType, "multipart/") ||
+		strings.HasSuffix(mediaType, "json") || strings.HasSuffix(mediaType, "xml") ||
+		mediaType == "application/http" || mediaType == "application/x-www-form-urlencoded"
+}
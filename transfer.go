@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// transferProgressInterval limits how often a running transfer reports progress
+const transferProgressInterval = 100 * time.Millisecond
+
+// progressWriter counts the bytes written through it and reports them at
+// most every transferProgressInterval
+type progressWriter struct {
+	written  int64
+	total    int64
+	report   func(written, total int64)
+	reported time.Time
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.written += int64(len(p))
+	if w.report != nil && time.Since(w.reported) >= transferProgressInterval {
+		w.reported = time.Now()
+		w.report(w.written, w.total)
+	}
+	return len(p), nil
+}
+
+// transferState is the download or upload shown in the status bar
+type transferState struct {
+	verb    string // "Downloading" or "Uploading"
+	name    string
+	written int64
+	total   int64
+	started time.Time
+	updates chan tea.Msg // transferProgressMsg values, then one done message
+}
+
+type transferProgressMsg struct {
+	written int64
+	total   int64
+}
+
+type downloadDoneMsg struct {
+	name    string
+	path    string
+	written int64
+	elapsed time.Duration
+	err     error
+}
+
+// newTransfer starts tracking a transfer and returns it with a progress
+// callback for the client library; the callback drops updates the UI hasn't
+// picked up yet rather than stall the transfer
+func newTransfer(verb, name string) (*transferState, func(written, total int64)) {
+	t := &transferState{verb: verb, name: name, total: -1, started: time.Now(), updates: make(chan tea.Msg, 1)}
+	return t, func(written, total int64) {
+		select {
+		case t.updates <- transferProgressMsg{written: written, total: total}:
+		default:
+		}
+	}
+}
+
+// waitForTransfer delivers the next update of a running transfer
+func waitForTransfer(updates chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-updates
+	}
+}
+
+// applyTransfer shows the progress of a running download or upload and logs
+// its outcome once it is done
+func (m *model) applyTransfer(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case transferProgressMsg:
+		if m.transfer != nil {
+			m.transfer.written = msg.written
+			m.transfer.total = msg.total
+			return waitForTransfer(m.transfer.updates)
+		}
+	case downloadDoneMsg:
+		m.transfer = nil
+		if msg.err != nil {
+			m.logs = append(m.logs, fmt.Sprintf("ERROR [download %s]: %v", msg.name, msg.err))
+		} else {
+			m.logs = append(m.logs, fmt.Sprintf("Saved %s to %s (%s in %.1fs, %s)", msg.name, msg.path, formatBytes(msg.written), msg.elapsed.Seconds(), formatThroughput(msg.written, msg.elapsed)))
+		}
+	case uploadDoneMsg:
+		m.transfer = nil
+		if msg.err != nil {
+			m.logs = append(m.logs, fmt.Sprintf("ERROR [upload %s]: %v", msg.name, msg.err))
+		} else {
+			m.logs = append(m.logs, fmt.Sprintf("Uploaded %s to %s in %.1fs", msg.path, msg.name, msg.elapsed.Seconds()))
+		}
+		if msg.result != nil {
+			if msg.err == nil && msg.entitySet != "" && msg.result.Entity != nil {
+				m.insertCreatedEntity(msg.entitySet, msg.result.Entity)
+			}
+			m.openResultColumn(msg.name, []*OperationResult{msg.result})
+		}
+	}
+	return nil
+}
+
+// status renders a running transfer for the status bar
+func (t *transferState) status() string {
+	progress := formatBytes(t.written)
+	if t.total > 0 {
+		progress = fmt.Sprintf("%s/%s %d%%", progress, formatBytes(t.total), t.written*100/t.total)
+	}
+	return fmt.Sprintf("%s %s: %s %s", t.verb, t.name, progress, formatThroughput(t.written, time.Since(t.started)))
+}
+
+// formatThroughput renders bytes per second over elapsed
+func formatThroughput(n int64, elapsed time.Duration) string {
+	if elapsed <= 0 {
+		return ""
+	}
+	return formatBytes(int64(float64(n)/elapsed.Seconds())) + "/s"
+}
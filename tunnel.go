@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/url"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tunnelStartTimeout is how long ssh may take to log in to a jump host and
+// open its forward
+const tunnelStartTimeout = 20 * time.Second
+
+// sshTunnel is a dynamic port forward ("ssh -D") to a jump host, which
+// serves as a SOCKS5 proxy on a local port. It is started by the first
+// request through it and restarted when ssh has exited since.
+type sshTunnel struct {
+	target string // [user@]host[:port]
+	args   []string
+
+	mu     sync.Mutex
+	addr   string // Local address of the SOCKS5 proxy
+	cmd    *exec.Cmd
+	exited chan struct{}
+}
+
+var (
+	tunnelsMu sync.Mutex
+	tunnels   = make(map[string]*sshTunnel)
+)
+
+// sshTunnelTo returns the tunnel to a jump host, shared by every service
+// reached through it with the same ssh options
+func sshTunnelTo(target string, args []string) *sshTunnel {
+	id := target + "\x00" + strings.Join(args, "\x00")
+	tunnelsMu.Lock()
+	defer tunnelsMu.Unlock()
+	if t, ok := tunnels[id]; ok {
+		return t
+	}
+	t := &sshTunnel{target: target, args: args}
+	tunnels[id] = t
+	return t
+}
+
+// proxyURL returns the SOCKS5 URL of the tunnel, starting ssh if it is not running
+func (t *sshTunnel) proxyURL() (*url.URL, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.cmd != nil {
+		select {
+		case <-t.exited:
+			t.cmd = nil
+		default:
+			return &url.URL{Scheme: "socks5", Host: t.addr}, nil
+		}
+	}
+	if err := t.start(); err != nil {
+		return nil, err
+	}
+	return &url.URL{Scheme: "socks5", Host: t.addr}, nil
+}
+
+// start runs ssh with a dynamic forward on a free local port and waits
+// until the forward accepts connections
+func (t *sshTunnel) start() error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("no local port for the SSH tunnel: %w", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	host, port := t.target, ""
+	if i := strings.LastIndex(host, ":"); i != -1 && !strings.Contains(host[i:], "]") {
+		host, port = host[:i], host[i+1:]
+	}
+	// BatchMode: the terminal belongs to the navigator, so ssh can't prompt
+	args := []string{"-N", "-D", addr, "-o", "ExitOnForwardFailure=yes", "-o", "BatchMode=yes"}
+	if port != "" {
+		args = append(args, "-p", port)
+	}
+	args = append(args, t.args...)
+	args = append(args, host)
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("ssh", args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("cannot start ssh to %s: %w", t.target, err)
+	}
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+
+	deadline := time.Now().Add(tunnelStartTimeout)
+	for {
+		if conn, err := net.DialTimeout("tcp", addr, time.Second); err == nil {
+			conn.Close()
+			break
+		}
+		select {
+		case <-exited:
+			return fmt.Errorf("SSH tunnel to %s failed: %s", t.target, strings.TrimSpace(stderr.String()))
+		case <-time.After(100 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			cmd.Process.Kill()
+			return fmt.Errorf("SSH tunnel to %s did not open within %s", t.target, tunnelStartTimeout)
+		}
+	}
+	t.addr, t.cmd, t.exited = addr, cmd, exited
+	return nil
+}
+
+// closeTunnels stops the ssh processes of all tunnels
+func closeTunnels() {
+	tunnelsMu.Lock()
+	defer tunnelsMu.Unlock()
+	for _, t := range tunnels {
+		t.mu.Lock()
+		if t.cmd != nil {
+			t.cmd.Process.Kill()
+			t.cmd = nil
+		}
+		t.mu.Unlock()
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is the release the binary was built from, set with
+// -ldflags "-X main.Version=v1.2.3"
+var Version = "dev"
+
+// latestReleaseURL is the GitHub API endpoint describing the newest release
+const latestReleaseURL = "https://api.github.com/repos/oisee/odatanavigator/releases/latest"
+
+type githubRelease struct {
+	TagName string        `json:"tag_name"`
+	Assets  []githubAsset `json:"assets"`
+}
+
+type githubAsset struct {
+	Name        string `json:"name"`
+	DownloadURL string `json:"browser_download_url"`
+}
+
+// runUpdate implements "odatanavigator update": it replaces the running
+// executable with the binary of the latest GitHub release for this platform
+// after checking it against the release's SHA-256 checksums
+func runUpdate() error {
+	client := &http.Client{Timeout: 5 * time.Minute, Transport: newBaseTransport(nil, DefaultTimeout)}
+
+	release, err := fetchLatestRelease(client)
+	if err != nil {
+		return err
+	}
+	if !newerVersion(release.TagName, Version) {
+		fmt.Printf("odatanavigator %s is up to date (latest release %s)\n", Version, release.TagName)
+		return nil
+	}
+
+	binary := release.platformAsset()
+	if binary == nil {
+		return fmt.Errorf("release %s has no binary for %s/%s", release.TagName, runtime.GOOS, runtime.GOARCH)
+	}
+	checksums := release.checksumAsset()
+	if checksums == nil {
+		return fmt.Errorf("release %s publishes no checksums, refusing to install an unverified binary", release.TagName)
+	}
+	want, err := fetchChecksum(client, checksums.DownloadURL, binary.Name)
+	if err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot locate the running executable: %w", err)
+	}
+	if executable, err = filepath.EvalSymlinks(executable); err != nil {
+		return fmt.Errorf("cannot locate the running executable: %w", err)
+	}
+
+	fmt.Printf("Downloading %s %s...\n", binary.Name, release.TagName)
+	// Download next to the executable so the final rename stays on one file system
+	temp, err := os.CreateTemp(filepath.Dir(executable), ".odatanavigator-update-*")
+	if err != nil {
+		return fmt.Errorf("cannot write next to %s: %w", executable, err)
+	}
+	defer os.Remove(temp.Name())
+
+	got, err := downloadAsset(client, binary.DownloadURL, temp)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if got != want {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", binary.Name, want, got)
+	}
+	if err := os.Chmod(temp.Name(), 0o755); err != nil {
+		return err
+	}
+
+	// Windows can't replace a running executable, but it can rename it
+	if runtime.GOOS == "windows" {
+		old := executable + ".old"
+		os.Remove(old)
+		if err := os.Rename(executable, old); err != nil {
+			return fmt.Errorf("cannot move the old executable aside: %w", err)
+		}
+	}
+	if err := os.Rename(temp.Name(), executable); err != nil {
+		if runtime.GOOS == "windows" {
+			// Put the old executable back rather than leave none
+			if restoreErr := os.Rename(executable+".old", executable); restoreErr != nil {
+				return fmt.Errorf("cannot replace %s: %w (and restoring it from %s.old failed: %v)", executable, err, executable, restoreErr)
+			}
+		}
+		return fmt.Errorf("cannot replace %s: %w", executable, err)
+	}
+	fmt.Printf("Updated %s from %s to %s\n", executable, Version, release.TagName)
+	return nil
+}
+
+func fetchLatestRelease(client *http.Client) (*githubRelease, error) {
+	req, err := http.NewRequest("GET", latestReleaseURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", DefaultUserAgent+"/"+Version)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for updates: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("failed to check for updates: HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	var release githubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("failed to parse release: %w", err)
+	}
+	return &release, nil
+}
+
+// platformAsset returns the raw binary built for this OS and architecture,
+// named like odatanavigator_linux_amd64 or odatanavigator-windows-amd64.exe.
+// The OS and architecture must end the name, so arm doesn't pick arm64 and
+// archives, signatures and the like, which carry a suffix, aren't taken.
+func (r *githubRelease) platformAsset() *githubAsset {
+	for i, asset := range r.Assets {
+		name := strings.ToLower(asset.Name)
+		if runtime.GOOS == "windows" {
+			if !strings.HasSuffix(name, ".exe") {
+				continue
+			}
+			name = strings.TrimSuffix(name, ".exe")
+		}
+		if strings.HasSuffix(name, "_"+runtime.GOOS+"_"+runtime.GOARCH) || strings.HasSuffix(name, "-"+runtime.GOOS+"-"+runtime.GOARCH) {
+			return &r.Assets[i]
+		}
+	}
+	return nil
+}
+
+// checksumAsset returns the sha256sum-style checksum list of the release
+func (r *githubRelease) checksumAsset() *githubAsset {
+	for i, asset := range r.Assets {
+		name := strings.ToLower(asset.Name)
+		if strings.Contains(name, "checksums") || strings.Contains(name, "sha256sums") {
+			return &r.Assets[i]
+		}
+	}
+	return nil
+}
+
+// fetchChecksum reads the expected SHA-256 of an asset from a list of
+// "<hex digest>  <file name>" lines
+func fetchChecksum(client *http.Client, url, name string) (string, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch checksums: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch checksums: HTTP %d", resp.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read checksums: %w", err)
+	}
+	return "", fmt.Errorf("no checksum listed for %s", name)
+}
+
+// downloadAsset writes an asset to w and returns its SHA-256 in hex
+func downloadAsset(client *http.Client, url string, w io.Writer) (string, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("failed to download update: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download update: HTTP %d", resp.StatusCode)
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, hash), resp.Body); err != nil {
+		return "", fmt.Errorf("failed to download update: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// newerVersion reports whether release is a later version than current.
+// Development builds are always older than any release.
+func newerVersion(release, current string) bool {
+	if current == "dev" || current == "" {
+		return true
+	}
+	a, b := versionParts(release), versionParts(current)
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			return x > y
+		}
+	}
+	return false
+}
+
+// versionParts splits "v1.2.3-rc1" into [1 2 3], ignoring pre-release suffixes
+func versionParts(version string) []int {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(version, "-+"); i != -1 {
+		version = version[:i]
+	}
+	var parts []int
+	for _, part := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUpdateChecksum(t *testing.T) {
+	binary := []byte("odatanavigator release binary")
+	sum := sha256.Sum256(binary)
+	digest := hex.EncodeToString(sum[:])
+	other := strings.Repeat("0", 64)
+	const name = "odatanavigator_linux_amd64"
+
+	tests := []struct {
+		name      string
+		checksums string
+		status    int
+		matches   bool // The downloaded binary has the listed checksum
+		err       bool
+	}{
+		{"listed", other + "  odatanavigator_darwin_arm64\n" + digest + "  " + name + "\n", http.StatusOK, true, false},
+		{"binary mode marker", digest + " *" + name + "\n", http.StatusOK, true, false},
+		{"upper case digest", strings.ToUpper(digest) + "  " + name + "\n", http.StatusOK, true, false},
+		{"tampered binary", other + "  " + name + "\n", http.StatusOK, false, false},
+		{"not listed", digest + "  " + name + ".exe\n", http.StatusOK, false, true},
+		{"name as a suffix only", digest + "  my" + name + "\n", http.StatusOK, false, true},
+		{"list missing", "", http.StatusNotFound, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/"+name {
+					w.Write(binary)
+					return
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.checksums))
+			}))
+			defer server.Close()
+
+			want, err := fetchChecksum(server.Client(), server.URL+"/checksums.txt", name)
+			if (err != nil) != tt.err {
+				t.Fatalf("fetchChecksum error %v, want error %v", err, tt.err)
+			}
+			var written bytes.Buffer
+			got, err := downloadAsset(server.Client(), server.URL+"/"+name, &written)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(written.Bytes(), binary) {
+				t.Errorf("downloaded %q", written.Bytes())
+			}
+			if matches := got == want; matches != tt.matches {
+				t.Errorf("checksum %s against %s: match %v, want %v", got, want, matches, tt.matches)
+			}
+		})
+	}
+}
+
+func TestNewerVersion(t *testing.T) {
+	tests := []struct {
+		release string
+		current string
+		want    bool
+	}{
+		{"v1.2.0", "v1.1.9", true},
+		{"v1.10.0", "v1.9.0", true},
+		{"v1.2.0", "v1.2.0", false},
+		{"v1.2.0", "v1.2.1", false},
+		{"v1.2", "v1.2.0", false},
+		{"v1.2.1", "v1.2", true},
+		{"v2.0.0-rc1", "v1.9.9", true},
+		{"1.2.0", "v1.2.0", false},
+		{"v0.1.0", "dev", true},
+		{"v0.1.0", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.release+" over "+tt.current, func(t *testing.T) {
+			if got := newerVersion(tt.release, tt.current); got != tt.want {
+				t.Errorf("newerVersion = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// entityDiff lists the properties that differ between two versions of an
+// entity: + for one added, ~ for one changed, with the old and new value,
+// and - for one left out, which a full replace (PUT) resets. Metadata and
+// deferred navigation properties are the service's and never differ.
+func entityDiff(before, after map[string]interface{}, full bool) []string {
+	names := make(map[string]bool)
+	for name, value := range after {
+		if sentProperty(name, value) {
+			names[name] = true
+		}
+	}
+	if full {
+		for name, value := range before {
+			if sentProperty(name, value) {
+				names[name] = true
+			}
+		}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var lines []string
+	for _, name := range sorted {
+		old, had := before[name]
+		value, has := after[name]
+		switch {
+		case !has:
+			lines = append(lines, fmt.Sprintf("- %s: %s", name, diffValue(old)))
+		case !had:
+			lines = append(lines, fmt.Sprintf("+ %s: %s", name, diffValue(value)))
+		case diffValue(old) != diffValue(value):
+			lines = append(lines, fmt.Sprintf("~ %s: %s → %s", name, diffValue(old), diffValue(value)))
+		}
+	}
+	return lines
+}
+
+// changedProperties returns the properties of after that are new or differ
+// from before: what a MERGE or PATCH needs to send
+func changedProperties(before, after map[string]interface{}) map[string]interface{} {
+	changes := make(map[string]interface{})
+	for name, value := range after {
+		if old, had := before[name]; sentProperty(name, value) && (!had || diffValue(old) != diffValue(value)) {
+			changes[name] = value
+		}
+	}
+	return changes
+}
+
+// sentProperty reports whether a member of an entity is written back, as
+// opposed to __metadata and deferred navigation properties
+func sentProperty(name string, value interface{}) bool {
+	if strings.HasPrefix(name, "__") {
+		return false
+	}
+	nested, ok := value.(map[string]interface{})
+	return !ok || nested["__deferred"] == nil
+}
+
+// diffValue writes a value of a diff as JSON
+func diffValue(value interface{}) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// renderDiff colors the lines of a diff, cut to width
+func renderDiff(lines []string, width int) []string {
+	rendered := make([]string, len(lines))
+	for i, line := range lines {
+		style := lipgloss.NewStyle()
+		switch {
+		case strings.HasPrefix(line, "+"):
+			style = style.Foreground(theme.Success)
+		case strings.HasPrefix(line, "~"):
+			style = style.Foreground(theme.Warning)
+		case strings.HasPrefix(line, "-"):
+			style = style.Foreground(theme.Error)
+		}
+		rendered[i] = style.Render(fitItem(line, width))
+	}
+	return rendered
+}
+
+// updateReview shows what an update changes before it is sent, from F2 in
+// the modal editor
+type updateReview struct {
+	active    bool
+	entitySet string
+	key       string
+	before    map[string]interface{} // Entity as loaded
+	after     map[string]interface{} // Entity as edited, converted for the service
+	diff      []string
+	scroll    int
+}
+
+// openUpdateReview shows the differences between the loaded and the edited
+// entity, to be confirmed before the update is sent
+func (m model) openUpdateReview(entitySet, key string, before, after map[string]interface{}) model {
+	m.review = updateReview{
+		active:    true,
+		entitySet: entitySet,
+		key:       key,
+		before:    before,
+		after:     after,
+		diff:      entityDiff(before, after, true),
+	}
+	return m
+}
+
+// reviewHeight is the number of diff lines the review shows at once
+func (m model) reviewHeight() int {
+	return max(m.height-14, 3)
+}
+
+// updateUpdateReview handles key presses while the changes of an update are
+// shown: Enter sends them the way the service is configured to, with PUT
+// or, with partial_updates, as a patch; m sends them the other way
+func (m model) updateUpdateReview(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	r := &m.review
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		r.active = false
+		m.logs = append(m.logs, "Update not sent - back to the editor")
+	case "up", "k":
+		r.scroll = max(r.scroll-1, 0)
+	case "down", "j":
+		r.scroll = max(min(r.scroll+1, len(r.diff)-m.reviewHeight()), 0)
+	case "enter":
+		return m.sendUpdate(m.odata.partialUpdates)
+	case "m":
+		return m.sendUpdate(!m.odata.partialUpdates)
+	}
+	return m, nil
+}
+
+// sendUpdate sends the reviewed update, or stages it while staging is on:
+// the whole entity with PUT, or patch, only the changed properties with
+// MERGE or PATCH. The editor stays open until the service accepts it.
+func (m model) sendUpdate(patch bool) (tea.Model, tea.Cmd) {
+	r := m.review
+	request := BatchRequest{Method: "PUT", EntitySet: r.entitySet, Key: r.key, Body: r.after, IfMatch: entityETag(r.before)}
+	if patch {
+		request.Method, request.Body = m.odata.patchMethod(), changedProperties(r.before, r.after)
+		if len(request.Body) == 0 {
+			m.logs = append(m.logs, "No properties changed - nothing to send")
+			return m, nil
+		}
+	}
+	m.review = updateReview{}
+	if m.staging {
+		m.closeModalEditor()
+		m.stage([]BatchRequest{request}, []map[string]interface{}{r.before})
+		return m, nil
+	}
+
+	m.loading = true
+	m.logs = append(m.logs, fmt.Sprintf("Performing update operation on %s with %s...", r.entitySet, request.Method))
+	odata := m.odata
+	return m, func() tea.Msg {
+		var result *OperationResult
+		var err error
+		if patch {
+			result, err = odata.PatchEntity(r.entitySet, r.key, request.Body, request.IfMatch)
+		} else {
+			result, err = odata.UpdateEntity(r.entitySet, r.key, request.Body, request.IfMatch)
+		}
+		if err != nil {
+			if result != nil && (result.StatusCode == http.StatusPreconditionFailed || result.StatusCode == http.StatusConflict) {
+				return fetchConflict(odata, r, result, err)
+			}
+			return newErrorMsg(err, "update operation")
+		}
+		// Updates usually answer 204 without a body, so keep what was sent
+		if result.Entity == nil {
+			result.Entity = request.Body
+		}
+		return saveSuccessMsg{
+			operation: "update",
+			entitySet: r.entitySet,
+			message:   "Entity updated successfully",
+			result:    result,
+		}
+	}
+}
+
+// renderUpdateReview draws the changes of the update waiting to be sent
+func (m model) renderUpdateReview() string {
+	r := m.review
+	hint := lipgloss.NewStyle().Foreground(theme.Muted)
+	width := min(100, m.width-4)
+
+	lines := []string{hint.Render(fmt.Sprintf("%s(%s) - %d properties differ from the entity as loaded:", r.entitySet, r.key, len(r.diff))), ""}
+	diff := r.diff
+	if len(diff) == 0 {
+		diff = []string{"  (no properties changed)"}
+	}
+	end := min(r.scroll+m.reviewHeight(), len(diff))
+	lines = append(lines, renderDiff(diff[r.scroll:end], width-4)...)
+	if end < len(diff) {
+		lines = append(lines, hint.Render(fmt.Sprintf("  ... %d more (Down scrolls)", len(diff)-end)))
+	}
+	send, other := "Send the whole entity (PUT)", fmt.Sprintf("Send the changes only (%s)", m.odata.patchMethod())
+	if m.odata.partialUpdates {
+		send, other = other, send
+	}
+	lines = append(lines, "", hint.Render("Enter: "+send+" | m: "+other+" | ESC: Edit"))
+	if m.staging {
+		lines = append(lines, hint.Render("Staging is on - the update waits in the pending changes"))
+	}
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Background(theme.Accent).
+		Foreground(theme.AccentText).
+		Padding(0, 1).
+		Render("Review Update")
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(theme.Accent).
+		Padding(0, 1).
+		Width(width).
+		Render(title + "\n\n" + strings.Join(lines, "\n"))
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// uploadChunkSize is the size of the pieces a file is sent in when the
+	// server takes ranged writes
+	uploadChunkSize = 4 << 20
+	// uploadAttempts is how often a request is tried before the upload fails
+	uploadAttempts = 3
+)
+
+// UploadValue replaces the media stream at url with the contents of a file,
+// calling progress as data goes out. Servers that advertise byte ranges
+// (Accept-Ranges: bytes on the stream) get the file in chunks with
+// Content-Range, so an interrupted upload resumes at the failed chunk;
+// others get one PUT. Every request is retried a few times on network
+// errors and 5xx answers before giving up.
+func (o *ODataService) UploadValue(url, path string, progress func(sent, total int64)) (*OperationResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+
+	contentType := uploadContentType(path)
+
+	chunk := size
+	if size > uploadChunkSize && o.acceptsRanges(url) {
+		chunk = uploadChunkSize
+	}
+
+	counter := &progressWriter{total: size, report: progress}
+	var result *OperationResult
+	for start := int64(0); ; {
+		end := start + chunk
+		if end > size {
+			end = size
+		}
+		var received int64
+		result, received, err = o.uploadRange(url, contentType, file, start, end, size, counter)
+		if err != nil {
+			return result, err
+		}
+		// Resumable endpoints answer 308 with the bytes they have so far
+		start = end
+		if result.StatusCode == http.StatusPermanentRedirect && received > 0 {
+			start = received
+		}
+		counter.written = start
+		if start >= size {
+			break
+		}
+	}
+	if progress != nil {
+		progress(size, size)
+	}
+	return result, nil
+}
+
+// CreateMediaEntity creates a media entity in the entity set at url from
+// the contents of a file: the file is POSTed with the Content-Type of its
+// extension and its name in the Slug header, which servers use to name the
+// new entity or fill its file name property. The single request is not
+// retried, since a repeated POST would create a second entity.
+func (o *ODataService) CreateMediaEntity(url, path string, progress func(sent, total int64)) (*OperationResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	counter := &progressWriter{total: info.Size(), report: progress}
+	req, err := http.NewRequest("POST", url, io.TeeReader(file, counter))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req = detached(req)
+	req.ContentLength = info.Size()
+	req.Header.Set("Content-Type", uploadContentType(path))
+	req.Header.Set("Slug", neturl.PathEscape(filepath.Base(path)))
+	req.Header.Set("Accept", "application/json")
+
+	if o.username != "" && o.password != "" {
+		req.SetBasicAuth(o.username, o.password)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("upload interrupted at %s: %w", formatBytes(counter.written), err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(resp.Body)
+
+	result := newOperationResult("create", req, resp, respBody)
+	if resp.StatusCode >= 400 {
+		return result, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(respBody))
+	}
+	if progress != nil {
+		progress(info.Size(), info.Size())
+	}
+	return result, nil
+}
+
+// uploadContentType is the Content-Type a file is sent with, told by its extension
+func uploadContentType(path string) string {
+	if contentType := mime.TypeByExtension(filepath.Ext(path)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// uploadRange sends bytes [start, end) of the file, retrying the request
+// with backoff when the connection drops or the server fails, and returns
+// how many bytes the server reports in its Range header, if any
+func (o *ODataService) uploadRange(url, contentType string, file *os.File, start, end, size int64, counter *progressWriter) (*OperationResult, int64, error) {
+	var lastErr error
+	for attempt := 1; attempt <= uploadAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(time.Duration(attempt-1) * time.Second)
+		}
+		counter.written = start
+
+		body := io.TeeReader(io.NewSectionReader(file, start, end-start), counter)
+		req, err := http.NewRequest("PUT", url, body)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to create request: %w", err)
+		}
+		req = detached(req)
+		req.ContentLength = end - start
+		req.Header.Set("Content-Type", contentType)
+		if end-start < size {
+			req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end-1, size))
+		}
+
+		if o.username != "" && o.password != "" {
+			req.SetBasicAuth(o.username, o.password)
+		}
+
+		resp, err := o.client.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("upload interrupted at %s: %w", formatBytes(counter.written), err)
+			continue
+		}
+		respBody, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		result := newOperationResult("upload", req, resp, respBody)
+		if resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests {
+			lastErr = fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(respBody))
+			continue
+		}
+		if resp.StatusCode >= 400 {
+			return result, 0, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(respBody))
+		}
+		return result, receivedBytes(resp.Header.Get("Range")), nil
+	}
+	return nil, 0, fmt.Errorf("%w (gave up after %d attempts)", lastErr, uploadAttempts)
+}
+
+// acceptsRanges asks whether the server takes a stream in byte ranges
+func (o *ODataService) acceptsRanges(url string) bool {
+	req, err := http.NewRequest("HEAD", url, nil)
+	if err != nil {
+		return false
+	}
+	req.Header.Set("Cache-Control", "no-store")
+	req = detached(req)
+	if o.username != "" && o.password != "" {
+		req.SetBasicAuth(o.username, o.password)
+	}
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode < 300 && resp.Header.Get("Accept-Ranges") == "bytes"
+}
+
+// receivedBytes reads the end of a "bytes=0-1234" Range header as a count of bytes
+func receivedBytes(header string) int64 {
+	dash := strings.LastIndex(header, "-")
+	if dash == -1 {
+		return 0
+	}
+	last, err := strconv.ParseInt(header[dash+1:], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return last + 1
+}
+
+// uploadPrompt is the state of the "u" and "U" overlay asking for the file to upload
+type uploadPrompt struct {
+	active    bool
+	name      string // Entity the file goes to, for display
+	url       string // Media stream the file replaces, or the entity set it is posted to
+	entitySet string // Entity set a new media entity is created in, "" to replace a stream
+	path      string
+}
+
+type uploadDoneMsg struct {
+	name      string
+	path      string
+	entitySet string // Set in which the upload created an entity, if it did
+	result    *OperationResult
+	elapsed   time.Duration
+	err       error
+}
+
+// openUploadPrompt asks for a file to replace the media stream of the
+// selected entity with
+func (m model) openUploadPrompt() model {
+	if m.transfer != nil {
+		m.logs = append(m.logs, fmt.Sprintf("%s %s still running", m.transfer.verb, m.transfer.name))
+		return m
+	}
+	url, name, ok := m.mediaTarget()
+	if !ok {
+		m.logs = append(m.logs, "Upload: select a media entity in an entity list or open it in Details")
+		return m
+	}
+	m.uploadDialog = uploadPrompt{active: true, name: name, url: url}
+	return m
+}
+
+// openMediaCreatePrompt asks for a file to create a new media entity from
+// in the entity set of the active entity list
+func (m model) openMediaCreatePrompt() model {
+	if m.transfer != nil {
+		m.logs = append(m.logs, fmt.Sprintf("%s %s still running", m.transfer.verb, m.transfer.name))
+		return m
+	}
+	if m.odata == nil || m.activeColumn >= len(m.columns) || !m.columns[m.activeColumn].isEntityList() {
+		m.logs = append(m.logs, "New media entity: open the entity list of a media entity set first")
+		return m
+	}
+	entitySet := m.columns[m.activeColumn].entitySet
+	if et := m.metadata().EntityTypeOf(entitySet); et != nil && !et.HasStream {
+		m.logs = append(m.logs, fmt.Sprintf("New media entity: %s is not a media entity set (its type has no HasStream)", entitySet))
+		return m
+	}
+	url := m.odata.BuildURL(entitySet, "", QueryOptions{})
+	m.uploadDialog = uploadPrompt{active: true, name: entitySet, url: url, entitySet: entitySet}
+	return m
+}
+
+// mediaTarget returns the media stream "u" replaces: the edit link of the
+// selected media entity, or else its $value
+func (m model) mediaTarget() (url, name string, ok bool) {
+	entitySet, entity, ok := m.targetEntity()
+	if !ok {
+		return "", "", false
+	}
+	key := extractEntityKey(m.metadata(), entitySet, entity)
+	if key == "" {
+		return "", "", false
+	}
+	name = entitySet + "(" + key + ")"
+
+	// Media entities name their writable stream in __metadata.edit_media (V2)
+	// or @odata.mediaEditLink (V4)
+	if metadata, ok := entity["__metadata"].(map[string]interface{}); ok {
+		if link, ok := metadata["edit_media"].(string); ok && link != "" {
+			return m.odata.BuildURL(link, "", QueryOptions{}), name, true
+		}
+	}
+	if link, ok := entity["@odata.mediaEditLink"].(string); ok && link != "" {
+		return m.odata.BuildURL(link, "", QueryOptions{}), name, true
+	}
+	return m.odata.BuildURL(entitySet, key, QueryOptions{}) + "/$value", name, true
+}
+
+// updateUploadPrompt handles key presses while the upload prompt is open
+func (m model) updateUploadPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	up := &m.uploadDialog
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		up.active = false
+	case "enter":
+		up.active = false
+		return m.startUpload(strings.TrimSpace(up.path))
+	case "backspace":
+		if len(up.path) > 0 {
+			runes := []rune(up.path)
+			up.path = string(runes[:len(runes)-1])
+		}
+	default:
+		up.path += typedText(msg)
+	}
+	return m, nil
+}
+
+// startUpload sends a file to the media stream chosen in the upload prompt,
+// reporting progress to the status bar
+func (m model) startUpload(path string) (tea.Model, tea.Cmd) {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+	if _, err := os.Stat(path); err != nil {
+		m.logs = append(m.logs, fmt.Sprintf("Upload: %v", err))
+		return m, nil
+	}
+
+	name := m.uploadDialog.name
+	url := m.uploadDialog.url
+	entitySet := m.uploadDialog.entitySet
+	transfer, progress := newTransfer("Uploading", filepath.Base(path))
+	m.transfer = transfer
+	m.logs = append(m.logs, fmt.Sprintf("Uploading %s to %s...", path, shortenURL(url)))
+
+	odata := m.odata
+	go func() {
+		var result *OperationResult
+		var err error
+		if entitySet != "" {
+			result, err = odata.CreateMediaEntity(url, path, progress)
+		} else {
+			result, err = odata.UploadValue(url, path, progress)
+		}
+		transfer.updates <- uploadDoneMsg{name: name, path: path, entitySet: entitySet, result: result, elapsed: time.Since(transfer.started), err: err}
+	}()
+	return m, waitForTransfer(transfer.updates)
+}
+
+// renderUploadPrompt renders the upload prompt box
+func (m model) renderUploadPrompt() string {
+	up := m.uploadDialog
+	prompt, title := "Replace the media stream with file:", "Upload "+up.name
+	if up.entitySet != "" {
+		prompt, title = "Create a media entity from file (its name is sent as Slug):", "New media entity in "+up.name
+	}
+	lines := []string{
+		prompt,
+		lipgloss.NewStyle().Background(theme.Panel).Render("> " + up.path + "█"),
+		"",
+		lipgloss.NewStyle().Foreground(theme.Muted).Render(shortenURL(up.url)),
+		"",
+		lipgloss.NewStyle().Foreground(theme.Muted).Render("Enter: Upload | ESC: Cancel"),
+	}
+
+	header := lipgloss.NewStyle().
+		Bold(true).
+		Background(theme.Accent).
+		Foreground(theme.AccentText).
+		Padding(0, 1).
+		Render(title)
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(theme.Accent).
+		Padding(0, 1).
+		Width(min(70, m.width-4)).
+		Render(header + "\n\n" + strings.Join(lines, "\n"))
+}
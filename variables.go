@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// variableRef matches {{Name}} references to session variables
+var variableRef = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_.]*)\s*\}\}`)
+
+// captureVariable stores the property under the cursor of the active Details
+// column as a session variable named after the property
+func (m model) captureVariable() model {
+	if m.activeColumn >= len(m.columns) {
+		return m
+	}
+	col := m.columns[m.activeColumn]
+	if !col.isDetails || col.cursor >= len(col.items) {
+		m.logs = append(m.logs, "Capture: place the cursor on a property in the Details column")
+		return m
+	}
+
+	name, value, ok := parseJSONPropertyLine(col.items[col.cursor])
+	if !ok {
+		m.logs = append(m.logs, "Capture: the selected line is not a simple property")
+		return m
+	}
+
+	if m.variables == nil {
+		m.variables = make(map[string]string)
+	}
+	m.variables[name] = value
+	m.logs = append(m.logs, fmt.Sprintf("Captured {{%s}} = %s", name, value))
+	return m
+}
+
+// parseJSONPropertyLine extracts the name and scalar value from a line of
+// indented JSON such as `  "OrderID": 10248,`. String values are unquoted.
+func parseJSONPropertyLine(line string) (string, string, bool) {
+	line = strings.TrimSuffix(strings.TrimSpace(line), ",")
+	colon := strings.Index(line, `": `)
+	if !strings.HasPrefix(line, `"`) || colon == -1 {
+		return "", "", false
+	}
+
+	var name string
+	if err := json.Unmarshal([]byte(line[:colon+1]), &name); err != nil {
+		return "", "", false
+	}
+
+	raw := strings.TrimSpace(line[colon+2:])
+	if raw == "{" || raw == "[" || raw == "{}" || raw == "[]" {
+		return "", "", false
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		return "", "", false
+	}
+	if s, ok := value.(string); ok {
+		return name, s, true
+	}
+	return name, raw, true
+}
+
+// expandVariables replaces {{Name}} references with captured values and
+// returns the names that could not be resolved
+func expandVariables(text string, variables map[string]string) (string, []string) {
+	var missing []string
+	expanded := variableRef.ReplaceAllStringFunc(text, func(ref string) string {
+		name := variableRef.FindStringSubmatch(ref)[1]
+		if value, ok := variables[name]; ok {
+			return value
+		}
+		missing = append(missing, name)
+		return ref
+	})
+	return expanded, missing
+}
+
+// variableSummary lists the captured variables for display
+func variableSummary(variables map[string]string) []string {
+	var names []string
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var lines []string
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("{{%s}} = %s", name, variables[name]))
+	}
+	return lines
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Key profiles, chosen with "keymap" in the config, -keymap or
+// ":set keymap=..." in the address bar
+const (
+	keymapDefault = "default"
+	keymapVim     = "vim"
+)
+
+// startKeymap is the key profile the navigator starts with
+var startKeymap = keymapDefault
+
+// validKeymap reports whether name is a key profile
+func validKeymap(name string) bool {
+	return name == keymapDefault || name == keymapVim
+}
+
+// vimState is what the vim profile remembers between key presses
+type vimState struct {
+	pending string // First key of a two-key command: "g" or "d"
+	visual  bool   // Visual mode marks the entities between anchor and the cursor
+	column  int    // ID of the column visual mode was started in
+	anchor  int
+}
+
+// updateVimKey handles a key press in the columns with the vim profile:
+// two-key commands and visual mode are handled here, the other keys are
+// translated to the keys of the default profile
+func (m model) updateVimKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.inlineEdit.active {
+		return m.updateKey(msg)
+	}
+	key := msg.String()
+	pending := m.vim.pending
+	m.vim.pending = ""
+
+	switch {
+	case pending == "g" && key == "g":
+		msg = tea.KeyMsg{Type: tea.KeyHome}
+	case pending == "g" && key == "h":
+		msg = runeKey("g")
+	case pending == "d" && key == "d":
+		msg = tea.KeyMsg{Type: tea.KeyF8}
+	case pending == "d" && key == "o":
+		msg = runeKey("d")
+	case pending != "":
+		// An unknown command is dropped, as vim does
+		return m, nil
+	case key == "g" || (key == "d" && !m.vim.visual):
+		m.vim.pending = key
+		return m, nil
+	case key == "G":
+		msg = tea.KeyMsg{Type: tea.KeyEnd}
+	case key == "V" && m.vim.visual, key == "esc" && m.vim.visual:
+		return m.endVisual(), nil
+	case key == "V" && m.activeColumn < len(m.columns) && m.columns[m.activeColumn].isEntityList():
+		return m.startVisual(), nil
+	case key == "d" && m.vim.visual:
+		m = m.endVisual()
+		msg = tea.KeyMsg{Type: tea.KeyF8}
+	}
+
+	next, cmd := m.updateKey(msg)
+	nm, ok := next.(model)
+	if !ok {
+		return next, cmd
+	}
+	if msg.Type == tea.KeyF8 && nm.confirmDelete {
+		nm.logs = append(nm.logs, "(dd confirms in the vim profile)")
+	}
+	if nm.vim.visual {
+		nm.extendVisual()
+	}
+	return nm, cmd
+}
+
+// runeKey is the key press typing s
+func runeKey(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+// startVisual starts marking entities from the one under the cursor
+func (m model) startVisual() model {
+	col := &m.columns[m.activeColumn]
+	m.vim = vimState{visual: true, column: col.id, anchor: col.cursor}
+	m.extendVisual()
+	m.logs = append(m.logs, "-- VISUAL -- move to mark entities, d deletes them, V or Esc keeps the marks")
+	return m
+}
+
+// extendVisual marks the entities between the anchor and the cursor, and
+// ends visual mode once the cursor left its column
+func (m *model) extendVisual() {
+	col := m.columnByID(m.vim.column)
+	if col == nil || m.activeColumn >= len(m.columns) || m.columns[m.activeColumn].id != m.vim.column || !col.isEntityList() {
+		m.vim.visual = false
+		return
+	}
+	from, to := m.vim.anchor, min(col.cursor, len(col.entities)-1)
+	if from > to {
+		from, to = to, from
+	}
+	col.selected = make(map[int]bool, to-from+1)
+	for i := from; i <= to; i++ {
+		col.selected[i] = true
+	}
+}
+
+// endVisual leaves visual mode, keeping the entities marked for the keys
+// acting on them
+func (m model) endVisual() model {
+	m.vim.visual = false
+	if col := m.columnByID(m.vim.column); col != nil {
+		m.logs = append(m.logs, fmt.Sprintf("%d entities marked", len(col.selected)))
+	}
+	return m
+}
+
+// runCommand runs what the address bar was given when it is a command
+// rather than a URL: ":set keymap=vim" switches the key profile and, with
+// the vim profile, ":q" quits
+func (m model) runCommand(input string) (tea.Model, tea.Cmd, bool) {
+	if name, ok := strings.CutPrefix(input, "set keymap="); ok {
+		if !validKeymap(name) {
+			m.logs = append(m.logs, fmt.Sprintf("Unknown keymap %q - use %s or %s", name, keymapDefault, keymapVim))
+			return m, nil, true
+		}
+		m.keymap = name
+		m.vim = vimState{}
+		m.logs = append(m.logs, "Keymap: "+name)
+		return m, nil, true
+	}
+	switch input {
+	case "q", "q!", "qa", "quit":
+		if m.keymap == keymapVim {
+			return m, tea.Quit, true
+		}
+	}
+	return m, nil, false
+}
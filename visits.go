@@ -0,0 +1,101 @@
+package main
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxVisits is how many locations "[" can go back through
+const maxVisits = 100
+
+// visit is a location shown, in the order they were visited like the
+// history of a browser. Unlike the history tree it spans services, and
+// locations opened from the address bar, links or the tree are visits too.
+type visit struct {
+	odata   *ODataService
+	service int // Index of the service in m.services
+	label   string
+	columns []column
+	active  int
+}
+
+// recordVisit adds the location shown to the visits, dropping the ones
+// gone back from, or updates the columns kept for it when it is the visit
+// shown. Locations still loading are recorded once loaded.
+func (m *model) recordVisit() {
+	last := m.locationColumn()
+	if m.odata == nil || last < 1 || m.columns[last].state == stateLoading {
+		return
+	}
+	labels := make([]string, 0, last)
+	for i := 1; i <= last; i++ {
+		labels = append(labels, m.locationLabel(i))
+	}
+	label := strings.Join(labels, " → ")
+
+	if m.visitPos >= len(m.visits) || m.visits[m.visitPos].odata != m.odata || m.visits[m.visitPos].label != label {
+		if len(m.visits) > 0 {
+			m.visits = m.visits[:m.visitPos+1]
+		}
+		m.visits = append(m.visits, visit{odata: m.odata, service: m.serviceIndex, label: label})
+		if len(m.visits) > maxVisits {
+			m.visits = m.visits[len(m.visits)-maxVisits:]
+		}
+		m.visitPos = len(m.visits) - 1
+	}
+	v := &m.visits[m.visitPos]
+	v.columns = append([]column(nil), m.columns[:last+1]...)
+	v.active = last
+}
+
+// stepVisit goes back (-1) or forward (+1) through the visited locations,
+// reconnecting to their service when it is not the connected one
+func (m model) stepVisit(step int) (tea.Model, tea.Cmd) {
+	if m.inlineEdit.active {
+		return m, nil
+	}
+	pos := m.visitPos + step
+	if len(m.visits) == 0 || pos < 0 || pos >= len(m.visits) {
+		if step < 0 {
+			m.logs = append(m.logs, "No earlier location to go back to")
+		} else {
+			m.logs = append(m.logs, "No later location to go forward to")
+		}
+		return m, nil
+	}
+	v := m.visits[pos]
+	if v.service >= len(m.services) {
+		m.logs = append(m.logs, "The service of "+v.label+" is no longer configured")
+		return m, nil
+	}
+
+	var cmds []tea.Cmd
+	if v.odata != m.odata {
+		if m.odata != nil {
+			m.odata.Cancel()
+		}
+		m.loading = false
+		m.preview.loading = false
+		m.odata = v.odata
+		m.serviceIndex = v.service
+		m.keepAliveSeq++
+		cmds = append(cmds, m.keepAlive())
+	}
+	m.visitPos = pos
+	m.columns = append([]column(nil), v.columns...)
+	m.activeColumn = v.active
+	for i := range m.columns {
+		m.columns[i].focused = i == m.activeColumn
+	}
+	// Services added since, e.g. from the address bar, stay listed
+	m.columns[0].items = GetServiceNames(m.services)
+	m.updateColumnSizes()
+
+	where := "Back at "
+	if step > 0 {
+		where = "Forward to "
+	}
+	m.logs = append(m.logs, where+v.label)
+	return m, tea.Batch(append(cmds, m.updatePreview())...)
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"fmt"
+	neturl "net/url"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Steps of the setup wizard
+const (
+	wizardChoose = iota
+	wizardURL
+	wizardName
+	wizardAuth
+	wizardUser
+	wizardPassword
+	wizardToken
+	wizardTest
+)
+
+// wizardAuthChoices are the ways to log in the wizard offers
+var wizardAuthChoices = []string{"None", "Basic (user and password)", "Bearer token"}
+
+// setupWizard is the first-run dialog that sets up the service to browse
+// and saves it as the user's config file
+type setupWizard struct {
+	step   int
+	cursor int
+	input  string // Text typed at the current step
+	svc    ServiceConfig
+	custom bool // The service was entered, not picked from the demo services
+
+	testing bool
+	tested  string // Outcome of the connection test
+	ok      bool
+
+	path   string // Config file the service is saved to
+	saved  bool
+	quit   bool // ctrl+c: leave the navigator
+	width  int
+	height int
+}
+
+// wizardTestMsg carries the outcome of the connection test
+type wizardTestMsg struct {
+	entitySets int
+	err        error
+}
+
+// runSetupWizard asks for the service to browse on first run and saves it
+// to the user's config file. It returns the services to offer and a line
+// for the log. Skipping the wizard offers the demo services.
+func runSetupWizard(services []ServiceConfig) ([]ServiceConfig, string) {
+	path, err := userConfigPath()
+	if err != nil {
+		return services, fmt.Sprintf("No config file and no user config directory (%v) - showing the demo services", err)
+	}
+	result, err := tea.NewProgram(setupWizard{path: path}, tea.WithAltScreen()).Run()
+	if err != nil {
+		return services, fmt.Sprintf("Setup wizard failed: %v - showing the demo services", err)
+	}
+	w := result.(setupWizard)
+	if w.quit {
+		os.Exit(0)
+	}
+	if !w.saved {
+		return services, fmt.Sprintf("No config file - showing the demo services; save your own to %s", path)
+	}
+	startLink = &DeepLink{Service: w.svc.Name}
+	return withDefaultServices([]ServiceConfig{w.svc}), fmt.Sprintf("Saved %s to %s", w.svc.Name, path)
+}
+
+func (w setupWizard) Init() tea.Cmd {
+	return nil
+}
+
+func (w setupWizard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		w.width, w.height = msg.Width, msg.Height
+	case wizardTestMsg:
+		w.testing = false
+		w.ok = msg.err == nil
+		if w.ok {
+			w.tested = fmt.Sprintf("Connected - %d entity sets", msg.entitySets)
+		} else {
+			w.tested = fmt.Sprintf("Connection failed: %v", msg.err)
+		}
+	case tea.KeyMsg:
+		return w.updateKey(msg)
+	}
+	return w, nil
+}
+
+// updateKey handles the key presses of the current step. Esc goes back a
+// step, and skips the wizard at the first one.
+func (w setupWizard) updateKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	key := msg.String()
+	if key == "ctrl+c" {
+		w.quit = true
+		return w, tea.Quit
+	}
+
+	switch w.step {
+	case wizardChoose, wizardAuth:
+		choices := len(DefaultServices) + 1
+		if w.step == wizardAuth {
+			choices = len(wizardAuthChoices)
+		}
+		switch key {
+		case "esc":
+			if w.step == wizardChoose {
+				return w, tea.Quit
+			}
+			return w.goTo(wizardName, w.svc.Name), nil
+		case "up", "k":
+			if w.cursor > 0 {
+				w.cursor--
+			}
+		case "down", "j":
+			if w.cursor < choices-1 {
+				w.cursor++
+			}
+		case "enter":
+			return w.choose()
+		}
+
+	case wizardTest:
+		switch {
+		case w.testing:
+		case key == "esc":
+			if w.custom {
+				return w.goTo(wizardURL, w.svc.URL), nil
+			}
+			return w.goTo(wizardChoose, ""), nil
+		case key == "enter" && w.ok, key == "s":
+			if err := saveConfig(w.path, Config{Services: []ServiceConfig{w.svc}}); err != nil {
+				w.tested = fmt.Sprintf("Cannot save %s: %v", w.path, err)
+				w.ok = false
+				return w, nil
+			}
+			w.saved = true
+			return w, tea.Quit
+		case key == "r":
+			return w.test()
+		}
+
+	default:
+		switch key {
+		case "esc":
+			return w.back(), nil
+		case "enter":
+			return w.submit()
+		case "backspace":
+			if len(w.input) > 0 {
+				runes := []rune(w.input)
+				w.input = string(runes[:len(runes)-1])
+			}
+		default:
+			w.input += typedText(msg)
+		}
+	}
+	return w, nil
+}
+
+// goTo moves to a step with its input prefilled
+func (w setupWizard) goTo(step int, input string) setupWizard {
+	w.step = step
+	w.input = input
+	w.cursor = 0
+	return w
+}
+
+// choose takes the choice under the cursor of the service or auth list
+func (w setupWizard) choose() (tea.Model, tea.Cmd) {
+	if w.step == wizardChoose {
+		if w.cursor < len(DefaultServices) {
+			w.svc = DefaultServices[w.cursor]
+			w.custom = false
+			return w.test()
+		}
+		w.custom = true
+		return w.goTo(wizardURL, w.svc.URL), nil
+	}
+
+	w.svc.Username, w.svc.Password, w.svc.Auth = "", "", nil
+	switch w.cursor {
+	case 1:
+		return w.goTo(wizardUser, ""), nil
+	case 2:
+		return w.goTo(wizardToken, ""), nil
+	}
+	return w.test()
+}
+
+// submit takes the text typed at the current step and moves to the next
+func (w setupWizard) submit() (tea.Model, tea.Cmd) {
+	input := strings.TrimSpace(w.input)
+	switch w.step {
+	case wizardURL:
+		u, err := neturl.Parse(input)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			w.tested = "Enter an http:// or https:// URL"
+			return w, nil
+		}
+		w.tested = ""
+		w.svc = ServiceConfig{URL: strings.TrimSuffix(input, "/"), Name: u.Host}
+		return w.goTo(wizardName, w.svc.Name), nil
+	case wizardName:
+		if input == "" {
+			return w, nil
+		}
+		w.svc.Name = input
+		return w.goTo(wizardAuth, ""), nil
+	case wizardUser:
+		w.svc.Username = input
+		return w.goTo(wizardPassword, ""), nil
+	case wizardPassword:
+		w.svc.Password = w.input
+		return w.test()
+	case wizardToken:
+		w.svc.Auth = tokenAuth(input)
+		return w.test()
+	}
+	return w, nil
+}
+
+// back returns to the step before a text input
+func (w setupWizard) back() setupWizard {
+	switch w.step {
+	case wizardURL:
+		return w.goTo(wizardChoose, "")
+	case wizardName:
+		return w.goTo(wizardURL, w.svc.URL)
+	case wizardPassword:
+		return w.goTo(wizardUser, w.svc.Username)
+	}
+	return w.goTo(wizardAuth, "")
+}
+
+// test tries to read the entity sets of the service
+func (w setupWizard) test() (tea.Model, tea.Cmd) {
+	w.step = wizardTest
+	w.testing = true
+	w.tested = ""
+	svc := w.svc
+	return w, func() tea.Msg {
+		entitySets, err := NewODataServiceForConfig(svc).GetEntitySets()
+		return wizardTestMsg{entitySets: len(entitySets), err: err}
+	}
+}
+
+func (w setupWizard) View() string {
+	hint := lipgloss.NewStyle().Foreground(theme.Muted)
+	var lines []string
+	switch w.step {
+	case wizardChoose:
+		lines = append(lines, "No config file was found. Pick a demo service or enter your own:", "")
+		var choices []string
+		for _, svc := range DefaultServices {
+			choices = append(choices, svc.Name+"  "+hint.Render(svc.URL))
+		}
+		choices = append(choices, "Enter a service URL...")
+		lines = append(lines, renderChoiceList(choices, w.cursor, len(choices))...)
+		lines = append(lines, "", hint.Render("Enter: Choose | ESC: Skip and show the demo services"))
+	case wizardAuth:
+		lines = append(lines, "How does "+w.svc.Name+" log in?", "")
+		lines = append(lines, renderChoiceList(wizardAuthChoices, w.cursor, len(wizardAuthChoices))...)
+		lines = append(lines, "", hint.Render("Enter: Choose | ESC: Back"))
+	case wizardTest:
+		lines = append(lines, "Service: "+w.svc.Name, "URL: "+w.svc.URL, "")
+		switch {
+		case w.testing:
+			lines = append(lines, "Testing the connection...")
+		case w.ok:
+			lines = append(lines, lipgloss.NewStyle().Foreground(theme.Success).Render(w.tested), "", "Save to "+w.path+"?")
+			lines = append(lines, "", hint.Render("Enter: Save and connect | r: Test again | ESC: Back"))
+		default:
+			lines = append(lines, lipgloss.NewStyle().Foreground(theme.Error).Render(w.tested))
+			lines = append(lines, "", hint.Render("s: Save anyway | r: Test again | ESC: Back"))
+		}
+	default:
+		labels := map[int]string{
+			wizardURL:      "Service URL",
+			wizardName:     "Name",
+			wizardUser:     "User",
+			wizardPassword: "Password",
+			wizardToken:    "Bearer token",
+		}
+		input := w.input
+		if w.step == wizardPassword || w.step == wizardToken {
+			input = strings.Repeat("•", len([]rune(input)))
+		}
+		lines = append(lines, labels[w.step]+":", input+"█")
+		if w.step == wizardURL && w.tested != "" {
+			lines = append(lines, "", lipgloss.NewStyle().Foreground(theme.Error).Render(w.tested))
+		}
+		lines = append(lines, "", hint.Render("Enter: Next | ESC: Back"))
+	}
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Background(theme.Accent).
+		Foreground(theme.AccentText).
+		Padding(0, 1).
+		Render("OData Navigator Setup")
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(theme.Accent).
+		Padding(0, 1).
+		Width(min(80, max(w.width-4, 40))).
+		Render(title + "\n\n" + strings.Join(lines, "\n"))
+	return lipgloss.Place(w.width, w.height, lipgloss.Center, lipgloss.Center, box)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/muesli/reflow/ansi"
+	"github.com/muesli/reflow/truncate"
+	"github.com/muesli/reflow/wordwrap"
+	"github.com/muesli/reflow/wrap"
+)
+
+// fitItem cuts an item to the width of its column, ending it with "…".
+// Widths are measured in cells, so wide runes and colors count right.
+func fitItem(item string, width int) string {
+	if ansi.PrintableRuneWidth(item) <= width {
+		return item
+	}
+	return truncate.StringWithTail(item, uint(max(width, 1)), "…")
+}
+
+// wrapItem breaks an item into lines of the width of its column, at spaces
+// where it can. Wrapped lines keep the indentation of the item, so nested
+// JSON stays readable.
+func wrapItem(item string, width int) []string {
+	body := strings.TrimLeft(item, " ")
+	indent := len(item) - len(body)
+	if indent > width/2 {
+		indent = 0
+		body = item
+	}
+	inner := max(width-indent, 1)
+	lines := strings.Split(wrap.String(wordwrap.String(body, inner), inner), "\n")
+	if indent > 0 {
+		pad := strings.Repeat(" ", indent)
+		for i := range lines {
+			lines[i] = pad + lines[i]
+		}
+	}
+	return lines
+}
+
+// wraps reports whether the long lines of a column are wrapped rather than cut
+func (m model) wraps(col column) bool {
+	return m.wrapDetails && col.isDetails && !m.inlineEdit.active
+}
+
+// wrappedRows is visibleRows for a column whose items wrap: the rows that
+// fit its height from the scroll offset on, starting further down when
+// the lines wrapped above the cursor would push it out of sight
+func (c column) wrappedRows(width int) []int {
+	if c.height <= 2 {
+		return c.visibleRows()
+	}
+	height := c.height - 2
+	rows := c.findMatches()
+	start := 0
+	if rows == nil {
+		for i := range c.items {
+			rows = append(rows, i)
+		}
+		start = min(c.scrollOffset, len(rows))
+	}
+	lines := func(pos int) int {
+		return len(wrapItem(c.items[rows[pos]], width))
+	}
+
+	for pos, i := range rows {
+		if i != c.cursor {
+			continue
+		}
+		start = min(start, pos)
+		used := 0
+		for p := start; p <= pos; p++ {
+			used += lines(p)
+		}
+		for used > height && start < pos {
+			used -= lines(start)
+			start++
+		}
+	}
+	end, used := start, 0
+	for end < len(rows) && used < height {
+		used += lines(end)
+		end++
+	}
+	return rows[start:end]
+}
+
+// toggleWrap wraps the long lines of Details columns, or cuts them at the
+// column width again
+func (m model) toggleWrap() (tea.Model, tea.Cmd) {
+	m.wrapDetails = !m.wrapDetails
+	if m.wrapDetails {
+		m.logs = append(m.logs, "Details wrap long lines")
+	} else {
+		m.logs = append(m.logs, "Details cut long lines at the column width")
+	}
+	return m, nil
+}
@@ -0,0 +1,482 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Rows of the XLSX export dialog
+const (
+	xlsxRowFile = iota
+	xlsxRowSheets
+	xlsxRowCount
+)
+
+// xlsxExportDialog is the state of the "W" overlay exporting entity lists
+// as an Excel workbook
+type xlsxExportDialog struct {
+	active bool
+	column int // Index of the active entity list
+	row    int
+	path   string
+	all    bool // A sheet for every open entity list instead of the active one
+}
+
+// xlsxSheet is a worksheet of an export: a header row of labels and a row
+// per entity
+type xlsxSheet struct {
+	name     string
+	fields   []string
+	labels   []string
+	types    map[string]string
+	entities []map[string]interface{}
+}
+
+// Cell styles of styles.xml: plain, bold header, date, date and time
+const (
+	xlsxStyleHeader   = 1
+	xlsxStyleDate     = 2
+	xlsxStyleDateTime = 3
+)
+
+// xlsxSheetNameChars are not allowed in worksheet names
+var xlsxSheetNameChars = strings.NewReplacer("[", "_", "]", "_", ":", "_", "*", "_", "?", "_", "/", "_", "\\", "_")
+
+// entityListColumns returns the open columns that are entity lists
+func (m model) entityListColumns() []int {
+	var columns []int
+	for i, col := range m.columns {
+		if col.isEntityList() {
+			columns = append(columns, i)
+		}
+	}
+	return columns
+}
+
+// openXLSXExport asks where to export the active entity list, or all open
+// ones, as an Excel workbook
+func (m model) openXLSXExport() model {
+	if m.activeColumn >= len(m.columns) || !m.columns[m.activeColumn].isEntityList() {
+		m.logs = append(m.logs, "Excel export is only available on an entity list")
+		return m
+	}
+	col := m.columns[m.activeColumn]
+	name := strings.Trim(unsafeFileChars.ReplaceAllString(col.entitySet, "_"), "_")
+	if name == "" {
+		name = "export"
+	}
+	m.xlsxExport = xlsxExportDialog{
+		active: true,
+		column: m.activeColumn,
+		path:   name + ".xlsx",
+		all:    m.xlsxExport.all && len(m.entityListColumns()) > 1,
+	}
+	return m
+}
+
+// updateXLSXExport handles key presses while the XLSX export dialog is open
+func (m model) updateXLSXExport(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	d := &m.xlsxExport
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		d.active = false
+	case "enter":
+		d.active = false
+		return m.exportXLSX()
+	case "up", "down", "tab":
+		d.row = (d.row + 1) % xlsxRowCount
+	case "left", "right":
+		if d.row == xlsxRowSheets && len(m.entityListColumns()) > 1 {
+			d.all = !d.all
+		}
+	case "backspace":
+		if d.row == xlsxRowFile && len(d.path) > 0 {
+			runes := []rune(d.path)
+			d.path = string(runes[:len(runes)-1])
+		}
+	default:
+		if d.row == xlsxRowFile {
+			d.path += typedText(msg)
+		}
+	}
+	return m, nil
+}
+
+// xlsxExportMsg carries the outcome of writing an Excel export
+type xlsxExportMsg struct {
+	path    string
+	counts  []string // "120 Products" per sheet
+	partial bool     // Only the loaded pages of a list were exported
+	err     error
+}
+
+// exportXLSX writes a sheet per exported entity list with its loaded
+// entities, or only its marked ones, in the background with the rows
+// written so far
+func (m model) exportXLSX() (tea.Model, tea.Cmd) {
+	d := m.xlsxExport
+	columns := []int{d.column}
+	if d.all {
+		columns = m.entityListColumns()
+	}
+
+	var sheets []xlsxSheet
+	var counts []string
+	used := make(map[string]bool)
+	partial := false
+	rows := 0
+	for _, i := range columns {
+		col := m.columns[i]
+		sheet := m.xlsxSheet(col)
+		sheet.name = uniqueSheetName(col.entitySet, used)
+		sheets = append(sheets, sheet)
+		counts = append(counts, fmt.Sprintf("%d %s", len(sheet.entities), sheet.name))
+		partial = partial || col.hasMore
+		rows += len(sheet.entities)
+	}
+
+	path := exportFilePath(d.path, "export.xlsx")
+	p := m.startProgress("Exporting to "+filepath.Base(path), "rows", rows)
+	if p == nil {
+		return m, nil
+	}
+	return m, p.run(func() tea.Msg {
+		msg := xlsxExportMsg{path: path, counts: counts, partial: partial}
+		data, err := writeXLSX(sheets, func(done int) bool {
+			p.report(done, rows)
+			return !p.cancelled()
+		})
+		if err == nil && p.cancelled() {
+			err = errRequestCancelled
+		}
+		if err == nil {
+			err = writeExport(path, data)
+		}
+		msg.err = err
+		return msg
+	})
+}
+
+// applyXLSXExport reports a written Excel export
+func (m *model) applyXLSXExport(msg xlsxExportMsg) {
+	m.loading = false
+	if msg.err != nil {
+		m.logs = append(m.logs, fmt.Sprintf("ERROR [Excel export]: %v", msg.err))
+		return
+	}
+	m.logs = append(m.logs, fmt.Sprintf("Exported %s to %s", strings.Join(msg.counts, ", "), msg.path))
+	if msg.partial {
+		m.logs = append(m.logs, "Only the loaded entities were exported; load more pages to export them too")
+	}
+}
+
+// xlsxSheet collects the fields, header labels and entities of a list; the
+// labels are the sap:label of the properties where the metadata has one
+func (m model) xlsxSheet(col column) xlsxSheet {
+	sheet := xlsxSheet{entities: col.entities}
+	if len(col.selected) > 0 {
+		sheet.entities = nil
+		for _, i := range col.selectedIndexes() {
+			sheet.entities = append(sheet.entities, col.entities[i])
+		}
+	}
+	_, sheet.types = m.columnProperties(col)
+
+	labels := make(map[string]string)
+	if et := m.metadata().EntityTypeOf(col.entitySet); et != nil {
+		for _, p := range et.Properties {
+			labels[p.Name] = p.Label
+		}
+	}
+	for _, f := range m.csvFields(col) {
+		if !f.include {
+			continue
+		}
+		sheet.fields = append(sheet.fields, f.name)
+		label := labels[f.name]
+		if label == "" {
+			label = f.name
+		}
+		sheet.labels = append(sheet.labels, label)
+	}
+	return sheet
+}
+
+// uniqueSheetName turns an entity set name into a worksheet name: at most
+// 31 characters, none of []:*?/\, and unique in the workbook
+func uniqueSheetName(name string, used map[string]bool) string {
+	base := strings.Trim(xlsxSheetNameChars.Replace(name), "'")
+	if base == "" {
+		base = "Sheet"
+	}
+	candidate := truncateRunes(base, 31)
+	for n := 2; used[strings.ToLower(candidate)]; n++ {
+		suffix := fmt.Sprintf(" (%d)", n)
+		candidate = truncateRunes(base, 31-len(suffix)) + suffix
+	}
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+// truncateRunes cuts s to at most n runes
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
+
+// writeXLSX packs the sheets into an Office Open XML workbook. It reports
+// the rows written over all sheets to progress, and stops early once
+// progress returns false.
+func writeXLSX(sheets []xlsxSheet, progress func(rows int) bool) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes(len(sheets))},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", xlsxWorkbook(sheets)},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels(len(sheets))},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	written := 0
+	for i, sheet := range sheets {
+		content := xlsxWorksheet(sheet, func(rows int) bool {
+			return progress(written + rows)
+		})
+		written += len(sheet.entities)
+		files = append(files, struct{ name, content string }{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), content})
+	}
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(f.content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func xlsxContentTypes(sheets int) string {
+	var b strings.Builder
+	b.WriteString(xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i)
+	}
+	b.WriteString(`</Types>`)
+	return b.String()
+}
+
+func xlsxWorkbook(sheets []xlsxSheet) string {
+	var b strings.Builder
+	b.WriteString(xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, sheet := range sheets {
+		fmt.Fprintf(&b, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(sheet.name), i+1, i+1)
+	}
+	b.WriteString(`</sheets></workbook>`)
+	return b.String()
+}
+
+func xlsxWorkbookRels(sheets int) string {
+	var b strings.Builder
+	b.WriteString(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i, i)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, sheets+1)
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
+
+// xlsxStyles holds the cell formats referenced by the xlsxStyle constants;
+// 14 and 22 are the built-in date and date-time number formats
+const xlsxStyles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="4">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="14" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="22" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs></styleSheet>`
+
+// xlsxWorksheet renders a sheet with a frozen header row and columns sized
+// to their content, reporting the rows rendered to progress
+func xlsxWorksheet(sheet xlsxSheet, progress func(rows int) bool) string {
+	widths := make([]int, len(sheet.fields))
+	var rows strings.Builder
+	rows.WriteString(`<row r="1">`)
+	for i, label := range sheet.labels {
+		widths[i] = len([]rune(label))
+		rows.WriteString(xlsxStringCell(xlsxCellRef(i, 1), label, xlsxStyleHeader))
+	}
+	rows.WriteString(`</row>`)
+	for r, entity := range sheet.entities {
+		fmt.Fprintf(&rows, `<row r="%d">`, r+2)
+		for i, field := range sheet.fields {
+			cell, width := xlsxCell(xlsxCellRef(i, r+2), entity[field], sheet.types[field])
+			rows.WriteString(cell)
+			widths[i] = max(widths[i], width)
+		}
+		rows.WriteString(`</row>`)
+		if !progress(r + 1) {
+			break
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	b.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	if len(widths) > 0 {
+		b.WriteString(`<cols>`)
+		for i, w := range widths {
+			fmt.Fprintf(&b, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, min(max(w, 8), 60)+2)
+		}
+		b.WriteString(`</cols>`)
+	}
+	b.WriteString(`<sheetData>` + rows.String() + `</sheetData></worksheet>`)
+	return b.String()
+}
+
+// xlsxCell renders a property value as a typed cell: numbers as numbers,
+// dates as Excel date serials, everything else as text. It also returns
+// the width of the value as displayed.
+func xlsxCell(ref string, value interface{}, edmType string) (string, int) {
+	switch v := value.(type) {
+	case nil:
+		return "", 0
+	case bool:
+		b := "0"
+		if v {
+			b = "1"
+		}
+		return fmt.Sprintf(`<c r="%s" t="b"><v>%s</v></c>`, ref, b), 5
+	case float64:
+		number := strconv.FormatFloat(v, 'f', -1, 64)
+		return fmt.Sprintf(`<c r="%s"><v>%s</v></c>`, ref, number), len(number)
+	case string:
+		switch edmType {
+		case "Edm.Decimal", "Edm.Double", "Edm.Single", "Edm.Int64":
+			// V2 sends these as strings; Excel keeps 15 significant digits,
+			// so longer numbers stay text
+			if _, err := strconv.ParseFloat(v, 64); err == nil && strings.Trim(v, "+-0123456789.eE") == "" && len(strings.TrimLeft(v, "-0.")) <= 15 {
+				return fmt.Sprintf(`<c r="%s"><v>%s</v></c>`, ref, v), len(v)
+			}
+		}
+		if t, ok := csvDate(v, edmType); ok {
+			style, width := xlsxStyleDate, 10
+			if t.Hour() != 0 || t.Minute() != 0 || t.Second() != 0 {
+				style, width = xlsxStyleDateTime, 16
+			}
+			return fmt.Sprintf(`<c r="%s" s="%d"><v>%s</v></c>`, ref, style, strconv.FormatFloat(excelSerial(t), 'f', -1, 64)), width
+		}
+		return xlsxStringCell(ref, v, 0), len([]rune(v))
+	}
+	text := csvValue(value, edmType, csvOptions{})
+	return xlsxStringCell(ref, text, 0), len([]rune(text))
+}
+
+// xlsxStringCell renders an inline text cell
+func xlsxStringCell(ref, text string, style int) string {
+	s := ""
+	if style != 0 {
+		s = fmt.Sprintf(` s="%d"`, style)
+	}
+	return fmt.Sprintf(`<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, s, xmlEscape(text))
+}
+
+// excelSerial converts a time to the days since 1899-12-30 that Excel
+// stores dates as, keeping the wall clock time as sent
+func excelSerial(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return wall.Sub(time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)).Hours() / 24
+}
+
+// xlsxCellRef names the cell of a zero-based column and a row, as "B7"
+func xlsxCellRef(column, row int) string {
+	name := ""
+	for column++; column > 0; column = (column - 1) / 26 {
+		name = string(rune('A'+(column-1)%26)) + name
+	}
+	return name + strconv.Itoa(row)
+}
+
+// xmlEscape escapes text for XML, dropping the characters XML can't hold
+func xmlEscape(text string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
+
+// renderXLSXExport renders the XLSX export dialog
+func (m model) renderXLSXExport() string {
+	d := m.xlsxExport
+	col := m.columns[d.column]
+
+	lists := m.entityListColumns()
+	sheets := fmt.Sprintf("This list: %s", col.entitySet)
+	if d.all {
+		var names []string
+		for _, i := range lists {
+			names = append(names, m.columns[i].entitySet)
+		}
+		sheets = fmt.Sprintf("All %d open lists: %s", len(lists), strings.Join(names, ", "))
+	}
+	if len(lists) > 1 {
+		sheets = "◂ " + sheets + " ▸"
+	}
+	rows := []struct{ label, value string }{
+		{"File", d.path + "█"},
+		{"Sheets", sheets},
+	}
+	var lines []string
+	for i, row := range rows {
+		line := fmt.Sprintf("%-8s %s", row.label+":", row.value)
+		if i == d.row {
+			line = lipgloss.NewStyle().Background(theme.Selection).Foreground(theme.AccentText).Render(line)
+		}
+		lines = append(lines, line)
+	}
+	hint := lipgloss.NewStyle().Foreground(theme.Muted)
+	lines = append(lines, "", hint.Render("One sheet per list, with the loaded or marked entities"))
+	lines = append(lines, "", hint.Render("Up/Down: Option | Left/Right: Change | Enter: Export | ESC: Cancel"))
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Background(theme.Accent).
+		Foreground(theme.AccentText).
+		Padding(0, 1).
+		Render("Export as Excel Workbook")
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(theme.Accent).
+		Padding(0, 1).
+		Width(min(70, m.width-4)).
+		Render(title + "\n\n" + strings.Join(lines, "\n"))
+}